@@ -0,0 +1,278 @@
+// Command eval runs a fixed corpus of queries through one or more agent
+// deployments and scores the answers, so prompt or model changes can be
+// compared before they ship. Each target is a running agent configured with
+// the prompt/model combination under test, e.g. via GEMINI_MODEL and
+// CONTENT_DIR.
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/a2aclient"
+)
+
+//go:embed corpus.json
+var defaultCorpus []byte
+
+// Target is an agent deployment under evaluation
+type Target struct {
+	Name string
+	URL  string
+}
+
+// Result is the outcome of one case against one target
+type Result struct {
+	Target    string        `json:"target"`
+	Case      string        `json:"case"`
+	State     string        `json:"state"`
+	Error     string        `json:"error,omitempty"`
+	LatencyMs int64         `json:"latencyMs"`
+	Score     Score         `json:"score"`
+	latency   time.Duration // kept for summaries
+}
+
+// Summary aggregates a target's results
+type Summary struct {
+	Target       string  `json:"target"`
+	Cases        int     `json:"cases"`
+	Failed       int     `json:"failed"`
+	Structure    float64 `json:"structure"`
+	Required     float64 `json:"required"`
+	Citations    float64 `json:"citations"`
+	AvgLatencyMs int64   `json:"avgLatencyMs"`
+}
+
+// targetList collects repeated -target name=url flags
+type targetList []Target
+
+func (t *targetList) String() string {
+	names := make([]string, len(*t))
+	for i, target := range *t {
+		names[i] = target.Name + "=" + target.URL
+	}
+	return strings.Join(names, ",")
+}
+
+func (t *targetList) Set(value string) error {
+	name, url, ok := strings.Cut(value, "=")
+	if !ok || name == "" || url == "" {
+		return fmt.Errorf("expected name=url, got %q", value)
+	}
+	*t = append(*t, Target{Name: name, URL: url})
+	return nil
+}
+
+func main() {
+	var targets targetList
+	flag.Var(&targets, "target", "agent to evaluate as name=url; repeat to compare deployments (default local=http://localhost:8080)")
+	corpusPath := flag.String("corpus", "", "JSON corpus of {id, query, required} cases (default: the built-in corpus)")
+	detail := flag.String("detail", "", "detail level to request: brief, standard or deep")
+	concurrency := flag.Int("concurrency", 2, "queries in flight per target")
+	timeout := flag.Duration("timeout", 2*time.Minute, "timeout per query")
+	asJSON := flag.Bool("json", false, "print the report as JSON instead of markdown")
+	output := flag.String("output", "", "write the report to this file instead of stdout")
+	minStructure := flag.Float64("min-structure", 0, "exit non-zero if any target's average structure score is below this (0-1)")
+	flag.Parse()
+
+	if len(targets) == 0 {
+		targets = targetList{{Name: "local", URL: "http://localhost:8080"}}
+	}
+
+	corpus, err := loadCorpus(*corpusPath)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	var results []Result
+	for _, target := range targets {
+		log.Printf("🧪 Evaluating %s (%s) on %d cases", target.Name, target.URL, len(corpus))
+		results = append(results, runTarget(target, corpus, *detail, *concurrency, *timeout)...)
+	}
+	summaries := summarize(targets, results)
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("❌ failed to create report: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		enc.Encode(map[string]interface{}{"summaries": summaries, "results": results})
+	} else {
+		writeMarkdown(out, summaries, results)
+	}
+
+	for _, s := range summaries {
+		if s.Structure < *minStructure {
+			log.Printf("❌ %s structure score %.2f is below %.2f", s.Target, s.Structure, *minStructure)
+			os.Exit(1)
+		}
+	}
+}
+
+// loadCorpus reads the corpus file, or the built-in corpus when path is ""
+func loadCorpus(path string) ([]Case, error) {
+	data := defaultCorpus
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read corpus: %v", err)
+		}
+	}
+	var corpus []Case
+	if err := json.Unmarshal(data, &corpus); err != nil {
+		return nil, fmt.Errorf("failed to parse corpus: %v", err)
+	}
+	if len(corpus) == 0 {
+		return nil, fmt.Errorf("corpus has no cases")
+	}
+	return corpus, nil
+}
+
+// runTarget sends every case to a target and scores the answers
+func runTarget(target Target, corpus []Case, detail string, concurrency int, timeout time.Duration) []Result {
+	client := a2aclient.New(target.URL, a2aclient.WithHTTPClient(&http.Client{Timeout: timeout}))
+	results := make([]Result, len(corpus))
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, c := range corpus {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, c Case) {
+			defer func() { <-slots; wg.Done() }()
+			results[i] = runCase(client, target, c, detail, timeout)
+		}(i, c)
+	}
+	wg.Wait()
+	return results
+}
+
+// runCase sends one query and scores the answer in the task's status
+// message, which carries the markdown whatever the artifact format
+func runCase(client *a2aclient.Client, target Target, c Case, detail string, timeout time.Duration) Result {
+	result := Result{Target: target.Name, Case: c.ID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	params := a2aclient.NewTextTask(c.Query)
+	params.Detail = detail
+	params.Metadata = map[string]interface{}{"source": "eval", "case": c.ID}
+
+	started := time.Now()
+	task, err := client.SendTask(ctx, params)
+	result.latency = time.Since(started)
+	result.LatencyMs = result.latency.Milliseconds()
+	if err != nil {
+		result.State, result.Error = "error", err.Error()
+		return result
+	}
+
+	result.State = task.Status.State
+	var answer string
+	if task.Status.Message != nil {
+		for _, part := range task.Status.Message.Parts {
+			if part.PartKind() == "text" {
+				answer += part.Text
+			}
+		}
+	}
+	if result.State != "completed" {
+		result.Error = answer
+		return result
+	}
+	result.Score = scoreAnswer(c, answer)
+	return result
+}
+
+// summarize averages each target's scores over its completed cases
+func summarize(targets []Target, results []Result) []Summary {
+	var summaries []Summary
+	for _, target := range targets {
+		s := Summary{Target: target.Name}
+		var latency time.Duration
+		for _, r := range results {
+			if r.Target != target.Name {
+				continue
+			}
+			s.Cases++
+			latency += r.latency
+			if r.State != "completed" {
+				s.Failed++
+				continue
+			}
+			s.Structure += r.Score.Structure
+			s.Required += r.Score.Required
+			s.Citations += float64(r.Score.Citations)
+		}
+		// Failed cases score zero, so failures drag the averages down
+		if s.Cases > 0 {
+			s.Structure /= float64(s.Cases)
+			s.Required /= float64(s.Cases)
+			s.Citations /= float64(s.Cases)
+			s.AvgLatencyMs = (latency / time.Duration(s.Cases)).Milliseconds()
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries
+}
+
+// writeMarkdown prints the summary table followed by per-case details
+func writeMarkdown(w io.Writer, summaries []Summary, results []Result) {
+	fmt.Fprintln(w, "# Evaluation Report")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Target | Cases | Failed | Structure | Required | Citations | Avg latency |")
+	fmt.Fprintln(w, "|--------|-------|--------|-----------|----------|-----------|-------------|")
+	for _, s := range summaries {
+		fmt.Fprintf(w, "| %s | %d | %d | %.0f%% | %.0f%% | %.1f | %dms |\n",
+			s.Target, s.Cases, s.Failed, s.Structure*100, s.Required*100, s.Citations, s.AvgLatencyMs)
+	}
+
+	sorted := append([]Result(nil), results...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Case < sorted[j].Case })
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "## Cases")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Case | Target | State | Structure | Required | Citations | Missing |")
+	fmt.Fprintln(w, "|------|--------|-------|-----------|----------|-----------|---------|")
+	for _, r := range sorted {
+		missing := strings.Join(r.Score.Missing, ", ")
+		if r.Error != "" {
+			missing = truncate(r.Error, 80)
+		}
+		fmt.Fprintf(w, "| %s | %s | %s | %.0f%% | %.0f%% | %d | %s |\n",
+			r.Case, r.Target, r.State, r.Score.Structure*100, r.Score.Required*100, r.Score.Citations,
+			strings.ReplaceAll(missing, "|", "/"))
+	}
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Case is one query in the evaluation corpus
+type Case struct {
+	ID    string `json:"id"`
+	Query string `json:"query"`
+
+	// Required lists phrases the answer must contain, case-insensitively;
+	// alternatives are separated by "|"
+	Required []string `json:"required"`
+}
+
+// Score is how one answer measured up
+type Score struct {
+	Structure float64  `json:"structure"` // share of the standard format's sections present, 0-1
+	Required  float64  `json:"required"`  // share of the case's required phrases present, 0-1
+	Citations int      `json:"citations"` // links and URLs in the answer
+	Missing   []string `json:"missing,omitempty"`
+}
+
+// structureChecks are the sections of the standard answer format
+var structureChecks = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"heading", regexp.MustCompile(`(?m)^#\s+Best Migration Option:`)},
+	{"key details", regexp.MustCompile(`\*\*Key Details:\*\*`)},
+	{"processing time", regexp.MustCompile(`(?im)^\s*[-*]\s*Processing time:`)},
+	{"cost", regexp.MustCompile(`(?im)^\s*[-*]\s*Cost:`)},
+	{"success rate", regexp.MustCompile(`(?im)^\s*[-*]\s*Success rate:`)},
+	{"requirements", regexp.MustCompile(`(?im)^\s*[-*]\s*Main requirements:`)},
+	{"timeline", regexp.MustCompile(`\*\*Timeline:\*\*`)},
+	{"next step", regexp.MustCompile(`(?im)^\**Next step:`)},
+}
+
+var (
+	timelineLine = regexp.MustCompile(`(?m)^\s*[-*]\s*\d{1,4}[-/.]\d{1,2}[-/.]\d{1,4}:`)
+	linkPattern  = regexp.MustCompile(`https?://[^\s)\]>"]+`)
+)
+
+// minTimelineMilestones is the fewest dated milestones the format asks for
+const minTimelineMilestones = 3
+
+// scoreAnswer checks an answer's structure, required phrases and citations
+func scoreAnswer(c Case, answer string) Score {
+	var s Score
+
+	checks := len(structureChecks) + 1
+	passed := 0
+	for _, check := range structureChecks {
+		if check.pattern.MatchString(answer) {
+			passed++
+		} else {
+			s.Missing = append(s.Missing, check.name)
+		}
+	}
+	if len(timelineLine.FindAllString(answer, -1)) >= minTimelineMilestones {
+		passed++
+	} else {
+		s.Missing = append(s.Missing, "dated milestones")
+	}
+	s.Structure = float64(passed) / float64(checks)
+
+	lower := strings.ToLower(answer)
+	found := 0
+	for _, phrase := range c.Required {
+		matched := false
+		for _, alternative := range strings.Split(phrase, "|") {
+			if strings.Contains(lower, strings.ToLower(strings.TrimSpace(alternative))) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			found++
+		} else {
+			s.Missing = append(s.Missing, "\""+phrase+"\"")
+		}
+	}
+	s.Required = 1
+	if len(c.Required) > 0 {
+		s.Required = float64(found) / float64(len(c.Required))
+	}
+
+	s.Citations = len(linkPattern.FindAllString(answer, -1))
+	return s
+}
@@ -0,0 +1,352 @@
+// Command loadtest sends synthetic queries to an agent at a steady rate and
+// reports latency percentiles and error rates, for capacity planning and
+// soak tests. Run the target with LLM_MODE=mock to measure the agent itself
+// rather than Gemini, without spending quota.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/a2aclient"
+)
+
+// Synthetic queries combine these at random
+var (
+	professions  = []string{"nurse", "software engineer", "electrician", "accountant", "teacher", "doctor", "chef", "data scientist", "welder", "pharmacist"}
+	origins      = []string{"Nigeria", "India", "Kenya", "Brazil", "Philippines", "Ghana", "Pakistan", "Mexico", "Vietnam", "Egypt"}
+	destinations = []string{"Canada", "the UK", "Germany", "Australia", "the USA"}
+	templates    = []string{
+		"I am a %s from %s and want to move to %s",
+		"How can a %s from %s migrate to %s?",
+		"%s in %s looking for a work visa for %s",
+	}
+)
+
+// Sample is the outcome of one request
+type Sample struct {
+	Outcome string        // task state, "error" or "dropped"
+	Failure string        // failure code of a failed task
+	Latency time.Duration // zero for dropped requests
+	At      time.Time
+}
+
+// Report summarizes a run
+type Report struct {
+	Target      string         `json:"target"`
+	Duration    string         `json:"duration"`
+	TargetRPS   float64        `json:"targetRps"`
+	AchievedRPS float64        `json:"achievedRps"`
+	Requests    int            `json:"requests"`
+	Outcomes    map[string]int `json:"outcomes"`
+	Failures    map[string]int `json:"failures,omitempty"` // failed tasks by failure code
+	ErrorRate   float64        `json:"errorRate"`
+	LatencyMs   Percentiles    `json:"latencyMs"`
+}
+
+// Percentiles of response latency in milliseconds
+type Percentiles struct {
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// headerList collects repeated -header "Name: value" flags
+type headerList [][2]string
+
+func (h *headerList) String() string {
+	pairs := make([]string, len(*h))
+	for i, header := range *h {
+		pairs[i] = header[0] + ": " + header[1]
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func (h *headerList) Set(value string) error {
+	name, val, ok := strings.Cut(value, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("expected \"Name: value\", got %q", value)
+	}
+	*h = append(*h, [2]string{strings.TrimSpace(name), strings.TrimSpace(val)})
+	return nil
+}
+
+func main() {
+	var headers headerList
+	target := flag.String("target", "http://localhost:8080", "agent base URL")
+	rps := flag.Float64("rps", 5, "requests per second to send")
+	duration := flag.Duration("duration", time.Minute, "how long to send requests for")
+	ramp := flag.Duration("ramp", 0, "time to ramp up linearly to -rps")
+	maxInFlight := flag.Int("max-in-flight", 256, "requests outstanding at once; sends beyond this are dropped and counted as errors")
+	timeout := flag.Duration("timeout", 2*time.Minute, "timeout per request")
+	detail := flag.String("detail", "", "detail level to request: brief, standard or deep")
+	every := flag.Duration("report-every", 10*time.Second, "interval between progress lines (0 disables them)")
+	asJSON := flag.Bool("json", false, "print the report as JSON instead of markdown")
+	maxErrorRate := flag.Float64("max-error-rate", 1, "exit non-zero if the error rate is above this (0-1)")
+	flag.Var(&headers, "header", "header to send with every request, e.g. \"Authorization: Bearer ...\"; repeatable")
+	flag.Parse()
+
+	if *rps <= 0 || *duration <= 0 || *maxInFlight < 1 {
+		log.Fatalf("❌ -rps, -duration and -max-in-flight must be positive")
+	}
+
+	opts := []a2aclient.Option{a2aclient.WithHTTPClient(&http.Client{
+		Timeout:   *timeout,
+		Transport: &http.Transport{MaxIdleConnsPerHost: *maxInFlight},
+	})}
+	for _, h := range headers {
+		opts = append(opts, a2aclient.WithHeader(h[0], h[1]))
+	}
+	client := a2aclient.New(*target, opts...)
+
+	log.Printf("🚦 Sending %.1f requests/s to %s for %s", *rps, *target, *duration)
+	samples := run(client, *rps, *duration, *ramp, *maxInFlight, *timeout, *detail, *every)
+	report := summarize(*target, samples, *rps, *duration)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		writeMarkdown(os.Stdout, report)
+	}
+
+	if report.ErrorRate > *maxErrorRate {
+		log.Printf("❌ Error rate %.1f%% is above %.1f%%", report.ErrorRate*100, *maxErrorRate*100)
+		os.Exit(1)
+	}
+}
+
+// run sends requests on an open-loop schedule, so a slow server faces the
+// same arrival rate rather than fewer requests, and waits for them to finish
+func run(client *a2aclient.Client, rps float64, duration, ramp time.Duration, maxInFlight int, timeout time.Duration, detail string, every time.Duration) []Sample {
+	var (
+		mu      sync.Mutex
+		samples []Sample
+		wg      sync.WaitGroup
+	)
+	record := func(s Sample) {
+		mu.Lock()
+		samples = append(samples, s)
+		mu.Unlock()
+	}
+
+	stopProgress := make(chan struct{})
+	if every > 0 {
+		go func() {
+			ticker := time.NewTicker(every)
+			defer ticker.Stop()
+			seen := 0
+			for {
+				select {
+				case <-stopProgress:
+					return
+				case <-ticker.C:
+					mu.Lock()
+					window := append([]Sample(nil), samples[seen:]...)
+					seen = len(samples)
+					mu.Unlock()
+					logProgress(window, every)
+				}
+			}
+		}()
+	}
+
+	slots := make(chan struct{}, maxInFlight)
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	start := time.Now()
+	for next := start; time.Since(start) < duration; {
+		time.Sleep(time.Until(next))
+
+		select {
+		case slots <- struct{}{}:
+			query := syntheticQuery(rng)
+			wg.Add(1)
+			go func() {
+				defer func() { <-slots; wg.Done() }()
+				record(send(client, query, detail, timeout))
+			}()
+		default:
+			record(Sample{Outcome: "dropped", At: time.Now()})
+		}
+
+		rate := rps
+		if elapsed := time.Since(start); elapsed < ramp {
+			rate = max(rps*float64(elapsed)/float64(ramp), 1)
+		}
+		next = next.Add(time.Duration(float64(time.Second) / rate))
+	}
+
+	wg.Wait()
+	close(stopProgress)
+	return samples
+}
+
+// syntheticQuery builds a random query
+func syntheticQuery(rng *rand.Rand) string {
+	query := fmt.Sprintf(templates[rng.Intn(len(templates))],
+		professions[rng.Intn(len(professions))],
+		origins[rng.Intn(len(origins))],
+		destinations[rng.Intn(len(destinations))])
+	if rng.Intn(2) == 0 {
+		query += fmt.Sprintf(" with a $%d budget", (rng.Intn(20)+1)*1000)
+	}
+	return query
+}
+
+// send makes one tasks/send call
+func send(client *a2aclient.Client, query, detail string, timeout time.Duration) Sample {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	params := a2aclient.NewTextTask(query)
+	params.Detail = detail
+	params.Metadata = map[string]interface{}{"source": "loadtest"}
+
+	started := time.Now()
+	task, err := client.SendTask(ctx, params)
+	sample := Sample{Latency: time.Since(started), At: time.Now()}
+	if err != nil {
+		sample.Outcome = "error"
+		return sample
+	}
+	sample.Outcome = task.Status.State
+	if failure := task.Failure(); failure != nil {
+		sample.Failure = failure.Code
+	}
+	return sample
+}
+
+// succeeded reports whether an outcome counts as served. A task queued for
+// a worker counts, since the agent accepted it.
+func succeeded(outcome string) bool {
+	return outcome == "completed" || outcome == "submitted"
+}
+
+// logProgress prints one line summarizing the latest interval
+func logProgress(window []Sample, interval time.Duration) {
+	if len(window) == 0 {
+		log.Printf("… no responses in the last %s", interval)
+		return
+	}
+	errors := 0
+	var latencies []time.Duration
+	for _, s := range window {
+		if !succeeded(s.Outcome) {
+			errors++
+		}
+		if s.Latency > 0 {
+			latencies = append(latencies, s.Latency)
+		}
+	}
+	p := percentiles(latencies)
+	log.Printf("… %.1f responses/s, p50 %.0fms, p95 %.0fms, errors %.1f%%",
+		float64(len(window))/interval.Seconds(), p.P50, p.P95, 100*float64(errors)/float64(len(window)))
+}
+
+// summarize aggregates the samples of a run
+func summarize(target string, samples []Sample, rps float64, duration time.Duration) Report {
+	report := Report{
+		Target:    target,
+		Duration:  duration.String(),
+		TargetRPS: rps,
+		Requests:  len(samples),
+		Outcomes:  make(map[string]int),
+		Failures:  make(map[string]int),
+	}
+
+	errors := 0
+	var latencies []time.Duration
+	for _, s := range samples {
+		report.Outcomes[s.Outcome]++
+		if s.Failure != "" {
+			report.Failures[s.Failure]++
+		}
+		if !succeeded(s.Outcome) {
+			errors++
+		}
+		if s.Latency > 0 {
+			latencies = append(latencies, s.Latency)
+		}
+	}
+	if len(samples) > 0 {
+		report.ErrorRate = float64(errors) / float64(len(samples))
+		report.AchievedRPS = float64(len(samples)) / duration.Seconds()
+	}
+	report.LatencyMs = percentiles(latencies)
+	return report
+}
+
+// percentiles computes latency percentiles by the nearest-rank method
+func percentiles(latencies []time.Duration) Percentiles {
+	if len(latencies) == 0 {
+		return Percentiles{}
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+	rank := func(p float64) float64 {
+		i := int(p*float64(len(sorted))+0.999999) - 1
+		return ms(sorted[max(i, 0)])
+	}
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return Percentiles{
+		Mean: ms(total / time.Duration(len(sorted))),
+		P50:  rank(0.50),
+		P90:  rank(0.90),
+		P95:  rank(0.95),
+		P99:  rank(0.99),
+		Max:  ms(sorted[len(sorted)-1]),
+	}
+}
+
+// writeMarkdown prints the report as markdown tables
+func writeMarkdown(w io.Writer, r Report) {
+	fmt.Fprintln(w, "# Load Test Report")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%d requests to %s over %s: %.1f/s sent of %.1f/s targeted, %.1f%% errors\n",
+		r.Requests, r.Target, r.Duration, r.AchievedRPS, r.TargetRPS, r.ErrorRate*100)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Mean | p50 | p90 | p95 | p99 | Max |")
+	fmt.Fprintln(w, "|------|-----|-----|-----|-----|-----|")
+	l := r.LatencyMs
+	fmt.Fprintf(w, "| %.0fms | %.0fms | %.0fms | %.0fms | %.0fms | %.0fms |\n", l.Mean, l.P50, l.P90, l.P95, l.P99, l.Max)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Outcome | Requests |")
+	fmt.Fprintln(w, "|---------|----------|")
+	for _, outcome := range sortedKeys(r.Outcomes) {
+		fmt.Fprintf(w, "| %s | %d |\n", outcome, r.Outcomes[outcome])
+	}
+	for _, code := range sortedKeys(r.Failures) {
+		fmt.Fprintf(w, "| failed: %s | %d |\n", code, r.Failures[code])
+	}
+}
+
+// sortedKeys lists a map's keys alphabetically
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/a2aclient"
+)
+
+// downloadArtifacts fetches a task and saves every artifact part to outDir:
+// text parts as .md, file parts as the decoded file, data parts as .json
+func downloadArtifacts(taskID, outDir string) {
+	task, err := client.GetTask(context.Background(), taskID)
+	if err != nil {
+		exitWithError(err)
+	}
+	if len(task.Artifacts) == 0 {
+		exitWithError(fmt.Errorf("task %s has no artifacts (status: %s)", task.ID, task.Status.State))
+	}
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		exitWithError(fmt.Errorf("error creating output directory: %v", err))
+	}
+
+	used := make(map[string]bool)
+	var saved []string
+	for i, artifact := range task.Artifacts {
+		base := artifact.Name
+		if base == "" {
+			base = fmt.Sprintf("artifact-%d", i+1)
+		}
+
+		for j, part := range artifact.Parts {
+			name, content, err := artifactPartContent(base, part)
+			if err != nil {
+				exitWithError(fmt.Errorf("artifact %q part %d: %v", base, j+1, err))
+			}
+			if content == nil {
+				continue
+			}
+
+			path := filepath.Join(outDir, uniqueFileName(name, used))
+			if err := os.WriteFile(path, content, 0o644); err != nil {
+				exitWithError(fmt.Errorf("error writing artifact: %v", err))
+			}
+			saved = append(saved, path)
+		}
+	}
+
+	if opts.JSON {
+		encoded, _ := json.MarshalIndent(map[string]interface{}{"taskId": task.ID, "files": saved}, "", "  ")
+		fmt.Fprintln(out, string(encoded))
+		return
+	}
+
+	fmt.Fprintf(out, "📦 Saved %d file(s) from task %s\n", len(saved), task.ID)
+	for _, path := range saved {
+		fmt.Fprintf(out, "  %s\n", path)
+	}
+}
+
+// artifactPartContent returns the file name and bytes to write for a part,
+// or nil content for parts with nothing to save
+func artifactPartContent(base string, part a2aclient.Part) (string, []byte, error) {
+	switch part.PartKind() {
+	case "text":
+		if part.Text == "" {
+			return "", nil, nil
+		}
+		return base + ".md", []byte(part.Text), nil
+
+	case "data":
+		encoded, err := json.MarshalIndent(part.Data, "", "  ")
+		if err != nil {
+			return "", nil, fmt.Errorf("error encoding data: %v", err)
+		}
+		return base + ".json", append(encoded, '\n'), nil
+
+	case "file":
+		if part.File == nil {
+			return "", nil, nil
+		}
+		name := part.File.Name
+		if name == "" {
+			name = base
+		}
+		if part.File.Bytes != "" {
+			content, err := base64.StdEncoding.DecodeString(part.File.Bytes)
+			if err != nil {
+				return "", nil, fmt.Errorf("error decoding file bytes: %v", err)
+			}
+			return name, content, nil
+		}
+		if part.File.URI != "" {
+			content, err := fetchURI(part.File.URI)
+			return name, content, err
+		}
+		return "", nil, nil
+
+	default:
+		return "", nil, nil
+	}
+}
+
+// fetchURI downloads a file part that is referenced by URI instead of inlined
+func fetchURI(uri string) ([]byte, error) {
+	if !strings.HasPrefix(uri, "http://") && !strings.HasPrefix(uri, "https://") {
+		return nil, fmt.Errorf("unsupported file URI %q", uri)
+	}
+
+	httpClient := &http.Client{Timeout: opts.Timeout}
+	resp, err := httpClient.Get(uri)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading %s: %v", uri, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error downloading %s: %s", uri, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// uniqueFileName sanitizes a name and appends a counter if it was already used
+func uniqueFileName(name string, used map[string]bool) string {
+	name = strings.Trim(unsafeFileChars.ReplaceAllString(filepath.Base(name), "_"), ".")
+	if name == "" {
+		name = "artifact"
+	}
+
+	candidate := name
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s-%d%s", stem, n, ext)
+	}
+	used[candidate] = true
+	return candidate
+}
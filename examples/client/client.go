@@ -1,159 +1,581 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/migration-pathways-agent/pkg/a2aclient"
+)
+
+// client and out are configured from the persistent flags before each command runs
+var (
+	client    *a2aclient.Client
+	out       io.Writer = os.Stdout
+	streaming bool
+	styled    bool
 )
 
-func main() {
-	agentURL := "http://localhost:8080"
-	
-	if len(os.Args) < 2 {
-		fmt.Println("Migration Pathways Agent - Test Client")
-		fmt.Println("======================================")
-		fmt.Println()
-		fmt.Println("Usage:")
-		fmt.Println("  go run client.go <command> [args]")
-		fmt.Println()
-		fmt.Println("Commands:")
-		fmt.Println("  card               - Get agent card")
-		fmt.Println("  query \"<text>\"     - Send a migration query")
-		fmt.Println()
-		fmt.Println("Examples:")
-		fmt.Println("  go run client.go card")
-		fmt.Println("  go run client.go query \"I'm a software engineer from Nigeria, want to move to Canada\"")
-		fmt.Println("  go run client.go query \"Data scientist looking to relocate to USA with $5000 budget\"")
-		os.Exit(0)
-	}
-
-	command := os.Args[1]
-
-	switch command {
-	case "card":
-		getAgentCard(agentURL)
-	case "query":
-		if len(os.Args) < 3 {
-			fmt.Println("Error: query command requires a text argument")
-			fmt.Println("Example: go run client.go query \"I'm a software engineer wanting to move to Canada\"")
+func getAgentCard() {
+	card, err := client.GetAgentCard(context.Background())
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var prettyJSON bytes.Buffer
+	json.Indent(&prettyJSON, card.Raw, "", "  ")
+
+	fmt.Fprintln(out, "🤖 Agent Card")
+	fmt.Fprintln(out, "=============")
+	fmt.Fprintln(out, prettyJSON.String())
+}
+
+// sendTask submits a query via tasks/send, optionally continuing a session
+func sendTask(query, sessionID string) (*a2aclient.Task, error) {
+	params := a2aclient.NewTextTask(query)
+	params.SessionID = sessionID
+	return client.SendTask(context.Background(), params)
+}
+
+// exitWithError reports a failed call, as a JSON error object on stdout in
+// -json mode, and exits non-zero
+func exitWithError(err error) {
+	if opts.JSON {
+		body := map[string]interface{}{"error": map[string]interface{}{"message": err.Error()}}
+		var rpcErr *a2aclient.RPCError
+		if errors.As(err, &rpcErr) {
+			body["error"] = rpcErr
+		}
+		encoded, _ := json.MarshalIndent(body, "", "  ")
+		fmt.Fprintln(out, string(encoded))
+	} else {
+		fmt.Printf("❌ Error: %v\n", err)
+	}
+	os.Exit(1)
+}
+
+// printJSON writes the task (or just its data parts) as indented JSON
+func printJSON(task *a2aclient.Task) {
+	var value interface{} = task.Raw
+	if opts.Data {
+		data := []interface{}{}
+		if task.Status.Message != nil {
+			for _, part := range task.Status.Message.Parts {
+				if part.PartKind() == "data" {
+					data = append(data, part.Data)
+				}
+			}
+		}
+		for _, artifact := range task.Artifacts {
+			for _, part := range artifact.Parts {
+				if part.PartKind() == "data" {
+					data = append(data, part.Data)
+				}
+			}
+		}
+		value = data
+	}
+
+	encoded, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		exitWithError(err)
+	}
+	fmt.Fprintln(out, string(encoded))
+}
+
+// showTask prints a task, optionally polling with backoff until it reaches
+// a terminal state and including its recent status history
+func showTask(taskID string, wait bool, maxWait time.Duration, history int) {
+	ctx := context.Background()
+	task, err := client.GetTask(ctx, taskID)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	deadline := time.Now().Add(maxWait)
+	delay := time.Second
+	for wait && !task.Terminal() {
+		if time.Now().After(deadline) {
+			exitWithError(fmt.Errorf("gave up after %s; task is still %s", maxWait, task.Status.State))
+		}
+
+		fmt.Fprintf(os.Stderr, "⏳ %s, checking again in %s...\n", task.Status.State, delay)
+		time.Sleep(delay)
+		delay = delay * 3 / 2
+		if delay > 15*time.Second {
+			delay = 15 * time.Second
+		}
+
+		if task, err = client.GetTask(ctx, taskID); err != nil {
+			exitWithError(err)
+		}
+	}
+
+	if history > 0 {
+		if task, err = client.GetTaskHistory(ctx, taskID, history); err != nil {
+			exitWithError(err)
+		}
+	}
+
+	if opts.JSON {
+		printJSON(task)
+		return
+	}
+
+	fmt.Fprintln(out, "📋 Task")
+	fmt.Fprintln(out, "=======")
+	fmt.Fprintf(out, "Task ID: %s\n", task.ID)
+	fmt.Fprintf(out, "Status: %s\n\n", task.Status.State)
+
+	if len(task.History) > 0 {
+		fmt.Fprintln(out, "History:")
+		for _, status := range task.History {
+			fmt.Fprintf(out, "  %s  %s\n", status.Timestamp, status.State)
+		}
+		fmt.Fprintln(out)
+	}
+
+	printArtifacts(task)
+}
+
+func sendQuery(query string) {
+	if opts.JSON {
+		task, err := sendTask(query, "")
+		if err != nil {
+			exitWithError(err)
+		}
+		printJSON(task)
+		return
+	}
+
+	// Display result
+	fmt.Fprintln(out, "📊 Migration Pathways Result")
+	fmt.Fprintln(out, "===========================")
+
+	if streaming {
+		if _, err := streamTask(query, ""); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
 			os.Exit(1)
 		}
-		query := os.Args[2]
-		sendQuery(agentURL, query)
-	default:
-		fmt.Printf("Unknown command: %s\n", command)
+		return
+	}
+
+	task, err := sendTask(query, "")
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
 		os.Exit(1)
 	}
+
+	fmt.Fprintf(out, "Task ID: %s\n", task.ID)
+	fmt.Fprintf(out, "Status: %s\n\n", task.Status.State)
+
+	printArtifacts(task)
 }
 
-func getAgentCard(agentURL string) {
-	resp, err := http.Get(agentURL + "/.well-known/agent.json")
+// sendMessage submits a query via message/send using either the wrapper
+// params form (which can carry a session ID) or the bare Message form
+func sendMessage(query, sessionID string, bare bool) {
+	params := a2aclient.NewTextTask(query)
+	params.SessionID = sessionID
+
+	var payload interface{} = params
+	if bare {
+		payload = params.Message
+	}
+
+	task, err := client.SendMessage(context.Background(), payload)
 	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+		exitWithError(err)
+	}
+
+	if opts.JSON {
+		printJSON(task)
+		return
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	fmt.Fprintln(out, "📨 Migration Pathways Message")
+	fmt.Fprintln(out, "=============================")
+	fmt.Fprintf(out, "Task ID: %s\n", task.ID)
+	if task.SessionID != "" {
+		fmt.Fprintf(out, "Session: %s (continue with --session %s)\n", task.SessionID, task.SessionID)
+	}
+	fmt.Fprintf(out, "Status: %s\n\n", task.Status.State)
+
+	printArtifacts(task)
+}
+
+// agentSupportsStreaming reports whether the agent card advertises streaming
+func agentSupportsStreaming() bool {
+	card, err := client.GetAgentCard(context.Background())
 	if err != nil {
-		fmt.Printf("Error reading response: %v\n", err)
-		os.Exit(1)
+		return false
 	}
+	return card.SupportsStreaming()
+}
 
-	var prettyJSON bytes.Buffer
-	json.Indent(&prettyJSON, body, "", "  ")
-	
-	fmt.Println("🤖 Agent Card")
-	fmt.Println("=============")
-	fmt.Println(prettyJSON.String())
-}
-
-func sendQuery(agentURL string, query string) {
-	// Create JSON-RPC request
-	request := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"method":  "tasks/send",
-		"params": map[string]interface{}{
-			"message": map[string]interface{}{
-				"role": "user",
-				"parts": []map[string]interface{}{
-					{
-						"type": "text",
-						"text": query,
-					},
-				},
-			},
-		},
-		"id": 1,
-	}
-
-	requestBody, err := json.Marshal(request)
+// streamTask submits a query via tasks/sendSubscribe and renders the events
+// as they arrive. It returns the final task state.
+func streamTask(query, sessionID string) (string, error) {
+	params := a2aclient.NewTextTask(query)
+	params.SessionID = sessionID
+
+	stream, err := client.Subscribe(context.Background(), params)
 	if err != nil {
-		fmt.Printf("Error creating request: %v\n", err)
-		os.Exit(1)
+		return "", err
+	}
+	defer stream.Close()
+
+	state := ""
+	for {
+		event, err := stream.Next()
+		if err == io.EOF {
+			return state, nil
+		}
+		if err != nil {
+			return state, err
+		}
+
+		// Agents that don't stream reply with the finished task
+		if event.Task != nil {
+			printArtifacts(event.Task)
+			return event.Task.Status.State, nil
+		}
+
+		if event.Status != nil {
+			state = event.Status.State
+			fmt.Printf("⏳ %s\n", state)
+			if event.Final && event.Status.Message != nil && state != "completed" {
+				printTextParts(event.Status.Message.Parts)
+			}
+		}
+		if event.Artifact != nil {
+			for _, part := range event.Artifact.Parts {
+				if part.PartKind() == "text" {
+					fmt.Fprint(out, formatText(part.Text))
+				}
+			}
+			if event.Artifact.LastChunk {
+				fmt.Fprintln(out)
+			}
+		}
+	}
+}
+
+// printArtifacts writes the text parts of every artifact, falling back to
+// the status message for tasks without artifacts (e.g. failed or rejected)
+func printArtifacts(task *a2aclient.Task) {
+	printed := false
+	for _, artifact := range task.LatestArtifacts() {
+		if printTextParts(artifact.Parts) {
+			printed = true
+		}
+	}
+
+	if !printed && task.Status.Message != nil {
+		printTextParts(task.Status.Message.Parts)
+	}
+}
+
+// printTextParts writes each text part and reports whether any were printed
+func printTextParts(parts []a2aclient.Part) bool {
+	printed := false
+	for _, part := range parts {
+		if part.PartKind() == "text" {
+			fmt.Fprintln(out, formatText(part.Text))
+			printed = true
+		}
+	}
+	return printed
+}
+
+// batchQuery is one entry of a batch input file
+type batchQuery struct {
+	ID        string `json:"id"`
+	Query     string `json:"query"`
+	SessionID string `json:"sessionId,omitempty"`
+}
+
+// batchSummary is written to summary.jsonl for each processed query
+type batchSummary struct {
+	ID         string `json:"id"`
+	Query      string `json:"query"`
+	TaskID     string `json:"taskId,omitempty"`
+	State      string `json:"state,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// readBatchFile loads queries from a file with one query per line, or JSONL
+// lines of the form {"id": "...", "query": "..."}. Blank lines and lines
+// starting with # are skipped.
+func readBatchFile(path string) ([]batchQuery, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
+	defer f.Close()
 
-	// Send request
-	resp, err := http.Post(agentURL+"/", "application/json", bytes.NewBuffer(requestBody))
+	var queries []batchQuery
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		q := batchQuery{Query: line}
+		if strings.HasPrefix(line, "{") {
+			q = batchQuery{}
+			if err := json.Unmarshal([]byte(line), &q); err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNo, err)
+			}
+			if q.Query == "" {
+				return nil, fmt.Errorf("line %d: missing \"query\"", lineNo)
+			}
+		}
+		if q.ID == "" {
+			q.ID = fmt.Sprintf("%04d", lineNo)
+		}
+		queries = append(queries, q)
+	}
+	return queries, scanner.Err()
+}
+
+// runBatch sends every query in a file with bounded concurrency and writes
+// each result (raw JSON and markdown) plus a summary to the output directory
+func runBatch(inputPath string, concurrency int, outDir string) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	queries, err := readBatchFile(inputPath)
 	if err != nil {
-		fmt.Printf("Error sending request: %v\n", err)
+		fmt.Printf("Error reading %s: %v\n", inputPath, err)
 		os.Exit(1)
 	}
-	defer resp.Body.Close()
-
-	// Parse response
-	var rpcResponse struct {
-		JSONRPC string `json:"jsonrpc"`
-		Result  struct {
-			ID        string `json:"id"`
-			Status    struct {
-				State   string `json:"state"`
-				Message string `json:"message"`
-			} `json:"status"`
-			Artifacts []struct {
-				Parts []struct {
-					Type string `json:"type"`
-					Text string `json:"text"`
-				} `json:"parts"`
-			} `json:"artifacts"`
-		} `json:"result"`
-		Error *struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-			Data    string `json:"data"`
-		} `json:"error"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&rpcResponse); err != nil {
-		fmt.Printf("Error decoding response: %v\n", err)
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		fmt.Printf("Error creating output directory: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Check for errors
-	if rpcResponse.Error != nil {
-		fmt.Printf("❌ Error: %s (code: %d)\n", rpcResponse.Error.Message, rpcResponse.Error.Code)
-		if rpcResponse.Error.Data != "" {
-			fmt.Printf("   Details: %s\n", rpcResponse.Error.Data)
+	summaries := make([]batchSummary, len(queries))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+
+	for i, q := range queries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, q batchQuery) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			started := time.Now()
+			summary := batchSummary{ID: q.ID, Query: q.Query}
+			result, err := sendTask(q.Query, q.SessionID)
+			summary.DurationMs = time.Since(started).Milliseconds()
+
+			if err != nil {
+				summary.Error = err.Error()
+			} else {
+				summary.TaskID = result.ID
+				summary.State = result.Status.State
+				if err := writeBatchResult(outDir, q.ID, result); err != nil {
+					summary.Error = err.Error()
+				}
+			}
+			summaries[i] = summary
+
+			mu.Lock()
+			done++
+			status := summary.State
+			if summary.Error != "" {
+				status = "error: " + summary.Error
+			}
+			fmt.Printf("[%d/%d] %s %s (%dms)\n", done, len(queries), q.ID, status, summary.DurationMs)
+			mu.Unlock()
+		}(i, q)
+	}
+	wg.Wait()
+
+	summaryFile, err := os.Create(filepath.Join(outDir, "summary.jsonl"))
+	if err != nil {
+		fmt.Printf("Error writing summary: %v\n", err)
+		os.Exit(1)
+	}
+	defer summaryFile.Close()
+
+	failed := 0
+	encoder := json.NewEncoder(summaryFile)
+	for _, summary := range summaries {
+		encoder.Encode(summary)
+		if summary.Error != "" || summary.State != "completed" {
+			failed++
 		}
+	}
+
+	fmt.Printf("\n✅ %d completed, %d failed — results in %s\n", len(queries)-failed, failed, outDir)
+	if failed > 0 {
 		os.Exit(1)
 	}
+}
 
-	// Display result
-	fmt.Println("📊 Migration Pathways Result")
-	fmt.Println("===========================")
-	fmt.Printf("Task ID: %s\n", rpcResponse.Result.ID)
-	fmt.Printf("Status: %s\n\n", rpcResponse.Result.Status.State)
+// unsafeFileChars matches characters not allowed in result file names
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
 
-	if len(rpcResponse.Result.Artifacts) > 0 {
-		for _, artifact := range rpcResponse.Result.Artifacts {
-			for _, part := range artifact.Parts {
-				if part.Type == "text" {
-					fmt.Println(part.Text)
-				}
+// writeBatchResult saves the raw task JSON and its rendered text
+func writeBatchResult(dir, id string, result *a2aclient.Task) error {
+	id = strings.Trim(unsafeFileChars.ReplaceAllString(id, "_"), ".")
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, result.Raw, "", "  "); err != nil {
+		return fmt.Errorf("error formatting result: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, id+".json"), pretty.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("error writing result: %v", err)
+	}
+
+	var text strings.Builder
+	for _, artifact := range result.Artifacts {
+		if artifactText := artifact.Text(); artifactText != "" {
+			text.WriteString(artifactText)
+			text.WriteString("\n")
+		}
+	}
+	if text.Len() == 0 {
+		return nil
+	}
+	if err := os.WriteFile(filepath.Join(dir, id+".md"), []byte(text.String()), 0o644); err != nil {
+		return fmt.Errorf("error writing result: %v", err)
+	}
+	return nil
+}
+
+// chat runs an interactive loop that keeps one session across turns
+func chat() {
+	sessionID := uuid.New().String()
+
+	fmt.Println("💬 Migration Pathways Chat")
+	fmt.Println("==========================")
+	fmt.Printf("Session: %s\n", sessionID)
+	fmt.Println("Describe your situation and press Enter. Type 'exit' to quit.")
+	fmt.Println()
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("you> ")
+		if !scanner.Scan() {
+			fmt.Println()
+			return
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		switch strings.ToLower(line) {
+		case "":
+			continue
+		case "exit", "quit", "/exit", "/quit":
+			return
+		}
+
+		fmt.Println("agent> ⏳ thinking...")
+		if streaming {
+			if _, err := streamTask(line, sessionID); err != nil {
+				fmt.Printf("agent> ❌ %v\n", err)
 			}
+			fmt.Fprintln(out)
+			continue
+		}
+
+		task, err := sendTask(line, sessionID)
+		if err != nil {
+			fmt.Printf("agent> ❌ %v\n\n", err)
+			continue
 		}
+
+		if task.Status.State != "completed" {
+			fmt.Printf("agent> [%s]\n", task.Status.State)
+		}
+		printArtifacts(task)
+		fmt.Fprintln(out)
+	}
+}
+
+// ANSI escape sequences used to render markdown in the terminal
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiNoBold    = "\x1b[22m"
+	ansiItalic    = "\x1b[3m"
+	ansiNoItalic  = "\x1b[23m"
+	ansiUnderline = "\x1b[4m"
+	ansiCyan      = "\x1b[36m"
+	ansiYellow    = "\x1b[33m"
+	ansiDim       = "\x1b[2m"
+	ansiNoColor   = "\x1b[39m"
+)
+
+var (
+	mdBold   = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	mdItalic = regexp.MustCompile(`\*([^*\s][^*]*?)\*`)
+	mdCode   = regexp.MustCompile("`([^`]+)`")
+	mdLink   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdList   = regexp.MustCompile(`^(\s*)[-*]\s+`)
+)
+
+// formatText renders markdown with terminal styling unless --raw is set
+func formatText(text string) string {
+	if !styled {
+		return text
+	}
+	return renderMarkdownANSI(text)
+}
+
+// renderMarkdownANSI styles headings, emphasis, code, links and bullets
+func renderMarkdownANSI(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			heading := strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			heading = mdBold.ReplaceAllString(heading, "$1")
+			if strings.HasPrefix(trimmed, "# ") {
+				lines[i] = ansiBold + ansiUnderline + ansiCyan + heading + ansiReset
+			} else {
+				lines[i] = ansiBold + ansiCyan + heading + ansiReset
+			}
+			continue
+
+		case trimmed == "---" || trimmed == "***":
+			lines[i] = ansiDim + strings.Repeat("─", 40) + ansiReset
+			continue
+
+		case mdList.MatchString(line):
+			indent := mdList.FindStringSubmatch(line)[1]
+			line = indent + "  " + ansiYellow + "•" + ansiNoColor + " " + mdList.ReplaceAllString(line, "")
+		}
+
+		line = mdCode.ReplaceAllString(line, ansiCyan+"$1"+ansiNoColor)
+		line = mdLink.ReplaceAllString(line, "$1 ("+ansiUnderline+"$2"+ansiReset+")")
+		line = mdBold.ReplaceAllString(line, ansiBold+"$1"+ansiNoBold)
+		line = mdItalic.ReplaceAllString(line, ansiItalic+"$1"+ansiNoItalic)
+		lines[i] = line
 	}
+	return strings.Join(lines, "\n")
 }
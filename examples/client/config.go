@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// envPrefix is prepended to flag names to form environment variable names
+const envPrefix = "PATHWAYS_"
+
+// bindEnvAndConfig fills every flag the user didn't set on the command line
+// from PATHWAYS_<FLAG> environment variables, then from the config file
+func bindEnvAndConfig(cmd *cobra.Command) error {
+	path := opts.Config
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv(envPrefix + "CONFIG")
+		explicit = path != ""
+	}
+	if !explicit {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, ".pathways.yaml")
+		}
+	}
+
+	config, err := loadConfigFile(path)
+	if err != nil && (explicit || !os.IsNotExist(err)) {
+		return fmt.Errorf("error reading config %s: %v", path, err)
+	}
+
+	var bindErr error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed || bindErr != nil {
+			return
+		}
+
+		envName := envPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		value, ok := os.LookupEnv(envName)
+		source := envName
+		if !ok {
+			value, ok = config[f.Name]
+			source = path
+		}
+		if !ok {
+			return
+		}
+
+		if err := f.Value.Set(value); err != nil {
+			bindErr = fmt.Errorf("invalid value %q for %s (from %s): %v", value, f.Name, source, err)
+		}
+	})
+	return bindErr
+}
+
+// loadConfigFile reads a flat YAML file of "key: value" lines. Blank lines
+// and # comments are skipped and values may be quoted; nested structures
+// are not supported.
+func loadConfigFile(path string) (map[string]string, error) {
+	values := make(map[string]string)
+	if path == "" {
+		return values, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return values, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return values, fmt.Errorf("line %d: expected \"key: value\"", lineNo)
+		}
+		key := strings.TrimSpace(parts[0])
+		val := strings.TrimSpace(parts[1])
+
+		// Drop trailing comments from unquoted values
+		if idx := strings.Index(val, " #"); idx != -1 && !strings.HasPrefix(val, `"`) && !strings.HasPrefix(val, "'") {
+			val = strings.TrimSpace(val[:idx])
+		}
+		// Remove surrounding quotes if present
+		if len(val) >= 2 && ((val[0] == '"' && val[len(val)-1] == '"') || (val[0] == '\'' && val[len(val)-1] == '\'')) {
+			val = val[1 : len(val)-1]
+		}
+
+		values[key] = val
+	}
+	return values, scanner.Err()
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/yourusername/migration-pathways-agent/pkg/a2aclient"
+)
+
+// options holds the persistent flags shared by every command. Values come
+// from (highest precedence first) command-line flags, PATHWAYS_* environment
+// variables, the config file, and the flag defaults.
+type options struct {
+	URL     string
+	Timeout time.Duration
+	Output  string
+	Stream  bool
+	Raw     bool
+	JSON    bool
+	Data    bool
+	Config  string
+}
+
+var opts options
+
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:   "pathways",
+		Short: "Command-line client for the Migration Pathways Agent",
+		Long: "pathways talks to a Migration Pathways Agent over A2A (JSON-RPC 2.0).\n\n" +
+			"Flags can also be set with PATHWAYS_<FLAG> environment variables (e.g. PATHWAYS_URL)\n" +
+			"or in ~/.pathways.yaml as \"key: value\" lines (e.g. url: https://agent.example.com).",
+		SilenceUsage:      true,
+		PersistentPreRunE: setup,
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&opts.URL, "url", "http://localhost:8080", "base URL of the agent (e.g. a staging or production deployment)")
+	flags.DurationVar(&opts.Timeout, "timeout", 90*time.Second, "HTTP request timeout")
+	flags.StringVar(&opts.Output, "output", "", "write results to this file instead of stdout")
+	flags.BoolVar(&opts.Stream, "stream", true, "stream results via tasks/sendSubscribe when the agent advertises streaming")
+	flags.BoolVar(&opts.Raw, "raw", false, "print markdown as-is instead of rendering it with terminal styling")
+	flags.BoolVar(&opts.JSON, "json", false, "print the JSON-RPC result as JSON (exits non-zero on RPC errors)")
+	flags.BoolVar(&opts.Data, "data", false, "with --json, print only the data parts of the result")
+	flags.StringVar(&opts.Config, "config", "", "config file (default ~/.pathways.yaml)")
+
+	root.AddCommand(
+		newCardCommand(),
+		newQueryCommand(),
+		newMessageCommand(),
+		newChatCommand(),
+		newTaskCommand(),
+		newArtifactCommand(),
+		newBatchCommand(),
+	)
+	return root
+}
+
+// setup resolves flag values from the environment and config file, then
+// configures the shared client and output
+func setup(cmd *cobra.Command, _ []string) error {
+	if err := bindEnvAndConfig(cmd); err != nil {
+		return err
+	}
+
+	client = a2aclient.New(opts.URL, a2aclient.WithHTTPClient(&http.Client{Timeout: opts.Timeout}))
+
+	if opts.Output != "" {
+		f, err := os.Create(opts.Output)
+		if err != nil {
+			return fmt.Errorf("error opening output file: %v", err)
+		}
+		out = f
+	}
+
+	// Style markdown only for interactive output
+	styled = !opts.Raw && opts.Output == "" && os.Getenv("NO_COLOR") == ""
+
+	if opts.Stream && !opts.JSON && (cmd.Name() == "query" || cmd.Name() == "chat") {
+		streaming = agentSupportsStreaming()
+	}
+	return nil
+}
+
+func newCardCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "card",
+		Short: "Get the agent card",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			getAgentCard()
+		},
+	}
+}
+
+func newQueryCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "query <text>",
+		Short: "Send a migration query",
+		Example: `  pathways query "I'm a software engineer from Nigeria, want to move to Canada"
+  pathways --url https://staging.example.com --timeout 2m query "Data scientist looking to relocate to USA with $5000 budget"
+  pathways --json query "Nurse from India wanting to move to UK" | jq .status.state`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			sendQuery(strings.Join(args, " "))
+		},
+	}
+}
+
+func newMessageCommand() *cobra.Command {
+	var sessionID string
+	var newSession bool
+	var bare bool
+
+	cmd := &cobra.Command{
+		Use:   "message <text>",
+		Short: "Send a query via message/send, optionally continuing a session",
+		Long: "message calls the message/send method. By default params use the wrapper form\n" +
+			"{\"message\": {...}, \"sessionId\": \"...\"}; --bare sends the Message itself as params\n" +
+			"(the bare form cannot carry a session ID).",
+		Example: `  pathways message --new-session "Software engineer from Kenya moving to Germany"
+  pathways message --session 6a0e... "What if my budget were $3000?"
+  pathways message --bare "Nurse from India wanting to move to UK"`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if bare && (sessionID != "" || newSession) {
+				return fmt.Errorf("--bare cannot be combined with --session or --new-session")
+			}
+			if newSession {
+				if sessionID != "" {
+					return fmt.Errorf("--new-session cannot be combined with --session")
+				}
+				sessionID = uuid.New().String()
+			}
+			sendMessage(strings.Join(args, " "), sessionID, bare)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&sessionID, "session", "", "continue an existing session")
+	cmd.Flags().BoolVar(&newSession, "new-session", false, "start a new session and print its ID")
+	cmd.Flags().BoolVar(&bare, "bare", false, "send the bare Message as params instead of the wrapper form")
+	return cmd
+}
+
+func newChatCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "chat",
+		Short: "Start an interactive session",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			chat()
+		},
+	}
+}
+
+func newTaskCommand() *cobra.Command {
+	var wait bool
+	var maxWait time.Duration
+	var history int
+
+	cmd := &cobra.Command{
+		Use:     "task <id>",
+		Short:   "Show a task, optionally polling until it finishes",
+		Example: "  pathways task 3f1c9a7e-... --wait\n  pathways task 3f1c9a7e-... --history 10",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			showTask(args[0], wait, maxWait, history)
+		},
+	}
+	cmd.Flags().BoolVar(&wait, "wait", false, "poll until the task reaches a terminal state")
+	cmd.Flags().DurationVar(&maxWait, "max-wait", 10*time.Minute, "give up waiting after this long")
+	cmd.Flags().IntVar(&history, "history", 0, "also show up to this many recent status transitions")
+	return cmd
+}
+
+func newArtifactCommand() *cobra.Command {
+	var outDir string
+
+	cmd := &cobra.Command{
+		Use:   "artifact <task-id>",
+		Short: "Save a task's artifacts to disk",
+		Long: "artifact downloads every artifact of a task: text parts are saved as .md,\n" +
+			"file parts as the decoded file (e.g. migration-timeline.ics) and data parts as .json.",
+		Example: "  pathways artifact 3f1c9a7e-... --out plan",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			downloadArtifacts(args[0], outDir)
+		},
+	}
+	cmd.Flags().StringVar(&outDir, "out", ".", "directory to write artifacts to")
+	return cmd
+}
+
+func newBatchCommand() *cobra.Command {
+	var concurrency int
+	var outDir string
+
+	cmd := &cobra.Command{
+		Use:   "batch <file>",
+		Short: "Send one query per line (or JSONL) and save results",
+		Long: "batch reads queries from a file with one query per line, or JSONL lines of the form\n" +
+			"{\"id\": \"...\", \"query\": \"...\"}, and writes each result plus summary.jsonl to --out.",
+		Example: "  pathways batch queries.txt --concurrency 8 --out results",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runBatch(args[0], concurrency, outDir)
+		},
+	}
+	cmd.Flags().IntVar(&concurrency, "concurrency", 4, "maximum number of queries in flight")
+	cmd.Flags().StringVar(&outDir, "out", "batch-results", "directory to write results to")
+	return cmd
+}
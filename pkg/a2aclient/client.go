@@ -0,0 +1,322 @@
+// Package a2aclient is a Go client for A2A agents speaking JSON-RPC 2.0 over
+// HTTP, such as the migration pathways agent.
+package a2aclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultEndpoint is the path of the agent's JSON-RPC endpoint
+const DefaultEndpoint = "/a2a/planner"
+
+// Client calls an A2A agent
+type Client struct {
+	baseURL    string
+	endpoint   string
+	httpClient *http.Client
+	header     http.Header
+	nextID     int64
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for all requests
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithEndpoint overrides the JSON-RPC endpoint path (or absolute URL)
+func WithEndpoint(endpoint string) Option {
+	return func(c *Client) { c.endpoint = endpoint }
+}
+
+// WithHeader adds a header to every request, e.g. for authentication
+func WithHeader(key, value string) Option {
+	return func(c *Client) { c.header.Add(key, value) }
+}
+
+// New creates a client for the agent at baseURL
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		endpoint:   DefaultEndpoint,
+		httpClient: http.DefaultClient,
+		header:     make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// BaseURL returns the agent's base URL
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+func (c *Client) endpointURL() string {
+	if strings.HasPrefix(c.endpoint, "http://") || strings.HasPrefix(c.endpoint, "https://") {
+		return c.endpoint
+	}
+	return c.baseURL + c.endpoint
+}
+
+// GetAgentCard fetches the agent card
+func (c *Client) GetAgentCard(ctx context.Context) (*AgentCard, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/.well-known/agent.json", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	c.applyHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching agent card: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading agent card: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &HTTPError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var card AgentCard
+	if err := json.Unmarshal(body, &card); err != nil {
+		return nil, fmt.Errorf("error decoding agent card: %v", err)
+	}
+	card.Raw = body
+	return &card, nil
+}
+
+// SendTask submits a task via tasks/send and returns it once processed
+func (c *Client) SendTask(ctx context.Context, params TaskSendParams) (*Task, error) {
+	return c.callTask(ctx, "tasks/send", params)
+}
+
+// SendMessage submits a message via message/send. params may be a
+// TaskSendParams (the wrapper form) or a bare Message.
+func (c *Client) SendMessage(ctx context.Context, params interface{}) (*Task, error) {
+	return c.callTask(ctx, "message/send", params)
+}
+
+// GetTask fetches a task via tasks/get
+func (c *Client) GetTask(ctx context.Context, taskID string) (*Task, error) {
+	return c.callTask(ctx, "tasks/get", map[string]interface{}{"id": taskID})
+}
+
+// GetTaskHistory fetches a task along with up to historyLength of its most
+// recent status transitions
+func (c *Client) GetTaskHistory(ctx context.Context, taskID string, historyLength int) (*Task, error) {
+	return c.callTask(ctx, "tasks/get", map[string]interface{}{"id": taskID, "historyLength": historyLength})
+}
+
+func (c *Client) callTask(ctx context.Context, method string, params interface{}) (*Task, error) {
+	var raw json.RawMessage
+	if err := c.Call(ctx, method, params, &raw); err != nil {
+		return nil, err
+	}
+
+	var task Task
+	if err := json.Unmarshal(raw, &task); err != nil {
+		return nil, fmt.Errorf("error decoding task: %v", err)
+	}
+	task.Raw = raw
+	return &task, nil
+}
+
+// rpcResponse is a JSON-RPC 2.0 response envelope
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *RPCError       `json:"error"`
+	ID      interface{}     `json:"id"`
+}
+
+// Call performs an arbitrary JSON-RPC method and decodes its result into
+// result (which may be nil to discard it)
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	resp, err := c.post(ctx, method, params, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return decodeResponse(resp, result)
+}
+
+// post sends a JSON-RPC request and returns the raw HTTP response
+func (c *Client) post(ctx context.Context, method string, params interface{}, accept string) (*http.Response, error) {
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+		"id":      atomic.AddInt64(&c.nextID, 1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpointURL(), bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", accept)
+	c.applyHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %v", err)
+	}
+	return resp, nil
+}
+
+func (c *Client) applyHeaders(req *http.Request) {
+	for key, values := range c.header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+}
+
+// decodeResponse parses a JSON-RPC envelope into result
+func decodeResponse(resp *http.Response, result interface{}) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response: %v", err)
+	}
+
+	var envelope rpcResponse
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		if resp.StatusCode >= 300 {
+			return &HTTPError{StatusCode: resp.StatusCode, Body: string(body)}
+		}
+		return fmt.Errorf("error decoding response: %v", err)
+	}
+	if envelope.Error != nil {
+		return envelope.Error
+	}
+	if resp.StatusCode >= 300 {
+		return &HTTPError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Result, result); err != nil {
+		return fmt.Errorf("error decoding result: %v", err)
+	}
+	return nil
+}
+
+// Stream reads events from a tasks/sendSubscribe call
+type Stream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	// single is set when the agent replied without streaming
+	single *Task
+	done   bool
+}
+
+// Subscribe submits a task via tasks/sendSubscribe. Agents that don't
+// stream answer with a plain response, which is surfaced as a single final
+// event carrying the task.
+func (c *Client) Subscribe(ctx context.Context, params TaskSendParams) (*Stream, error) {
+	resp, err := c.post(ctx, "tasks/sendSubscribe", params, "text/event-stream")
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		defer resp.Body.Close()
+
+		var raw json.RawMessage
+		if err := decodeResponse(resp, &raw); err != nil {
+			return nil, err
+		}
+		var task Task
+		if err := json.Unmarshal(raw, &task); err != nil {
+			return nil, fmt.Errorf("error decoding task: %v", err)
+		}
+		task.Raw = raw
+		return &Stream{single: &task}, nil
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	return &Stream{body: resp.Body, scanner: scanner}, nil
+}
+
+// Next returns the next event, or io.EOF once the final event has been read
+func (s *Stream) Next() (*StreamEvent, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+
+	if s.single != nil {
+		s.done = true
+		return &StreamEvent{
+			ID:     s.single.ID,
+			Status: &s.single.Status,
+			Final:  true,
+			Task:   s.single,
+		}, nil
+	}
+
+	var data strings.Builder
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+		if strings.HasPrefix(line, "data:") {
+			data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+
+		// A blank line terminates the event
+		var envelope struct {
+			Result StreamEvent `json:"result"`
+			Error  *RPCError   `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data.String()), &envelope); err != nil {
+			return nil, fmt.Errorf("error decoding event: %v", err)
+		}
+		if envelope.Error != nil {
+			s.done = true
+			return nil, envelope.Error
+		}
+
+		event := envelope.Result
+		if event.Final {
+			s.done = true
+		}
+		return &event, nil
+	}
+	if err := s.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading stream: %v", err)
+	}
+
+	s.done = true
+	return nil, io.EOF
+}
+
+// Close releases the underlying connection
+func (s *Stream) Close() error {
+	if s.body != nil {
+		return s.body.Close()
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package a2aclient
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Standard JSON-RPC 2.0 error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// A2A-specific error codes
+const (
+	CodeTaskNotFound                 = -32001
+	CodeTaskNotCancelable            = -32002
+	CodePushNotificationNotSupported = -32003
+	CodeUnsupportedOperation         = -32004
+	CodeContentTypeNotSupported      = -32005
+	CodeInvalidAgentResponse         = -32006
+)
+
+// RPCError is a JSON-RPC error returned by the agent
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	if e.Data != nil {
+		return fmt.Sprintf("%s (code: %d): %v", e.Message, e.Code, e.Data)
+	}
+	return fmt.Sprintf("%s (code: %d)", e.Message, e.Code)
+}
+
+// HTTPError is returned when the agent responds with a non-2xx status and
+// no JSON-RPC body
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status %d: %s", e.StatusCode, e.Body)
+}
+
+// ErrorCode returns the JSON-RPC error code carried by err, or 0 if err is
+// not an RPC error
+func ErrorCode(err error) int {
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code
+	}
+	return 0
+}
@@ -0,0 +1,290 @@
+package a2aclient
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// AgentCard is the metadata an agent publishes at /.well-known/agent.json
+type AgentCard struct {
+	Name         string       `json:"name"`
+	Description  string       `json:"description"`
+	URL          string       `json:"url,omitempty"`
+	Version      string       `json:"version"`
+	Capabilities Capabilities `json:"capabilities"`
+	Skills       []Skill      `json:"skills,omitempty"`
+	Metadata     struct {
+		Tags []string `json:"tags,omitempty"`
+	} `json:"metadata"`
+	Channels struct {
+		A2A struct {
+			URL              string       `json:"url"`
+			SupportedMethods []string     `json:"supported_methods"`
+			Capabilities     Capabilities `json:"capabilities"`
+		} `json:"a2a"`
+	} `json:"channels"`
+
+	// Raw holds the card exactly as served
+	Raw json.RawMessage `json:"-"`
+}
+
+// SupportsStreaming reports whether the card advertises streaming, either at
+// the top level or on the A2A channel
+func (c *AgentCard) SupportsStreaming() bool {
+	return c.Capabilities.Streaming || c.Channels.A2A.Capabilities.Streaming
+}
+
+// Tags returns the card's metadata tags and skill tags, lowercased and
+// without duplicates
+func (c *AgentCard) Tags() []string {
+	seen := make(map[string]bool)
+	var tags []string
+	add := func(tag string) {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	for _, tag := range c.Metadata.Tags {
+		add(tag)
+	}
+	for _, skill := range c.Skills {
+		for _, tag := range skill.Tags {
+			add(tag)
+		}
+	}
+	return tags
+}
+
+// Skill is a capability advertised on an agent card
+type Skill struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Examples    []string `json:"examples,omitempty"`
+}
+
+// Capabilities defines what the agent can do
+type Capabilities struct {
+	Streaming              bool `json:"streaming"`
+	PushNotifications      bool `json:"pushNotifications"`
+	StateTransitionHistory bool `json:"stateTransitionHistory"`
+}
+
+// Task is a unit of work tracked by the agent
+type Task struct {
+	ID        string                 `json:"id"`
+	SessionID string                 `json:"sessionId,omitempty"`
+	Kind      string                 `json:"kind,omitempty"`
+	Status    TaskStatus             `json:"status"`
+	Artifacts []Artifact             `json:"artifacts,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	History   []TaskStatus           `json:"history,omitempty"` // status transitions, when requested
+	CreatedAt time.Time              `json:"createdAt,omitempty"`
+	UpdatedAt time.Time              `json:"updatedAt,omitempty"`
+
+	// Raw holds the task exactly as returned by the agent, including fields
+	// this package doesn't model
+	Raw json.RawMessage `json:"-"`
+}
+
+// LatestArtifacts returns the newest version of each artifact, in the order
+// the artifacts first appeared. Artifacts without a version are all kept.
+func (t *Task) LatestArtifacts() []Artifact {
+	position := make(map[int]int)
+	var latest []Artifact
+	for _, artifact := range t.Artifacts {
+		if artifact.Version == 0 {
+			latest = append(latest, artifact)
+			continue
+		}
+		if i, seen := position[artifact.Index]; seen {
+			if artifact.Version >= latest[i].Version {
+				latest[i] = artifact
+			}
+			continue
+		}
+		position[artifact.Index] = len(latest)
+		latest = append(latest, artifact)
+	}
+	return latest
+}
+
+// Terminal reports whether the task will no longer change state
+func (t *Task) Terminal() bool {
+	return IsTerminalState(t.Status.State)
+}
+
+// TaskFailure is the machine-readable reason a task failed
+type TaskFailure struct {
+	Code      string `json:"code"` // e.g. llm_unavailable, quota_exceeded, safety_blocked
+	Retryable bool   `json:"retryable"`
+	Message   string `json:"message"` // wording safe to show the user
+}
+
+// Failure returns why a failed task failed, read from the "error" data part
+// of its status message, or nil if the agent didn't say
+func (t *Task) Failure() *TaskFailure {
+	if t.Status.Message == nil {
+		return nil
+	}
+	for _, part := range t.Status.Message.Parts {
+		data, ok := part.Data.(map[string]interface{})
+		if !ok || data["error"] == nil {
+			continue
+		}
+		encoded, err := json.Marshal(data["error"])
+		if err != nil {
+			return nil
+		}
+		var failure TaskFailure
+		if err := json.Unmarshal(encoded, &failure); err != nil || failure.Code == "" {
+			return nil
+		}
+		return &failure
+	}
+	return nil
+}
+
+// IsTerminalState reports whether a task state is final
+func IsTerminalState(state string) bool {
+	switch state {
+	case "completed", "failed", "canceled", "cancelled", "rejected":
+		return true
+	default:
+		return false
+	}
+}
+
+// TaskStatus is the current state of a task
+type TaskStatus struct {
+	State     string   `json:"state"`
+	Timestamp string   `json:"timestamp,omitempty"`
+	Message   *Message `json:"message,omitempty"`
+}
+
+// Message is communication between user and agent
+type Message struct {
+	Role      string                 `json:"role"`
+	Parts     []Part                 `json:"parts"`
+	MessageID string                 `json:"messageId,omitempty"`
+	TaskID    string                 `json:"taskId,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Part is a piece of message or artifact content. Agents disagree on
+// whether the discriminator is called kind or type, so both are kept.
+type Part struct {
+	Kind string       `json:"kind,omitempty"`
+	Type string       `json:"type,omitempty"`
+	Text string       `json:"text,omitempty"`
+	File *FileContent `json:"file,omitempty"`
+	Data interface{}  `json:"data,omitempty"`
+}
+
+// PartKind returns the part's discriminator regardless of spelling
+func (p Part) PartKind() string {
+	if p.Kind != "" {
+		return p.Kind
+	}
+	return p.Type
+}
+
+// TextPart creates a text part using both spellings of the discriminator
+func TextPart(text string) Part {
+	return Part{Kind: "text", Type: "text", Text: text}
+}
+
+// FileContent is the payload of a file part
+type FileContent struct {
+	Name     string `json:"name,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Bytes    string `json:"bytes,omitempty"` // base64 encoded
+	URI      string `json:"uri,omitempty"`
+}
+
+// Artifact is output generated by the agent
+type Artifact struct {
+	ArtifactID string                 `json:"artifactId,omitempty"`
+	Name       string                 `json:"name,omitempty"`
+	Parts      []Part                 `json:"parts"`
+	Index      int                    `json:"index,omitempty"`
+	Version    int                    `json:"version,omitempty"`
+	Append     bool                   `json:"append,omitempty"`
+	LastChunk  bool                   `json:"lastChunk,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Text concatenates the artifact's text parts
+func (a Artifact) Text() string {
+	text := ""
+	for _, part := range a.Parts {
+		if part.PartKind() == "text" {
+			text += part.Text
+		}
+	}
+	return text
+}
+
+// TaskSendParams are the parameters of tasks/send and tasks/sendSubscribe
+type TaskSendParams struct {
+	ID           string                 `json:"id,omitempty"`
+	SessionID    string                 `json:"sessionId,omitempty"`
+	Message      Message                `json:"message"`
+	OutputFormat string                 `json:"outputFormat,omitempty"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	Locale       string                 `json:"locale,omitempty"` // e.g. en-NG; formats amounts and dates
+	Detail       string                 `json:"detail,omitempty"` // brief, standard or deep
+	Tone         string                 `json:"tone,omitempty"`   // formal, encouraging or plain-language
+	Mode         string                 `json:"mode,omitempty"`   // "wizard" for a guided, multi-turn plan
+	Extra        map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON merges Extra into the encoded params so callers can use
+// agent-specific parameters this package doesn't model
+func (p TaskSendParams) MarshalJSON() ([]byte, error) {
+	type plain TaskSendParams
+	encoded, err := json.Marshal(plain(p))
+	if err != nil || len(p.Extra) == 0 {
+		return encoded, err
+	}
+
+	merged := make(map[string]interface{})
+	if err := json.Unmarshal(encoded, &merged); err != nil {
+		return nil, err
+	}
+	for k, v := range p.Extra {
+		if _, exists := merged[k]; !exists {
+			merged[k] = v
+		}
+	}
+	return json.Marshal(merged)
+}
+
+// NewTextTask builds send params for a single user text message
+func NewTextTask(text string) TaskSendParams {
+	return TaskSendParams{
+		Message: Message{
+			Role:  "user",
+			Parts: []Part{TextPart(text)},
+		},
+	}
+}
+
+// StreamEvent is a TaskStatusUpdateEvent or TaskArtifactUpdateEvent
+// received from tasks/sendSubscribe
+type StreamEvent struct {
+	Kind     string      `json:"kind,omitempty"` // status-update or artifact-update
+	ID       string      `json:"id,omitempty"`
+	TaskID   string      `json:"taskId,omitempty"`
+	Status   *TaskStatus `json:"status,omitempty"`
+	Artifact *Artifact   `json:"artifact,omitempty"`
+	Final    bool        `json:"final,omitempty"`
+
+	// Task is set instead of the fields above when the agent answered the
+	// subscription with a plain (non-streaming) JSON-RPC response
+	Task *Task `json:"-"`
+}
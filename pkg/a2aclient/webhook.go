@@ -0,0 +1,61 @@
+package a2aclient
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers the agent signs push notifications and webhooks with
+const (
+	SignatureHeader          = "X-Signature"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+)
+
+// DefaultSignatureTolerance is how old a signed callback may be before
+// VerifyWebhook treats it as a replay
+const DefaultSignatureTolerance = 5 * time.Minute
+
+// Errors from verifying a callback's signature
+var (
+	ErrSignatureMissing  = errors.New("missing webhook signature")
+	ErrSignatureExpired  = errors.New("webhook timestamp outside the tolerance")
+	ErrSignatureMismatch = errors.New("webhook signature mismatch")
+)
+
+// VerifyWebhook checks that a push notification or webhook body was signed
+// by the agent with the shared secret, and that its timestamp is within
+// tolerance of now (DefaultSignatureTolerance if zero). Verify the raw body
+// before decoding it.
+func VerifyWebhook(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	if tolerance <= 0 {
+		tolerance = DefaultSignatureTolerance
+	}
+	timestamp := header.Get(SignatureTimestampHeader)
+	signature, ok := strings.CutPrefix(header.Get(SignatureHeader), "v1=")
+	if timestamp == "" || !ok {
+		return ErrSignatureMissing
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrSignatureMissing
+	}
+	if skew := time.Since(time.Unix(ts, 0)); skew > tolerance || skew < -tolerance {
+		return ErrSignatureExpired
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package agent
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+)
+
+// A2A Protocol Types (Simplified)
+
+// AgentCard represents the agent's capabilities and metadata
+type AgentCard struct {
+	Name               string       `json:"name"`
+	Description        string       `json:"description"`
+	URL                string       `json:"url"`
+	Version            string       `json:"version"`
+	Capabilities       Capabilities `json:"capabilities"`
+	DefaultInputModes  []string     `json:"defaultInputModes"`
+	DefaultOutputModes []string     `json:"defaultOutputModes"`
+	Skills             []Skill      `json:"skills"`
+}
+
+// Capabilities defines what the agent can do
+type Capabilities struct {
+	Streaming              bool `json:"streaming"`
+	PushNotifications      bool `json:"pushNotifications"`
+	StateTransitionHistory bool `json:"stateTransitionHistory"`
+}
+
+// Skill represents a specific capability of the agent
+type Skill struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Examples    []string `json:"examples"`
+}
+
+// Task represents a unit of work
+type Task struct {
+	ID        string     `json:"id"`
+	SessionID string     `json:"sessionId,omitempty"`
+	Kind      string     `json:"kind"`
+	Status    TaskStatus `json:"status"`
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+	Reminders []Reminder `json:"reminders,omitempty"`
+	Metadata  Metadata   `json:"metadata,omitempty"`
+	CreatedAt time.Time  `json:"createdAt,omitempty"`
+
+	// Variant is the rollout variant that produced the answer
+	Variant string `json:"variant,omitempty"`
+	// Model is the Gemini model that produced the answer
+	Model string `json:"model,omitempty"`
+
+	promptVersion   string            // version of the prompts used for the answer
+	datasetVersions map[string]string // version of each reference dataset used
+	tenant          string            // ID of the tenant that sent the task; "" for the default tenant
+
+	// Quality is the judge model's grading, added shortly after completion
+	// when quality scoring is on
+	Quality *QualityScore `json:"quality,omitempty"`
+
+	// Feedback is the user's latest rating of the answer, if any
+	Feedback *Feedback `json:"feedback,omitempty"`
+
+	// Wizard is the progress of a guided wizard task
+	Wizard *WizardState `json:"wizard,omitempty"`
+
+	// Recorded in the audit log for analytics
+	usage    llm.TokenUsage // tokens spent on the answer; zero when it was shared
+	corridor Corridor       // who asked about moving where
+
+	query      string      // the redacted query
+	milestones []Milestone // the answer's dated milestones, read before localization
+
+	// History lists status transitions, oldest first. It is only filled in
+	// on tasks/get responses that ask for it with historyLength.
+	History []TaskStatus `json:"history,omitempty"`
+
+	// PushNotification is returned only by tasks/pushNotificationConfig/get
+	PushNotification *PushNotificationConfig `json:"-"`
+
+	// statusHistory records every status the task has had
+	statusHistory []TaskStatus
+	err           error     // why the task failed, kept out of responses
+	UpdatedAt     time.Time `json:"updatedAt,omitempty"`
+}
+
+// TaskStatus represents the current state of a task
+type TaskStatus struct {
+	State     TaskState      `json:"state"`
+	Timestamp string         `json:"timestamp,omitempty"`
+	Message   *StatusMessage `json:"message,omitempty"`
+}
+
+// StatusMessage represents the message within status
+type StatusMessage struct {
+	Kind      string `json:"kind"`
+	Role      string `json:"role"`
+	Parts     []Part `json:"parts"`
+	MessageID string `json:"messageId"`
+	TaskID    string `json:"taskId"`
+}
+
+// Message represents communication between user and agent
+type Message struct {
+	Role     string   `json:"role"` // user or agent
+	Parts    []Part   `json:"parts"`
+	Metadata Metadata `json:"metadata,omitempty"`
+}
+
+// Metadata is caller-supplied data (e.g. ticket or user IDs) stored on a
+// task and echoed back untouched
+type Metadata map[string]interface{}
+
+// Part represents a piece of content. A2A clients disagree on whether the
+// discriminator is called kind or type, so both are accepted on input and
+// emitted on output.
+type Part struct {
+	Kind string       `json:"kind,omitempty"` // text, file or data
+	Text string       `json:"text,omitempty"`
+	File *FileContent `json:"file,omitempty"`
+	Data interface{}  `json:"data,omitempty"`
+}
+
+// partJSON is the wire form of a Part
+type partJSON struct {
+	Kind string       `json:"kind,omitempty"`
+	Type string       `json:"type,omitempty"`
+	Text string       `json:"text,omitempty"`
+	File *FileContent `json:"file,omitempty"`
+	Data interface{}  `json:"data,omitempty"`
+}
+
+// MarshalJSON writes the discriminator under both kind and type
+func (p Part) MarshalJSON() ([]byte, error) {
+	kind := p.kind()
+	return json.Marshal(partJSON{Kind: kind, Type: kind, Text: p.Text, File: p.File, Data: p.Data})
+}
+
+// UnmarshalJSON reads the discriminator from kind or type, inferring it
+// from the content when neither is set
+func (p *Part) UnmarshalJSON(data []byte) error {
+	var raw partJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*p = Part{Kind: raw.Kind, Text: raw.Text, File: raw.File, Data: raw.Data}
+	if p.Kind == "" {
+		p.Kind = raw.Type
+	}
+	p.Kind = p.kind()
+	return nil
+}
+
+// kind returns the part's discriminator, inferred from its content if unset
+func (p Part) kind() string {
+	switch {
+	case p.Kind != "":
+		return p.Kind
+	case p.File != nil:
+		return "file"
+	case p.Data != nil:
+		return "data"
+	default:
+		return "text"
+	}
+}
+
+// FileContent represents the payload of a file part
+type FileContent struct {
+	Name     string `json:"name,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Bytes    string `json:"bytes,omitempty"` // base64 encoded
+	URI      string `json:"uri,omitempty"`
+}
+
+// Artifact represents output generated by the agent. When a task ID is
+// reprocessed, new versions share the index of the artifact they replace.
+type Artifact struct {
+	ArtifactID string   `json:"artifactId,omitempty"`
+	Name       string   `json:"name,omitempty"`
+	Parts      []Part   `json:"parts"`
+	Index      int      `json:"index"`
+	Version    int      `json:"version,omitempty"`
+	Append     bool     `json:"append,omitempty"`    // a newer version of an earlier artifact
+	LastChunk  bool     `json:"lastChunk,omitempty"` // the artifact is complete
+	Metadata   Metadata `json:"metadata,omitempty"`
+}
+
+// JSON-RPC 2.0 request structure
+type JSONRPCRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+	ID      interface{} `json:"id"`
+
+	hasID  bool    // the request has an id member, even if it is null
+	tenant *Tenant // the caller, nil for the default tenant
+	accept string  // the HTTP Accept header
+}
+
+// UnmarshalJSON decodes a request, noting whether it has an id member, so
+// "id": null can be told apart from a missing id
+func (r *JSONRPCRequest) UnmarshalJSON(data []byte) error {
+	type plain JSONRPCRequest
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	_, r.hasID = members["id"]
+	return nil
+}
+
+// notification reports whether the request has no id member. Under JSON-RPC
+// 2.0 only such requests go unanswered; "id": null still gets a response.
+func (r JSONRPCRequest) notification() bool {
+	return !r.hasID
+}
+
+// JSON-RPC 2.0 response structure
+type JSONRPCResponse struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Result  interface{} `json:"result,omitempty"`
+	Error   *RPCError   `json:"error,omitempty"`
+	ID      interface{} `json:"id"`
+}
+
+// RPCError represents an error in JSON-RPC
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// TaskSendParams represents parameters for tasks/send
+type TaskSendParams struct {
+	ID           string          `json:"id,omitempty"`
+	SessionID    string          `json:"sessionId,omitempty"`
+	UserID       string          `json:"userId,omitempty"` // end user for daily quotas, default the session
+	Message      Message         `json:"message"`
+	OutputFormat string          `json:"outputFormat,omitempty"` // markdown (default), html or json
+	Mode         string          `json:"mode,omitempty"`         // "wizard" for a guided, multi-turn plan
+	Reminders    *ReminderParams `json:"reminders,omitempty"`
+	Metadata     Metadata        `json:"metadata,omitempty"`
+	Locale       string          `json:"locale,omitempty"`   // BCP 47 tag, e.g. en-NG, for amounts and dates
+	Detail       string          `json:"detail,omitempty"`   // brief, standard (default) or deep
+	Tone         string          `json:"tone,omitempty"`     // formal, encouraging or plain-language
+	Sections     []string        `json:"sections,omitempty"` // optional sections to add: tax or arrival
+	Model        string          `json:"model,omitempty"`    // Gemini model to answer with, from the allowed models
+	Language     string          `json:"language,omitempty"` // language to answer in, e.g. fr or pt-BR, regardless of the query's
+
+	// AcceptedOutputModes lists the media types the caller can use, in
+	// order of preference; A2A clients may send it under configuration
+	AcceptedOutputModes []string           `json:"acceptedOutputModes,omitempty"`
+	Configuration       *SendConfiguration `json:"configuration,omitempty"`
+
+	// PushNotification registers a callback for this task up front
+	PushNotification *PushNotificationConfig `json:"pushNotification,omitempty"`
+
+	// DelegationDepth is set by agents delegating a sub-question to us
+	DelegationDepth int `json:"delegationDepth,omitempty"`
+}
+
+// SendConfiguration is the A2A message/send configuration object
+type SendConfiguration struct {
+	AcceptedOutputModes []string `json:"acceptedOutputModes,omitempty"`
+}
+
+// ReminderParams opts a task into milestone reminders
+type ReminderParams struct {
+	Channel  string `json:"channel"`            // webhook or email
+	Target   string `json:"target"`             // webhook URL or email address
+	LeadDays int    `json:"leadDays,omitempty"` // days before each milestone, default 7
+}
+
+// TaskIDParams represents parameters for task operations
+type TaskIDParams struct {
+	ID            string `json:"id"`
+	HistoryLength *int   `json:"historyLength,omitempty"` // tasks/get: number of recent status transitions to include
+}
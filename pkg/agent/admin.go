@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// adminAPIKey returns the bearer token protecting admin and privacy endpoints
+func adminAPIKey() string {
+	return os.Getenv("ADMIN_API_KEY")
+}
+
+// requireAdmin checks the request's bearer token against ADMIN_API_KEY and
+// writes an error response if it doesn't match. The endpoints are disabled
+// entirely when no key is configured.
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	key := adminAPIKey()
+	if key == "" {
+		writeJSONError(w, http.StatusServiceUnavailable, "admin API disabled: ADMIN_API_KEY not configured")
+		return false
+	}
+
+	token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if subtle.ConstantTimeCompare([]byte(token), []byte(key)) != 1 {
+		writeJSONError(w, http.StatusUnauthorized, "invalid or missing admin token")
+		return false
+	}
+
+	return true
+}
+
+// writeJSON sends a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError sends a plain (non JSON-RPC) error body
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,1042 @@
+// Package agent is the migration pathways agent: it routes queries to
+// destination specialists, writes recommendations with the model in package
+// llm, and serves them over A2A, MCP and chat integrations.
+package agent
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+//go:embed agent.json
+var agentCardData []byte
+
+// MigrationAgent is the main agent server
+type MigrationAgent struct {
+	gemini        *llm.GeminiClient
+	clock         Clock
+	ids           IDGenerator
+	tasks         *TaskCache
+	reminders     *ReminderScheduler
+	compliance    *CompliancePolicy
+	audit         *AuditLog
+	registry      *AgentRegistry
+	uploads       *UploadStore
+	delegator     *Delegator
+	inflight      *QueryCoalescer
+	queues        *TaskQueues
+	store         TaskStore // nil when tasks are kept in memory only
+	files         *ArtifactStorage
+	events        *TaskEvents
+	quotaHold     *QuotaHold
+	dataRefresher *DatasetRefresher
+	officialTimes *OfficialTimes
+	extractors    *parser.Extractors
+	hooks         hookSet
+	mu            sync.RWMutex
+
+	transcriber     Transcriber       // turns voice notes into query text
+	duplicatePolicy string            // what tasks/send does with an existing task ID
+	defaultLanguage string            // answer language when the query's can't be detected
+	agentCards      map[string][]byte // translated agent cards by language
+	rolloutStats    *RolloutStats
+	tenantStats     *TenantStats
+	tenantLimiter   *TenantLimiter
+	userQuota       *UserQuota
+	deadLetters     DeadLetterStore
+	plans           PlanStore
+	pushRetryFor    time.Duration // how long failed push deliveries are retried
+	models          []string      // models callers may choose besides the default
+	whatsappSeen    *seenMessages // WhatsApp message IDs already answered
+	billing         *BillingExporter
+	judge           *QualityJudge
+}
+
+// NewMigrationAgent creates a new migration pathways agent configured from
+// the environment
+func NewMigrationAgent() *MigrationAgent {
+	return NewMigrationAgentWithDeps(nil, NewTaskStore(), nil, nil)
+}
+
+// NewMigrationAgentWithDeps creates an agent with its model, task store,
+// clock and ID generator supplied by the caller, so embedders can swap
+// components and write deterministic tests. A nil provider uses the Gemini
+// client configured from the environment, a nil store keeps tasks in
+// memory only, and a nil clock or idGen uses the wall clock or random
+// UUIDs. Everything else is configured from the environment as usual.
+func NewMigrationAgentWithDeps(provider llm.Provider, store TaskStore, clock Clock, idGen IDGenerator) *MigrationAgent {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	if idGen == nil {
+		idGen = uuidGenerator{}
+	}
+	gemini, ok := provider.(*llm.GeminiClient)
+	if !ok {
+		gemini = llm.NewGeminiClient()
+		if provider != nil {
+			gemini.Provider = provider
+			gemini.Mode = llm.ModeGemini
+		}
+	}
+	gemini.Now = clock.Now
+
+	agent := &MigrationAgent{
+		gemini:     gemini,
+		clock:      clock,
+		ids:        idGen,
+		tasks:      NewTaskCache(),
+		compliance: NewCompliancePolicy(),
+		audit:      NewAuditLog(),
+		registry:   NewAgentRegistry(),
+		uploads:    NewUploadStore(),
+		inflight:   NewQueryCoalescer(),
+		queues:     NewTaskQueues(clock),
+		store:      store,
+		files:      NewArtifactStorage(),
+		events:     NewTaskEvents(),
+		extractors: &parser.Extractors{},
+
+		duplicatePolicy: duplicateTaskPolicy(),
+		defaultLanguage: defaultLanguage(),
+		rolloutStats:    NewRolloutStats(),
+		tenantStats:     NewTenantStats(),
+		tenantLimiter:   NewTenantLimiter(),
+		userQuota:       NewUserQuota(),
+		pushRetryFor:    pushRetryWindow(),
+		models:          allowedModels(),
+		whatsappSeen:    newSeenMessages(whatsappDedupeWindow),
+	}
+	agent.audit.ids = idGen
+	agent.tasks.clock = clock
+	agent.uploads.clock, agent.uploads.ids = clock, idGen
+	agent.userQuota.clock = clock
+	agent.files.clock = clock
+	agent.tenantLimiter.clock = clock
+	agent.registry.clock = clock
+	agent.deadLetters = newDeadLetterStore(agent.store)
+	agent.plans = newPlanStore(agent.store)
+	agent.delegator = NewDelegator(agent.registry)
+	agent.transcriber = NewTranscriber(agent.gemini)
+	agent.judge = NewQualityJudge(agent.gemini)
+	agent.reminders = NewReminderScheduler(agent)
+	agent.tasks.remindersStored = agent.reminders.store != nil
+	agent.quotaHold = NewQuotaHold(agent)
+	agent.dataRefresher = NewDatasetRefresher()
+	agent.dataRefresher.clock = clock
+	agent.dataRefresher.onChange = agent.alertPolicyChange
+	agent.officialTimes = NewOfficialTimes()
+	agent.officialTimes.clock = clock
+	agent.billing = NewBillingExporter(agent.audit)
+	agent.billing.clock = clock
+
+	// Load prompts, dictionaries, the knowledge base and card translations;
+	// a broken CONTENT_DIR falls back to the built-in content
+	if _, err := agent.ReloadContent(); err != nil {
+		log.Printf("⚠️  Using built-in content: %v", err)
+		cards, err := localizeAgentCards(agentCardData, loadCardTranslations())
+		if err != nil {
+			log.Printf("⚠️  Serving the agent card in English only: %v", err)
+		}
+		agent.agentCards = cards
+		if err := ReloadFeatureFlags(); err != nil {
+			log.Printf("⚠️  Using default feature flags: %v", err)
+		}
+		if rollout, err := loadRollout(); err != nil {
+			log.Printf("⚠️  Rollout disabled, every task uses the control variant: %v", err)
+		} else {
+			currentRollout.Store(rollout)
+		}
+	}
+	return agent
+}
+
+// GetAgentCard returns the agent's metadata as raw JSON
+func (a *MigrationAgent) GetAgentCard() []byte {
+	return agentCardData
+}
+
+// TaskOptions carries per-request processing preferences
+type TaskOptions struct {
+	SessionID    string          // groups tasks belonging to the same user
+	OutputFormat string          // markdown (default), html or json
+	Reminders    *ReminderParams // nil unless the user opted into reminders
+	Delegated    bool            // the query is itself a sub-question from a peer agent
+	Metadata     Metadata        // caller data persisted on the task
+	Push         *PushNotificationConfig
+	Locale       string   // BCP 47 tag for amounts and dates; the user's origin country when empty
+	Detail       string   // answer length: DetailBrief, DetailStandard or DetailDeep
+	Tone         string   // answer voice: one of the Tone* values
+	Sections     []string // optional sections to add, such as SectionTax
+	HoldOnQuota  bool     // wait in submitted for the Gemini quota to reset instead of failing
+	Tenant       string   // ID of the tenant sending the task; "" for the default tenant
+	Wizard       bool     // confirm the profile and walk through the steps over several turns
+	Model        string   // Gemini model to answer with instead of the default or the rollout's
+	Language     string   // language to answer in regardless of the query's; "" detects it
+}
+
+// answerArtifactName names the artifact holding the recommendation
+const answerArtifactName = "Migration Pathway Recommendation"
+
+// maxMetadataSize bounds the encoded size of caller metadata on a task
+const maxMetadataSize = 16 * 1024
+
+// ProcessTask handles incoming tasks
+func (a *MigrationAgent) ProcessTask(taskID string, message Message, opts TaskOptions) (*Task, error) {
+	// Generate a message ID
+	messageID := a.ids.NewID()
+
+	// Create task
+	task := &Task{
+		ID:        taskID,
+		SessionID: opts.SessionID,
+		Kind:      "task",
+		Metadata:  mergeMetadata(opts.Metadata, message.Metadata),
+
+		PushNotification: opts.Push,
+		CreatedAt:        a.clock.Now(),
+		tenant:           opts.Tenant,
+	}
+	tenant := lookupTenant(opts.Tenant)
+
+	// Store task, keeping the artifacts, history and callback of an earlier
+	// run with the same ID so reprocessing adds versions instead of
+	// overwriting
+	a.mu.Lock()
+	previous, _ := a.tasks.Peek(taskID)
+	if previous != nil {
+		task.Artifacts = previous.Artifacts
+		task.statusHistory = append([]TaskStatus(nil), previous.statusHistory...)
+		if task.PushNotification == nil {
+			task.PushNotification = previous.PushNotification
+		}
+	}
+	task.SetStatus(TaskStateSubmitted, nil, a.clock.Now())
+	a.tasks.Put(task)
+	a.mu.Unlock()
+
+	a.updateStatus(task, TaskStateWorking, nil)
+
+	// Plugins see the task before its message is read
+	if err := a.taskCreated(task, &message); err != nil {
+		a.failTask(task, messageID, err)
+		go a.notifyPush(task)
+		return task, err
+	}
+
+	// Extract text from message
+	var userQuery string
+	for _, part := range message.Parts {
+		if part.Kind == "text" {
+			userQuery += part.Text + " "
+		}
+	}
+
+	// Attached documents such as a CV fill in the user's background, and a
+	// voice note is treated as part of the query
+	attachments := a.readAttachments(task, message)
+	userQuery = strings.TrimSpace(userQuery + attachments.Transcript)
+
+	// Strip personal data so only the redacted query is logged, stored or
+	// sent to the LLM
+	userQuery, redacted := redactPII(userQuery)
+	if len(redacted) > 0 {
+		log.Printf("Redacted %s from task %s", strings.Join(redacted, ", "), taskID)
+	}
+	if userQuery == "" && (attachments.Resume != "" || attachments.ImageFacts != "") {
+		userQuery = "Recommend the best migration pathway for the applicant described in the attached documents."
+	}
+	a.mu.Lock()
+	task.query = userQuery
+	a.mu.Unlock()
+
+	// Record who asked what and the outcome once processing finishes, then
+	// tell the caller's callback about it unless the task is held for a retry
+	defer func() {
+		a.mu.RLock()
+		held := task.Status.State == TaskStateSubmitted
+		a.mu.RUnlock()
+		if !held {
+			go a.notifyPush(task)
+		}
+	}()
+	defer a.recordAudit(task, userQuery, task.CreatedAt)
+
+	// Nothing to plan from: neither a question nor documents
+	if userQuery == "" {
+		err := fmt.Errorf("%w: the message has no text, voice note or documents", ErrInvalidProfile)
+		a.failTask(task, messageID, err)
+		return task, err
+	}
+
+	// Refuse requests for fraudulent assistance before anything reaches the LLM
+	if violation := a.compliance.Check(userQuery); violation != nil {
+		a.compliance.LogViolation(taskID, violation, userQuery)
+		a.rejectTask(task, messageID, violation)
+		return task, nil
+	}
+
+	// Ask peer agents relevant sub-questions while Gemini works on the
+	// main answer; sub-questions from peers are never delegated again, and
+	// nothing leaves the process when LLM_MODE is off
+	delegated := make(chan []DelegatedAnswer, 1)
+	if opts.Delegated || !tenant.enabled(FlagDelegation) || a.gemini.Mode == llm.ModeOff {
+		delegated <- nil
+	} else {
+		go func() { delegated <- a.delegator.Ask(userQuery) }()
+	}
+
+	// Parse user query to extract: profession, destination, origin, budget
+	profile := a.parseProfile(userQuery)
+	if attachments.Resume != "" {
+		profile.Resume = attachments.Resume
+		parser.MergeCV(&profile, attachments.CV, parser.SourceCV)
+	}
+	if attachments.ImageFacts != "" {
+		profile.DocumentFacts = attachments.ImageFacts
+		parser.MergeCV(&profile, attachments.ImageProfile, parser.SourceImage)
+	}
+
+	// Route to the destination's specialist, which builds its own prompt
+	// and runs its calculators
+	specialist := routeSpecialist(userQuery)
+	log.Printf("Routing task %s to the %s specialist", taskID, specialist.Name)
+	corridor := queryCorridor(userQuery, profile, specialist)
+	a.mu.Lock()
+	task.corridor = corridor
+	a.mu.Unlock()
+
+	// Rollout variants swap the model or prompts for a share of tasks, a
+	// model the caller chose wins over the variant's, and plugins see the
+	// prompts and responses of the task's calls
+	variant := assignVariant(taskID, opts.SessionID)
+	client := variant.client(a.gemini)
+	if opts.Model != "" {
+		client = client.WithModel(opts.Model)
+	}
+	gemini := a.hookedClient(client, task)
+	a.mu.Lock()
+	task.Model = gemini.Model
+	if rolloutActive() {
+		task.Variant = variant.name()
+	}
+	a.mu.Unlock()
+	style := AnswerStyle{
+		Language: a.responseLanguage(userQuery, task.Metadata, opts.Language),
+		Detail:   opts.Detail,
+		Tone:     opts.Tone,
+		Variant:  variant,
+		Datasets: datasets(),
+		Tenant:   tenant,
+		Now:      a.clock.Now(),
+		Context:  readPromptContext(task.Metadata),
+	}
+	a.mu.Lock()
+	task.promptVersion = style.prompts().PromptVersion
+	task.datasetVersions = style.Datasets.versions()
+	a.mu.Unlock()
+
+	// Followers of the task see the answer section by section as it is
+	// generated
+	artifactID := a.ids.NewID()
+	a.mu.RLock()
+	sections := a.streamSections(taskID, Artifact{
+		ArtifactID: artifactID,
+		Name:       answerArtifactName,
+		Index:      artifactIndex(task.Artifacts, answerArtifactName),
+	}, opts.OutputFormat)
+	a.mu.RUnlock()
+	if sections != nil {
+		gemini = gemini.Streaming(sections.Feed)
+	}
+
+	// Identical queries arriving together, such as Telex resending a
+	// message, share one Gemini call
+	responseText, shared, err := a.inflight.Do(coalesceKey(specialist, userQuery, profile, style, gemini.Model), tenant.enabled(FlagCoalescing), func() (string, error) {
+		text, usage, err := specialist.Handle(gemini, profile, userQuery, style)
+		a.mu.Lock()
+		task.usage = usage
+		a.mu.Unlock()
+		return text, err
+	})
+	if shared {
+		log.Printf("Task %s reused the answer of an identical in-flight query", taskID)
+	}
+	sections.Flush()
+
+	// Out of quota, wait for it to reset if the caller can collect the
+	// answer later
+	var quota *llm.QuotaError
+	if opts.HoldOnQuota && errors.As(err, &quota) {
+		a.mu.Lock()
+		task.err = err
+		a.mu.Unlock()
+		a.holdTask(task, messageID, quota)
+		return task, err
+	}
+
+	// When Gemini fails, answer from the knowledge base rather than not at
+	// all; the error is still recorded in the audit log
+	fallback := err != nil
+	if fallback {
+		text, ok := fallbackAnswer(err, specialist, profile, userQuery, style)
+		if !ok {
+			a.failTask(task, messageID, err)
+			return task, err
+		}
+		log.Printf("Task %s answered from the knowledge base (%s): %v", taskID, classifyFailure(err).Code, err)
+		a.mu.Lock()
+		task.err = err
+		a.mu.Unlock()
+		responseText = text
+	}
+
+	answers := <-delegated
+	responseText = mergeDelegatedAnswers(responseText, answers)
+
+	// Ask once more for a pathway the budget covers when it doesn't cover
+	// this one; the cheaper answer is kept, with the shortfall and a
+	// savings target if it is still over. The model's own Cost line is
+	// read before the fee table replaces it, for fees without a rate.
+	var budget *BudgetCheck
+	if style.Detail != DetailBrief {
+		budget = checkBudget(responseText, specialist.Country, profile.Budget, style.Now.Year(), style.Datasets)
+	}
+	if budget != nil && !budget.fits() && !fallback && a.gemini.Mode == llm.ModeGemini && tenant.enabled(FlagBudgetRetry) {
+		retryStyle := style
+		retryStyle.Constraint = budgetConstraint(budget)
+		text, usage, err := specialist.Handle(a.hookedClient(client, task), profile, userQuery, retryStyle)
+		a.mu.Lock()
+		task.usage.PromptTokens += usage.PromptTokens
+		task.usage.OutputTokens += usage.OutputTokens
+		a.mu.Unlock()
+		if err != nil {
+			log.Printf("⚠️  Task %s could not be regenerated within budget: %v", taskID, err)
+		} else if retry := checkBudget(text, specialist.Country, profile.Budget, style.Now.Year(), style.Datasets); retry != nil && retry.Pathway != budget.Pathway && retry.Cost < budget.Cost {
+			log.Printf("💰 Task %s regenerated within budget: %s instead of %s", taskID, retry.Pathway, budget.Pathway)
+			retry.Replaced = budget
+			responseText, budget = mergeDelegatedAnswers(text, answers), retry
+		}
+	}
+
+	// Quote the official fees of the recommended program from the fee table
+	// rather than the model's estimate
+	responseText, feeSource := applyFeeTable(responseText, specialist.Country, style.Now.Year(), style.Datasets)
+	responseText = applyBudgetCheck(responseText, budget)
+
+	// Quote the processing time from the destination's official source where
+	// it publishes one; canned answers don't name real programs
+	originCode := ""
+	if origin, ok := originLocale(userQuery); ok {
+		originCode = localeCode(origin)
+	}
+	var officialTime *OfficialTime
+	if a.gemini.Mode == llm.ModeGemini {
+		officialTime = a.officialTimes.Lookup(context.Background(), specialist.Country, pathwayName(responseText), originCode)
+		responseText = applyOfficialTime(responseText, officialTime)
+	}
+	responseText = applyOccupationLists(responseText, specialist.Country, profile.Profession)
+	if style.Detail != DetailBrief {
+		responseText = applyEligibility(responseText, specialist.Country, profile, userQuery, style.Now.Year(), style.Datasets)
+		responseText = applyScholarships(responseText, specialist.Country, originCode, userQuery, profile)
+		responseText = applyHealthRequirements(responseText, specialist, originCode)
+		responseText = applyDependents(responseText, specialist, profile)
+		responseText = applyHorizon(responseText, specialist, originCode, style.Now)
+		if containsString(opts.Sections, SectionTax) {
+			responseText = applyTaxOverview(responseText, specialist.Country, originCode)
+		}
+		if containsString(opts.Sections, SectionArrival) {
+			responseText = applyArrivalChecklist(responseText, specialist.Country, profile.Profession)
+		}
+	}
+	if origin, ok := originLocale(userQuery); ok && specialist != generalist {
+		responseText = applyApplicationCentres(responseText, origin.Country, specialist.Country, style.Datasets)
+	}
+	responseText = a.compliance.ApplyDisclaimer(responseText)
+
+	// Read the milestones before dates are reformatted for the user's locale
+	milestones := parseTimeline(responseText)
+	pathway := pathwayName(responseText)
+	dateLayout := "2006-01-02"
+	if locale, ok := answerLocale(opts.Locale, userQuery); ok {
+		responseText = localizeMarkdown(responseText, locale, style.Datasets.rates())
+		dateLayout = locale.DateLayout
+	}
+
+	// Tell a returning user what changed since their last recommendation
+	// for the corridor; both answers are localized by now
+	var lastAnswer *PreviousAnswer
+	if style.Detail != DetailBrief {
+		lastAnswer = a.previousAnswer(task, userQuery)
+		responseText = applyChanges(responseText, lastAnswer, dateLayout)
+	}
+
+	// Render the artifact in the requested format; the status message
+	// always carries the original markdown
+	answerPart := Part{Kind: "text", Text: responseText}
+	switch opts.OutputFormat {
+	case OutputFormatHTML:
+		answerPart.Text = renderMarkdownHTML(responseText)
+	case OutputFormatJSON:
+		answerPart = Part{Kind: "data", Data: answerData(responseText, milestones)}
+	}
+
+	// Attach the results, then mark the task completed
+	artifacts := []Artifact{
+		{
+			ArtifactID: artifactID,
+			Name:       answerArtifactName,
+			Parts:      []Part{answerPart},
+		},
+	}
+	if feeSource != nil || budget != nil || officialTime != nil || lastAnswer != nil {
+		artifacts[0].Metadata = Metadata{}
+	}
+	if feeSource != nil {
+		artifacts[0].Metadata["feeTable"] = feeSource
+	}
+	if budget != nil {
+		artifacts[0].Metadata["budgetCheck"] = budget
+	}
+	if officialTime != nil {
+		artifacts[0].Metadata["processingTime"] = officialTime
+	}
+	if lastAnswer != nil {
+		artifacts[0].Metadata["comparedWith"] = lastAnswer
+	}
+	if calendar := calendarArtifact(milestones, pathway, style.Now, a.ids); calendar != nil {
+		artifacts = append(artifacts, *calendar)
+	}
+	if jobs := jobResourcesArtifact(specialist, pathway, profile, a.ids); jobs != nil {
+		artifacts = append(artifacts, *jobs)
+	}
+	if profile.Resume != "" || profile.DocumentFacts != "" {
+		artifacts = append(artifacts, Artifact{
+			ArtifactID: a.ids.NewID(),
+			Name:       "Applicant Profile",
+			Parts:      []Part{{Kind: "data", Data: profile.Data()}},
+		})
+	}
+	for _, answer := range answers {
+		artifacts = append(artifacts, answer.Extra...)
+	}
+	var reminders []Reminder
+	if opts.Reminders != nil {
+		reminders = scheduleReminders(milestones, *opts.Reminders, style.Now, a.ids)
+	}
+	artifacts = append(artifacts, a.taskCompleted(task, responseText)...)
+	artifacts = a.files.Offload(taskID, artifacts)
+
+	a.mu.Lock()
+	task.Artifacts = versionArtifacts(task.Artifacts, artifacts)
+	task.Reminders = reminders
+	task.milestones = milestones
+	a.mu.Unlock()
+
+	parts := []Part{{Kind: "text", Text: responseText}}
+	if fallback {
+		parts = append(parts, Part{
+			Kind: "data",
+			Data: map[string]interface{}{"fallback": map[string]interface{}{
+				"personalized": false,
+				"reason":       classifyFailure(err).Code,
+			}},
+		})
+	}
+	a.updateStatus(task, TaskStateCompleted, agentMessage(taskID, messageID, parts...))
+
+	// Grade the answer with a second model without holding up the reply;
+	// templated answers aren't worth grading
+	if !fallback {
+		go a.gradeAnswer(task, userQuery, responseText)
+	}
+	return task, nil
+}
+
+// rejectTask marks a task as refused by the compliance policy, returning the
+// refusal both as text and as a structured data part
+func (a *MigrationAgent) rejectTask(task *Task, messageID string, violation *PolicyViolation) {
+	a.updateStatus(task, TaskStateRejected, agentMessage(task.ID, messageID,
+		Part{
+			Kind: "text",
+			Text: a.compliance.RefusalText(violation),
+		},
+		Part{
+			Kind: "data",
+			Data: map[string]interface{}{
+				"refused":  true,
+				"category": violation.Category,
+				"reason":   violation.Reason,
+			},
+		},
+	))
+}
+
+// failTask marks a task as failed, reporting the failure's category and
+// whether a retry may help as a data part alongside wording for the user.
+// The underlying error is kept for the audit log only.
+func (a *MigrationAgent) failTask(task *Task, messageID string, err error) {
+	failure := classifyFailure(err)
+	log.Printf("Task %s failed (%s): %v", task.ID, failure.Code, err)
+
+	a.mu.Lock()
+	task.err = err
+	a.mu.Unlock()
+	a.updateStatus(task, TaskStateFailed, agentMessage(task.ID, messageID,
+		Part{
+			Kind: "text",
+			Text: failure.Message,
+		},
+		Part{
+			Kind: "data",
+			Data: map[string]interface{}{"error": failure},
+		},
+	))
+}
+
+// GetTask retrieves a task by ID
+func (a *MigrationAgent) GetTask(taskID string) (*Task, error) {
+	task, exists := a.lookupTask(taskID)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+	}
+
+	return task, nil
+}
+
+// ServeAgentCard serves the agent card JSON in the caller's language
+func (a *MigrationAgent) ServeAgentCard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	card, language := a.agentCardFor(r.Header.Get("Accept-Language"))
+	if !featureEnabled(FlagStreaming) {
+		card = withoutStreaming(card)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", language)
+	w.Header().Add("Vary", "Accept-Language")
+	w.Write(card)
+}
+
+// withoutStreaming returns the agent card with its streaming capability
+// turned off
+func withoutStreaming(card []byte) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(card, &fields); err != nil {
+		return card
+	}
+	var capabilities map[string]interface{}
+	if err := json.Unmarshal(fields["capabilities"], &capabilities); err != nil || capabilities == nil {
+		return card
+	}
+	capabilities["streaming"] = false
+	patched, err := json.Marshal(capabilities)
+	if err != nil {
+		return card
+	}
+	fields["capabilities"] = patched
+	if patched, err = json.MarshalIndent(fields, "", "  "); err != nil {
+		return card
+	}
+	return patched
+}
+
+// HandlePlanner is the A2A protocol endpoint for planner interactions
+// It accepts JSON-RPC 2.0 with methods: tasks/send, tasks/get, message/send,
+// tasks/sendSubscribe, message/stream, tasks/resubscribe,
+// tasks/pushNotificationConfig/set and /get, and feedback/send. Requests
+// without an id member are notifications, answered with 204 and no body;
+// "id": null is answered like any other id. Register mounts it behind
+// CORS, Authenticate and RateLimit.
+func (a *MigrationAgent) HandlePlanner(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r, body := readRPC(w, r)
+	if body.tooLarge() {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, body.err.Error())
+		return
+	}
+	var req JSONRPCRequest
+	if err := json.Unmarshal(body.raw, &req); err != nil || body.err != nil {
+		a.sendError(w, nil, ErrCodeParse, errorMessages[ErrCodeParse], req.ID)
+		return
+	}
+
+	// With tenants configured, every call needs a tenant's API key
+	tenant, err := requestTenant(r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		if req.notification() {
+			// Notifications get no body, but the status still tells the
+			// caller why nothing was done
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		a.sendError(w, err, ErrCodeUnauthenticated, errorMessages[ErrCodeUnauthenticated], req.ID)
+		return
+	}
+	req.tenant = tenant
+	req.accept = r.Header.Get("Accept")
+
+	// Requests without an id member are notifications: they are carried
+	// out, but per JSON-RPC 2.0 never answered
+	if req.notification() {
+		w.WriteHeader(http.StatusNoContent)
+		go a.handleNotification(r, req)
+		return
+	}
+	a.dispatch(w, r, req)
+}
+
+// dispatch runs an authenticated JSON-RPC request
+func (a *MigrationAgent) dispatch(w http.ResponseWriter, r *http.Request, req JSONRPCRequest) {
+	switch req.Method {
+	case "tasks/send":
+		a.handleTasksSend(w, req)
+	case "tasks/get":
+		a.handleTasksGet(w, req)
+	case "message/send":
+		a.handleMessage(w, req)
+	case "tasks/pushNotificationConfig/set":
+		a.handlePushConfigSet(w, req)
+	case "tasks/pushNotificationConfig/get":
+		a.handlePushConfigGet(w, req)
+	case "feedback/send":
+		a.handleFeedbackSend(w, req)
+	case "simulate":
+		a.handleSimulate(w, req)
+	case "plans/save":
+		a.handlePlansSave(w, req)
+	case "plans/get":
+		a.handlePlansGet(w, req)
+	case "plans/list":
+		a.handlePlansList(w, req)
+	case "tasks/sendSubscribe", "message/stream":
+		a.handleTasksSubscribe(w, r, req)
+	case "tasks/resubscribe":
+		a.handleTasksResubscribe(w, r, req)
+	case "tasks/cancel":
+		a.sendError(w, nil, ErrCodeUnsupportedOperation, errorMessages[ErrCodeUnsupportedOperation], req.ID)
+	default:
+		a.sendError(w, nil, ErrCodeMethodNotFound, errorMessages[ErrCodeMethodNotFound], req.ID)
+	}
+}
+
+// handleTasksSend processes tasks/send RPC method
+func (a *MigrationAgent) handleTasksSend(w http.ResponseWriter, req JSONRPCRequest) {
+	// Parse params
+	paramsJSON, err := json.Marshal(req.Params)
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	if err := validateParams(SchemaSendParams, paramsJSON); err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	var params TaskSendParams
+	if err := json.Unmarshal(paramsJSON, &params); err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+	a.sendParams(w, req, params)
+}
+
+// sendParams runs a tasks/send or message/send call through the checks
+// every send passes, then processes the task and replies with it: the
+// options are validated, a send to a wizard task answers its question, a
+// reused ID is handled by the duplicate policy and the end user's quota is
+// taken
+func (a *MigrationAgent) sendParams(w http.ResponseWriter, req JSONRPCRequest, params TaskSendParams) {
+	opts, err := a.taskOptions(params, req)
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	// Generate task ID if not provided
+	taskID := params.ID
+	release, replied := func() {}, false
+	if taskID == "" {
+		taskID = a.ids.NewID()
+	} else if a.replyToWizard(w, req, taskID, params.Message) {
+		return
+	} else if release, replied = a.replyToDuplicate(w, req, taskID); replied {
+		return
+	}
+	if a.replyToUserQuota(w, req, params) {
+		release()
+		return
+	}
+
+	// Process task; a failed task is still a result, with the reason in
+	// its status
+	task, err := a.sendTask(taskID, params.Message, opts)
+	if task == nil {
+		a.sendRPCError(w, err, ErrCodeInternal, req.ID)
+		return
+	}
+
+	// Send response
+	a.sendSuccess(w, a.responseTask(task), req.ID)
+}
+
+// handleMessage maps Telex/A2A `message` calls to the tasks/send flow.
+func (a *MigrationAgent) handleMessage(w http.ResponseWriter, req JSONRPCRequest) {
+	// Try to marshal params into JSON for flexible parsing
+	paramsJSON, err := json.Marshal(req.Params)
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	// Check the params against the wrapper's schema, or the message's when
+	// they are the message itself
+	schema := SchemaMessage
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(paramsJSON, &fields) == nil && fields["message"] != nil {
+		schema = SchemaSendParams
+	}
+	if err := validateParams(schema, paramsJSON); err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	// Try to parse a wrapper {"message": {...}, "id": "..."}, else the
+	// message itself; both forms pass the same checks
+	var params TaskSendParams
+	if err := json.Unmarshal(paramsJSON, &params); err != nil || (params.Message.Role == "" && len(params.Message.Parts) == 0) {
+		var msg Message
+		if err := json.Unmarshal(paramsJSON, &msg); err != nil || (msg.Role == "" && len(msg.Parts) == 0) {
+			a.sendError(w, nil, ErrCodeInvalidParams, "Invalid params for message", req.ID)
+			return
+		}
+		params = TaskSendParams{Message: msg}
+	}
+	a.sendParams(w, req, params)
+}
+
+// replyToDuplicate answers a send for an existing task ID according to the
+// duplicate policy and reports whether a response was written. When it
+// wasn't, the ID is claimed for the send, and release gives it back if the
+// send is refused before it is processed.
+func (a *MigrationAgent) replyToDuplicate(w http.ResponseWriter, req JSONRPCRequest, taskID string) (release func(), replied bool) {
+	existing, release, err := a.claimTask(req.tenant, taskID)
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeTaskNotCancelable, req.ID)
+		return nil, true
+	}
+	if existing != nil {
+		a.mu.RLock()
+		defer a.mu.RUnlock()
+		a.sendSuccess(w, a.files.Resign(existing), req.ID)
+		return nil, true
+	}
+	return release, false
+}
+
+// taskOptions validates the optional processing settings in send params
+// and negotiates the answer's format with the caller
+func (a *MigrationAgent) taskOptions(params TaskSendParams, req JSONRPCRequest) (TaskOptions, error) {
+	switch params.OutputFormat {
+	case "", OutputFormatMarkdown, OutputFormatHTML, OutputFormatJSON:
+	default:
+		return TaskOptions{}, fmt.Errorf("%w: unsupported outputFormat %q (expected %q, %q or %q)", ErrContentTypeNotSupported, params.OutputFormat, OutputFormatMarkdown, OutputFormatHTML, OutputFormatJSON)
+	}
+	format, err := negotiateFormat(params, req.accept)
+	if err != nil {
+		return TaskOptions{}, err
+	}
+
+	if err := a.reminders.validateReminderParams(params.Reminders); err != nil {
+		return TaskOptions{}, err
+	}
+	metadata := mergeMetadata(params.Metadata, params.Message.Metadata)
+	if err := validateMetadata(metadata); err != nil {
+		return TaskOptions{}, err
+	}
+	if err := validatePromptContext(metadata); err != nil {
+		return TaskOptions{}, err
+	}
+	if err := a.uploads.validateFileParts(params.Message.Parts); err != nil {
+		return TaskOptions{}, err
+	}
+	if params.PushNotification != nil {
+		if err := validatePushConfig(params.PushNotification); err != nil {
+			return TaskOptions{}, err
+		}
+	}
+	if err := validateDetail(params.Detail); err != nil {
+		return TaskOptions{}, err
+	}
+	if err := validateTone(params.Tone); err != nil {
+		return TaskOptions{}, err
+	}
+	if err := validateSections(params.Sections); err != nil {
+		return TaskOptions{}, err
+	}
+	if params.Locale != "" {
+		if _, ok := lookupLocale(params.Locale); !ok {
+			return TaskOptions{}, fmt.Errorf("unsupported locale %q", params.Locale)
+		}
+	}
+	if err := validateLanguage(params.Language); err != nil {
+		return TaskOptions{}, err
+	}
+	if err := a.validateModel(params.Model, req.tenant); err != nil {
+		return TaskOptions{}, err
+	}
+	if params.Mode != "" && params.Mode != ModeWizard {
+		return TaskOptions{}, fmt.Errorf("unsupported mode %q (expected %q)", params.Mode, ModeWizard)
+	}
+
+	return TaskOptions{
+		SessionID:    params.SessionID,
+		OutputFormat: format,
+		Reminders:    params.Reminders,
+		Delegated:    params.DelegationDepth > 0,
+		Metadata:     params.Metadata,
+		Push:         params.PushNotification,
+		Locale:       params.Locale,
+		Detail:       params.Detail,
+		Tone:         params.Tone,
+		Sections:     params.Sections,
+		Tenant:       req.tenant.id(),
+		Wizard:       params.Mode == ModeWizard,
+		Model:        params.Model,
+		Language:     params.Language,
+	}, nil
+}
+
+// validateMetadata rejects metadata too large to store on a task
+func validateMetadata(metadata Metadata) error {
+	if len(metadata) == 0 {
+		return nil
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("invalid metadata: %v", err)
+	}
+	if len(encoded) > maxMetadataSize {
+		return fmt.Errorf("metadata must not exceed %d bytes when encoded", maxMetadataSize)
+	}
+	return nil
+}
+
+// mergeMetadata combines task-level and message-level metadata; task-level
+// keys win on conflict
+func mergeMetadata(task, message Metadata) Metadata {
+	if len(message) == 0 {
+		return task
+	}
+	merged := make(Metadata, len(task)+len(message))
+	for k, v := range message {
+		merged[k] = v
+	}
+	for k, v := range task {
+		merged[k] = v
+	}
+	return merged
+}
+
+// handleTasksGet processes tasks/get RPC method
+func (a *MigrationAgent) handleTasksGet(w http.ResponseWriter, req JSONRPCRequest) {
+	// Parse params
+	paramsJSON, err := json.Marshal(req.Params)
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	var params TaskIDParams
+	if err := json.Unmarshal(paramsJSON, &params); err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	// Get task
+	task, err := a.taskFor(req.tenant, params.ID)
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	if params.HistoryLength != nil && *params.HistoryLength < 0 {
+		a.sendError(w, nil, ErrCodeInvalidParams, "historyLength must not be negative", req.ID)
+		return
+	}
+	a.mu.RLock()
+	if params.HistoryLength != nil {
+		task = task.withHistory(*params.HistoryLength)
+	} else {
+		copied := *task
+		task = &copied
+	}
+	task = a.files.Resign(task)
+	a.mu.RUnlock()
+
+	// Send response
+	a.sendSuccess(w, task, req.ID)
+}
+
+// responseTask copies a task under a.mu for a response, with its stored
+// artifacts' links signed afresh, so the answer can be encoded while
+// background work such as the quality judge still updates the task
+func (a *MigrationAgent) responseTask(task *Task) *Task {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	copied := *task
+	return a.files.Resign(&copied)
+}
+
+// sendSuccess sends a successful JSON-RPC response
+func (a *MigrationAgent) sendSuccess(w http.ResponseWriter, result interface{}, id interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	response := JSONRPCResponse{
+		JSONRPC: "2.0",
+		Result:  result,
+		ID:      id,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// sendError sends an error JSON-RPC response
+func (a *MigrationAgent) sendError(w http.ResponseWriter, err error, code int, message string, id interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	response := JSONRPCResponse{
+		JSONRPC: "2.0",
+		Error: &RPCError{
+			Code:    code,
+			Message: message,
+			Data:    nil,
+		},
+		ID: id,
+	}
+	// Errors with structured details send those instead of their text
+	var structured interface{ rpcData() interface{} }
+	if errors.As(err, &structured) {
+		response.Error.Data = structured.rpcData()
+	} else if err != nil {
+		response.Error.Data = err.Error()
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,32 @@
+package agent
+
+import (
+	"testing"
+)
+
+// TestProcessTaskWritesUnderLock reads the task the way handlers do while it
+// is processed; run with -race to catch fields written without a.mu
+func TestProcessTaskWritesUnderLock(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	a := NewMigrationAgent()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		message := Message{Role: "user", Parts: []Part{{Kind: "text", Text: "Nurse from India wanting to move to Canada"}}}
+		a.ProcessTask("task-1", message, TaskOptions{SessionID: "session-1"})
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		a.mu.RLock()
+		if task, ok := a.tasks.Peek("task-1"); ok {
+			_ = *task
+		}
+		a.mu.RUnlock()
+	}
+}
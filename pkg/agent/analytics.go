@@ -0,0 +1,214 @@
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// Corridor is the origin, destination and profession a query was about,
+// reduced to coarse labels so no free text reaches the analytics
+type Corridor struct {
+	Origin      string // origin country, e.g. nigeria
+	Destination string // destination country, e.g. canada
+	Profession  string // profession keyword, e.g. nurse
+}
+
+// queryCorridor labels a query's corridor. The destination is the routed
+// specialist's country; fields that can't be determined are left empty.
+func queryCorridor(query string, profile parser.UserProfile, specialist *Specialist) Corridor {
+	var corridor Corridor
+	if locale, ok := originLocale(query); ok {
+		corridor.Origin = locale.Country
+	}
+	if specialist != generalist {
+		corridor.Destination = strings.ToLower(specialist.Country)
+	}
+	corridor.Profession = professionKeyword(profile.Profession)
+	return corridor
+}
+
+// professionKeyword returns the earliest known profession word in text,
+// also matching plurals such as "nurses"
+func professionKeyword(text string) string {
+	text = " " + strings.ToLower(text) + " "
+	best, bestPos := "", -1
+	for _, word := range content().CVProfessionWords {
+		for _, form := range []string{word, word + "s"} {
+			if pos := parser.IndexWord(text, form); pos != -1 && (bestPos == -1 || pos < bestPos) {
+				best, bestPos = word, pos
+			}
+		}
+	}
+	return best
+}
+
+// analyticsWindow is how far back /admin/analytics looks by default
+const analyticsWindow = 30 * 24 * time.Hour
+
+// Count is one row of a ranked or dated breakdown
+type Count struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// Analytics aggregates the audit trail over a time window
+type Analytics struct {
+	Since           time.Time                 `json:"since"`
+	Until           time.Time                 `json:"until"`
+	Queries         int                       `json:"queries"`
+	Outcomes        map[string]int            `json:"outcomes"`
+	Failures        map[string]int            `json:"failures"` // failed tasks by failure category
+	FailureRate     float64                   `json:"failureRate"`
+	MedianLatencyMs int64                     `json:"medianLatencyMs"` // completed tasks only
+	QueriesPerDay   []Count                   `json:"queriesPerDay"`
+	TopCorridors    []Count                   `json:"topCorridors"` // keyed "origin→destination"
+	TopProfessions  []Count                   `json:"topProfessions"`
+	Tokens          map[string]llm.TokenUsage `json:"tokens"` // by model, plus "total"
+	Feedback        map[string]interface{}    `json:"feedback"`
+}
+
+// computeAnalytics aggregates audit entries, newest first, keeping the top
+// entries of each ranking
+func computeAnalytics(entries []AuditEntry, since, until time.Time, top int) Analytics {
+	stats := Analytics{
+		Since:    since,
+		Until:    until,
+		Outcomes: make(map[string]int),
+		Failures: make(map[string]int),
+		Tokens:   map[string]llm.TokenUsage{"total": {}},
+	}
+
+	perDay := make(map[string]int)
+	corridors := make(map[string]int)
+	professions := make(map[string]int)
+	var latencies []int64
+	for _, entry := range entries {
+		if entry.Outcome == AuditOutcomeFeedback {
+			continue
+		}
+		stats.Queries++
+		stats.Outcomes[entry.Outcome]++
+		perDay[entry.Timestamp.UTC().Format("2006-01-02")]++
+		if entry.ErrorCode != "" {
+			stats.Failures[entry.ErrorCode]++
+		}
+		if entry.Outcome == string(TaskStateCompleted) {
+			latencies = append(latencies, entry.DurationMs)
+		}
+
+		if entry.Origin != "" || entry.Destination != "" {
+			corridors[orUnknown(entry.Origin)+"→"+orUnknown(entry.Destination)]++
+		}
+		if entry.Profession != "" {
+			professions[entry.Profession]++
+		}
+
+		for _, key := range []string{"total", entry.Model} {
+			usage := stats.Tokens[key]
+			usage.PromptTokens += entry.PromptTokens
+			usage.OutputTokens += entry.OutputTokens
+			stats.Tokens[key] = usage
+		}
+	}
+
+	if stats.Queries > 0 {
+		stats.FailureRate = float64(stats.Outcomes[string(TaskStateFailed)]) / float64(stats.Queries)
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		mid := len(latencies) / 2
+		stats.MedianLatencyMs = latencies[mid]
+		if len(latencies)%2 == 0 {
+			stats.MedianLatencyMs = (latencies[mid-1] + latencies[mid]) / 2
+		}
+	}
+
+	stats.QueriesPerDay = sortedCounts(perDay, false)
+	stats.TopCorridors = topCounts(corridors, top)
+	stats.TopProfessions = topCounts(professions, top)
+	stats.Feedback = summarizeFeedback(latestFeedback(entries))
+	return stats
+}
+
+// orUnknown labels a missing corridor field
+func orUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
+
+// sortedCounts lists counts by key, or by count (highest first) when byCount is set
+func sortedCounts(counts map[string]int, byCount bool) []Count {
+	list := make([]Count, 0, len(counts))
+	for key, count := range counts {
+		list = append(list, Count{Key: key, Count: count})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if byCount && list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Key < list[j].Key
+	})
+	return list
+}
+
+// topCounts returns the n highest counts
+func topCounts(counts map[string]int, n int) []Count {
+	list := sortedCounts(counts, true)
+	if n > 0 && len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
+
+// ServeAnalytics handles GET /admin/analytics with optional since and until
+// (RFC 3339; the last 30 days by default) and top (ranking length, default
+// 10) query parameters. Figures are computed from the audit trail, so they
+// cover restarts when AUDIT_LOG_PATH is set.
+func (a *MigrationAgent) ServeAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	q := r.URL.Query()
+	until := a.clock.Now().UTC()
+	since := until.Add(-analyticsWindow)
+	for name, dst := range map[string]*time.Time{"since": &since, "until": &until} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s: %v", name, err))
+				return
+			}
+			*dst = t
+		}
+	}
+	top := 10
+	if v := q.Get("top"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeJSONError(w, http.StatusBadRequest, "invalid top")
+			return
+		}
+		top = n
+	}
+
+	entries, err := a.audit.Query(AuditFilter{Since: since, Until: until})
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, computeAnalytics(entries, since, until, top))
+}
@@ -0,0 +1,131 @@
+package agent
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// applicationCentresData is the built-in list of application centres,
+// replaced by the application-centres dataset when
+// DATASET_APPLICATION_CENTRES_URL is set
+//
+//go:embed application_centres.json
+var applicationCentresData []byte
+
+// ApplicationCentre is where applicants in one country apply for, or give
+// biometrics for, another country's visas: a visa application centre run
+// by a contractor such as VFS Global or TLScontact, or an embassy
+type ApplicationCentre struct {
+	Origin      string   `json:"origin"`      // country applied from
+	Destination string   `json:"destination"` // country whose visas it handles
+	Name        string   `json:"name"`
+	Operator    string   `json:"operator"`           // e.g. VFS Global, TLScontact, Embassy
+	Cities      []string `json:"cities"`             // where it has offices
+	Services    string   `json:"services,omitempty"` // what applicants do there
+	WaitDays    int      `json:"waitDays,omitempty"` // typical wait for an appointment
+	URL         string   `json:"url,omitempty"`      // where to book
+}
+
+// applicationCentreTable is the versioned built-in list
+type applicationCentreTable struct {
+	Version string              `json:"version"`
+	Updated string              `json:"updated"` // YYYY-MM-DD
+	Centres []ApplicationCentre `json:"centres"`
+}
+
+var builtinApplicationCentres = mustParseApplicationCentres(applicationCentresData)
+
+// mustParseApplicationCentres parses the embedded list, which ships with
+// the binary, so a broken one is a build defect
+func mustParseApplicationCentres(data []byte) applicationCentreTable {
+	var table applicationCentreTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		panic(fmt.Sprintf("invalid embedded application centres: %v", err))
+	}
+	if _, err := time.Parse("2006-01-02", table.Updated); err != nil || table.Version == "" {
+		panic("embedded application centres need a version and an updated date")
+	}
+	if err := validateApplicationCentres(table.Centres); err != nil {
+		panic(fmt.Sprintf("invalid embedded application centres: %v", err))
+	}
+	return table
+}
+
+// validateApplicationCentres checks that every centre says where it is and
+// who it serves
+func validateApplicationCentres(centres []ApplicationCentre) error {
+	for i, c := range centres {
+		if c.Origin == "" || c.Destination == "" || c.Name == "" || len(c.Cities) == 0 || c.WaitDays < 0 {
+			return fmt.Errorf("application centre %d needs an origin, destination, name and cities", i)
+		}
+		if c.URL != "" {
+			if u, err := url.Parse(c.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+				return fmt.Errorf("application centre %d: url must be an https URL", i)
+			}
+		}
+	}
+	return nil
+}
+
+// applicationCentres returns the centres where applicants from origin
+// apply for destination's visas
+func (d *Datasets) applicationCentres(origin, destination string) []ApplicationCentre {
+	if d == nil {
+		return nil
+	}
+	var centres []ApplicationCentre
+	for _, c := range d.Centres {
+		if strings.EqualFold(c.Origin, origin) && strings.EqualFold(c.Destination, destination) {
+			centres = append(centres, c)
+		}
+	}
+	return centres
+}
+
+// nextStepPattern matches the Next step line of an answer
+var nextStepPattern = regexp.MustCompile(`(?im)^[ \t]*(?:\*\*)?next step(?:\*\*)?:.*$`)
+
+// applyApplicationCentres lists, under the Next step line, where applicants
+// from the origin country apply for the destination's visas and how long
+// appointments typically take. Answers without a Next step line, or for a
+// corridor with no known centres, are returned unchanged.
+func applyApplicationCentres(markdown, origin, destination string, d *Datasets) string {
+	centres := d.applicationCentres(origin, destination)
+	loc := nextStepPattern.FindStringIndex(markdown)
+	if len(centres) == 0 || loc == nil {
+		return markdown
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nWhere to apply from %s:", titleWords(origin))
+	for _, c := range centres {
+		fmt.Fprintf(&b, "\n- %s (%s) in %s", c.Name, c.Operator, joinList(c.Cities))
+		if c.Services != "" {
+			b.WriteString(": " + c.Services)
+		}
+		if c.WaitDays > 0 {
+			b.WriteString("; appointments typically " + appointmentWait(c.WaitDays) + " away")
+		}
+		if c.URL != "" {
+			b.WriteString(". Book at " + c.URL)
+		}
+	}
+	return markdown[:loc[1]] + b.String() + markdown[loc[1]:]
+}
+
+// appointmentWait describes a wait in days, weeks or months
+func appointmentWait(days int) string {
+	switch {
+	case days < 14:
+		return "about " + plural(days, "day")
+	case days < 60:
+		return "about " + plural((days+3)/7, "week")
+	default:
+		return "about " + plural((days+15)/30, "month")
+	}
+}
@@ -0,0 +1,128 @@
+package agent
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// arrivalData is the curated post-arrival checklist for each destination,
+// maintained with the code like the fee table
+//
+//go:embed arrival.json
+var arrivalData []byte
+
+// ArrivalTask is one thing to do after landing at a destination
+type ArrivalTask struct {
+	Destination string   `json:"destination"`
+	Topic       string   `json:"topic"`                 // one of arrivalTopics
+	Professions []string `json:"professions,omitempty"` // lowercase words for the professions it is for; none means anyone
+	Task        string   `json:"task"`
+	URL         string   `json:"url,omitempty"`
+}
+
+// arrivalTable is the versioned built-in checklist
+type arrivalTable struct {
+	Version string        `json:"version"`
+	Updated string        `json:"updated"` // YYYY-MM-DD
+	Tasks   []ArrivalTask `json:"tasks"`
+}
+
+// arrivalTopics label the checklist's topics, in the order they are done
+var arrivalTopics = []struct {
+	Name  string
+	Label string
+}{
+	{"housing", "Housing"},
+	{"id", "ID numbers"},
+	{"bank", "Bank account"},
+	{"phone", "Phone"},
+	{"health", "Health care"},
+	{"credentials", "Credentials"},
+	{"community", "Community"},
+}
+
+var arrivalTasks = mustParseArrivalTasks(arrivalData)
+
+// mustParseArrivalTasks parses the embedded checklist, which ships with the
+// binary, so a broken one is a build defect
+func mustParseArrivalTasks(data []byte) arrivalTable {
+	var table arrivalTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		panic(fmt.Sprintf("invalid embedded arrival checklist: %v", err))
+	}
+	if _, err := time.Parse("2006-01-02", table.Updated); err != nil || table.Version == "" {
+		panic("embedded arrival checklist needs a version and an updated date")
+	}
+	for i, t := range table.Tasks {
+		known := false
+		for _, topic := range arrivalTopics {
+			known = known || topic.Name == t.Topic
+		}
+		if t.Destination == "" || t.Task == "" || !known {
+			panic(fmt.Sprintf("arrival task %d needs a destination, a task and a known topic", i))
+		}
+		if u, err := url.Parse(t.URL); t.URL != "" && (err != nil || u.Scheme != "https" || u.Host == "") {
+			panic(fmt.Sprintf("arrival task %d needs an https url", i))
+		}
+	}
+	return table
+}
+
+// findArrivalTasks returns the checklist for a destination, by topic.
+// Tasks for the applicant's profession replace the general ones of their
+// topic.
+func findArrivalTasks(destination, profession string) map[string][]ArrivalTask {
+	text := " " + strings.ToLower(profession) + " "
+	general := make(map[string][]ArrivalTask)
+	specific := make(map[string][]ArrivalTask)
+	for _, t := range arrivalTasks.Tasks {
+		if !strings.EqualFold(t.Destination, destination) {
+			continue
+		}
+		if len(t.Professions) == 0 {
+			general[t.Topic] = append(general[t.Topic], t)
+			continue
+		}
+		for _, word := range t.Professions {
+			if parser.IndexWord(text, word) != -1 {
+				specific[t.Topic] = append(specific[t.Topic], t)
+				break
+			}
+		}
+	}
+	for topic, tasks := range specific {
+		general[topic] = tasks
+	}
+	return general
+}
+
+// applyArrivalChecklist adds a second-phase checklist for after landing to
+// the end of an answer: housing, ID numbers, banking, phone, health care,
+// registering credentials for the applicant's profession and community
+// resources. Destinations without one are returned unchanged.
+func applyArrivalChecklist(markdown, destination, profession string) string {
+	tasks := findArrivalTasks(destination, profession)
+	if len(tasks) == 0 {
+		return markdown
+	}
+
+	var section strings.Builder
+	section.WriteString("**After You Arrive:**\n")
+	for _, topic := range arrivalTopics {
+		for _, t := range tasks[topic.Name] {
+			fmt.Fprintf(&section, "- %s: %s", topic.Label, t.Task)
+			if t.URL != "" {
+				section.WriteString(" (" + t.URL + ")")
+			}
+			section.WriteString("\n")
+		}
+	}
+	fmt.Fprintf(&section, "Arrival checklist version %s; offices and requirements vary by city and state.\n", arrivalTasks.Version)
+	return strings.TrimRight(markdown, "\n") + "\n\n" + section.String()
+}
@@ -0,0 +1,340 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPresignTTL is the longest validity SigV4 allows for a presigned URL
+const maxPresignTTL = 7 * 24 * time.Hour
+
+// ArtifactStorage keeps file artifacts in an S3-compatible bucket (Amazon
+// S3, or Google Cloud Storage through its XML API with HMAC keys) and puts
+// presigned download URLs in their parts instead of inline base64, keeping
+// responses and stored tasks small
+type ArtifactStorage struct {
+	endpoint  string // scheme and host, e.g. https://storage.googleapis.com
+	bucket    string
+	prefix    string // key prefix for every object, e.g. artifacts/
+	region    string
+	accessKey string
+	secretKey string
+	token     string
+	urlTTL    time.Duration // validity of each presigned URL
+	retention time.Duration // objects older than this are deleted; 0 keeps them
+	inlineMax int           // files up to this many bytes stay inline
+	client    *http.Client
+	clock     Clock
+}
+
+// NewArtifactStorage configures artifact storage from ARTIFACT_BUCKET (it
+// is off without one), ARTIFACT_STORAGE_ENDPOINT, ARTIFACT_PREFIX,
+// ARTIFACT_URL_TTL, ARTIFACT_RETENTION and ARTIFACT_INLINE_MAX_BYTES.
+// Credentials and region come from the ARTIFACT_* variables, falling back to
+// the standard AWS ones.
+func NewArtifactStorage() *ArtifactStorage {
+	s := &ArtifactStorage{
+		bucket:    os.Getenv("ARTIFACT_BUCKET"),
+		prefix:    "artifacts/",
+		region:    firstEnv("ARTIFACT_REGION", "AWS_REGION"),
+		accessKey: firstEnv("ARTIFACT_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID"),
+		secretKey: firstEnv("ARTIFACT_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY"),
+		urlTTL:    24 * time.Hour,
+		retention: 30 * 24 * time.Hour,
+		client:    &http.Client{Timeout: 60 * time.Second},
+		clock:     systemClock{},
+	}
+	if s.bucket == "" {
+		return s
+	}
+	if os.Getenv("ARTIFACT_ACCESS_KEY_ID") == "" {
+		s.token = os.Getenv("AWS_SESSION_TOKEN")
+	}
+
+	if v, ok := os.LookupEnv("ARTIFACT_PREFIX"); ok {
+		s.prefix = v
+	}
+	if v := os.Getenv("ARTIFACT_URL_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= time.Minute && d <= maxPresignTTL {
+			s.urlTTL = d
+		} else {
+			log.Printf("⚠️  Invalid ARTIFACT_URL_TTL %q (1m to 168h), using %s", v, s.urlTTL)
+		}
+	}
+	if v := os.Getenv("ARTIFACT_RETENTION"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			s.retention = d
+		} else {
+			log.Printf("⚠️  Invalid ARTIFACT_RETENTION %q, using %s", v, s.retention)
+		}
+	}
+	if v := os.Getenv("ARTIFACT_INLINE_MAX_BYTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			s.inlineMax = n
+		} else {
+			log.Printf("⚠️  Invalid ARTIFACT_INLINE_MAX_BYTES %q, uploading every file", v)
+		}
+	}
+
+	s.endpoint = strings.TrimSuffix(os.Getenv("ARTIFACT_STORAGE_ENDPOINT"), "/")
+	if s.endpoint == "" && s.region != "" {
+		s.endpoint = "https://s3." + s.region + ".amazonaws.com"
+	}
+	if s.endpoint == "https://storage.googleapis.com" && s.region == "" {
+		s.region = "auto"
+	}
+
+	switch {
+	case s.endpoint == "" || s.region == "":
+		log.Printf("⚠️  Artifact storage needs ARTIFACT_REGION or ARTIFACT_STORAGE_ENDPOINT; inlining files")
+		s.bucket = ""
+	case s.accessKey == "" || s.secretKey == "":
+		log.Printf("⚠️  Artifact storage needs an access key and secret; inlining files")
+		s.bucket = ""
+	}
+	return s
+}
+
+// firstEnv returns the first of the named environment variables that is set
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Enabled reports whether file artifacts are uploaded
+func (s *ArtifactStorage) Enabled() bool {
+	return s.bucket != ""
+}
+
+// Offload uploads the inline files of a task's new artifacts and replaces
+// their bytes with presigned URLs. A file that can't be uploaded stays
+// inline, so a storage outage never fails a task.
+func (s *ArtifactStorage) Offload(taskID string, artifacts []Artifact) []Artifact {
+	if !s.Enabled() {
+		return artifacts
+	}
+
+	for i, artifact := range artifacts {
+		for j, part := range artifact.Parts {
+			if part.File == nil || part.File.Bytes == "" {
+				continue
+			}
+			data, err := base64.StdEncoding.DecodeString(part.File.Bytes)
+			if err != nil || len(data) <= s.inlineMax {
+				continue
+			}
+
+			key := s.prefix + taskID + "/" + artifact.ArtifactID + "/" + part.File.Name
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			err = s.put(ctx, key, part.File.MimeType, data)
+			cancel()
+			if err != nil {
+				log.Printf("⚠️  Keeping %s of task %s inline: %v", part.File.Name, taskID, err)
+				continue
+			}
+
+			file := *part.File
+			file.Bytes = ""
+			file.URI = s.presign(key, s.clock.Now())
+			parts := append([]Part(nil), artifacts[i].Parts...)
+			parts[j].File = &file
+			artifacts[i].Parts = parts
+		}
+	}
+	return artifacts
+}
+
+// Resign returns a copy of task whose stored files have freshly presigned
+// URLs, so links keep working for as long as the objects are retained.
+// Callers must hold a.mu.
+func (s *ArtifactStorage) Resign(task *Task) *Task {
+	if !s.Enabled() {
+		return task
+	}
+
+	copied := *task
+	copied.Artifacts = make([]Artifact, len(task.Artifacts))
+	now := s.clock.Now()
+	for i, artifact := range task.Artifacts {
+		artifact.Parts = append([]Part(nil), artifact.Parts...)
+		for j, part := range artifact.Parts {
+			if part.File == nil {
+				continue
+			}
+			if key, ok := s.keyOf(part.File.URI); ok {
+				file := *part.File
+				file.URI = s.presign(key, now)
+				artifact.Parts[j].File = &file
+			}
+		}
+		copied.Artifacts[i] = artifact
+	}
+	return &copied
+}
+
+// DeleteTask removes every stored file of a task
+func (s *ArtifactStorage) DeleteTask(ctx context.Context, taskID string) (int, error) {
+	if !s.Enabled() {
+		return 0, nil
+	}
+	return s.deleteMatching(ctx, s.prefix+taskID+"/", func(time.Time) bool { return true })
+}
+
+// Run deletes objects older than the retention period once an hour until
+// the process exits. Use a bucket lifecycle rule instead by setting
+// ARTIFACT_RETENTION to 0.
+func (s *ArtifactStorage) Run() {
+	if !s.Enabled() || s.retention == 0 {
+		return
+	}
+	log.Printf("🪣 Deleting stored artifacts after %s", s.retention)
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for ; ; <-ticker.C {
+		cutoff := s.clock.Now().Add(-s.retention)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		deleted, err := s.deleteMatching(ctx, s.prefix, func(modified time.Time) bool { return modified.Before(cutoff) })
+		cancel()
+		if err != nil {
+			log.Printf("⚠️  Artifact cleanup failed: %v", err)
+		}
+		if deleted > 0 {
+			log.Printf("🪣 Deleted %d expired artifact(s)", deleted)
+		}
+	}
+}
+
+// objectURL is the path-style URL of an object
+func (s *ArtifactStorage) objectURL(key string) *url.URL {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = awsEscape(segment)
+	}
+	u, _ := url.Parse(s.endpoint + "/" + awsEscape(s.bucket) + "/" + strings.Join(segments, "/"))
+	return u
+}
+
+// presign returns a download URL for key valid for the configured TTL
+func (s *ArtifactStorage) presign(key string, now time.Time) string {
+	return presignV4(s.objectURL(key), s.accessKey, s.secretKey, s.token, s.region, "s3", now, s.urlTTL)
+}
+
+// keyOf returns the object key behind a URL handed out by presign
+func (s *ArtifactStorage) keyOf(uri string) (string, bool) {
+	base := s.endpoint + "/" + awsEscape(s.bucket) + "/"
+	if !strings.HasPrefix(uri, base) {
+		return "", false
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", false
+	}
+	key := strings.TrimPrefix(u.Path, "/"+s.bucket+"/")
+	return key, strings.HasPrefix(key, s.prefix)
+}
+
+// put uploads an object
+func (s *ArtifactStorage) put(ctx context.Context, key, contentType string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create upload request: %v", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	_, err = s.do(req, data)
+	return err
+}
+
+// deleteMatching deletes the objects under prefix whose last modification
+// time matches, returning how many were deleted
+func (s *ArtifactStorage) deleteMatching(ctx context.Context, prefix string, match func(time.Time) bool) (int, error) {
+	deleted := 0
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		listURL := s.endpoint + "/" + awsEscape(s.bucket) + "?" + canonicalQuery(query)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, listURL, nil)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to create list request: %v", err)
+		}
+		body, err := s.do(req, nil)
+		if err != nil {
+			return deleted, err
+		}
+
+		var listing struct {
+			Contents []struct {
+				Key          string
+				LastModified time.Time
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		if err := xml.Unmarshal(body, &listing); err != nil {
+			return deleted, fmt.Errorf("failed to parse object listing: %v", err)
+		}
+
+		for _, object := range listing.Contents {
+			if !match(object.LastModified) {
+				continue
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(object.Key).String(), nil)
+			if err != nil {
+				return deleted, fmt.Errorf("failed to create delete request: %v", err)
+			}
+			if _, err := s.do(req, nil); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+
+		if !listing.IsTruncated || listing.NextContinuationToken == "" {
+			return deleted, nil
+		}
+		token = listing.NextContinuationToken
+	}
+}
+
+// do signs and sends a request, returning the response body
+func (s *ArtifactStorage) do(req *http.Request, body []byte) ([]byte, error) {
+	req.Header.Set("X-Amz-Content-Sha256", sha256Hex(body))
+	if s.token != "" {
+		req.Header.Set("X-Amz-Security-Token", s.token)
+	}
+	signV4(req, body, s.accessKey, s.secretKey, s.region, "s3", s.clock.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("object storage %s failed: %v", req.Method, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object storage response: %v", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("object storage %s failed (status %d): %s", req.Method, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
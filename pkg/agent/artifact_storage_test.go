@@ -0,0 +1,147 @@
+package agent
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestArtifactStorage configures artifact storage against endpoint with
+// the given clock
+func newTestArtifactStorage(t *testing.T, endpoint string, clock Clock) *ArtifactStorage {
+	t.Helper()
+	t.Setenv("ARTIFACT_BUCKET", "pathways")
+	t.Setenv("ARTIFACT_STORAGE_ENDPOINT", endpoint)
+	t.Setenv("ARTIFACT_REGION", "eu-west-2")
+	t.Setenv("ARTIFACT_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("ARTIFACT_SECRET_ACCESS_KEY", "secret")
+	s := NewArtifactStorage()
+	if !s.Enabled() {
+		t.Fatal("artifact storage not enabled")
+	}
+	s.clock = clock
+	return s
+}
+
+// presignedExpiry reads when a presigned URL stops working
+func presignedExpiry(t *testing.T, uri string) time.Time {
+	t.Helper()
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("invalid presigned URL %q: %v", uri, err)
+	}
+	query := u.Query()
+	signedAt, err := time.Parse("20060102T150405Z", query.Get("X-Amz-Date"))
+	if err != nil {
+		t.Fatalf("X-Amz-Date of %s: %v", uri, err)
+	}
+	seconds, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+	if err != nil {
+		t.Fatalf("X-Amz-Expires of %s: %v", uri, err)
+	}
+	return signedAt.Add(time.Duration(seconds) * time.Second)
+}
+
+func TestArtifactPresignedURL(t *testing.T) {
+	t.Setenv("ARTIFACT_URL_TTL", "1h")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestArtifactStorage(t, "https://storage.example.com", fixedClock{now})
+
+	key := "artifacts/task-1/plan/My Plan.pdf"
+	uri := s.presign(key, now)
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.EscapedPath() != "/pathways/artifacts/task-1/plan/My%20Plan.pdf" {
+		t.Errorf("path = %s, want the escaped key in the bucket", u.EscapedPath())
+	}
+	query := u.Query()
+	if got := query.Get("X-Amz-Credential"); got != "AKIDEXAMPLE/20260301/eu-west-2/s3/aws4_request" {
+		t.Errorf("X-Amz-Credential = %s", got)
+	}
+	if len(query.Get("X-Amz-Signature")) != 64 {
+		t.Errorf("X-Amz-Signature = %q, want a hex SHA-256 HMAC", query.Get("X-Amz-Signature"))
+	}
+	if got := presignedExpiry(t, uri); !got.Equal(now.Add(time.Hour)) {
+		t.Errorf("URL expires at %s, want an hour after signing", got)
+	}
+	if got, ok := s.keyOf(uri); !ok || got != key {
+		t.Errorf("keyOf = %q, %v, want %q", got, ok, key)
+	}
+	if _, ok := s.keyOf("https://elsewhere.example.com/pathways/" + key); ok {
+		t.Error("keyOf accepted a URL outside the bucket")
+	}
+}
+
+func TestArtifactURLTTL(t *testing.T) {
+	for ttl, want := range map[string]time.Duration{
+		"2h":   2 * time.Hour,
+		"168h": maxPresignTTL,
+		"30s":  24 * time.Hour, // below the minute minimum
+		"200h": 24 * time.Hour, // beyond what SigV4 allows
+		"soon": 24 * time.Hour,
+	} {
+		t.Setenv("ARTIFACT_URL_TTL", ttl)
+		s := newTestArtifactStorage(t, "https://storage.example.com", systemClock{})
+		if s.urlTTL != want {
+			t.Errorf("ARTIFACT_URL_TTL=%s gives %s, want %s", ttl, s.urlTTL, want)
+		}
+	}
+}
+
+func TestArtifactURLsAreResignedAfterExpiry(t *testing.T) {
+	var uploads []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			uploads = append(uploads, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("ARTIFACT_URL_TTL", "1h")
+	clock := &manualClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	s := newTestArtifactStorage(t, server.URL, clock)
+
+	pdf := base64.StdEncoding.EncodeToString([]byte("%PDF-1.4 plan"))
+	artifacts := s.Offload("task-1", []Artifact{{
+		ArtifactID: "plan",
+		Parts: []Part{
+			{Kind: "file", File: &FileContent{Name: "plan.pdf", MimeType: "application/pdf", Bytes: pdf}},
+			{Kind: "file", File: &FileContent{Name: "guide.pdf", URI: "https://gov.example.com/guide.pdf"}},
+		},
+	}})
+	if len(uploads) != 1 || uploads[0] != "/pathways/artifacts/task-1/plan/plan.pdf" {
+		t.Fatalf("uploads = %v, want the inline file", uploads)
+	}
+	file := artifacts[0].Parts[0].File
+	if file.Bytes != "" || file.URI == "" {
+		t.Fatalf("offloaded file = %+v, want a URI instead of bytes", file)
+	}
+	offloaded := file.URI
+
+	clock.now = clock.now.Add(2 * time.Hour)
+	if expiry := presignedExpiry(t, offloaded); !expiry.Before(clock.now) {
+		t.Fatalf("URL still valid at %s, until %s", clock.now, expiry)
+	}
+
+	resigned := s.Resign(&Task{ID: "task-1", Artifacts: artifacts})
+	uri := resigned.Artifacts[0].Parts[0].File.URI
+	if expiry := presignedExpiry(t, uri); !expiry.Equal(clock.now.Add(time.Hour)) {
+		t.Errorf("resigned URL expires at %s, want an hour from now", expiry)
+	}
+	if !strings.HasPrefix(uri, server.URL+"/pathways/artifacts/task-1/plan/plan.pdf?") {
+		t.Errorf("resigned URL %s points at another object", uri)
+	}
+	if got := resigned.Artifacts[0].Parts[1].File.URI; got != "https://gov.example.com/guide.pdf" {
+		t.Errorf("URI outside the bucket changed to %s", got)
+	}
+	if artifacts[0].Parts[0].File.URI != offloaded {
+		t.Error("Resign changed the task it was given")
+	}
+}
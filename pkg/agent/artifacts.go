@@ -0,0 +1,78 @@
+package agent
+
+// versionArtifacts combines the artifacts of a previous run of a task with
+// those of a new run. Previous versions are kept; each new artifact reuses
+// the index of the earlier artifact with the same name, gets the next
+// version number and is marked as appended, so clients can diff versions.
+func versionArtifacts(previous, latest []Artifact) []Artifact {
+	combined := append([]Artifact(nil), previous...)
+
+	type lineage struct {
+		index   int
+		version int
+	}
+	byName := make(map[string]lineage)
+	nextIndex := 0
+	for _, artifact := range previous {
+		l := byName[artifact.Name]
+		if artifact.Version > l.version {
+			l.version = artifact.Version
+		}
+		l.index = artifact.Index
+		byName[artifact.Name] = l
+		if artifact.Index >= nextIndex {
+			nextIndex = artifact.Index + 1
+		}
+	}
+
+	for _, artifact := range latest {
+		if l, ok := byName[artifact.Name]; ok {
+			artifact.Index = l.index
+			artifact.Version = l.version + 1
+			artifact.Append = true
+			byName[artifact.Name] = lineage{index: l.index, version: artifact.Version}
+		} else {
+			artifact.Index = nextIndex
+			artifact.Version = 1
+			nextIndex++
+		}
+		artifact.LastChunk = true
+		combined = append(combined, artifact)
+	}
+	return combined
+}
+
+// latestArtifacts returns only the newest version of each artifact
+func latestArtifacts(artifacts []Artifact) []Artifact {
+	newest := make(map[int]int) // index -> position in artifacts
+	var order []int
+	for i, artifact := range artifacts {
+		if j, seen := newest[artifact.Index]; !seen {
+			order = append(order, artifact.Index)
+			newest[artifact.Index] = i
+		} else if artifact.Version >= artifacts[j].Version {
+			newest[artifact.Index] = i
+		}
+	}
+
+	latest := make([]Artifact, 0, len(order))
+	for _, index := range order {
+		latest = append(latest, artifacts[newest[index]])
+	}
+	return latest
+}
+
+// artifactIndex returns the index versionArtifacts will give a new artifact
+// named name, so it can be streamed before it is stored
+func artifactIndex(previous []Artifact, name string) int {
+	next := 0
+	for _, artifact := range previous {
+		if artifact.Name == name {
+			return artifact.Index
+		}
+		if artifact.Index >= next {
+			next = artifact.Index + 1
+		}
+	}
+	return next
+}
@@ -0,0 +1,359 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AuditEntry records one processed query
+type AuditEntry struct {
+	ID            string            `json:"id"`
+	Timestamp     time.Time         `json:"timestamp"`
+	TaskID        string            `json:"taskId"`
+	SessionID     string            `json:"sessionId,omitempty"`
+	Query         string            `json:"query"` // redacted
+	Model         string            `json:"model"`
+	PromptVersion string            `json:"promptVersion"`
+	Datasets      map[string]string `json:"datasets,omitempty"` // dataset versions the answer used
+	Variant       string            `json:"variant,omitempty"`
+	Tenant        string            `json:"tenant,omitempty"`
+	Outcome       string            `json:"outcome"` // final task state, or AuditOutcomeFeedback
+	Error         string            `json:"error,omitempty"`
+	ErrorCode     string            `json:"errorCode,omitempty"` // failure category of failed tasks and fallback answers
+	DurationMs    int64             `json:"durationMs"`
+	Rating        int               `json:"rating,omitempty"`  // feedback entries only
+	Comment       string            `json:"comment,omitempty"` // feedback entries only, redacted
+
+	Origin       string `json:"origin,omitempty"`
+	Destination  string `json:"destination,omitempty"`
+	Profession   string `json:"profession,omitempty"`
+	PromptTokens int    `json:"promptTokens,omitempty"`
+	OutputTokens int    `json:"outputTokens,omitempty"`
+
+	// Erased marks an entry whose session data was deleted on request; only
+	// the fields metering and analytics count are left
+	Erased bool `json:"erased,omitempty"`
+}
+
+// erase strips the entry down to a tombstone: what ties it to a person is
+// cleared, the counts billing and analytics rely on are kept
+func (e AuditEntry) erase() AuditEntry {
+	e.TaskID, e.SessionID, e.Query, e.Error, e.Comment = "", "", "", "", ""
+	e.Erased = true
+	return e
+}
+
+// AuditFilter narrows an audit query
+type AuditFilter struct {
+	SessionID string
+	TaskID    string
+	Outcome   string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+func (f AuditFilter) matches(e AuditEntry) bool {
+	if f.SessionID != "" && e.SessionID != f.SessionID {
+		return false
+	}
+	if f.TaskID != "" && e.TaskID != f.TaskID {
+		return false
+	}
+	if f.Outcome != "" && e.Outcome != f.Outcome {
+		return false
+	}
+	if !f.Since.IsZero() && e.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Timestamp.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// AuditLog is an append-only trail of processed queries. Entries are written
+// as JSON lines to AUDIT_LOG_PATH; without a path they are kept in memory only.
+// The only change ever made to an entry is erasing it on a data-subject
+// request.
+type AuditLog struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	entries []AuditEntry
+	ids     IDGenerator
+}
+
+// NewAuditLog opens (or creates) the audit log configured by AUDIT_LOG_PATH
+func NewAuditLog() *AuditLog {
+	audit := &AuditLog{ids: uuidGenerator{}}
+
+	path := os.Getenv("AUDIT_LOG_PATH")
+	if path == "" {
+		return audit
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		log.Printf("⚠️  Could not open audit log %s, keeping entries in memory: %v", path, err)
+		return audit
+	}
+	audit.path = path
+	audit.file = f
+	return audit
+}
+
+// Append writes an entry; existing entries are only modified by Erase
+func (l *AuditLog) Append(entry AuditEntry) {
+	if entry.ID == "" {
+		entry.ID = l.ids.NewID()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		l.entries = append(l.entries, entry)
+		return
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("failed to marshal audit entry: %v", err)
+		return
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		log.Printf("failed to write audit entry: %v", err)
+	}
+}
+
+// Query returns matching entries, newest first
+func (l *AuditLog) Query(filter AuditFilter) ([]AuditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	all := l.entries
+	if l.file != nil {
+		var err error
+		if all, err = l.readFile(); err != nil {
+			return nil, err
+		}
+	}
+
+	var results []AuditEntry
+	for i := len(all) - 1; i >= 0; i-- {
+		if !filter.matches(all[i]) {
+			continue
+		}
+		results = append(results, all[i])
+		if filter.Limit > 0 && len(results) >= filter.Limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+// Erase turns every entry that match selects into a tombstone and returns
+// how many were erased. The audit file is rewritten in place of the old one.
+func (l *AuditLog) Erase(match func(AuditEntry) bool) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		erased := 0
+		for i, entry := range l.entries {
+			if !entry.Erased && match(entry) {
+				l.entries[i] = entry.erase()
+				erased++
+			}
+		}
+		return erased, nil
+	}
+
+	entries, err := l.readFile()
+	if err != nil {
+		return 0, err
+	}
+	erased := 0
+	for i, entry := range entries {
+		if !entry.Erased && match(entry) {
+			entries[i] = entry.erase()
+			erased++
+		}
+	}
+	if erased == 0 {
+		return 0, nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), ".audit-*.jsonl")
+	if err != nil {
+		return 0, fmt.Errorf("failed to rewrite audit log: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			tmp.Close()
+			return 0, fmt.Errorf("failed to marshal audit entry: %v", err)
+		}
+		w.Write(append(line, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to rewrite audit log: %v", err)
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to rewrite audit log: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("failed to rewrite audit log: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		return 0, fmt.Errorf("failed to replace audit log: %v", err)
+	}
+
+	// Keep appending to the rewritten file
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return erased, fmt.Errorf("failed to reopen audit log: %v", err)
+	}
+	l.file.Close()
+	l.file = f
+	return erased, nil
+}
+
+// readFile loads every entry from the audit file
+func (l *AuditLog) readFile() ([]AuditEntry, error) {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %v", err)
+	}
+	return entries, nil
+}
+
+// recordAudit appends the outcome of a processed task to the audit trail
+func (a *MigrationAgent) recordAudit(task *Task, query string, started time.Time) {
+	elapsed := a.clock.Now().Sub(started)
+
+	a.mu.RLock()
+	entry := AuditEntry{
+		Timestamp:     started.UTC(),
+		TaskID:        task.ID,
+		SessionID:     task.SessionID,
+		Query:         query,
+		Model:         a.gemini.Model,
+		PromptVersion: content().PromptVersion,
+		Variant:       task.Variant,
+		Tenant:        task.tenant,
+		Outcome:       string(task.Status.State),
+		DurationMs:    elapsed.Milliseconds(),
+	}
+	if task.err != nil {
+		// The raw error can carry request URLs and other secrets; only its
+		// category is kept
+		failure := classifyFailure(task.err)
+		entry.Error, entry.ErrorCode = failure.Message, failure.Code
+	} else if task.Status.State == TaskStateFailed && task.Status.Message != nil && len(task.Status.Message.Parts) > 0 {
+		entry.Error = task.Status.Message.Parts[0].Text
+	}
+	if task.Model != "" {
+		entry.Model = task.Model
+	}
+	if task.promptVersion != "" {
+		entry.PromptVersion = task.promptVersion
+	}
+	entry.Datasets = task.datasetVersions
+	entry.Origin = task.corridor.Origin
+	entry.Destination = task.corridor.Destination
+	entry.Profession = task.corridor.Profession
+	entry.PromptTokens = task.usage.PromptTokens
+	entry.OutputTokens = task.usage.OutputTokens
+	state, usage := task.Status.State, task.usage
+	a.mu.RUnlock()
+
+	a.audit.Append(entry)
+	if state != TaskStateSubmitted {
+		// Held tasks are counted when their retry finishes
+		a.tenantStats.RecordTask(entry.Tenant, state, usage)
+	}
+	if entry.Variant != "" {
+		a.rolloutStats.Record(entry.Variant, state, elapsed)
+	}
+}
+
+// ServeAuditLog handles GET /admin/audit with optional sessionId, taskId,
+// outcome, since, until (RFC 3339) and limit query parameters
+func (a *MigrationAgent) ServeAuditLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	q := r.URL.Query()
+	filter := AuditFilter{
+		SessionID: q.Get("sessionId"),
+		TaskID:    q.Get("taskId"),
+		Outcome:   q.Get("outcome"),
+		Limit:     100,
+	}
+	for name, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s: %v", name, err))
+				return
+			}
+			*dst = t
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			writeJSONError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := a.audit.Query(filter)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if entries == nil {
+		entries = []AuditEntry{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
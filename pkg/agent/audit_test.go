@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+)
+
+func TestAuditKeepsOnlyTheFailureCategory(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	a := NewMigrationAgent()
+
+	transport := &url.Error{Op: "Post", URL: "https://generativelanguage.googleapis.com/v1beta/models/gemini:generateContent?key=SECRET-KEY", Err: fmt.Errorf("connection refused")}
+	task := &Task{ID: "task-1", SessionID: "session-1", err: fmt.Errorf("%w: %v", llm.ErrUnavailable, transport)}
+	task.SetStatus(TaskStateFailed, nil, time.Now())
+	a.recordAudit(task, "query", time.Now())
+
+	entries, err := a.audit.Query(AuditFilter{TaskID: "task-1"})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Query = %d entries, %v; want 1", len(entries), err)
+	}
+	entry := entries[0]
+	if strings.Contains(entry.Error, "SECRET-KEY") || strings.Contains(entry.Error, "googleapis") {
+		t.Errorf("audit entry keeps the raw error: %q", entry.Error)
+	}
+	if entry.ErrorCode != FailureLLMUnavailable || entry.Error != failures[FailureLLMUnavailable].Message {
+		t.Errorf("audit entry = %q (%s), want the %s message", entry.Error, entry.ErrorCode, FailureLLMUnavailable)
+	}
+}
+
+func TestAuditDurationUsesTheAgentClock(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	a := NewMigrationAgentWithDeps(nil, nil, fixedClock{now}, &sequentialIDs{})
+
+	task := &Task{ID: "task-1", SessionID: "session-1"}
+	task.SetStatus(TaskStateCompleted, nil, now)
+	a.recordAudit(task, "query", now.Add(-1500*time.Millisecond))
+
+	entries, err := a.audit.Query(AuditFilter{TaskID: "task-1"})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Query = %d entries, %v; want 1", len(entries), err)
+	}
+	if entries[0].DurationMs != 1500 {
+		t.Errorf("DurationMs = %d, want 1500", entries[0].DurationMs)
+	}
+}
@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// savingsMonths is the horizon a savings target is spread over
+const savingsMonths = 12
+
+// Where a budget check's cost came from
+const (
+	CostSourceFeeTable = "fee table" // the program's official fees and funds
+	CostSourceAnswer   = "answer"    // the amounts on the answer's Cost line
+)
+
+// BudgetCheck compares what a recommendation costs, in US dollars, with the
+// user's budget
+type BudgetCheck struct {
+	Budget    int     `json:"budget"`
+	Pathway   string  `json:"pathway"`
+	Cost      float64 `json:"cost"` // one-off fees, a year of recurring ones and funds to show
+	Source    string  `json:"source"`
+	Shortfall float64 `json:"shortfall,omitempty"`
+	// MonthlySavings spreads the shortfall over savingsMonths
+	MonthlySavings float64 `json:"monthlySavings,omitempty"`
+	// Replaced is the over-budget recommendation regenerated away from
+	Replaced *BudgetCheck `json:"replaced,omitempty"`
+}
+
+// fits reports whether the budget covers the cost
+func (c *BudgetCheck) fits() bool {
+	return c.Shortfall == 0
+}
+
+// checkBudget works out what the recommended pathway costs and compares it
+// with the budget. It returns nil when no budget was given or the cost
+// can't be worked out.
+func checkBudget(markdown, country string, budget, year int, d *Datasets) *BudgetCheck {
+	if budget <= 0 {
+		return nil
+	}
+	pathway := pathwayName(markdown)
+	cost, source := feeTableCost(d.programFees(country, pathway, year), d.rates()), CostSourceFeeTable
+	if cost == 0 {
+		cost, source = answerCost(markdown, d.rates()), CostSourceAnswer
+	}
+	if cost == 0 {
+		return nil
+	}
+
+	c := &BudgetCheck{Budget: budget, Pathway: pathway, Cost: math.Round(cost), Source: source}
+	if c.Cost > float64(budget) {
+		c.Shortfall = c.Cost - float64(budget)
+		c.MonthlySavings = math.Ceil(c.Shortfall / savingsMonths)
+	}
+	return c
+}
+
+// feeTableCost totals a program's fees in US dollars: one-off fees, a year
+// of yearly charges and the funds to show. It returns 0 when the program
+// has no fees or one is in a currency without an exchange rate.
+func feeTableCost(fees []Fee, rates map[string]float64) float64 {
+	var total float64
+	for _, fee := range fees {
+		if fee.Per != "" && fee.Per != "year" {
+			continue
+		}
+		rate := rates[fee.Currency]
+		if fee.Currency == "USD" {
+			rate = 1
+		}
+		if rate == 0 {
+			return 0
+		}
+		total += fee.Amount / rate
+	}
+	return total
+}
+
+// costAmountPattern matches an amount with its currency, before or after:
+// "$20,000", "€11,208", "CAD 1,525", "1,525 CAD", "$20k"
+var costAmountPattern = regexp.MustCompile(`(?i)(US\$|C\$|A\$|\$|€|£|\b(?:USD|CAD|AUD|NZD|EUR|GBP)\s?)(\d[\d,]*(?:\.\d+)?)(\s?k\b)?|(\d[\d,]*(?:\.\d+)?)(\s?k\b)?\s?(USD|CAD|AUD|NZD|EUR|GBP)\b`)
+
+// currencySymbols are the currencies of the symbols costAmountPattern knows
+var currencySymbols = map[string]string{"$": "USD", "US$": "USD", "C$": "CAD", "A$": "AUD", "€": "EUR", "£": "GBP"}
+
+// answerCost reads the cost from an answer's Cost line in US dollars: the
+// largest amount on it, which is the top of a range. It returns 0 when
+// the line has no amount it can convert.
+func answerCost(markdown string, rates map[string]float64) float64 {
+	var largest float64
+	for _, m := range costAmountPattern.FindAllStringSubmatch(costLinePattern.FindString(markdown), -1) {
+		number, thousands, currency := m[2], m[3], m[1]
+		if m[1] == "" {
+			number, thousands, currency = m[4], m[5], m[6]
+		}
+		if symbol, ok := currencySymbols[strings.ToUpper(currency)]; ok {
+			currency = symbol
+		}
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		amount, err := strconv.ParseFloat(strings.ReplaceAll(number, ",", ""), 64)
+		if err != nil {
+			continue
+		}
+		if thousands != "" {
+			amount *= 1000
+		}
+		rate := rates[currency]
+		if currency == "USD" {
+			rate = 1
+		}
+		if rate > 0 && amount/rate > largest {
+			largest = amount / rate
+		}
+	}
+	return largest
+}
+
+// budgetConstraint asks the model for a recommendation the budget covers,
+// naming the one that didn't fit
+func budgetConstraint(c *BudgetCheck) string {
+	return fmt.Sprintf("\nBUDGET CONSTRAINT: %s costs about %s, more than the applicant's budget of %s. Recommend the best pathway whose total cost fits within the budget, such as a cheaper visa, a route with no proof of funds or a free or low-cost first step. If none fits, recommend the cheapest realistic pathway.\n",
+		c.Pathway, formatFee(c.Cost, "USD"), formatFee(float64(c.Budget), "USD"))
+}
+
+// applyBudgetCheck adds a budget section after the key details when the
+// recommendation costs more than the budget, with a savings target for the
+// shortfall, or when a pathway was passed over for costing too much.
+// Answers within budget on the first try are returned unchanged.
+func applyBudgetCheck(markdown string, c *BudgetCheck) string {
+	if c == nil || c.fits() && c.Replaced == nil {
+		return markdown
+	}
+	cost := "about " + formatFee(c.Cost, "USD")
+	if c.Source == CostSourceFeeTable {
+		cost += " in government fees and funds to show"
+	}
+
+	var section strings.Builder
+	section.WriteString("**Budget Check:**\n")
+	if c.fits() {
+		fmt.Fprintf(&section, "- ✅ %s needs %s, within your budget of %s\n", c.Pathway, cost, formatFee(float64(c.Budget), "USD"))
+	} else {
+		fmt.Fprintf(&section, "- ⚠️ %s needs %s, %s more than your budget of %s\n", c.Pathway, cost, formatFee(c.Shortfall, "USD"), formatFee(float64(c.Budget), "USD"))
+		fmt.Fprintf(&section, "- Savings target: %s more, about %s a month over the next %d months\n", formatFee(c.Shortfall, "USD"), formatFee(c.MonthlySavings, "USD"), savingsMonths)
+	}
+	if c.Replaced != nil {
+		fmt.Fprintf(&section, "- %s was passed over because it needs about %s\n", c.Replaced.Pathway, formatFee(c.Replaced.Cost, "USD"))
+	}
+	return insertAfterKeyDetails(markdown, section.String())
+}
@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Milestone is a dated step from the timeline section of a recommendation
+type Milestone struct {
+	Date  time.Time
+	Title string
+}
+
+// timelineItem matches lines such as "- 2025-03-01: Book IELTS test"
+var timelineItem = regexp.MustCompile(`^[-*]\s*(?:\*\*)?(\d{4}-\d{2}-\d{2})(?:\*\*)?\s*[:–-]\s*(.+)$`)
+
+// parseTimeline extracts the dated milestones from the "Timeline" section of
+// the generated markdown. Lines that don't carry an ISO date are ignored.
+func parseTimeline(markdown string) []Milestone {
+	var milestones []Milestone
+	inTimeline := false
+
+	for _, rawLine := range strings.Split(markdown, "\n") {
+		line := strings.TrimSpace(rawLine)
+		lower := strings.ToLower(line)
+
+		if strings.Contains(lower, "timeline") && !strings.HasPrefix(line, "-") {
+			inTimeline = true
+			continue
+		}
+		if !inTimeline {
+			continue
+		}
+		// The section ends at the next heading or non-list paragraph
+		if line != "" && !strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "*") {
+			if len(milestones) > 0 {
+				break
+			}
+			continue
+		}
+
+		match := timelineItem.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", match[1])
+		if err != nil {
+			continue
+		}
+		title := strings.TrimSpace(strings.Trim(match[2], "*"))
+		milestones = append(milestones, Milestone{Date: date, Title: title})
+	}
+
+	return milestones
+}
+
+// buildICS renders milestones as an iCalendar (RFC 5545) document with one
+// all-day event per milestone
+func buildICS(milestones []Milestone, pathway string, now time.Time, ids IDGenerator) string {
+	var b strings.Builder
+	writeLine := func(line string) {
+		b.WriteString(foldICSLine(line))
+		b.WriteString("\r\n")
+	}
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//Migration Pathways Agent//Timeline//EN")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("METHOD:PUBLISH")
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, m := range milestones {
+		writeLine("BEGIN:VEVENT")
+		writeLine("UID:" + ids.NewID() + "@migration-pathways-agent")
+		writeLine("DTSTAMP:" + stamp)
+		writeLine("DTSTART;VALUE=DATE:" + m.Date.Format("20060102"))
+		writeLine("DTEND;VALUE=DATE:" + m.Date.AddDate(0, 0, 1).Format("20060102"))
+		writeLine("SUMMARY:" + escapeICSText(m.Title))
+		if pathway != "" {
+			writeLine("DESCRIPTION:" + escapeICSText(fmt.Sprintf("Milestone for %s", pathway)))
+		}
+		writeLine("END:VEVENT")
+	}
+
+	writeLine("END:VCALENDAR")
+	return b.String()
+}
+
+// escapeICSText escapes characters that have meaning in iCalendar text values
+func escapeICSText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// foldICSLine splits content lines longer than 75 octets as required by
+// RFC 5545, taking care not to split multi-byte UTF-8 sequences
+func foldICSLine(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
+
+// pathwayName pulls the visa name out of the "# Best Migration Option: X" heading
+func pathwayName(markdown string) string {
+	for _, line := range strings.Split(markdown, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			heading := strings.TrimSpace(strings.TrimLeft(line, "#"))
+			if idx := strings.Index(heading, ":"); idx != -1 {
+				return strings.TrimSpace(heading[idx+1:])
+			}
+			return heading
+		}
+	}
+	return ""
+}
+
+// calendarArtifact builds the ICS file artifact for a recommendation, or
+// returns nil when the response contains no dated milestones
+func calendarArtifact(milestones []Milestone, pathway string, now time.Time, ids IDGenerator) *Artifact {
+	if len(milestones) == 0 {
+		return nil
+	}
+
+	ics := buildICS(milestones, pathway, now, ids)
+	return &Artifact{
+		ArtifactID: ids.NewID(),
+		Name:       "Migration Timeline Calendar",
+		Parts: []Part{
+			{
+				Kind: "file",
+				File: &FileContent{
+					Name:     "migration-timeline.ics",
+					MimeType: "text/calendar",
+					Bytes:    base64.StdEncoding.EncodeToString([]byte(ics)),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,186 @@
+package agent
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cardTranslationFiles are the built-in agent card translations, one
+// <language>.json file per ISO 639-1 code
+//
+//go:embed cardi18n/*.json
+var cardTranslationFiles embed.FS
+
+// CardTranslation replaces the human-readable text of the agent card
+type CardTranslation struct {
+	Name        string                      `json:"name"`
+	Description string                      `json:"description"`
+	Examples    []string                    `json:"examples"` // example inputs, in card order
+	Skills      map[string]SkillTranslation `json:"skills"`   // keyed by skill id
+}
+
+// SkillTranslation replaces the text of one skill on the agent card
+type SkillTranslation struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// loadCardTranslations reads the built-in translations, then any
+// <language>.json files in CARD_TRANSLATIONS_DIR, which add languages or
+// replace built-in ones
+func loadCardTranslations() map[string]CardTranslation {
+	translations := make(map[string]CardTranslation)
+
+	builtin, _ := cardTranslationFiles.ReadDir("cardi18n")
+	for _, entry := range builtin {
+		data, err := cardTranslationFiles.ReadFile("cardi18n/" + entry.Name())
+		if err == nil {
+			addCardTranslation(translations, entry.Name(), data)
+		}
+	}
+
+	dir := os.Getenv("CARD_TRANSLATIONS_DIR")
+	if dir == "" {
+		return translations
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		log.Printf("⚠️  Cannot read CARD_TRANSLATIONS_DIR %s: %v", dir, err)
+		return translations
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("⚠️  Cannot read card translation %s: %v", path, err)
+			continue
+		}
+		addCardTranslation(translations, filepath.Base(path), data)
+	}
+	return translations
+}
+
+// addCardTranslation parses one translation file named after its language
+func addCardTranslation(translations map[string]CardTranslation, name string, data []byte) {
+	code := normalizeLanguage(strings.TrimSuffix(name, ".json"))
+	if code == "" {
+		log.Printf("⚠️  Ignoring card translation %s: unsupported language", name)
+		return
+	}
+	var translation CardTranslation
+	if err := json.Unmarshal(data, &translation); err != nil {
+		log.Printf("⚠️  Ignoring card translation %s: %v", name, err)
+		return
+	}
+	translations[code] = translation
+}
+
+// localizeAgentCards renders the agent card once per translated language.
+// Fields a translation leaves empty keep their English text.
+func localizeAgentCards(card []byte, translations map[string]CardTranslation) (map[string][]byte, error) {
+	cards := make(map[string][]byte)
+	for code, translation := range translations {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(card, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse agent card: %v", err)
+		}
+
+		setString(doc, "name", translation.Name)
+		setString(doc, "description", translation.Description)
+		if metadata, ok := doc["metadata"].(map[string]interface{}); ok {
+			metadata["language"] = code
+		}
+		if examples, ok := doc["examples"].([]interface{}); ok {
+			for i, input := range translation.Examples {
+				if i >= len(examples) {
+					break
+				}
+				if example, ok := examples[i].(map[string]interface{}); ok {
+					setString(example, "input", input)
+				}
+			}
+		}
+		if skills, ok := doc["skills"].([]interface{}); ok {
+			for _, s := range skills {
+				skill, ok := s.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				id, _ := skill["id"].(string)
+				if t, ok := translation.Skills[id]; ok {
+					setString(skill, "name", t.Name)
+					setString(skill, "description", t.Description)
+				}
+			}
+		}
+
+		localized, err := json.MarshalIndent(doc, "", "    ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s agent card: %v", code, err)
+		}
+		cards[code] = localized
+	}
+	return cards, nil
+}
+
+// setString sets key to value unless value is empty
+func setString(doc map[string]interface{}, key, value string) {
+	if value != "" {
+		doc[key] = value
+	}
+}
+
+// preferredLanguages returns the languages of an Accept-Language header as
+// ISO 639-1 codes, most preferred first, skipping q=0 and unsupported ones
+func preferredLanguages(header string) []string {
+	type choice struct {
+		code string
+		q    float64
+	}
+	var choices []choice
+	for _, item := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if code := normalizeLanguage(tag); code != "" && q > 0 {
+			choices = append(choices, choice{code, q})
+		}
+	}
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+
+	codes := make([]string, len(choices))
+	for i, c := range choices {
+		codes[i] = c.code
+	}
+	return codes
+}
+
+// agentCardFor returns the agent card in the best language the caller
+// accepts, falling back to the English original
+func (a *MigrationAgent) agentCardFor(acceptLanguage string) ([]byte, string) {
+	a.mu.RLock()
+	cards := a.agentCards
+	a.mu.RUnlock()
+
+	for _, code := range preferredLanguages(acceptLanguage) {
+		if code == "en" {
+			break
+		}
+		if card, ok := cards[code]; ok {
+			return card, code
+		}
+	}
+	return a.GetAgentCard(), "en"
+}
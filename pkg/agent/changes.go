@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// PreviousAnswer is the recommendation a returning user last got for the
+// same corridor, from an earlier task of the session or a saved plan
+type PreviousAnswer struct {
+	TaskID   string    `json:"taskId,omitempty"`
+	PlanID   string    `json:"planId,omitempty"`
+	Date     time.Time `json:"date"`
+	Pathway  string    `json:"pathway,omitempty"`
+	markdown string
+}
+
+// previousAnswer finds the newest completed recommendation for the
+// session's user with the same destination and origin as query, or nil for
+// a first-time question or a session shared by several people
+func (a *MigrationAgent) previousAnswer(task *Task, query string) *PreviousAnswer {
+	if task.SessionID == "" || sharedSession(task.SessionID) {
+		return nil
+	}
+	var best *PreviousAnswer
+	tasks, err := a.TasksForSession(task.SessionID)
+	if err != nil {
+		log.Printf("⚠️  Could not load earlier tasks of session %s: %v", task.SessionID, err)
+	}
+	a.mu.RLock()
+	for _, prev := range tasks {
+		if prev.ID == task.ID || prev.tenant != task.tenant || prev.Wizard != nil || prev.Status.State != TaskStateCompleted {
+			continue
+		}
+		if prev.Status.Message == nil || len(prev.Status.Message.Parts) == 0 || !sameCorridor(prev.query, query) {
+			continue
+		}
+		markdown := prev.Status.Message.Parts[0].Text
+		if pathway := pathwayName(markdown); pathway != "" && (best == nil || prev.CreatedAt.After(best.Date)) {
+			best = &PreviousAnswer{TaskID: prev.ID, Date: prev.CreatedAt, Pathway: pathway, markdown: markdown}
+		}
+	}
+	a.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	plans, err := a.plans.Plans(ctx, PlanFilter{UserID: task.SessionID, Tenant: task.tenant})
+	if err != nil {
+		log.Printf("⚠️  Could not load the plans of %s: %v", task.SessionID, err)
+	}
+	destination := routeSpecialist(query)
+	origin, _ := originLocale(query)
+	for _, plan := range plans {
+		if !strings.EqualFold(plan.Country, destination.Country) || !strings.EqualFold(plan.Profile["origin"], origin.Country) {
+			continue
+		}
+		if plan.TaskID == task.ID || plan.Recommendation == "" {
+			continue
+		}
+		if best == nil || plan.UpdatedAt.After(best.Date) {
+			best = &PreviousAnswer{PlanID: plan.ID, Date: plan.UpdatedAt, Pathway: plan.Pathway, markdown: plan.Recommendation}
+		}
+	}
+	return best
+}
+
+// sharedSession reports whether a session groups the tasks of several
+// people rather than one user's: a Telex channel, or the single session
+// MCP tasks were once all given
+func sharedSession(id string) bool {
+	return id == "mcp" || strings.HasPrefix(id, "telex:")
+}
+
+// sameCorridor reports whether two queries ask about moving to the same
+// known destination from the same origin
+func sameCorridor(a, b string) bool {
+	destination := routeSpecialist(a)
+	if destination == generalist || routeSpecialist(b) != destination {
+		return false
+	}
+	originA, _ := originLocale(a)
+	originB, _ := originLocale(b)
+	return originA.Country == originB.Country
+}
+
+// keyDetails reads the label and value of each key details item, keyed by
+// the lowercase label
+func keyDetails(markdown string) ([]string, map[string]string) {
+	var labels []string
+	values := make(map[string]string)
+	sections, _ := answerData(markdown, nil)["sections"].([]AnswerSection)
+	for _, section := range sections {
+		if !strings.EqualFold(section.Title, "Key Details") {
+			continue
+		}
+		for _, item := range section.Items {
+			label, value, ok := strings.Cut(item, ":")
+			if !ok {
+				continue
+			}
+			key := strings.ToLower(strings.TrimSpace(label))
+			if _, seen := values[key]; !seen {
+				labels = append(labels, strings.TrimSpace(label))
+			}
+			values[key] = strings.TrimSpace(value)
+		}
+	}
+	return labels, values
+}
+
+// applyChanges adds a section after the key details saying how the
+// recommendation differs from the user's previous one: the pathway and
+// each key detail (fees, thresholds, processing time) that changed. Dates
+// are written with dateLayout.
+func applyChanges(markdown string, prev *PreviousAnswer, dateLayout string) string {
+	if prev == nil {
+		return markdown
+	}
+	var changes []string
+	if pathway := pathwayName(markdown); prev.Pathway != "" && !strings.EqualFold(pathway, prev.Pathway) {
+		changes = append(changes, fmt.Sprintf("- Recommended pathway: was %s, now %s", prev.Pathway, pathway))
+	}
+	labels, now := keyDetails(markdown)
+	_, was := keyDetails(prev.markdown)
+	for _, label := range labels {
+		key := strings.ToLower(label)
+		if before, ok := was[key]; ok && before != now[key] {
+			changes = append(changes, fmt.Sprintf("- %s: was %s, now %s", label, before, now[key]))
+		}
+	}
+	if len(changes) == 0 {
+		changes = append(changes, "- Nothing in the key details has changed.")
+	}
+
+	section := fmt.Sprintf("**What Changed Since Last Time (%s):**\n%s", prev.Date.Format(dateLayout), strings.Join(changes, "\n"))
+	return insertAfterKeyDetails(markdown, section)
+}
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// QueryCoalescer lets identical queries that arrive while one is already
+// being answered wait for that answer instead of calling Gemini again.
+// Nothing is cached: once the first call returns, the next identical query
+// starts a fresh generation.
+type QueryCoalescer struct {
+	mu    sync.Mutex
+	calls map[string]*inflightQuery
+}
+
+// inflightQuery is a generation other tasks may be waiting on
+type inflightQuery struct {
+	done     chan struct{}
+	response string
+	err      error
+}
+
+// NewQueryCoalescer creates an empty coalescer
+func NewQueryCoalescer() *QueryCoalescer {
+	return &QueryCoalescer{calls: make(map[string]*inflightQuery)}
+}
+
+// Do runs generate for key unless an identical call is in flight, in which
+// case it waits for and returns that call's result. shared reports whether
+// the result came from another task's call. With coalesce false (the
+// coalescing flag is off for the caller) every call generates its own answer.
+func (c *QueryCoalescer) Do(key string, coalesce bool, generate func() (string, error)) (response string, shared bool, err error) {
+	if !coalesce {
+		response, err = generate()
+		return response, false, err
+	}
+
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.response, true, call.err
+	}
+	call := &inflightQuery{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	call.response, call.err = generate()
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	c.mu.Unlock()
+	close(call.done)
+	return call.response, false, call.err
+}
+
+// coalesceKey identifies everything that shapes an answer: the specialist,
+// the normalized query, the parsed profile and attachments, the style and
+// the model
+func coalesceKey(specialist *Specialist, query string, profile parser.UserProfile, style AnswerStyle, model string) string {
+	h := sha256.New()
+	for _, field := range []string{
+		specialist.Name,
+		strings.Join(strings.Fields(strings.ToLower(query)), " "),
+		profile.Summary(),
+		strconv.Itoa(profile.Budget),
+		profile.Resume,
+		profile.DocumentFacts,
+		style.Language,
+		style.detail(),
+		style.Tone,
+		style.Variant.name(),
+		style.Tenant.id(),
+		style.Constraint,
+		style.Context.promptContext(),
+		model,
+	} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// defaultDisclaimer is appended to every recommendation unless overridden
+// with LEGAL_DISCLAIMER (set it to "none" to disable)
+const defaultDisclaimer = "This recommendation is general information, not legal advice. " +
+	"Immigration rules change frequently — confirm requirements with official government sources " +
+	"or a licensed immigration adviser before applying."
+
+// PolicyViolation describes why a request was refused
+type PolicyViolation struct {
+	Category string `json:"category"`
+	Reason   string `json:"reason"`
+}
+
+// blockedPattern is a category of fraudulent request the agent refuses
+type blockedPattern struct {
+	category string
+	reason   string
+	pattern  *regexp.Regexp
+}
+
+// blockedPatterns lists request types that indicate immigration fraud
+var blockedPatterns = []blockedPattern{
+	{
+		category: "document_fraud",
+		reason:   "Forging, faking or altering documents",
+		pattern:  regexp.MustCompile(`(?i)\b((make|get|buy|create|use|obtain|print|submit|need)\s+(a\s+|an\s+|some\s+)?(fake|forged|counterfeit|falsified|doctored)|forge|falsify|doctor|photoshop)\s+(my\s+|a\s+|the\s+)?(\w+\s+){0,2}(documents?|passports?|diplomas?|degrees?|certificates?|bank\s+statements?|payslips?|ielts|transcripts?|job\s+offers?|reference\s+letters?)\b`),
+	},
+	{
+		category: "sham_marriage",
+		reason:   "Arranging a sham marriage or relationship",
+		pattern:  regexp.MustCompile(`(?i)\b(sham|fake|paper|contract|arranged\s+fake)\s+(marriage|wedding|spouse|husband|wife|relationship)\b|\bmarriage\s+of\s+convenience\b|\bmarry\s+(someone|a\s+citizen)\s+(just\s+)?(for|to\s+get)\s+(papers|a\s+visa|a\s+green\s+card|citizenship)\b`),
+	},
+	{
+		category: "misrepresentation",
+		reason:   "Lying to or deceiving immigration authorities",
+		pattern:  regexp.MustCompile(`(?i)\b(lie|lying|hide|conceal)\s+(on|in|about|from)\s+(\w+\s+){0,3}(application|visa|immigration|interview|officer|criminal\s+record)\b|\bwithout\s+(immigration|border)\s+(officers?|authorities)\s+(knowing|finding\s+out)\b`),
+	},
+	{
+		category: "bribery",
+		reason:   "Bribing officials",
+		pattern:  regexp.MustCompile(`(?i)\b(bribe|bribing|pay\s+off)\s+(an?\s+|the\s+)?(official|officer|embassy|consulate|immigration)`),
+	},
+	{
+		category: "illegal_entry",
+		reason:   "Entering or remaining in a country illegally",
+		pattern:  regexp.MustCompile(`(?i)\b(smuggl(e|ed|ing)|sneak)\s+(me\s+)?(into|across)\b|\b(cross|enter)\s+(\w+\s+){0,2}border\s+illegally\b|\boverstay\s+(\w+\s+){0,2}without\s+(getting\s+)?caught\b`),
+	},
+}
+
+// CompliancePolicy applies the legal guardrails around every recommendation
+type CompliancePolicy struct {
+	Disclaimer string
+	patterns   []blockedPattern
+	logger     *log.Logger
+}
+
+// NewCompliancePolicy creates the policy layer from the environment.
+// Refused requests are logged to COMPLIANCE_LOG_PATH (stderr if unset) so
+// they can be reviewed separately from the application log.
+func NewCompliancePolicy() *CompliancePolicy {
+	disclaimer := defaultDisclaimer
+	if v, ok := os.LookupEnv("LEGAL_DISCLAIMER"); ok {
+		disclaimer = strings.TrimSpace(v)
+		if strings.EqualFold(disclaimer, "none") {
+			disclaimer = ""
+		}
+	}
+
+	var out io.Writer = os.Stderr
+	if path := os.Getenv("COMPLIANCE_LOG_PATH"); path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			log.Printf("⚠️  Could not open compliance log %s, using stderr: %v", path, err)
+		} else {
+			out = f
+		}
+	}
+
+	return &CompliancePolicy{
+		Disclaimer: disclaimer,
+		patterns:   blockedPatterns,
+		logger:     log.New(out, "[compliance] ", log.LstdFlags|log.LUTC),
+	}
+}
+
+// Check returns a violation if the query asks for fraudulent assistance
+func (p *CompliancePolicy) Check(query string) *PolicyViolation {
+	for _, bp := range p.patterns {
+		if bp.pattern.MatchString(query) {
+			return &PolicyViolation{Category: bp.category, Reason: bp.reason}
+		}
+	}
+	return nil
+}
+
+// LogViolation records a refused request in the compliance log
+func (p *CompliancePolicy) LogViolation(taskID string, v *PolicyViolation, query string) {
+	p.logger.Printf("refused task=%s category=%s query=%q", taskID, v.Category, query)
+}
+
+// RefusalText is the user-facing explanation for a refused request
+func (p *CompliancePolicy) RefusalText(v *PolicyViolation) string {
+	return "I can't help with this request. " + v.Reason + " is unlawful and can lead to " +
+		"visa refusal, bans from future applications, and criminal prosecution. " +
+		"I'm happy to help you find a legitimate migration pathway instead."
+}
+
+// ApplyDisclaimer appends the configured legal disclaimer to a recommendation
+func (p *CompliancePolicy) ApplyDisclaimer(markdown string) string {
+	if p.Disclaimer == "" {
+		return markdown
+	}
+	return strings.TrimRight(markdown, "\n") + "\n\n---\n*" + p.Disclaimer + "*\n"
+}
@@ -0,0 +1,18 @@
+package agent
+
+import (
+	"net/http"
+)
+
+// ServeConnStats handles GET /admin/connections, reporting connection reuse
+// for Gemini calls since the server started
+func (a *MigrationAgent) ServeConnStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"gemini": a.gemini.Conns.Snapshot()})
+}
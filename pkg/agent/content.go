@@ -0,0 +1,270 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// Content is the reloadable text the agent works from: prompt templates,
+// parser dictionaries, the specialists' visa knowledge base and the
+// corridor packs. A loaded
+// Content is never modified; reloads swap in a new one.
+type Content struct {
+	PromptVersion     string
+	DetailFormats     map[string]string
+	ToneInstructions  map[string]string
+	CVProfessionWords []string
+	CVLanguages       []string
+	LanguageStopwords map[string][]string
+	Specialists       []*Specialist
+	Corridors         []*CorridorPack
+	LoadedAt          time.Time
+}
+
+// Files read from CONTENT_DIR; each is optional and overrides the built-in
+// content it covers
+const (
+	promptsFile      = "prompts.json"
+	dictionariesFile = "dictionaries.json"
+	specialistsFile  = "specialists.json"
+	corridorsFile    = "corridors.json"
+)
+
+var currentContent atomic.Pointer[Content]
+
+// content returns the content in use, the built-in content until the first
+// load
+func content() *Content {
+	if c := currentContent.Load(); c != nil {
+		return c
+	}
+	return builtinContent()
+}
+
+// cvDictionary is the dictionary the CV parser uses
+func (c *Content) cvDictionary() parser.Dictionary {
+	return parser.Dictionary{ProfessionWords: c.CVProfessionWords, Languages: c.CVLanguages}
+}
+
+// builtinContent is the content compiled into the server
+func builtinContent() *Content {
+	return &Content{
+		PromptVersion:     promptVersion,
+		DetailFormats:     detailFormats,
+		ToneInstructions:  toneInstructions,
+		CVProfessionWords: parser.DefaultDictionary.ProfessionWords,
+		CVLanguages:       parser.DefaultDictionary.Languages,
+		LanguageStopwords: languageStopwords,
+		Specialists:       specialists,
+		Corridors:         corridorPacks,
+	}
+}
+
+// loadContent builds content from the built-in defaults and the files in
+// dir. A file that can't be read or parsed fails the whole load, so a bad
+// edit never leaves the agent half-updated.
+func loadContent(dir string) (*Content, error) {
+	c := builtinContent()
+	if dir == "" {
+		return c, nil
+	}
+
+	var prompts struct {
+		Version string            `json:"version"`
+		Detail  map[string]string `json:"detail"`
+		Tone    map[string]string `json:"tone"`
+	}
+	if ok, err := readContentFile(dir, promptsFile, &prompts); err != nil {
+		return nil, err
+	} else if ok {
+		formats := make(map[string]string)
+		for level, format := range c.DetailFormats {
+			formats[level] = format
+		}
+		for level, format := range prompts.Detail {
+			if _, known := formats[level]; !known {
+				return nil, fmt.Errorf("%s: unknown detail level %q", promptsFile, level)
+			}
+			formats[level] = format
+		}
+		c.DetailFormats = formats
+
+		if prompts.Tone != nil {
+			c.ToneInstructions = prompts.Tone
+		}
+		if prompts.Version != "" {
+			c.PromptVersion = prompts.Version
+		}
+	}
+
+	var dictionaries struct {
+		CVProfessionWords []string            `json:"cvProfessionWords"`
+		CVLanguages       []string            `json:"cvLanguages"`
+		LanguageStopwords map[string][]string `json:"languageStopwords"`
+	}
+	if ok, err := readContentFile(dir, dictionariesFile, &dictionaries); err != nil {
+		return nil, err
+	} else if ok {
+		if dictionaries.CVProfessionWords != nil {
+			c.CVProfessionWords = dictionaries.CVProfessionWords
+		}
+		if dictionaries.CVLanguages != nil {
+			c.CVLanguages = dictionaries.CVLanguages
+		}
+		if dictionaries.LanguageStopwords != nil {
+			c.LanguageStopwords = dictionaries.LanguageStopwords
+		}
+	}
+
+	var loaded []*Specialist
+	if ok, err := readContentFile(dir, specialistsFile, &loaded); err != nil {
+		return nil, err
+	} else if ok {
+		for i, s := range loaded {
+			if s.Name == "" || s.Country == "" || len(s.Aliases) == 0 {
+				return nil, fmt.Errorf("%s: specialist %d needs a name, country and aliases", specialistsFile, i)
+			}
+			for _, r := range s.Health {
+				if r.Requirement == "" {
+					return nil, fmt.Errorf("%s: specialist %s has a health rule without a requirement", specialistsFile, s.Name)
+				}
+			}
+			for _, r := range s.Family {
+				if r.Detail == "" || familyTopicLabels[r.Topic] == "" {
+					return nil, fmt.Errorf("%s: specialist %s has a family rule without a detail or a known topic", specialistsFile, s.Name)
+				}
+			}
+			for _, r := range s.Horizon {
+				if r.Residency.Via == "" || r.Citizenship.Via == "" || r.Residency.MinMonths > r.Residency.MaxMonths || r.Citizenship.MinMonths > r.Citizenship.MaxMonths {
+					return nil, fmt.Errorf("%s: specialist %s has a horizon rule without a route or with months out of order", specialistsFile, s.Name)
+				}
+			}
+			// Calculators are code, so they stay with the built-in
+			// specialist of the same name
+			for _, builtin := range specialists {
+				if builtin.Name == s.Name {
+					s.Calculators = builtin.Calculators
+				}
+			}
+		}
+		c.Specialists = loaded
+	}
+
+	var corridors []*CorridorPack
+	if ok, err := readContentFile(dir, corridorsFile, &corridors); err != nil {
+		return nil, err
+	} else if ok {
+		if err := validateCorridorPacks(corridors); err != nil {
+			return nil, fmt.Errorf("%s: %v", corridorsFile, err)
+		}
+		c.Corridors = corridors
+	}
+	return c, nil
+}
+
+// readContentFile decodes dir/name into v, reporting false if the file
+// doesn't exist
+func readContentFile(dir, name string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to parse %s: %v", name, err)
+	}
+	return true, nil
+}
+
+// ReloadContent reloads prompts, dictionaries and the knowledge base from
+// CONTENT_DIR, agent card translations, feature flags, rollout variants and
+// tenants, without touching tasks. On error the content in use is kept.
+func (a *MigrationAgent) ReloadContent() (*Content, error) {
+	c, err := loadContent(os.Getenv("CONTENT_DIR"))
+	if err != nil {
+		return nil, err
+	}
+	c.LoadedAt = a.clock.Now()
+	cards, err := localizeAgentCards(agentCardData, loadCardTranslations())
+	if err != nil {
+		return nil, err
+	}
+	flags, err := loadFeatureFlags()
+	if err != nil {
+		return nil, err
+	}
+	rollout, err := loadRollout()
+	if err != nil {
+		return nil, err
+	}
+	tenants, err := loadTenants()
+	if err != nil {
+		return nil, err
+	}
+
+	currentContent.Store(c)
+	currentFlags.Store(flags)
+	currentRollout.Store(rollout)
+	currentTenants.Store(tenants)
+	a.mu.Lock()
+	a.agentCards = cards
+	a.mu.Unlock()
+	log.Printf("📚 Content loaded: prompt version %s, %d specialists, %d corridor packs, %d card languages, %d rollout variants, %d tenants", c.PromptVersion, len(c.Specialists), len(c.Corridors), len(cards), len(rollout.Variants), len(tenants.tenants))
+	return c, nil
+}
+
+// reloadOnSignal reloads content every time the process receives SIGHUP
+func (a *MigrationAgent) reloadOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		if _, err := a.ReloadContent(); err != nil {
+			log.Printf("⚠️  Content reload failed, keeping current content: %v", err)
+		}
+	}
+}
+
+// ServeReload handles POST /admin/reload
+func (a *MigrationAgent) ServeReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	c, err := a.ReloadContent()
+	if err != nil {
+		log.Printf("⚠️  Content reload failed: %v", err)
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	tones := make([]string, 0, len(c.ToneInstructions))
+	for tone := range c.ToneInstructions {
+		tones = append(tones, tone)
+	}
+	sort.Strings(tones)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"promptVersion": c.PromptVersion,
+		"specialists":   len(c.Specialists),
+		"corridors":     len(c.Corridors),
+		"tones":         tones,
+		"loadedAt":      c.LoadedAt,
+	})
+}
@@ -0,0 +1,238 @@
+package agent
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Formats of the corridor export, set with CORRIDOR_EXPORT_FORMAT
+const (
+	ExportFormatCSV   = "csv"
+	ExportFormatJSONL = "jsonl"
+)
+
+// CorridorCount is one row of the corridor export. It has no free-text
+// fields: every value comes from a fixed vocabulary (locale countries,
+// specialist destinations and profession keywords), so no personal data
+// can end up in an export.
+type CorridorCount struct {
+	PeriodStart time.Time `json:"periodStart"`
+	PeriodEnd   time.Time `json:"periodEnd"`
+	Origin      string    `json:"origin"`
+	Destination string    `json:"destination"`
+	Profession  string    `json:"profession"`
+	Queries     int       `json:"queries"`
+}
+
+// otherLabel replaces every field of the row summing suppressed groups
+const otherLabel = "other"
+
+// CorridorExporter periodically writes anonymized query counts per
+// origin, destination and profession for demand analysis
+type CorridorExporter struct {
+	audit    *AuditLog
+	dir      string
+	format   string
+	interval time.Duration
+	clock    Clock
+	minCount int // groups with fewer queries are folded into the "other" row
+}
+
+// NewCorridorExporter configures the export from CORRIDOR_EXPORT_DIR (the
+// export is off without it), CORRIDOR_EXPORT_FORMAT (csv or jsonl),
+// CORRIDOR_EXPORT_INTERVAL (default 24h) and CORRIDOR_EXPORT_MIN_COUNT
+// (default 5)
+func NewCorridorExporter(audit *AuditLog) *CorridorExporter {
+	e := &CorridorExporter{
+		audit:    audit,
+		dir:      os.Getenv("CORRIDOR_EXPORT_DIR"),
+		format:   ExportFormatCSV,
+		interval: 24 * time.Hour,
+		clock:    systemClock{},
+		minCount: 5,
+	}
+
+	switch format := os.Getenv("CORRIDOR_EXPORT_FORMAT"); format {
+	case "", ExportFormatCSV:
+	case ExportFormatJSONL:
+		e.format = format
+	default:
+		log.Printf("⚠️  Unknown CORRIDOR_EXPORT_FORMAT %q, using %q", format, ExportFormatCSV)
+	}
+	if v := os.Getenv("CORRIDOR_EXPORT_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= time.Minute {
+			e.interval = d
+		} else {
+			log.Printf("⚠️  Invalid CORRIDOR_EXPORT_INTERVAL %q, exporting every %s", v, e.interval)
+		}
+	}
+	if v := os.Getenv("CORRIDOR_EXPORT_MIN_COUNT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 1 {
+			e.minCount = n
+		} else {
+			log.Printf("⚠️  Invalid CORRIDOR_EXPORT_MIN_COUNT %q, using %d", v, e.minCount)
+		}
+	}
+	return e
+}
+
+// Enabled reports whether an export directory is configured
+func (e *CorridorExporter) Enabled() bool {
+	return e.dir != ""
+}
+
+// Run exports each completed period, aligned to the interval in UTC, until
+// the process exits
+func (e *CorridorExporter) Run() {
+	if !e.Enabled() {
+		return
+	}
+	if err := os.MkdirAll(e.dir, 0o755); err != nil {
+		log.Printf("⚠️  Corridor export disabled: %v", err)
+		return
+	}
+	log.Printf("📤 Exporting corridor counts to %s every %s", e.dir, e.interval)
+
+	for {
+		now := e.clock.Now().UTC()
+		end := now.Truncate(e.interval).Add(e.interval)
+		time.Sleep(end.Sub(now))
+		path, err := e.Export(end.Add(-e.interval), end)
+		if err != nil {
+			log.Printf("⚠️  Corridor export failed: %v", err)
+			continue
+		}
+		if path != "" {
+			log.Printf("📤 Wrote corridor counts to %s", path)
+		}
+	}
+}
+
+// Export writes the counts for [start, end) and returns the file path. A
+// period that was already exported, e.g. by another replica sharing the
+// directory, is skipped and "" is returned.
+func (e *CorridorExporter) Export(start, end time.Time) (string, error) {
+	name := fmt.Sprintf("corridors-%s.%s", start.UTC().Format("20060102T1504Z"), e.format)
+	path := filepath.Join(e.dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return "", nil
+	}
+
+	entries, err := e.audit.Query(AuditFilter{Since: start, Until: end})
+	if err != nil {
+		return "", err
+	}
+	rows := countCorridors(entries, start, end, e.minCount)
+
+	// Write to a temporary file first so readers never see a partial export
+	tmp, err := os.CreateTemp(e.dir, ".corridors-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create export: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if e.format == ExportFormatJSONL {
+		err = writeCorridorsJSONL(tmp, rows)
+	} else {
+		err = writeCorridorsCSV(tmp, rows)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write export: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to move export into place: %v", err)
+	}
+	return path, nil
+}
+
+// countCorridors groups processed queries in [start, end) by corridor and
+// profession. Groups with fewer than minCount queries could single out an
+// individual, so they are summed into one "other" row instead.
+func countCorridors(entries []AuditEntry, start, end time.Time, minCount int) []CorridorCount {
+	counts := make(map[Corridor]int)
+	for _, entry := range entries {
+		if entry.Outcome == AuditOutcomeFeedback || entry.Timestamp.Before(start) || !entry.Timestamp.Before(end) {
+			continue
+		}
+		counts[Corridor{
+			Origin:      orUnknown(entry.Origin),
+			Destination: orUnknown(entry.Destination),
+			Profession:  orUnknown(entry.Profession),
+		}]++
+	}
+
+	var rows []CorridorCount
+	other := 0
+	for corridor, n := range counts {
+		if n < minCount {
+			other += n
+			continue
+		}
+		rows = append(rows, CorridorCount{
+			PeriodStart: start.UTC(),
+			PeriodEnd:   end.UTC(),
+			Origin:      corridor.Origin,
+			Destination: corridor.Destination,
+			Profession:  corridor.Profession,
+			Queries:     n,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Queries != rows[j].Queries {
+			return rows[i].Queries > rows[j].Queries
+		}
+		a, b := rows[i], rows[j]
+		return a.Origin+a.Destination+a.Profession < b.Origin+b.Destination+b.Profession
+	})
+	if other > 0 {
+		rows = append(rows, CorridorCount{
+			PeriodStart: start.UTC(),
+			PeriodEnd:   end.UTC(),
+			Origin:      otherLabel,
+			Destination: otherLabel,
+			Profession:  otherLabel,
+			Queries:     other,
+		})
+	}
+	return rows
+}
+
+// writeCorridorsCSV writes rows as CSV with a header line
+func writeCorridorsCSV(w io.Writer, rows []CorridorCount) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"period_start", "period_end", "origin", "destination", "profession", "queries"})
+	for _, row := range rows {
+		out.Write([]string{
+			row.PeriodStart.Format(time.RFC3339),
+			row.PeriodEnd.Format(time.RFC3339),
+			row.Origin,
+			row.Destination,
+			row.Profession,
+			strconv.Itoa(row.Queries),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// writeCorridorsJSONL writes one JSON object per row
+func writeCorridorsJSONL(w io.Writer, rows []CorridorCount) error {
+	enc := json.NewEncoder(w)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
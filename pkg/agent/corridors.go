@@ -0,0 +1,113 @@
+package agent
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// corridorPackData holds the curated packs for the corridors we get the
+// most queries for
+//
+//go:embed corridors.json
+var corridorPackData []byte
+
+// CorridorPack is curated context for one origin→destination corridor: how
+// people from the origin usually get to the destination and what is
+// specific to applying from there
+type CorridorPack struct {
+	Name        string   `json:"name"`        // display name, e.g. Nigeria → Canada
+	Origin      string   `json:"origin"`      // origin locale country, e.g. nigeria
+	Destination string   `json:"destination"` // specialist name, e.g. canada
+	Summary     string   `json:"summary"`
+	Pathways    []string `json:"pathways"` // the routes most used from the origin, most common first
+	Facts       []string `json:"facts"`    // origin-specific requirements and practicalities
+}
+
+// corridorPacks are the built-in corridor packs; CONTENT_DIR can replace
+// them (see content.go)
+var corridorPacks = mustParseCorridorPacks(corridorPackData)
+
+// mustParseCorridorPacks parses the embedded packs. They ship with the
+// binary, so a broken one is a build defect.
+func mustParseCorridorPacks(data []byte) []*CorridorPack {
+	packs, err := parseCorridorPacks(data)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded corridor packs: %v", err))
+	}
+	return packs
+}
+
+// parseCorridorPacks decodes and checks a list of corridor packs
+func parseCorridorPacks(data []byte) ([]*CorridorPack, error) {
+	var packs []*CorridorPack
+	if err := json.Unmarshal(data, &packs); err != nil {
+		return nil, err
+	}
+	if err := validateCorridorPacks(packs); err != nil {
+		return nil, err
+	}
+	return packs, nil
+}
+
+// validateCorridorPacks checks that each pack names its corridor and has a
+// summary, and that no corridor appears twice
+func validateCorridorPacks(packs []*CorridorPack) error {
+	seen := make(map[string]bool)
+	for i, p := range packs {
+		if p.Name == "" || p.Origin == "" || p.Destination == "" || p.Summary == "" {
+			return fmt.Errorf("corridor pack %d needs a name, origin, destination and summary", i)
+		}
+		p.Origin = strings.ToLower(p.Origin)
+		p.Destination = strings.ToLower(p.Destination)
+		key := p.Origin + "→" + p.Destination
+		if seen[key] {
+			return fmt.Errorf("corridor pack %d: duplicate corridor %s", i, key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// corridorPack returns the pack for a query's corridor: the origin the
+// query states and the destination it was routed to, or nil
+func (c *Content) corridorPack(query string, specialist *Specialist) *CorridorPack {
+	if specialist == generalist {
+		return nil
+	}
+	origin, ok := originLocale(query)
+	if !ok {
+		return nil
+	}
+	for _, p := range c.Corridors {
+		if p.Origin == origin.Country && p.Destination == specialist.Name {
+			return p
+		}
+	}
+	return nil
+}
+
+// promptContext renders the pack for the prompt, or "" without one
+func (p *CorridorPack) promptContext() string {
+	if p == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\nCORRIDOR BRIEFING: " + p.Name + "\n")
+	b.WriteString(p.Summary + "\n")
+	if len(p.Pathways) > 0 {
+		b.WriteString("\nPATHWAYS MOST USED ON THIS CORRIDOR (most common first):\n")
+		for _, pathway := range p.Pathways {
+			b.WriteString("- " + pathway + "\n")
+		}
+	}
+	if len(p.Facts) > 0 {
+		b.WriteString("\nAPPLYING FROM THE ORIGIN COUNTRY (be specific about these where relevant):\n")
+		for _, fact := range p.Facts {
+			b.WriteString("- " + fact + "\n")
+		}
+	}
+	return b.String()
+}
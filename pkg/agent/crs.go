@@ -0,0 +1,145 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// CRSBreakdown is a CRS estimate with its components
+type CRSBreakdown struct {
+	Age                int `json:"age"`
+	Education          int `json:"education"`
+	Language           int `json:"language"`
+	CanadianExperience int `json:"canadianExperience"`
+	Transferability    int `json:"skillTransferability"`
+	Additional         int `json:"additional"`
+	Total              int `json:"total"`
+}
+
+// crsAgePoints are the points for ages 17 through 45 (single applicant)
+var crsAgePoints = map[int]int{
+	17: 50, 18: 99, 19: 105, 20: 110, 21: 110, 22: 110, 23: 110, 24: 110, 25: 110,
+	26: 110, 27: 110, 28: 110, 29: 110, 30: 105, 31: 99, 32: 94, 33: 88, 34: 83,
+	35: 77, 36: 72, 37: 66, 38: 61, 39: 55, 40: 50, 41: 39, 42: 28, 43: 17, 44: 6,
+}
+
+var crsEducationPoints = map[string]int{
+	parser.EducationSecondary: 30,
+	parser.EducationOneYear:   90,
+	parser.EducationTwoYear:   98,
+	parser.EducationBachelor:  120,
+	parser.EducationTwoOrMore: 128,
+	parser.EducationMaster:    135,
+	parser.EducationDoctorate: 150,
+}
+
+// CalculateCRS estimates a CRS score from core human capital, skill
+// transferability and provincial nomination points
+func CalculateCRS(in parser.CRSInput) CRSBreakdown {
+	var b CRSBreakdown
+	b.Age = crsAgePoints[in.Age]
+	b.Education = crsEducationPoints[in.Education]
+	b.Language = 4 * crsLanguagePoints(in.CLB)
+
+	switch {
+	case in.CanadianExperience >= 5:
+		b.CanadianExperience = 80
+	case in.CanadianExperience == 4:
+		b.CanadianExperience = 72
+	case in.CanadianExperience == 3:
+		b.CanadianExperience = 64
+	case in.CanadianExperience == 2:
+		b.CanadianExperience = 53
+	case in.CanadianExperience == 1:
+		b.CanadianExperience = 40
+	}
+
+	// Skill transferability: education and foreign experience, each combined
+	// with language ability, capped at 50 points per group
+	postSecondary := in.Education != parser.EducationSecondary && in.Education != ""
+	advanced := in.Education == parser.EducationTwoOrMore || in.Education == parser.EducationMaster || in.Education == parser.EducationDoctorate
+	educationTransfer := 0
+	if postSecondary && in.CLB >= 9 {
+		educationTransfer = 25
+		if advanced {
+			educationTransfer = 50
+		}
+	} else if postSecondary && in.CLB >= 7 {
+		educationTransfer = 13
+		if advanced {
+			educationTransfer = 25
+		}
+	}
+	experienceTransfer := 0
+	if in.ForeignExperience >= 1 && in.CLB >= 7 {
+		switch {
+		case in.ForeignExperience >= 3 && in.CLB >= 9:
+			experienceTransfer = 50
+		case in.ForeignExperience >= 3, in.CLB >= 9:
+			experienceTransfer = 25
+		default:
+			experienceTransfer = 13
+		}
+	}
+	b.Transferability = educationTransfer + experienceTransfer
+	if b.Transferability > 100 {
+		b.Transferability = 100
+	}
+
+	if in.ProvincialNominated {
+		b.Additional = 600
+	}
+
+	b.Total = b.Age + b.Education + b.Language + b.CanadianExperience + b.Transferability + b.Additional
+	return b
+}
+
+// crsLanguagePoints returns the points per ability for a CLB level
+func crsLanguagePoints(clb int) int {
+	switch {
+	case clb >= 10:
+		return 34
+	case clb == 9:
+		return 31
+	case clb == 8:
+		return 23
+	case clb == 7:
+		return 17
+	case clb == 6:
+		return 9
+	case clb >= 4:
+		return 6
+	default:
+		return 0
+	}
+}
+
+// crsCalculator adds an Express Entry CRS estimate when the query, plus
+// any attached CV, contains enough detail to compute one
+func crsCalculator(profile parser.UserProfile, query string) string {
+	in, _ := parser.ParseCRSInput(query)
+	if in.Education == "" {
+		in.Education = profile.Education
+	}
+	if in.CLB == 0 {
+		in.CLB = profile.CLB
+	}
+	if in.ForeignExperience == 0 {
+		in.ForeignExperience = profile.ExperienceYears
+	}
+	if in.Age < 17 || in.Education == "" || in.CLB == 0 {
+		return ""
+	}
+	b := CalculateCRS(in)
+	return fmt.Sprintf(`**Estimated CRS score:** %d
+- Age: %d
+- Education: %d
+- Language (CLB %d): %d
+- Canadian work experience: %d
+- Skill transferability: %d
+- Provincial nomination: %d
+
+_Estimate for a single applicant from the details you provided; confirm with the official CRS tool._`,
+		b.Total, b.Age, b.Education, in.CLB, b.Language, b.CanadianExperience, b.Transferability, b.Additional)
+}
@@ -0,0 +1,598 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Reference datasets that are refreshed from configured sources
+const (
+	DatasetFees            = "fees"
+	DatasetProcessingTimes = "processing-times"
+	DatasetExchangeRates   = "exchange-rates"
+	DatasetCentres         = "application-centres"
+)
+
+// datasetNames lists the datasets in the order they are refreshed and
+// reported
+var datasetNames = []string{DatasetFees, DatasetProcessingTimes, DatasetExchangeRates, DatasetCentres}
+
+// datasetSourceVars names the environment variable holding each dataset's
+// source URL
+var datasetSourceVars = map[string]string{
+	DatasetFees:            "DATASET_FEES_URL",
+	DatasetProcessingTimes: "DATASET_PROCESSING_TIMES_URL",
+	DatasetExchangeRates:   "DATASET_EXCHANGE_RATES_URL",
+	DatasetCentres:         "DATASET_APPLICATION_CENTRES_URL",
+}
+
+// maxDatasetSize caps a downloaded dataset
+const maxDatasetSize = 10 << 20
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Fee is one government fee of a visa program, or money the applicant
+// must show such as settlement funds
+type Fee struct {
+	Country  string  `json:"country"`
+	Program  string  `json:"program"`
+	Year     int     `json:"year,omitempty"` // year of the fee schedule; 0 applies to every year
+	Item     string  `json:"item"`           // e.g. application fee, biometrics
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`       // ISO 4217 code
+	Per      string  `json:"per,omitempty"`  // month or year for recurring charges; "" when paid once
+	Kind     string  `json:"kind,omitempty"` // FeeKindFunds for money to show; "" for a fee
+}
+
+// ProcessingTime is how long a visa program currently takes to decide
+type ProcessingTime struct {
+	Country string `json:"country"`
+	Program string `json:"program"`
+	Time    string `json:"time"` // as published, e.g. "6 months"
+}
+
+// DatasetVersion identifies the copy of a dataset in use
+type DatasetVersion struct {
+	Version   string    `json:"version"` // fetch time and content hash, e.g. 20251102T0300Z-1a2b3c4d
+	Source    string    `json:"source,omitempty"`
+	FetchedAt time.Time `json:"fetchedAt,omitempty"`
+	Entries   int       `json:"entries"`
+}
+
+// Datasets is a snapshot of the reference data answers are built from. A
+// loaded Datasets is never modified; refreshes swap in a new one, so a task
+// keeps the snapshot it started with.
+type Datasets struct {
+	Fees            []Fee
+	ProcessingTimes []ProcessingTime
+	ExchangeRates   map[string]float64 // units of each currency per US dollar
+	Centres         []ApplicationCentre
+	Versions        map[string]DatasetVersion
+}
+
+var currentDatasets atomic.Pointer[Datasets]
+
+// datasets returns the datasets in use, the built-in ones until the first
+// load
+func datasets() *Datasets {
+	if d := currentDatasets.Load(); d != nil {
+		return d
+	}
+	return builtinDatasets()
+}
+
+// builtinDatasets holds the built-in fee table and application centres,
+// the exchange rates from EXCHANGE_RATES and no processing times
+func builtinDatasets() *Datasets {
+	d := &Datasets{Fees: builtinFeeTable.Fees, ExchangeRates: exchangeRates(), Centres: builtinApplicationCentres.Centres, Versions: make(map[string]DatasetVersion)}
+	updated, _ := time.Parse("2006-01-02", builtinFeeTable.Updated)
+	d.Versions[DatasetFees] = DatasetVersion{Version: builtinFeeTable.Version, Source: "built-in", FetchedAt: updated, Entries: len(d.Fees)}
+	updated, _ = time.Parse("2006-01-02", builtinApplicationCentres.Updated)
+	d.Versions[DatasetCentres] = DatasetVersion{Version: builtinApplicationCentres.Version, Source: "built-in", FetchedAt: updated, Entries: len(d.Centres)}
+	if len(d.ExchangeRates) > 0 {
+		d.Versions[DatasetExchangeRates] = DatasetVersion{Version: "env", Source: "EXCHANGE_RATES", Entries: len(d.ExchangeRates)}
+	}
+	return d
+}
+
+// with returns a copy of the snapshot with one dataset replaced
+func (d *Datasets) with(name string, data *Datasets, version DatasetVersion) *Datasets {
+	next := *d
+	next.Versions = make(map[string]DatasetVersion, len(d.Versions)+1)
+	for n, v := range d.Versions {
+		next.Versions[n] = v
+	}
+	next.Versions[name] = version
+
+	switch name {
+	case DatasetFees:
+		next.Fees = data.Fees
+	case DatasetProcessingTimes:
+		next.ProcessingTimes = data.ProcessingTimes
+	case DatasetExchangeRates:
+		next.ExchangeRates = data.ExchangeRates
+	case DatasetCentres:
+		next.Centres = data.Centres
+	}
+	return &next
+}
+
+// versions lists the version of each dataset in the snapshot, for audit
+// records
+func (d *Datasets) versions() map[string]string {
+	if d == nil || len(d.Versions) == 0 {
+		return nil
+	}
+	versions := make(map[string]string, len(d.Versions))
+	for name, v := range d.Versions {
+		versions[name] = v.Version
+	}
+	return versions
+}
+
+// rates returns the exchange rates, or nil without a snapshot
+func (d *Datasets) rates() map[string]float64 {
+	if d == nil {
+		return nil
+	}
+	return d.ExchangeRates
+}
+
+// countryFacts lists a year's fees and the current processing times of a
+// country's programs
+func (d *Datasets) countryFacts(country string, year int) []string {
+	if d == nil {
+		return nil
+	}
+	var facts []string
+	for _, fee := range d.currentFees(country, year) {
+		fact := fmt.Sprintf("%s %s: %s %s", fee.Program, fee.Item, formatFeeAmount(fee.Amount), fee.Currency)
+		if fee.Per != "" {
+			fact += " per " + fee.Per
+		}
+		if fee.Year > 0 {
+			fact += fmt.Sprintf(" (%d)", fee.Year)
+		}
+		facts = append(facts, fact)
+	}
+	for _, pt := range d.ProcessingTimes {
+		if strings.EqualFold(pt.Country, country) {
+			facts = append(facts, fmt.Sprintf("%s processing time: %s", pt.Program, pt.Time))
+		}
+	}
+	return facts
+}
+
+// formatFeeAmount writes a fee without decimals when it is whole
+func formatFeeAmount(amount float64) string {
+	if amount == float64(int64(amount)) {
+		return fmt.Sprintf("%d", int64(amount))
+	}
+	return fmt.Sprintf("%.2f", amount)
+}
+
+// asOf describes when the fee and processing-time data was fetched
+func (d *Datasets) asOf() string {
+	var latest time.Time
+	for _, name := range []string{DatasetFees, DatasetProcessingTimes} {
+		if v := d.Versions[name]; v.FetchedAt.After(latest) {
+			latest = v.FetchedAt
+		}
+	}
+	if latest.IsZero() {
+		return ""
+	}
+	return " as of " + latest.UTC().Format("2006-01-02")
+}
+
+// promptContext renders a country's current fees and processing times for
+// the prompt, or "" when none are known
+func (d *Datasets) promptContext(country string, year int) string {
+	facts := d.countryFacts(country, year)
+	if len(facts) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\nCURRENT OFFICIAL FEES AND PROCESSING TIMES" + d.asOf() + " (quote these instead of older figures you know):\n")
+	for _, fact := range facts {
+		b.WriteString("- " + fact + "\n")
+	}
+	return b.String()
+}
+
+// parseDataset reads a downloaded dataset into a snapshot holding just that
+// dataset, rejecting data that is empty or malformed so a bad download never
+// replaces a good copy. Fees, processing times and application centres are
+// JSON arrays of Fee, ProcessingTime and ApplicationCentre; exchange rates are an object with US dollar based "rates",
+// as returned by most exchange rate APIs.
+func parseDataset(name string, body []byte) (*Datasets, int, error) {
+	d := &Datasets{}
+	switch name {
+	case DatasetFees:
+		if err := json.Unmarshal(body, &d.Fees); err != nil {
+			return nil, 0, fmt.Errorf("failed to parse fees: %v", err)
+		}
+		if err := validateFees(d.Fees); err != nil {
+			return nil, 0, err
+		}
+		return d, len(d.Fees), checkEntries(len(d.Fees))
+
+	case DatasetProcessingTimes:
+		if err := json.Unmarshal(body, &d.ProcessingTimes); err != nil {
+			return nil, 0, fmt.Errorf("failed to parse processing times: %v", err)
+		}
+		for i, pt := range d.ProcessingTimes {
+			if pt.Country == "" || pt.Program == "" || pt.Time == "" {
+				return nil, 0, fmt.Errorf("processing time %d needs a country, program and time", i)
+			}
+		}
+		return d, len(d.ProcessingTimes), checkEntries(len(d.ProcessingTimes))
+
+	case DatasetExchangeRates:
+		var rates struct {
+			Base     string             `json:"base"`
+			BaseCode string             `json:"base_code"`
+			Rates    map[string]float64 `json:"rates"`
+		}
+		if err := json.Unmarshal(body, &rates); err != nil {
+			return nil, 0, fmt.Errorf("failed to parse exchange rates: %v", err)
+		}
+		if base := strings.ToUpper(rates.Base + rates.BaseCode); base != "" && base != "USD" {
+			return nil, 0, fmt.Errorf("exchange rates must be based on USD, not %s", base)
+		}
+		d.ExchangeRates = make(map[string]float64, len(rates.Rates))
+		for code, rate := range rates.Rates {
+			code = strings.ToUpper(code)
+			if !currencyCode.MatchString(code) || rate <= 0 {
+				return nil, 0, fmt.Errorf("invalid exchange rate %s=%v", code, rate)
+			}
+			d.ExchangeRates[code] = rate
+		}
+		return d, len(d.ExchangeRates), checkEntries(len(d.ExchangeRates))
+
+	case DatasetCentres:
+		if err := json.Unmarshal(body, &d.Centres); err != nil {
+			return nil, 0, fmt.Errorf("failed to parse application centres: %v", err)
+		}
+		if err := validateApplicationCentres(d.Centres); err != nil {
+			return nil, 0, err
+		}
+		return d, len(d.Centres), checkEntries(len(d.Centres))
+	}
+	return nil, 0, fmt.Errorf("unknown dataset %q", name)
+}
+
+// checkEntries rejects an empty dataset
+func checkEntries(n int) error {
+	if n == 0 {
+		return errors.New("dataset is empty")
+	}
+	return nil
+}
+
+// storedDataset is a dataset as persisted in DATASET_DIR
+type storedDataset struct {
+	Name    string          `json:"name"`
+	Version DatasetVersion  `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// DatasetStatus reports the state of one dataset for /admin/datasets
+type DatasetStatus struct {
+	Name string `json:"name"`
+	DatasetVersion
+	CheckedAt time.Time `json:"checkedAt,omitempty"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+// DatasetRefresher re-downloads the reference datasets on a schedule,
+// persists each new version in DATASET_DIR and swaps it in for new tasks
+type DatasetRefresher struct {
+	dir      string            // where versions are persisted; "" keeps them in memory
+	sources  map[string]string // source URL by dataset name
+	schedule refreshSchedule
+	client   *http.Client
+	clock    Clock
+
+	refreshing sync.Mutex // one refresh at a time
+	mu         sync.Mutex
+	checkedAt  map[string]time.Time
+	lastErrors map[string]string
+
+	// onChange is called in the background after a dataset is swapped for
+	// a new version
+	onChange func(name string, before, after *Datasets)
+}
+
+// NewDatasetRefresher configures refreshes from the DATASET_*_URL sources,
+// DATASET_REFRESH_SCHEDULE (default @daily) and DATASET_DIR, and loads the
+// versions persisted there
+func NewDatasetRefresher() *DatasetRefresher {
+	r := &DatasetRefresher{
+		dir:        os.Getenv("DATASET_DIR"),
+		sources:    make(map[string]string),
+		schedule:   refreshSchedule{every: 24 * time.Hour, align: true},
+		client:     &http.Client{Timeout: 30 * time.Second},
+		clock:      systemClock{},
+		checkedAt:  make(map[string]time.Time),
+		lastErrors: make(map[string]string),
+	}
+	for _, name := range datasetNames {
+		if url := os.Getenv(datasetSourceVars[name]); url != "" {
+			r.sources[name] = url
+		}
+	}
+	if v := os.Getenv("DATASET_REFRESH_SCHEDULE"); v != "" {
+		if schedule, err := parseRefreshSchedule(v); err == nil {
+			r.schedule = schedule
+		} else {
+			log.Printf("⚠️  Invalid DATASET_REFRESH_SCHEDULE %q, refreshing daily: %v", v, err)
+		}
+	}
+	r.load()
+	return r
+}
+
+// Enabled reports whether any dataset has a source to refresh from
+func (r *DatasetRefresher) Enabled() bool {
+	return len(r.sources) > 0
+}
+
+// load swaps in the built-in datasets updated with the versions persisted
+// in DATASET_DIR. A persisted copy that can't be read is skipped and logged.
+func (r *DatasetRefresher) load() {
+	d := builtinDatasets()
+	for _, name := range datasetNames {
+		if r.dir == "" {
+			break
+		}
+		data, err := os.ReadFile(filepath.Join(r.dir, name+".json"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		var stored storedDataset
+		if err == nil {
+			err = json.Unmarshal(data, &stored)
+		}
+		var parsed *Datasets
+		if err == nil {
+			parsed, _, err = parseDataset(name, stored.Data)
+		}
+		if err != nil {
+			log.Printf("⚠️  Ignoring persisted %s dataset: %v", name, err)
+			continue
+		}
+		d = d.with(name, parsed, stored.Version)
+	}
+	currentDatasets.Store(d)
+	if versions := d.versions(); len(versions) > 0 {
+		log.Printf("📊 Datasets loaded: %v", versions)
+	}
+}
+
+// Run refreshes the datasets now and then on the schedule, until the
+// process exits
+func (r *DatasetRefresher) Run() {
+	if !r.Enabled() {
+		return
+	}
+	log.Printf("📊 Refreshing %d datasets %s", len(r.sources), r.schedule)
+	for {
+		r.Refresh(context.Background())
+		now := r.clock.Now()
+		time.Sleep(r.schedule.next(now).Sub(now))
+	}
+}
+
+// Refresh downloads every configured dataset, swapping in and persisting
+// those that changed, and returns their status. A dataset that fails to
+// download or validate keeps its current version.
+func (r *DatasetRefresher) Refresh(ctx context.Context) []DatasetStatus {
+	r.refreshing.Lock()
+	defer r.refreshing.Unlock()
+
+	for _, name := range datasetNames {
+		source, ok := r.sources[name]
+		if !ok {
+			continue
+		}
+		err := r.refreshOne(ctx, name, source)
+		r.mu.Lock()
+		r.checkedAt[name] = r.clock.Now()
+		if err != nil {
+			r.lastErrors[name] = err.Error()
+			log.Printf("⚠️  Failed to refresh the %s dataset, keeping the current version: %v", name, err)
+		} else {
+			delete(r.lastErrors, name)
+		}
+		r.mu.Unlock()
+	}
+	return r.Status()
+}
+
+// refreshOne downloads one dataset and swaps it in if its content changed
+func (r *DatasetRefresher) refreshOne(ctx context.Context, name, source string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return fmt.Errorf("invalid source: %v", err)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("download failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed: status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDatasetSize+1))
+	if err != nil {
+		return fmt.Errorf("download failed: %v", err)
+	}
+	if len(body) > maxDatasetSize {
+		return fmt.Errorf("dataset is larger than %d bytes", maxDatasetSize)
+	}
+
+	parsed, entries, err := parseDataset(name, body)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:4])
+	current := datasets()
+	if strings.HasSuffix(current.Versions[name].Version, "-"+hash) {
+		return nil
+	}
+
+	now := r.clock.Now().UTC()
+	version := DatasetVersion{
+		Version:   now.Format("20060102T1504Z") + "-" + hash,
+		Source:    source,
+		FetchedAt: now,
+		Entries:   entries,
+	}
+	if err := r.persist(name, version, body); err != nil {
+		return err
+	}
+	next := current.with(name, parsed, version)
+	currentDatasets.Store(next)
+	log.Printf("📊 Dataset %s updated to version %s (%d entries)", name, version.Version, entries)
+	if r.onChange != nil {
+		go r.onChange(name, current, next)
+	}
+	return nil
+}
+
+// persist writes a dataset version to DATASET_DIR, both as the current copy
+// loaded at startup and under its version so the data behind an old task
+// can be looked up
+func (r *DatasetRefresher) persist(name string, version DatasetVersion, body []byte) error {
+	if r.dir == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(storedDataset{Name: name, Version: version, Data: body}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode dataset: %v", err)
+	}
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create DATASET_DIR: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(r.dir, name+"@"+version.Version+".json"), data, 0o644); err != nil {
+		return fmt.Errorf("failed to persist dataset: %v", err)
+	}
+
+	// Replace the current copy atomically so a crash never leaves half a file
+	tmp := filepath.Join(r.dir, name+".json.tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to persist dataset: %v", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(r.dir, name+".json")); err != nil {
+		return fmt.Errorf("failed to persist dataset: %v", err)
+	}
+	return nil
+}
+
+// Status reports the version in use of every dataset and its last refresh
+func (r *DatasetRefresher) Status() []DatasetStatus {
+	d := datasets()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := make([]DatasetStatus, 0, len(datasetNames))
+	for _, name := range datasetNames {
+		status := DatasetStatus{
+			Name:           name,
+			DatasetVersion: d.Versions[name],
+			CheckedAt:      r.checkedAt[name],
+			LastError:      r.lastErrors[name],
+		}
+		if status.Source == "" {
+			status.Source = r.sources[name]
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// refreshSchedule says when datasets are refreshed: every interval, or at
+// the start of every hour, day or week (UTC) like the cron descriptors
+type refreshSchedule struct {
+	every time.Duration
+	align bool // run on interval boundaries rather than relative to now
+}
+
+// parseRefreshSchedule reads @hourly, @daily, @weekly or a duration such as
+// 6h
+func parseRefreshSchedule(s string) (refreshSchedule, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "@hourly":
+		return refreshSchedule{every: time.Hour, align: true}, nil
+	case "@daily", "@midnight":
+		return refreshSchedule{every: 24 * time.Hour, align: true}, nil
+	case "@weekly":
+		return refreshSchedule{every: 7 * 24 * time.Hour, align: true}, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < time.Minute {
+		return refreshSchedule{}, fmt.Errorf("want @hourly, @daily, @weekly or a duration of at least 1m")
+	}
+	return refreshSchedule{every: d}, nil
+}
+
+// next returns the first refresh time after now
+func (s refreshSchedule) next(now time.Time) time.Time {
+	if s.align {
+		// Truncate counts from the zero time, a Monday at midnight UTC
+		return now.Truncate(s.every).Add(s.every)
+	}
+	return now.Add(s.every)
+}
+
+func (s refreshSchedule) String() string {
+	if !s.align {
+		return "every " + s.every.String()
+	}
+	switch s.every {
+	case time.Hour:
+		return "hourly"
+	case 24 * time.Hour:
+		return "daily at 00:00 UTC"
+	}
+	return "weekly on Monday at 00:00 UTC"
+}
+
+// ServeDatasets handles /admin/datasets: GET lists the dataset versions in
+// use and POST refreshes them now
+func (a *MigrationAgent) ServeDatasets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		if !a.dataRefresher.Enabled() {
+			writeJSONError(w, http.StatusConflict, "no dataset sources configured")
+			return
+		}
+		writeJSON(w, http.StatusOK, a.dataRefresher.Refresh(r.Context()))
+		return
+	}
+	writeJSON(w, http.StatusOK, a.dataRefresher.Status())
+}
@@ -0,0 +1,266 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Push delivery backoff: the first retry waits pushRetryInitial, doubling
+// up to pushRetryMax between attempts
+const (
+	pushRetryInitial = 5 * time.Second
+	pushRetryMax     = 10 * time.Minute
+)
+
+// maxMemoryDeadLetters bounds the dead letters kept without a database
+const maxMemoryDeadLetters = 1000
+
+// DeadLetter is a push notification that could not be delivered, kept for
+// operators to inspect and replay by hand
+type DeadLetter struct {
+	ID             string          `json:"id"`
+	TaskID         string          `json:"taskId"`
+	Tenant         string          `json:"tenant,omitempty"`
+	URL            string          `json:"url"`
+	Attempts       int             `json:"attempts"`
+	LastError      string          `json:"lastError"`
+	LastStatus     int             `json:"lastStatus,omitempty"` // HTTP status of the last attempt, if one came back
+	FirstAttemptAt time.Time       `json:"firstAttemptAt"`
+	FailedAt       time.Time       `json:"failedAt"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+// DeadLetterFilter narrows a dead letter query
+type DeadLetterFilter struct {
+	TaskID string
+	Tenant string
+	Since  time.Time
+	Limit  int
+}
+
+func (f DeadLetterFilter) matches(d DeadLetter) bool {
+	if f.TaskID != "" && d.TaskID != f.TaskID {
+		return false
+	}
+	if f.Tenant != "" && d.Tenant != f.Tenant {
+		return false
+	}
+	return f.Since.IsZero() || !d.FailedAt.Before(f.Since)
+}
+
+// DeadLetterStore records failed deliveries. The PostgreSQL task store
+// keeps them in the dead_letters table; without one they are kept in memory.
+type DeadLetterStore interface {
+	// Record stores a failed delivery
+	Record(ctx context.Context, d DeadLetter) error
+	// DeadLetters returns matching failed deliveries, newest first
+	DeadLetters(ctx context.Context, filter DeadLetterFilter) ([]DeadLetter, error)
+	// DeleteDeadLetters removes the failed deliveries of the given tasks and
+	// returns how many were removed
+	DeleteDeadLetters(ctx context.Context, taskIDs []string) (int, error)
+}
+
+// newDeadLetterStore uses the task store for dead letters when it can keep
+// them, so every replica and worker shares one table
+func newDeadLetterStore(store TaskStore) DeadLetterStore {
+	if dl, ok := store.(DeadLetterStore); ok {
+		return dl
+	}
+	return &memoryDeadLetters{}
+}
+
+// memoryDeadLetters keeps the most recent dead letters of this process
+type memoryDeadLetters struct {
+	mu      sync.Mutex
+	letters []DeadLetter // oldest first
+}
+
+func (m *memoryDeadLetters) Record(_ context.Context, d DeadLetter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.letters = append(m.letters, d)
+	if len(m.letters) > maxMemoryDeadLetters {
+		m.letters = m.letters[len(m.letters)-maxMemoryDeadLetters:]
+	}
+	return nil
+}
+
+func (m *memoryDeadLetters) DeadLetters(_ context.Context, filter DeadLetterFilter) ([]DeadLetter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var results []DeadLetter
+	for i := len(m.letters) - 1; i >= 0; i-- {
+		if !filter.matches(m.letters[i]) {
+			continue
+		}
+		results = append(results, m.letters[i])
+		if filter.Limit > 0 && len(results) >= filter.Limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+func (m *memoryDeadLetters) DeleteDeadLetters(_ context.Context, taskIDs []string) (int, error) {
+	remove := make(map[string]bool, len(taskIDs))
+	for _, id := range taskIDs {
+		remove[id] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.letters[:0]
+	for _, d := range m.letters {
+		if !remove[d.TaskID] {
+			kept = append(kept, d)
+		}
+	}
+	deleted := len(m.letters) - len(kept)
+	m.letters = kept
+	return deleted, nil
+}
+
+// pushRetryWindow is how long failed push deliveries are retried, from
+// PUSH_RETRY_FOR (default 1h; 0 sends each notification once)
+func pushRetryWindow() time.Duration {
+	window := time.Hour
+	if v := os.Getenv("PUSH_RETRY_FOR"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			window = d
+		} else {
+			log.Printf("⚠️  Invalid PUSH_RETRY_FOR %q, retrying for %s", v, window)
+		}
+	}
+	return window
+}
+
+// pushBackoff is the wait before retry number attempt (1-based), with up to
+// 20% jitter so callbacks that failed together don't all retry together
+func pushBackoff(attempt int) time.Duration {
+	wait := pushRetryMax
+	if attempt < 20 {
+		wait = min(pushRetryInitial<<(attempt-1), pushRetryMax)
+	}
+	return wait - time.Duration(rand.Int63n(int64(wait)/5+1))
+}
+
+// deliverPush sends a push notification, retrying failures that may pass
+// on a later attempt until the retry window runs out. A delivery that
+// never succeeds is recorded as a dead letter.
+func (a *MigrationAgent) deliverPush(taskID, tenant string, config *PushNotificationConfig, payload []byte) {
+	first := a.clock.Now()
+	deadline := first.Add(a.pushRetryFor)
+	for attempt := 1; ; attempt++ {
+		err := sendPushNotification(config, payload, webhookSecret(tenant), a.clock.Now())
+		if err == nil {
+			if attempt > 1 {
+				log.Printf("Push notification for task %s delivered on attempt %d", taskID, attempt)
+			}
+			return
+		}
+
+		wait := pushBackoff(attempt)
+		if !retryablePush(err) || a.clock.Now().Add(wait).After(deadline) {
+			log.Printf("push notification for task %s failed after %d attempt(s): %v", taskID, attempt, err)
+			a.recordDeadLetter(DeadLetter{
+				TaskID:         taskID,
+				Tenant:         tenant,
+				URL:            config.URL,
+				Attempts:       attempt,
+				LastError:      err.Error(),
+				LastStatus:     pushStatus(err),
+				FirstAttemptAt: first.UTC(),
+				Payload:        payload,
+			})
+			return
+		}
+		log.Printf("⚠️  Push notification for task %s failed, retrying in %s: %v", taskID, wait.Round(time.Second), err)
+		time.Sleep(wait)
+	}
+}
+
+// recordDeadLetter stores a failed delivery, logging if even that fails
+func (a *MigrationAgent) recordDeadLetter(d DeadLetter) {
+	d.ID = a.ids.NewID()
+	d.FailedAt = a.clock.Now().UTC()
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := a.deadLetters.Record(ctx, d); err != nil {
+		log.Printf("⚠️  Failed to record dead letter for task %s: %v", d.TaskID, err)
+	}
+}
+
+// deadLettersForTasks returns the failed deliveries of the given tasks,
+// newest first
+func (a *MigrationAgent) deadLettersForTasks(ctx context.Context, tasks []*Task) ([]DeadLetter, error) {
+	var letters []DeadLetter
+	for _, task := range tasks {
+		found, err := a.deadLetters.DeadLetters(ctx, DeadLetterFilter{TaskID: task.ID})
+		if err != nil {
+			return nil, err
+		}
+		letters = append(letters, found...)
+	}
+	sort.Slice(letters, func(i, j int) bool {
+		return letters[i].FailedAt.After(letters[j].FailedAt)
+	})
+	return letters, nil
+}
+
+// ServeDeadLetters handles GET /admin/dead-letters: push notifications that
+// were never delivered, newest first, with optional taskId, tenant, since
+// (RFC 3339) and limit (default 100) query parameters
+func (a *MigrationAgent) ServeDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	q := r.URL.Query()
+	filter := DeadLetterFilter{TaskID: q.Get("taskId"), Tenant: q.Get("tenant"), Limit: 100}
+	if v := q.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid since: %v", err))
+			return
+		}
+		filter.Since = since
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			writeJSONError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), storeTimeout)
+	defer cancel()
+	letters, err := a.deadLetters.DeadLetters(ctx, filter)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if letters == nil {
+		letters = []DeadLetter{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"deadLetters": letters,
+		"count":       len(letters),
+	})
+}
@@ -0,0 +1,260 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/a2aclient"
+)
+
+// Delegate is a peer A2A agent that answers one kind of sub-question
+type Delegate struct {
+	Topic    string   // e.g. jobs or scholarships
+	URL      string   // base URL of the peer agent
+	Keywords []string // query words that trigger delegation
+	Question string   // sub-question template; %s is replaced by the query
+}
+
+// DelegatedAnswer is a peer agent's reply to a sub-question
+type DelegatedAnswer struct {
+	Delegate Delegate
+	Agent    string // peer agent name from its card, if known
+	Text     string
+	Extra    []Artifact // non-text artifacts to attach to the task
+}
+
+// delegateTopics are the known sub-question kinds and how to ask them
+var delegateTopics = map[string]Delegate{
+	"jobs": {
+		Keywords: []string{"job", "work", "employ", "career", "salary", "hiring"},
+		Question: "What is the current job market outlook, in-demand roles and typical salaries for this person? %s",
+	},
+	"scholarships": {
+		Keywords: []string{"study", "student", "scholarship", "university", "degree", "master", "phd"},
+		Question: "Which scholarships, grants or funding options could this person apply for? %s",
+	},
+}
+
+// Delegator fans sub-questions out to peer agents. Peers are configured with
+// A2A_DELEGATES as comma-separated topic=url pairs; a bare topic, or a known
+// topic that isn't configured, is resolved to a discovered agent tagged with it.
+type Delegator struct {
+	delegates []Delegate
+	registry  *AgentRegistry
+	timeout   time.Duration
+	client    *http.Client
+}
+
+// NewDelegator reads the configured peer agents
+func NewDelegator(registry *AgentRegistry) *Delegator {
+	d := &Delegator{registry: registry, timeout: 20 * time.Second}
+	if v := os.Getenv("A2A_DELEGATE_TIMEOUT"); v != "" {
+		if timeout, err := time.ParseDuration(v); err == nil && timeout > 0 {
+			d.timeout = timeout
+		} else {
+			log.Printf("⚠️  Invalid A2A_DELEGATE_TIMEOUT %q, using %s", v, d.timeout)
+		}
+	}
+	d.client = &http.Client{Timeout: d.timeout}
+
+	for _, entry := range strings.Split(os.Getenv("A2A_DELEGATES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) == 1 {
+			parts = append(parts, "")
+		}
+
+		topic := strings.ToLower(strings.TrimSpace(parts[0]))
+		delegate, known := delegateTopics[topic]
+		if !known {
+			// Unknown topics are asked the user's query as-is whenever it mentions the topic
+			delegate = Delegate{Keywords: []string{topic}, Question: "%s"}
+		}
+		delegate.Topic = topic
+		delegate.URL = strings.TrimSpace(parts[1])
+		d.delegates = append(d.delegates, delegate)
+	}
+
+	if len(d.delegates) > 0 {
+		log.Printf("🤝 Delegating sub-questions to %d peer agent(s)", len(d.delegates))
+	}
+	return d
+}
+
+// candidates returns the delegates to consider, resolving topics without a
+// URL through the registry
+func (d *Delegator) candidates() []Delegate {
+	configured := make(map[string]bool)
+	var resolved []Delegate
+	for _, delegate := range d.delegates {
+		configured[delegate.Topic] = true
+		if delegate.URL == "" {
+			delegate.URL = d.discover(delegate.Topic)
+		}
+		if delegate.URL != "" {
+			resolved = append(resolved, delegate)
+		}
+	}
+
+	for topic, delegate := range delegateTopics {
+		if configured[topic] {
+			continue
+		}
+		if delegate.URL = d.discover(topic); delegate.URL != "" {
+			delegate.Topic = topic
+			resolved = append(resolved, delegate)
+		}
+	}
+	return resolved
+}
+
+// discover returns the URL of a reachable registered agent tagged with the
+// topic, or "" if there is none
+func (d *Delegator) discover(topic string) string {
+	if d.registry == nil {
+		return ""
+	}
+	if agents := d.registry.Find(topic); len(agents) > 0 {
+		return agents[0].URL
+	}
+	return ""
+}
+
+// Ask sends the relevant sub-questions for a query to peer agents in
+// parallel and returns the answers that arrived in time. Failures are logged
+// and skipped so a slow or broken peer never fails the main answer.
+func (d *Delegator) Ask(query string) []DelegatedAnswer {
+	var relevant []Delegate
+	queryLower := strings.ToLower(query)
+	for _, delegate := range d.candidates() {
+		for _, keyword := range delegate.Keywords {
+			if strings.Contains(queryLower, keyword) {
+				relevant = append(relevant, delegate)
+				break
+			}
+		}
+	}
+	if len(relevant) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	answers := make([]*DelegatedAnswer, len(relevant))
+	var wg sync.WaitGroup
+	for i, delegate := range relevant {
+		wg.Add(1)
+		go func(i int, delegate Delegate) {
+			defer wg.Done()
+			answer, err := d.ask(ctx, delegate, query)
+			if err != nil {
+				log.Printf("Delegation to %s agent at %s failed: %v", delegate.Topic, delegate.URL, err)
+				return
+			}
+			answers[i] = answer
+		}(i, delegate)
+	}
+	wg.Wait()
+
+	var results []DelegatedAnswer
+	for _, answer := range answers {
+		if answer != nil {
+			results = append(results, *answer)
+		}
+	}
+	return results
+}
+
+// ask sends one sub-question and converts the peer's task into an answer
+func (d *Delegator) ask(ctx context.Context, delegate Delegate, query string) (*DelegatedAnswer, error) {
+	client := a2aclient.New(delegate.URL, a2aclient.WithHTTPClient(d.client))
+
+	params := a2aclient.NewTextTask(fmt.Sprintf(delegate.Question, query))
+	// Peers running this agent must not delegate the sub-question again
+	params.Extra = map[string]interface{}{"delegationDepth": 1}
+
+	task, err := client.SendTask(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if task.Status.State != "completed" {
+		return nil, fmt.Errorf("peer task ended in state %s", task.Status.State)
+	}
+
+	answer := &DelegatedAnswer{Delegate: delegate}
+	if known := d.registry.Get(delegate.URL); known != nil && known.Name != "" {
+		answer.Agent = known.Name
+	} else if card, err := client.GetAgentCard(ctx); err == nil {
+		answer.Agent = card.Name
+	}
+
+	for _, artifact := range task.Artifacts {
+		if text := artifact.Text(); text != "" && answer.Text == "" {
+			answer.Text = text
+			continue
+		}
+		var converted Artifact
+		if err := convertPeerArtifact(artifact, &converted); err != nil {
+			return nil, err
+		}
+		if len(converted.Parts) > 0 {
+			answer.Extra = append(answer.Extra, converted)
+		}
+	}
+	if answer.Text == "" && task.Status.Message != nil {
+		for _, part := range task.Status.Message.Parts {
+			if part.PartKind() == "text" {
+				answer.Text += part.Text
+			}
+		}
+	}
+	return answer, nil
+}
+
+// convertPeerArtifact copies a client-side artifact into the server's type,
+// keeping only non-text parts
+func convertPeerArtifact(src a2aclient.Artifact, dst *Artifact) error {
+	var parts []a2aclient.Part
+	for _, part := range src.Parts {
+		if part.PartKind() != "text" {
+			parts = append(parts, part)
+		}
+	}
+	src.Parts = parts
+
+	encoded, err := json.Marshal(src)
+	if err != nil {
+		return fmt.Errorf("failed to convert peer artifact: %v", err)
+	}
+	return json.Unmarshal(encoded, dst)
+}
+
+// mergeDelegatedAnswers appends peer answers to the recommendation as
+// attributed sections
+func mergeDelegatedAnswers(responseText string, answers []DelegatedAnswer) string {
+	var b strings.Builder
+	b.WriteString(responseText)
+	for _, answer := range answers {
+		if strings.TrimSpace(answer.Text) == "" {
+			continue
+		}
+		source := answer.Agent
+		if source == "" {
+			source = answer.Delegate.URL
+		}
+		topic := strings.ToUpper(answer.Delegate.Topic[:1]) + answer.Delegate.Topic[1:]
+		fmt.Fprintf(&b, "\n\n---\n\n### %s (via %s)\n\n%s", topic, source, strings.TrimSpace(answer.Text))
+	}
+	return b.String()
+}
@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// Topics of family rule
+const (
+	FamilyVisa      = "visa"      // how dependants come along
+	FamilyWork      = "work"      // the spouse's right to work
+	FamilySchooling = "schooling" // school for the children
+)
+
+// FamilyRule is one of a destination's rules for applicants bringing a
+// spouse or children, part of its specialist's knowledge base
+type FamilyRule struct {
+	Topic      string   `json:"topic"` // FamilyVisa, FamilyWork or FamilySchooling
+	Detail     string   `json:"detail"`
+	Categories []string `json:"categories,omitempty"` // program categories it applies to; none means all
+	SpouseFee  *Fee     `json:"spouseFee,omitempty"`  // what including a spouse adds
+	ChildFee   *Fee     `json:"childFee,omitempty"`   // what including each child adds
+}
+
+// familyRules returns the specialist's rules for a route of the given
+// category ("" if unknown) that matter to the applicant's family: visa
+// rules for anyone, work rights for a spouse and schooling for children
+func (s *Specialist) familyRules(category string, p parser.UserProfile) []FamilyRule {
+	var rules []FamilyRule
+	for _, r := range s.Family {
+		if len(r.Categories) > 0 && !containsString(r.Categories, category) {
+			continue
+		}
+		if r.Topic == FamilyWork && !p.Spouse || r.Topic == FamilySchooling && p.Children == 0 {
+			continue
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// familyPromptContext lists the destination's family rules for the prompt
+// when the applicant brings family, or is "" otherwise
+func (s *Specialist) familyPromptContext(p parser.UserProfile) string {
+	if !p.HasFamily() || len(s.Family) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\nFAMILY (the applicant brings their " + p.Family() + "; cover dependant visas, spouse work rights and schooling, and include the dependants' fees in the costs):\n")
+	for _, r := range s.Family {
+		line := "- " + r.Detail
+		if len(r.Categories) > 0 {
+			line += " [" + strings.Join(r.Categories, ", ") + " routes]"
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// familyTopicLabels label each topic in the family section
+var familyTopicLabels = map[string]string{
+	FamilyVisa:      "Dependant visas",
+	FamilyWork:      "Spouse work rights",
+	FamilySchooling: "Schooling",
+}
+
+// applyDependents adds what bringing a spouse or children means for the
+// recommended route: their extra fees as a line after Cost, and a section
+// after the key details on dependant visas, the spouse's work rights and
+// schooling. Answers for applicants without family, or routes without
+// rules, are returned unchanged.
+func applyDependents(markdown string, s *Specialist, p parser.UserProfile) string {
+	if !p.HasFamily() {
+		return markdown
+	}
+	rules := s.familyRules(s.programCategory(pathwayName(markdown)), p)
+	if len(rules) == 0 {
+		return markdown
+	}
+
+	var fees []string
+	for _, r := range rules {
+		if r.SpouseFee != nil && p.Spouse {
+			fees = append(fees, formatFee(r.SpouseFee.Amount, r.SpouseFee.Currency)+" for your spouse ("+r.SpouseFee.Item+")")
+		}
+		if r.ChildFee != nil && p.Children > 0 {
+			fee := formatFee(r.ChildFee.Amount, r.ChildFee.Currency) + " for your child (" + r.ChildFee.Item + ")"
+			if p.Children > 1 {
+				fee = fmt.Sprintf("%s for %d children (%s, %s each)", formatFee(r.ChildFee.Amount*float64(p.Children), r.ChildFee.Currency),
+					p.Children, r.ChildFee.Item, formatFee(r.ChildFee.Amount, r.ChildFee.Currency))
+			}
+			fees = append(fees, fee)
+		}
+	}
+	if loc := costLinePattern.FindStringSubmatchIndex(markdown); loc != nil && len(fees) > 0 {
+		label := "Family fees:"
+		if loc[4] != -1 {
+			label = "**Family fees:**"
+		}
+		line := "\n" + markdown[loc[2]:loc[3]] + label + " " + joinList(fees) + ", on top of the fees above"
+		markdown = markdown[:loc[1]] + line + markdown[loc[1]:]
+	}
+
+	var section strings.Builder
+	fmt.Fprintf(&section, "**Bringing Your Family (%s):**\n", p.Family())
+	for _, topic := range []string{FamilyVisa, FamilyWork, FamilySchooling} {
+		for _, r := range rules {
+			if r.Topic == topic {
+				fmt.Fprintf(&section, "- %s: %s\n", familyTopicLabels[topic], r.Detail)
+			}
+		}
+	}
+	return insertAfterKeyDetails(markdown, section.String())
+}
@@ -0,0 +1,28 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Clock tells the agent the time: task timestamps, the date in prompts,
+// timelines and fee years all come from it
+type Clock interface {
+	Now() time.Time
+}
+
+// IDGenerator makes the IDs of tasks, messages, artifacts and stored records
+type IDGenerator interface {
+	NewID() string
+}
+
+// systemClock is the wall clock
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// uuidGenerator makes random UUIDs
+type uuidGenerator struct{}
+
+func (uuidGenerator) NewID() string { return uuid.New().String() }
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+)
+
+// fixedClock always tells the same time
+type fixedClock struct{ now time.Time }
+
+func (c fixedClock) Now() time.Time { return c.now }
+
+// sequentialIDs numbers IDs in the order they are made
+type sequentialIDs struct{ n int }
+
+func (g *sequentialIDs) NewID() string {
+	g.n++
+	return fmt.Sprintf("id-%d", g.n)
+}
+
+// cannedModel answers every prompt with the same text and keeps the prompts
+type cannedModel struct {
+	answer  string
+	prompts []string
+}
+
+func (m *cannedModel) GenerateWithUsage(prompt string, media []llm.GeminiInlineData, config *llm.GeminiGenerationConfig, tools []llm.GeminiTool) (string, llm.TokenUsage, error) {
+	m.prompts = append(m.prompts, prompt)
+	return m.answer, llm.TokenUsage{}, nil
+}
+
+func TestAgentUsesInjectedClock(t *testing.T) {
+	now := time.Date(2031, time.March, 4, 9, 30, 0, 0, time.UTC)
+	model := &cannedModel{answer: "**Recommended Pathway:** Health and Care Worker visa"}
+	a := NewMigrationAgentWithDeps(model, nil, fixedClock{now}, &sequentialIDs{})
+
+	message := Message{Role: "user", Parts: []Part{{Kind: "text", Text: "Nurse from India wanting to move to UK"}}}
+	task, err := a.ProcessTask("task-1", message, TaskOptions{SessionID: "session-1"})
+	if err != nil {
+		t.Fatalf("ProcessTask: %v", err)
+	}
+
+	if !task.CreatedAt.Equal(now) {
+		t.Errorf("CreatedAt = %s, want %s", task.CreatedAt, now)
+	}
+	if task.Status.Timestamp != now.Format(time.RFC3339) {
+		t.Errorf("status timestamp = %q, want %q", task.Status.Timestamp, now.Format(time.RFC3339))
+	}
+	if len(model.prompts) == 0 {
+		t.Fatal("the model was never called")
+	}
+	if !strings.Contains(model.prompts[0], "TODAY'S DATE: 2031-03-04") {
+		t.Errorf("prompt doesn't carry the injected date:\n%s", model.prompts[0])
+	}
+	for _, artifact := range task.Artifacts {
+		if !strings.HasPrefix(artifact.ArtifactID, "id-") {
+			t.Errorf("artifact ID %q didn't come from the injected generator", artifact.ArtifactID)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package agent
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// discordAPIBase is the Discord REST API used to edit deferred responses
+const discordAPIBase = "https://discord.com/api/v10"
+
+// Discord interaction and response types
+const (
+	discordInteractionPing    = 1
+	discordInteractionCommand = 2
+
+	discordResponsePong     = 1
+	discordResponseMessage  = 4
+	discordResponseDeferred = 5
+)
+
+// discordMaxSkew is how old a signed interaction may be before it is
+// rejected as a possible replay
+const discordMaxSkew = 5 * time.Minute
+
+// discordEmbedLimit is the maximum length of an embed description
+const discordEmbedLimit = 4096
+
+// DiscordInteraction is the subset of an interaction payload the bot uses
+type DiscordInteraction struct {
+	Type          int    `json:"type"`
+	ApplicationID string `json:"application_id"`
+	Token         string `json:"token"`
+	Data          struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Name  string      `json:"name"`
+			Value interface{} `json:"value"`
+		} `json:"options"`
+	} `json:"data"`
+	Member *struct {
+		User discordUser `json:"user"`
+	} `json:"member"`
+	User *discordUser `json:"user"` // set instead of member in DMs
+}
+
+type discordUser struct {
+	ID string `json:"id"`
+}
+
+// ServeDiscord handles /integrations/discord, the interactions endpoint for
+// the /migrate slash command
+func (a *MigrationAgent) ServeDiscord(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	publicKey, err := hex.DecodeString(os.Getenv("DISCORD_PUBLIC_KEY"))
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		writeJSONError(w, http.StatusServiceUnavailable, "Discord integration disabled: DISCORD_PUBLIC_KEY not configured")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	// Discord periodically sends bad signatures and disables endpoints that accept them
+	if !validDiscordSignature(publicKey, r.Header, body, a.clock.Now()) {
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+
+	var interaction DiscordInteraction
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid interaction payload")
+		return
+	}
+
+	switch interaction.Type {
+	case discordInteractionPing:
+		writeJSON(w, http.StatusOK, map[string]int{"type": discordResponsePong})
+	case discordInteractionCommand:
+		a.handleDiscordCommand(w, interaction)
+	default:
+		writeJSONError(w, http.StatusBadRequest, "unsupported interaction type")
+	}
+}
+
+// validDiscordSignature checks the Ed25519 signature over timestamp + body,
+// and that the timestamp is within discordMaxSkew of now
+func validDiscordSignature(publicKey ed25519.PublicKey, header http.Header, body []byte, now time.Time) bool {
+	signature, err := hex.DecodeString(header.Get("X-Signature-Ed25519"))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return false
+	}
+	ts, err := strconv.ParseInt(header.Get("X-Signature-Timestamp"), 10, 64)
+	if err != nil {
+		return false
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > discordMaxSkew || skew < -discordMaxSkew {
+		return false
+	}
+	message := append([]byte(header.Get("X-Signature-Timestamp")), body...)
+	return ed25519.Verify(publicKey, message, signature)
+}
+
+// handleDiscordCommand defers the response, since generation takes longer
+// than the three seconds Discord allows, then edits in the answer
+func (a *MigrationAgent) handleDiscordCommand(w http.ResponseWriter, interaction DiscordInteraction) {
+	query := ""
+	for _, option := range interaction.Data.Options {
+		if option.Name == "query" {
+			query, _ = option.Value.(string)
+		}
+	}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"type": discordResponseMessage,
+			"data": map[string]interface{}{
+				"content": "Tell me about your situation, e.g. `/migrate query: Nurse from India wanting to move to UK`",
+				"flags":   64, // ephemeral
+			},
+		})
+		return
+	}
+
+	userID := ""
+	if interaction.Member != nil {
+		userID = interaction.Member.User.ID
+	} else if interaction.User != nil {
+		userID = interaction.User.ID
+	}
+
+	go func() {
+		message := Message{
+			Role:  "user",
+			Parts: []Part{{Kind: "text", Text: query}},
+		}
+		task, err := a.ProcessTask(a.ids.NewID(), message, TaskOptions{SessionID: "discord:" + userID})
+
+		var reply map[string]interface{}
+		if err != nil {
+			log.Printf("Discord query failed: %v", err)
+			reply = map[string]interface{}{"content": "❌ Sorry, I couldn't generate migration pathways right now. Please try again later."}
+		} else {
+			text := ""
+			if task.Status.Message != nil && len(task.Status.Message.Parts) > 0 {
+				text = task.Status.Message.Parts[0].Text
+			}
+			reply = map[string]interface{}{"embeds": discordEmbeds(text)}
+		}
+
+		if err := editDiscordResponse(interaction.ApplicationID, interaction.Token, reply); err != nil {
+			log.Printf("Failed to send Discord response: %v", err)
+		}
+	}()
+
+	writeJSON(w, http.StatusOK, map[string]int{"type": discordResponseDeferred})
+}
+
+// discordEmbeds splits a markdown answer across embeds; Discord renders
+// markdown natively but caps each description and allows at most 10 embeds
+// totalling 6000 characters
+func discordEmbeds(markdown string) []map[string]interface{} {
+	title := "Migration Pathway Recommendation"
+	if name := pathwayName(markdown); name != "" && len(name) <= 256 {
+		title = name
+	}
+
+	var embeds []map[string]interface{}
+	total := len(title)
+	for i, chunk := range splitMessage(markdown, discordEmbedLimit) {
+		if len(embeds) == 10 || total+len(chunk) > 6000 {
+			break
+		}
+		embed := map[string]interface{}{
+			"description": chunk,
+			"color":       0x0B5FFF,
+		}
+		if i == 0 {
+			embed["title"] = title
+		}
+		embeds = append(embeds, embed)
+		total += len(chunk)
+	}
+	return embeds
+}
+
+// editDiscordResponse replaces the deferred "thinking" message with the reply
+func editDiscordResponse(applicationID, token string, reply map[string]interface{}) error {
+	body, err := json.Marshal(reply)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Discord message: %v", err)
+	}
+
+	url := fmt.Sprintf("%s/webhooks/%s/%s/messages/@original", discordAPIBase, applicationID, token)
+	req, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Discord request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Discord message: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("Discord API returned status %d: %s", resp.StatusCode, string(detail))
+	}
+	return nil
+}
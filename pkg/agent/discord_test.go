@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// signDiscord sets the Ed25519 signature headers Discord sends with an
+// interaction
+func signDiscord(header http.Header, key ed25519.PrivateKey, sentAt time.Time, body []byte) {
+	timestamp := strconv.FormatInt(sentAt.Unix(), 10)
+	header.Set("X-Signature-Timestamp", timestamp)
+	header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(key, append([]byte(timestamp), body...))))
+}
+
+func TestValidDiscordSignature(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"type": 1}`)
+	for _, tc := range []struct {
+		name     string
+		key      ed25519.PrivateKey // signs the request; nil leaves it unsigned
+		sentAt   time.Time
+		received []byte
+		valid    bool
+	}{
+		{"valid", privateKey, now, body, true},
+		{"tampered body", privateKey, now, []byte(`{"type": 2}`), false},
+		{"wrong key", otherKey, now, body, false},
+		{"stale timestamp", privateKey, now.Add(-discordMaxSkew - time.Second), body, false},
+		{"timestamp from the future", privateKey, now.Add(discordMaxSkew + time.Second), body, false},
+		{"unsigned", nil, now, body, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			header := http.Header{}
+			if tc.key != nil {
+				signDiscord(header, tc.key, tc.sentAt, body)
+			}
+
+			if got := validDiscordSignature(publicKey, header, tc.received, now); got != tc.valid {
+				t.Errorf("validDiscordSignature = %v, want %v", got, tc.valid)
+			}
+		})
+	}
+}
+
+func TestDiscordRejectsStaleInteractions(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LLM_MODE", "mock")
+	t.Setenv("DISCORD_PUBLIC_KEY", hex.EncodeToString(publicKey))
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	a := NewMigrationAgentWithDeps(nil, nil, fixedClock{now}, nil)
+
+	body := []byte(`{"type": 1}`)
+	for _, tc := range []struct {
+		name   string
+		sentAt time.Time
+		want   int
+	}{
+		{"fresh", now.Add(-time.Minute), http.StatusOK},
+		{"stale", now.Add(-10 * time.Minute), http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/integrations/discord", bytes.NewReader(body))
+			signDiscord(req.Header, privateKey, tc.sentAt, body)
+
+			rec := httptest.NewRecorder()
+			a.ServeDiscord(rec, req)
+			if rec.Code != tc.want {
+				t.Errorf("got %d %q, want %d", rec.Code, rec.Body, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,405 @@
+package agent
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Document types accepted as file parts
+const (
+	MimeTypeText     = "text/plain"
+	MimeTypeMarkdown = "text/markdown"
+	MimeTypePDF      = "application/pdf"
+	MimeTypeDOCX     = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+)
+
+// imageTypes are the image formats passed to Gemini vision
+var imageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+	"image/heic": true,
+	"image/heif": true,
+}
+
+// isImage reports whether a resolved upload type is an image
+func isImage(mimeType string) bool {
+	return imageTypes[mimeType]
+}
+
+// audioAliases maps alternative audio MIME types, including those sniffed
+// by http.DetectContentType, to the ones transcribed
+var audioAliases = map[string]string{
+	"audio/ogg":       "audio/ogg",
+	"audio/opus":      "audio/ogg",
+	"application/ogg": "audio/ogg",
+	"audio/mpeg":      "audio/mpeg",
+	"audio/mp3":       "audio/mpeg",
+	"audio/wav":       "audio/wav",
+	"audio/wave":      "audio/wav",
+	"audio/x-wav":     "audio/wav",
+	"audio/webm":      "audio/webm",
+	"audio/mp4":       "audio/mp4",
+	"audio/x-m4a":     "audio/mp4",
+	"audio/aac":       "audio/aac",
+	"audio/flac":      "audio/flac",
+}
+
+// isAudio reports whether a resolved upload type is a voice recording
+func isAudio(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "audio/") && audioAliases[mimeType] == mimeType
+}
+
+// documentExtensions maps file extensions to document types for uploads
+// sent without a usable MIME type
+var documentExtensions = map[string]string{
+	".txt":  MimeTypeText,
+	".md":   MimeTypeMarkdown,
+	".pdf":  MimeTypePDF,
+	".docx": MimeTypeDOCX,
+	".ogg":  "audio/ogg",
+	".opus": "audio/ogg",
+	".mp3":  "audio/mpeg",
+	".wav":  "audio/wav",
+	".m4a":  "audio/mp4",
+	".aac":  "audio/aac",
+	".flac": "audio/flac",
+}
+
+// documentType resolves the type of an uploaded document, image or voice
+// recording from its declared MIME type, then its file name, then its
+// content; "" means unsupported
+func documentType(name, mimeType string, data []byte) string {
+	mimeType = strings.ToLower(strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0]))
+	switch mimeType {
+	case MimeTypeText, MimeTypeMarkdown, MimeTypePDF, MimeTypeDOCX:
+		return mimeType
+	}
+	if mimeType == "image/jpg" {
+		mimeType = "image/jpeg"
+	}
+	if isImage(mimeType) {
+		return mimeType
+	}
+	if audio, ok := audioAliases[mimeType]; ok {
+		return audio
+	}
+	if t, ok := documentExtensions[strings.ToLower(filepath.Ext(name))]; ok {
+		return t
+	}
+	detected := http.DetectContentType(data)
+	if isImage(detected) {
+		return detected
+	}
+	if audio, ok := audioAliases[detected]; ok {
+		return audio
+	}
+	if bytes.HasPrefix(data, []byte("%PDF-")) {
+		return MimeTypePDF
+	}
+	if strings.HasPrefix(detected, "text/plain") {
+		return MimeTypeText
+	}
+	return ""
+}
+
+// extractDocumentText returns the readable text of a document
+func extractDocumentText(mimeType string, data []byte) (string, error) {
+	var text string
+	var err error
+	switch mimeType {
+	case MimeTypeText, MimeTypeMarkdown:
+		text = string(data)
+	case MimeTypePDF:
+		text, err = extractPDFText(data)
+	case MimeTypeDOCX:
+		text, err = extractDOCXText(data)
+	default:
+		return "", fmt.Errorf("unsupported document type %q", mimeType)
+	}
+	if err != nil {
+		return "", err
+	}
+	return cleanExtractedText(text), nil
+}
+
+// extractDOCXText reads the paragraphs of word/document.xml
+func extractDOCXText(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to open docx: %v", err)
+	}
+
+	for _, f := range archive.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", fmt.Errorf("failed to open docx body: %v", err)
+		}
+		defer rc.Close()
+
+		var b strings.Builder
+		decoder := xml.NewDecoder(rc)
+		inText := false
+		for {
+			token, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", fmt.Errorf("failed to parse docx body: %v", err)
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				switch t.Name.Local {
+				case "t":
+					inText = true
+				case "tab":
+					b.WriteString("\t")
+				case "br", "cr":
+					b.WriteString("\n")
+				}
+			case xml.EndElement:
+				switch t.Name.Local {
+				case "t":
+					inText = false
+				case "p":
+					b.WriteString("\n")
+				}
+			case xml.CharData:
+				if inText {
+					b.Write(t)
+				}
+			}
+		}
+		return b.String(), nil
+	}
+	return "", fmt.Errorf("docx has no word/document.xml")
+}
+
+var pdfStreamPattern = regexp.MustCompile(`(?s)<<(.*?)>>\s*stream\r?\n`)
+
+// extractPDFText pulls the strings shown by text operators out of a PDF's
+// content streams. It handles uncompressed and Flate streams with simple
+// font encodings, which covers CVs exported by common word processors;
+// scanned PDFs have no text to extract.
+func extractPDFText(data []byte) (string, error) {
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return "", fmt.Errorf("not a PDF file")
+	}
+
+	var b strings.Builder
+	for _, loc := range pdfStreamPattern.FindAllSubmatchIndex(data, -1) {
+		dict := string(data[loc[2]:loc[3]])
+		if strings.Contains(dict, "/Image") || strings.Contains(dict, "/Length1") {
+			continue // images and embedded fonts
+		}
+
+		start := loc[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end == -1 {
+			break
+		}
+		stream := data[start : start+end]
+
+		if strings.Contains(dict, "/FlateDecode") {
+			r, err := zlib.NewReader(bytes.NewReader(stream))
+			if err != nil {
+				continue
+			}
+			stream, err = io.ReadAll(r)
+			r.Close()
+			if err != nil && len(stream) == 0 {
+				continue
+			}
+		} else if strings.Contains(dict, "/Filter") {
+			continue // other filters aren't supported
+		}
+
+		if bytes.Contains(stream, []byte("BT")) {
+			b.WriteString(pdfContentText(stream))
+			b.WriteString("\n")
+		}
+	}
+
+	if strings.TrimSpace(b.String()) == "" {
+		return "", fmt.Errorf("no extractable text found in PDF")
+	}
+	return b.String(), nil
+}
+
+// pdfContentText interprets the text operators of one content stream
+func pdfContentText(content []byte) string {
+	var b strings.Builder
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '(':
+			var s string
+			s, i = pdfLiteralString(content, i+1)
+			b.WriteString(s)
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end == -1 {
+				return b.String()
+			}
+			b.WriteString(pdfHexString(content[i+1 : i+end]))
+			i += end
+		case c == '-' || (c >= '0' && c <= '9'):
+			// Large negative TJ offsets separate words
+			j := i
+			for j < len(content) && (content[j] == '-' || content[j] == '.' || (content[j] >= '0' && content[j] <= '9')) {
+				j++
+			}
+			var n float64
+			if _, err := fmt.Sscan(string(content[i:j]), &n); err == nil && n < -200 {
+				b.WriteString(" ")
+			}
+			i = j - 1
+		case isPDFOperatorStart(c):
+			j := i
+			for j < len(content) && (isPDFOperatorStart(content[j]) || content[j] == '*') {
+				j++
+			}
+			switch string(content[i:j]) {
+			case "Td", "TD", "T*", "ET":
+				b.WriteString("\n")
+			}
+			i = j - 1
+		case c == '\'' || c == '"':
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func isPDFOperatorStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// pdfLiteralString decodes a (...) string starting after the opening
+// parenthesis and returns it with the index of the closing parenthesis
+func pdfLiteralString(content []byte, i int) (string, int) {
+	var b strings.Builder
+	depth := 1
+	for ; i < len(content); i++ {
+		c := content[i]
+		switch c {
+		case '\\':
+			i++
+			if i >= len(content) {
+				return b.String(), i
+			}
+			switch e := content[i]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					n := 0
+					for k := 0; k < 3 && i < len(content) && content[i] >= '0' && content[i] <= '7'; k++ {
+						n = n*8 + int(content[i]-'0')
+						i++
+					}
+					i--
+					b.WriteRune(rune(n & 0xff))
+				} else {
+					b.WriteByte(e)
+				}
+			}
+		case '(':
+			depth++
+			b.WriteByte(c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return b.String(), i
+			}
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), i
+}
+
+// pdfHexString decodes a <...> string, treating it as UTF-16BE when it
+// starts with a byte order mark and as single bytes otherwise
+func pdfHexString(hex []byte) string {
+	var raw []byte
+	hi := -1
+	for _, c := range hex {
+		var v int
+		switch {
+		case c >= '0' && c <= '9':
+			v = int(c - '0')
+		case c >= 'a' && c <= 'f':
+			v = int(c-'a') + 10
+		case c >= 'A' && c <= 'F':
+			v = int(c-'A') + 10
+		default:
+			continue
+		}
+		if hi == -1 {
+			hi = v
+		} else {
+			raw = append(raw, byte(hi<<4|v))
+			hi = -1
+		}
+	}
+	if hi != -1 {
+		raw = append(raw, byte(hi<<4))
+	}
+
+	if len(raw) >= 2 && raw[0] == 0xfe && raw[1] == 0xff {
+		var b strings.Builder
+		for i := 2; i+1 < len(raw); i += 2 {
+			b.WriteRune(rune(raw[i])<<8 | rune(raw[i+1]))
+		}
+		return b.String()
+	}
+	var b strings.Builder
+	for _, c := range raw {
+		b.WriteRune(rune(c))
+	}
+	return b.String()
+}
+
+// cleanExtractedText drops control characters and collapses blank space so
+// extracted text is compact enough for the prompt
+func cleanExtractedText(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Map(func(r rune) rune {
+			if r == '\t' {
+				return ' '
+			}
+			if !unicode.IsPrint(r) {
+				return -1
+			}
+			return r
+		}, line)
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
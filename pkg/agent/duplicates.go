@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// Policies for tasks/send calls reusing an existing task ID, set with
+// DUPLICATE_TASK_POLICY
+const (
+	DuplicatePolicyReprocess = "reprocess" // run again, keeping earlier artifact versions
+	DuplicatePolicyReturn    = "return"    // return the stored task untouched (default)
+	DuplicatePolicyConflict  = "conflict"  // reject the call with a -32002 error
+)
+
+// errTaskConflict is returned when a task ID is reused under the conflict policy
+type errTaskConflict struct {
+	taskID string
+	state  TaskState
+}
+
+func (e *errTaskConflict) Error() string {
+	return fmt.Sprintf("task %s already exists in state %s", e.taskID, e.state)
+}
+
+// duplicateTaskPolicy reads DUPLICATE_TASK_POLICY
+func duplicateTaskPolicy() string {
+	policy := os.Getenv("DUPLICATE_TASK_POLICY")
+	switch policy {
+	case "":
+		return DuplicatePolicyReturn
+	case DuplicatePolicyReprocess, DuplicatePolicyReturn, DuplicatePolicyConflict:
+		return policy
+	default:
+		log.Printf("⚠️  Unknown DUPLICATE_TASK_POLICY %q, using %q", policy, DuplicatePolicyReturn)
+		return DuplicatePolicyReturn
+	}
+}
+
+// claimTask decides what to do with a send for an ID that may already
+// exist. It returns the stored task when that should be answered instead of
+// processing, or an error under the conflict policy. Otherwise the ID is
+// claimed for the send, atomically under a.mu, by storing a submitted
+// placeholder, so a concurrent send with the same ID finds a task in
+// progress; call release if the send is refused before it is processed. A
+// task is therefore never run twice at once, and another tenant's task ID
+// is reported as not found, so its existence isn't revealed.
+func (a *MigrationAgent) claimTask(tenant *Tenant, taskID string) (existing *Task, release func(), err error) {
+	// Bring a task stored by another replica or before a restart into
+	// memory first; the store can't be read under a.mu
+	a.lookupTask(taskID)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	previous, exists := a.tasks.Peek(taskID)
+	if exists {
+		state := previous.Status.State
+		switch {
+		case previous.tenant != tenant.id():
+			return nil, nil, fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+		case a.duplicatePolicy == DuplicatePolicyConflict:
+			return nil, nil, &errTaskConflict{taskID: taskID, state: state}
+		case a.duplicatePolicy == DuplicatePolicyReturn || !state.Terminal():
+			return previous, nil, nil
+		}
+	}
+
+	// The placeholder keeps what a reprocessed task carries over, and its
+	// status isn't added to the history, which the run records itself
+	claim := &Task{ID: taskID, Kind: "task", CreatedAt: a.clock.Now(), tenant: tenant.id()}
+	if exists {
+		claim.SessionID, claim.Metadata = previous.SessionID, previous.Metadata
+		claim.Artifacts, claim.PushNotification = previous.Artifacts, previous.PushNotification
+		claim.statusHistory = previous.statusHistory
+	}
+	claim.Status = TaskStatus{State: TaskStateSubmitted, Timestamp: claim.CreatedAt.UTC().Format(time.RFC3339)}
+	a.tasks.Put(claim)
+
+	release = func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if current, ok := a.tasks.Peek(taskID); !ok || current != claim {
+			return
+		}
+		if exists {
+			a.tasks.Put(previous)
+		} else {
+			a.tasks.Delete(taskID)
+		}
+	}
+	return nil, release, nil
+}
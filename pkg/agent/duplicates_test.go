@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClaimTaskLetsOneConcurrentSendThrough(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	a := NewMigrationAgent()
+
+	const senders = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	claimed, answered := 0, 0
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			existing, _, err := a.claimTask(nil, "task-1")
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				t.Errorf("claimTask: %v", err)
+			case existing == nil:
+				claimed++
+			default:
+				answered++
+			}
+		}()
+	}
+	wg.Wait()
+	if claimed != 1 || answered != senders-1 {
+		t.Errorf("%d sends claimed the ID and %d got the task in progress; want 1 and %d", claimed, answered, senders-1)
+	}
+}
+
+func TestClaimTaskRelease(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	a := NewMigrationAgent()
+
+	_, release, err := a.claimTask(nil, "task-1")
+	if err != nil {
+		t.Fatalf("claimTask: %v", err)
+	}
+	release()
+	if _, ok := a.tasks.Peek("task-1"); ok {
+		t.Error("released claim is still stored")
+	}
+	if existing, _, _ := a.claimTask(nil, "task-1"); existing != nil {
+		t.Error("ID can't be claimed again after release")
+	}
+}
+
+func TestClaimTaskReturnsStoredTaskByDefault(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	a := NewMigrationAgent()
+
+	done := &Task{ID: "task-1", Kind: "task"}
+	done.SetStatus(TaskStateCompleted, nil, time.Now())
+	a.tasks.Put(done)
+
+	existing, _, err := a.claimTask(nil, "task-1")
+	if err != nil || existing != done {
+		t.Errorf("claimTask = %v, %v; want the stored task", existing, err)
+	}
+}
+
+func TestClaimTaskHidesOtherTenantsTasks(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	a := NewMigrationAgent()
+
+	other := &Task{ID: "task-1", Kind: "task", tenant: "acme"}
+	other.SetStatus(TaskStateCompleted, nil, time.Now())
+	a.tasks.Put(other)
+
+	_, _, err := a.claimTask(&Tenant{ID: "globex"}, "task-1")
+	if !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("claimTask = %v, want ErrTaskNotFound", err)
+	}
+	if code := errorCode(err, ErrCodeTaskNotCancelable); code != ErrCodeTaskNotFound {
+		t.Errorf("error code = %d, want %d", code, ErrCodeTaskNotFound)
+	}
+}
@@ -0,0 +1,385 @@
+package agent
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// Requirement statuses in an eligibility check
+const (
+	RequirementMet     = "met"
+	RequirementMissing = "missing"
+	RequirementUnknown = "unknown" // the profile doesn't say
+)
+
+// RequirementCheck is one requirement of a program checked against a
+// profile
+type RequirementCheck struct {
+	Requirement string `json:"requirement"`
+	Status      string `json:"status"` // one of the Requirement* statuses
+	Detail      string `json:"detail"` // what the profile has and how far off it is
+}
+
+// OtherRequirement is a requirement the profile fields can't show, such as
+// a job offer. It counts as met when the query mentions one of its words.
+type OtherRequirement struct {
+	Name     string
+	Evidence []string // lowercase words or phrases that show the profile meets it
+}
+
+// ProgramRules are the minimum requirements of a program the rules engine
+// checks a profile against; zero values aren't checked
+type ProgramRules struct {
+	Country       string
+	Program       string
+	MinCLB        int    // English as a Canadian Language Benchmark; CEFR B1 is about CLB 5 and B2 about CLB 7
+	MinEducation  string // one of the Education* levels
+	MinExperience int    // years of skilled work
+	MaxAge        int    // applicants must be younger
+	Funds         bool   // the funds listed in the fee table must be shown
+	Other         []OtherRequirement
+}
+
+// programRules are the checkable requirements of the specialists' main
+// programs
+var programRules = []ProgramRules{
+	{Country: "Canada", Program: "Express Entry (Federal Skilled Worker)", MinCLB: 7, MinEducation: parser.EducationSecondary, MinExperience: 1, Funds: true,
+		Other: []OtherRequirement{{Name: "Educational Credential Assessment", Evidence: []string{"eca", "wes"}}}},
+	{Country: "Canada", Program: "Canadian Experience Class", MinCLB: 7,
+		Other: []OtherRequirement{{Name: "One year of skilled work in Canada", Evidence: []string{"canadian experience", "experience in canada", "working in canada"}}}},
+	{Country: "Canada", Program: "Provincial Nominee Program", MinExperience: 1,
+		Other: []OtherRequirement{{Name: "Provincial nomination", Evidence: []string{"nomination", "nominated"}}}},
+	{Country: "Canada", Program: "Study Permit", Funds: true,
+		Other: []OtherRequirement{{Name: "Letter of acceptance from a designated learning institution", Evidence: []string{"admission", "admitted", "letter of acceptance", "accepted"}}}},
+
+	{Country: "United Kingdom", Program: "Skilled Worker visa", MinCLB: 5, Funds: true,
+		Other: []OtherRequirement{{Name: "Job offer from a licensed sponsor", Evidence: []string{"job offer", "sponsor"}}}},
+	{Country: "United Kingdom", Program: "Health and Care Worker visa", MinCLB: 5, Funds: true,
+		Other: []OtherRequirement{{Name: "Job offer from the NHS or a licensed care provider", Evidence: []string{"job offer", "nhs offer", "sponsor"}}}},
+	{Country: "United Kingdom", Program: "Student visa", MinCLB: 7, Funds: true,
+		Other: []OtherRequirement{{Name: "Confirmation of Acceptance for Studies (CAS)", Evidence: []string{"cas", "admission", "admitted", "offer letter"}}}},
+	{Country: "United Kingdom", Program: "Global Talent visa",
+		Other: []OtherRequirement{{Name: "Endorsement from an approved body", Evidence: []string{"endorsement", "endorsed"}}}},
+
+	{Country: "Germany", Program: "EU Blue Card", MinEducation: parser.EducationBachelor,
+		Other: []OtherRequirement{{Name: "Job offer above the salary threshold", Evidence: []string{"job offer", "contract"}}}},
+	{Country: "Germany", Program: "Opportunity Card (Chancenkarte)", MinCLB: 7, MinEducation: parser.EducationTwoYear, Funds: true},
+	{Country: "Germany", Program: "Student residence permit", Funds: true,
+		Other: []OtherRequirement{{Name: "University admission", Evidence: []string{"admission", "admitted", "zulassung"}}}},
+
+	{Country: "Australia", Program: "Skilled Independent visa (subclass 189)", MinCLB: 7, MaxAge: 45,
+		Other: []OtherRequirement{{Name: "Positive skills assessment", Evidence: []string{"skills assessment", "assessed"}}}},
+	{Country: "Australia", Program: "Skilled Nominated visa (subclass 190)", MinCLB: 7, MaxAge: 45,
+		Other: []OtherRequirement{{Name: "Positive skills assessment", Evidence: []string{"skills assessment", "assessed"}}, {Name: "State or territory nomination", Evidence: []string{"nomination", "nominated"}}}},
+	{Country: "Australia", Program: "Student visa (subclass 500)", Funds: true,
+		Other: []OtherRequirement{{Name: "Confirmation of Enrolment (CoE)", Evidence: []string{"coe", "enrolment", "admission", "admitted"}}}},
+
+	{Country: "United States", Program: "H-1B Specialty Occupation", MinEducation: parser.EducationBachelor,
+		Other: []OtherRequirement{{Name: "Job offer from a US employer", Evidence: []string{"job offer", "employer"}}}},
+	{Country: "United States", Program: "EB-2 National Interest Waiver",
+		Other: []OtherRequirement{{Name: "Advanced degree, or a bachelor's degree and five years of progressive experience", Evidence: []string{"master", "phd", "doctorate"}}}},
+	{Country: "United States", Program: "F-1 Student", Funds: true,
+		Other: []OtherRequirement{{Name: "Form I-20 from a SEVP-certified school", Evidence: []string{"i-20", "admission", "admitted"}}}},
+}
+
+// educationRank orders the Education* levels
+var educationRank = map[string]int{
+	parser.EducationSecondary: 1,
+	parser.EducationOneYear:   2,
+	parser.EducationTwoYear:   3,
+	parser.EducationBachelor:  4,
+	parser.EducationTwoOrMore: 5,
+	parser.EducationMaster:    6,
+	parser.EducationDoctorate: 7,
+}
+
+// educationNames describes the Education* levels in answers
+var educationNames = map[string]string{
+	parser.EducationSecondary: "secondary school",
+	parser.EducationOneYear:   "a one-year diploma",
+	parser.EducationTwoYear:   "a two-year diploma",
+	parser.EducationBachelor:  "a bachelor's degree",
+	parser.EducationTwoOrMore: "two or more post-secondary credentials",
+	parser.EducationMaster:    "a master's degree",
+	parser.EducationDoctorate: "a doctorate",
+}
+
+// clbIELTS is roughly the overall IELTS General band for each CLB level
+var clbIELTS = map[int]string{4: "4.0", 5: "5.0", 6: "5.5", 7: "6.0", 8: "6.5", 9: "7.0", 10: "7.5"}
+
+// checkEligibility runs the rules of the program a recommendation names
+// against the profile, returning the program and one check per
+// requirement, or "" and nil when the program has no rules
+func checkEligibility(pathway, country string, profile parser.UserProfile, query string, year int, d *Datasets) (string, []RequirementCheck) {
+	var names []string
+	for _, r := range programRules {
+		if strings.EqualFold(r.Country, country) {
+			names = append(names, r.Program)
+		}
+	}
+	program := matchProgram(pathway, names)
+	if program == "" {
+		return "", nil
+	}
+	var rules ProgramRules
+	for _, r := range programRules {
+		if r.Program == program && strings.EqualFold(r.Country, country) {
+			rules = r
+		}
+	}
+
+	var checks []RequirementCheck
+	if rules.MinCLB > 0 {
+		checks = append(checks, checkLanguage(rules.MinCLB, profile.CLB))
+	}
+	if rules.MinEducation != "" {
+		checks = append(checks, checkEducation(rules.MinEducation, profile.Education))
+	}
+	if rules.MinExperience > 0 {
+		years := -1
+		if profile.Sources["experienceYears"] != "" {
+			years = profile.ExperienceYears
+		}
+		checks = append(checks, checkExperience(rules.MinExperience, years))
+	}
+	if rules.MaxAge > 0 {
+		age, _ := parser.ParseCRSInput(query)
+		checks = append(checks, checkAge(rules.MaxAge, age.Age))
+	}
+	if rules.Funds {
+		if check, ok := checkFunds(d.programFees(country, program, year), profile.Budget, d.rates()); ok {
+			checks = append(checks, check)
+		}
+	}
+	for _, other := range rules.Other {
+		checks = append(checks, checkOther(other, query))
+	}
+	return program, checks
+}
+
+func checkLanguage(minCLB, clb int) RequirementCheck {
+	c := RequirementCheck{Requirement: fmt.Sprintf("English (CLB %d)", minCLB)}
+	switch {
+	case clb == 0:
+		c.Status = RequirementUnknown
+		c.Detail = fmt.Sprintf("no test score given; you need CLB %d, about IELTS %s overall", minCLB, clbIELTS[minCLB])
+	case clb >= minCLB:
+		c.Status = RequirementMet
+		c.Detail = fmt.Sprintf("you have CLB %d", clb)
+	default:
+		c.Status = RequirementMissing
+		c.Detail = fmt.Sprintf("you have CLB %d, %s short; aim for about IELTS %s overall", clb, plural(minCLB-clb, "level"), clbIELTS[minCLB])
+	}
+	return c
+}
+
+func checkEducation(min, education string) RequirementCheck {
+	c := RequirementCheck{Requirement: "Education (" + educationNames[min] + ")"}
+	switch {
+	case education == "":
+		c.Status = RequirementUnknown
+		c.Detail = "your education isn't stated"
+	case educationRank[education] >= educationRank[min]:
+		c.Status = RequirementMet
+		c.Detail = "you have " + educationNames[education]
+	default:
+		c.Status = RequirementMissing
+		c.Detail = "you have " + educationNames[education] + "; " + educationNames[min] + " or higher is needed"
+	}
+	return c
+}
+
+// checkExperience compares years of experience, -1 when not stated
+func checkExperience(min, years int) RequirementCheck {
+	c := RequirementCheck{Requirement: "Work experience (" + plural(min, "year") + ")"}
+	switch {
+	case years < 0:
+		c.Status = RequirementUnknown
+		c.Detail = "your years of experience aren't stated"
+	case years >= min:
+		c.Status = RequirementMet
+		c.Detail = "you have " + plural(years, "year")
+	default:
+		c.Status = RequirementMissing
+		c.Detail = fmt.Sprintf("you have %s, %s short", plural(years, "year"), plural(min-years, "year"))
+	}
+	return c
+}
+
+func checkAge(maxAge, age int) RequirementCheck {
+	c := RequirementCheck{Requirement: fmt.Sprintf("Age (under %d)", maxAge)}
+	switch {
+	case age == 0:
+		c.Status = RequirementUnknown
+		c.Detail = "your age isn't stated"
+	case age < maxAge:
+		c.Status = RequirementMet
+		c.Detail = fmt.Sprintf("you are %d", age)
+	default:
+		c.Status = RequirementMissing
+		c.Detail = fmt.Sprintf("you are %d; applicants must be under %d when invited", age, maxAge)
+	}
+	return c
+}
+
+// checkFunds compares the budget, in US dollars, with the one-off or
+// yearly funds in the fee table. It reports false when the table lists
+// none for the program.
+func checkFunds(fees []Fee, budget int, rates map[string]float64) (RequirementCheck, bool) {
+	var funds *Fee
+	for i, fee := range fees {
+		if fee.Kind == FeeKindFunds && fee.Per != "month" {
+			funds = &fees[i]
+			break
+		}
+	}
+	if funds == nil {
+		return RequirementCheck{}, false
+	}
+
+	needed := formatFee(funds.Amount, funds.Currency)
+	if funds.Per != "" {
+		needed += " per " + funds.Per
+	}
+	c := RequirementCheck{Requirement: "Funds (" + needed + ")"}
+	rate := rates[funds.Currency]
+	if funds.Currency == "USD" {
+		rate = 1
+	}
+	switch {
+	case budget == 0:
+		c.Status = RequirementUnknown
+		c.Detail = "your budget isn't stated; you must show " + funds.Item + " of " + needed
+	case rate == 0:
+		c.Status = RequirementUnknown
+		c.Detail = fmt.Sprintf("compare your budget of %s with %s of %s", formatFee(float64(budget), "USD"), funds.Item, needed)
+	default:
+		neededUSD := math.Round(funds.Amount / rate)
+		if float64(budget) >= neededUSD {
+			c.Status = RequirementMet
+			c.Detail = fmt.Sprintf("your budget of %s covers about %s", formatFee(float64(budget), "USD"), formatFee(neededUSD, "USD"))
+		} else {
+			c.Status = RequirementMissing
+			c.Detail = fmt.Sprintf("your budget of %s is about %s short of %s", formatFee(float64(budget), "USD"), formatFee(neededUSD-float64(budget), "USD"), formatFee(neededUSD, "USD"))
+		}
+	}
+	return c, true
+}
+
+// negationPattern matches a negation just before a word
+var negationPattern = regexp.MustCompile(`\b(?:no|without|not|don'?t have|haven'?t got|lack)(?:\s+(?:a|an|any|yet))?\s*$`)
+
+// checkOther looks for evidence of a requirement in the query, reading a
+// negated mention ("no job offer") as missing
+func checkOther(r OtherRequirement, query string) RequirementCheck {
+	q := " " + strings.ToLower(query) + " "
+	for _, word := range r.Evidence {
+		idx := parser.IndexWord(q, word)
+		if idx == -1 {
+			continue
+		}
+		if negationPattern.MatchString(q[:idx]) {
+			return RequirementCheck{Requirement: r.Name, Status: RequirementMissing, Detail: "you said you don't have this yet"}
+		}
+		return RequirementCheck{Requirement: r.Name, Status: RequirementMet, Detail: "you mentioned this"}
+	}
+	return RequirementCheck{Requirement: r.Name, Status: RequirementUnknown, Detail: "not stated; you will need this to apply"}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+var (
+	keyDetailsPattern       = regexp.MustCompile(`(?i)^\s*(?:\*\*|#+\s*)key details`)
+	mainRequirementsPattern = regexp.MustCompile(`(?im)^([ \t]*[-*][ \t]+)(\*\*)?main requirements(?:\*\*)?:(?:\*\*)?.*$`)
+)
+
+// statusIcons mark each requirement status in the eligibility section
+var statusIcons = map[string]string{RequirementMet: "✅", RequirementMissing: "❌", RequirementUnknown: "❔"}
+
+// applyEligibility weaves a personalized gap analysis into a
+// recommendation: the Main requirements line says what the profile meets
+// and lacks, and an eligibility section after the key details lists each
+// requirement. Answers for programs without rules are returned unchanged.
+func applyEligibility(markdown, country string, profile parser.UserProfile, query string, year int, d *Datasets) string {
+	program, checks := checkEligibility(pathwayName(markdown), country, profile, query, year, d)
+	if len(checks) == 0 {
+		return markdown
+	}
+
+	var met int
+	var missing, unknown []string
+	var section strings.Builder
+	section.WriteString("**Your Eligibility for " + program + ":**\n")
+	for _, c := range checks {
+		fmt.Fprintf(&section, "- %s %s: %s\n", statusIcons[c.Status], c.Requirement, c.Detail)
+		switch c.Status {
+		case RequirementMet:
+			met++
+		case RequirementMissing:
+			missing = append(missing, c.Requirement)
+		default:
+			unknown = append(unknown, c.Requirement)
+		}
+	}
+
+	summary := []string{fmt.Sprintf("you meet %d of %d", met, len(checks))}
+	if len(missing) > 0 {
+		summary = append(summary, "still needed: "+joinList(missing))
+	}
+	if len(unknown) > 0 {
+		summary = append(summary, "to confirm: "+joinList(unknown))
+	}
+	if loc := mainRequirementsPattern.FindStringSubmatchIndex(markdown); loc != nil {
+		label := "Main requirements:"
+		if loc[4] != -1 {
+			label = "**Main requirements:**"
+		}
+		markdown = markdown[:loc[0]] + markdown[loc[2]:loc[3]] + label + " " + strings.Join(summary, "; ") + " (see below)" + markdown[loc[1]:]
+	}
+
+	return insertAfterKeyDetails(markdown, section.String())
+}
+
+// insertAfterKeyDetails adds a section to an answer after its key details
+// list, or before the next step when there is none
+func insertAfterKeyDetails(markdown, section string) string {
+	lines := strings.Split(markdown, "\n")
+	at := -1
+	for i, line := range lines {
+		if keyDetailsPattern.MatchString(line) {
+			at = i + 1
+			for at < len(lines) && (strings.HasPrefix(strings.TrimSpace(lines[at]), "- ") || strings.HasPrefix(strings.TrimSpace(lines[at]), "* ")) {
+				at++
+			}
+			break
+		}
+	}
+	if at == -1 {
+		at = len(lines)
+		for i, line := range lines {
+			if strings.HasPrefix(strings.ToLower(strings.TrimSpace(line)), "next step") {
+				at = i
+				break
+			}
+		}
+	}
+	inserted := append([]string{}, lines[:at]...)
+	if at > 0 && strings.TrimSpace(lines[at-1]) != "" {
+		inserted = append(inserted, "")
+	}
+	inserted = append(inserted, strings.TrimRight(section, "\n"))
+	if at < len(lines) && strings.TrimSpace(lines[at]) != "" {
+		inserted = append(inserted, "")
+	}
+	inserted = append(inserted, lines[at:]...)
+	return strings.Join(inserted, "\n")
+}
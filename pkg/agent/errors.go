@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+)
+
+// JSON-RPC 2.0 and A2A-specific error codes
+const (
+	ErrCodeParse                        = -32700
+	ErrCodeInvalidRequest               = -32600
+	ErrCodeMethodNotFound               = -32601
+	ErrCodeInvalidParams                = -32602
+	ErrCodeInternal                     = -32603
+	ErrCodeTaskNotFound                 = -32001
+	ErrCodeTaskNotCancelable            = -32002
+	ErrCodePushNotificationNotSupported = -32003
+	ErrCodeUnsupportedOperation         = -32004
+	ErrCodeContentTypeNotSupported      = -32005
+	ErrCodeInvalidAgentResponse         = -32006
+	ErrCodeUnauthenticated              = -32010
+	ErrCodeRateLimited                  = -32011
+	ErrCodeUserQuotaExceeded            = -32012
+)
+
+// Errors that map onto A2A error codes; wrap them with %w to add detail
+var (
+	ErrTaskNotFound            = errors.New("task not found")
+	ErrContentTypeNotSupported = errors.New("incompatible content types")
+)
+
+// Categories of task failure, reported in a failed task's status so
+// clients can choose their own wording
+const (
+	FailureParse          = "parse_error"     // the model's reply couldn't be read
+	FailureLLMUnavailable = "llm_unavailable" // the model couldn't be reached or returned an error
+	FailureQuotaExceeded  = "quota_exceeded"  // the model's rate limit or quota is used up
+	FailureSafetyBlocked  = "safety_blocked"  // the model's safety filters refused the query
+	FailureInvalidProfile = "invalid_profile" // the query gives nothing to plan from
+)
+
+// Errors behind each failure category; wrap them with %w to add detail.
+// The model's errors are those of package llm.
+var (
+	ErrLLMResponse    = llm.ErrResponse
+	ErrLLMUnavailable = llm.ErrUnavailable
+	ErrQuotaExceeded  = llm.ErrQuotaExceeded
+	ErrSafetyBlocked  = llm.ErrSafetyBlocked
+	ErrInvalidProfile = errors.New("invalid profile")
+)
+
+// TaskFailure is the machine-readable reason a task failed
+type TaskFailure struct {
+	Code      string `json:"code"`      // one of the Failure* categories
+	Retryable bool   `json:"retryable"` // whether sending the same query again may succeed
+	Message   string `json:"message"`   // wording safe to show the user
+}
+
+// failures describes each category
+var failures = map[string]TaskFailure{
+	FailureParse:          {Code: FailureParse, Retryable: true, Message: "The recommendation came back in an unexpected form. Please try again."},
+	FailureLLMUnavailable: {Code: FailureLLMUnavailable, Retryable: true, Message: "The recommendation service is temporarily unavailable. Please try again in a few minutes."},
+	FailureQuotaExceeded:  {Code: FailureQuotaExceeded, Retryable: true, Message: "We're handling too many requests right now. Please try again later."},
+	FailureSafetyBlocked:  {Code: FailureSafetyBlocked, Retryable: false, Message: "This request can't be answered. Please rephrase your question about migration options."},
+	FailureInvalidProfile: {Code: FailureInvalidProfile, Retryable: false, Message: "Please describe your profession, where you live and where you'd like to move."},
+}
+
+// classifyFailure maps an error from processing a task onto its category.
+// Errors outside the taxonomy come from reaching the model, so they count
+// as the model being unavailable.
+func classifyFailure(err error) TaskFailure {
+	switch {
+	case errors.Is(err, ErrLLMResponse):
+		return failures[FailureParse]
+	case errors.Is(err, ErrQuotaExceeded):
+		return failures[FailureQuotaExceeded]
+	case errors.Is(err, ErrSafetyBlocked):
+		return failures[FailureSafetyBlocked]
+	case errors.Is(err, ErrInvalidProfile):
+		return failures[FailureInvalidProfile]
+	}
+	return failures[FailureLLMUnavailable]
+}
+
+// errorMessages holds the standard message sent with each error code
+var errorMessages = map[int]string{
+	ErrCodeParse:                        "Parse error",
+	ErrCodeInvalidRequest:               "Invalid request",
+	ErrCodeMethodNotFound:               "Method not found",
+	ErrCodeInvalidParams:                "Invalid params",
+	ErrCodeInternal:                     "Internal error",
+	ErrCodeTaskNotFound:                 "Task not found",
+	ErrCodeTaskNotCancelable:            "Task cannot be canceled",
+	ErrCodePushNotificationNotSupported: "Push Notification is not supported",
+	ErrCodeUnsupportedOperation:         "This operation is not supported",
+	ErrCodeContentTypeNotSupported:      "Incompatible content types",
+	ErrCodeInvalidAgentResponse:         "Invalid agent response",
+	ErrCodeUnauthenticated:              "Authentication required",
+	ErrCodeRateLimited:                  "Rate limit exceeded",
+	ErrCodeUserQuotaExceeded:            "User quota exceeded",
+}
+
+// errorCode picks the A2A error code for err, falling back to the given
+// code for errors without a more specific one
+func errorCode(err error, fallback int) int {
+	var conflict *errTaskConflict
+	switch {
+	case errors.Is(err, ErrTaskNotFound):
+		return ErrCodeTaskNotFound
+	case errors.As(err, &conflict):
+		return ErrCodeTaskNotCancelable
+	case errors.Is(err, ErrContentTypeNotSupported):
+		return ErrCodeContentTypeNotSupported
+	}
+	return fallback
+}
+
+// sendRPCError sends err with the most specific error code that applies
+func (a *MigrationAgent) sendRPCError(w http.ResponseWriter, err error, fallback int, id interface{}) {
+	code := errorCode(err, fallback)
+	a.sendError(w, err, code, errorMessages[code], id)
+}
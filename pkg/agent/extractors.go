@@ -0,0 +1,25 @@
+package agent
+
+import (
+	"log"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// Extractors returns the profile extractors the agent reads queries with.
+// Register custom ones on it before serving, e.g.
+//
+//	a.Extractors().Register(nerExtractor)
+func (a *MigrationAgent) Extractors() *parser.Extractors {
+	return a.extractors
+}
+
+// parseProfile reads the profile from a query with the registered
+// extractors and the built-in parser, logging any extractor that fails
+func (a *MigrationAgent) parseProfile(query string) parser.UserProfile {
+	profile, err := a.extractors.Extract(query)
+	if err != nil {
+		log.Printf("⚠️  Profile extraction: %v", err)
+	}
+	return profile
+}
@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// fallbackNotice labels answers assembled without the LLM
+const fallbackNotice = "⚠️ Our AI planner is unavailable right now, so this is general information from our curated visa knowledge base, not a personalized recommendation. Ask again later for a plan tailored to your profile."
+
+// studyWords mark a query as being about studying abroad
+var studyWords = []string{"study", "student", "university", "college", "masters", "phd", "degree program"}
+
+// fallbackAnswer assembles a templated answer from the knowledge base of the
+// query's destination when generating one failed with err. It reports false
+// when there is nothing to fall back on: the fallback flag is off, the
+// failure isn't the LLM's (a safety block or an empty query), or the
+// destination has no specialist.
+func fallbackAnswer(err error, specialist *Specialist, profile parser.UserProfile, query string, style AnswerStyle) (string, bool) {
+	if !style.Tenant.enabled(FlagFallback) || errors.Is(err, ErrSafetyBlocked) || errors.Is(err, ErrInvalidProfile) {
+		return "", false
+	}
+	if specialist == generalist || len(specialist.Programs) == 0 {
+		return "", false
+	}
+	return renderKnowledgeAnswer(specialist, profile, query, style, fallbackNotice), true
+}
+
+// renderKnowledgeAnswer writes an answer from a specialist's knowledge base
+// in the standard format's shape, minus the timeline, which needs the
+// applicant's details. The notice explains why the answer isn't personal.
+func renderKnowledgeAnswer(specialist *Specialist, profile parser.UserProfile, query string, style AnswerStyle, notice string) string {
+	programs := fallbackPrograms(specialist.Programs, query)
+	nextStep := fmt.Sprintf("Next step: Check the eligibility requirements for the %s on the official %s immigration website.", programs[0].Name, specialist.Country)
+
+	if style.detail() == DetailBrief {
+		names := make([]string, len(programs))
+		for i, program := range programs {
+			names[i] = program.Name
+		}
+		return fmt.Sprintf("%s\n\nThe main routes to %s are %s. %s\n", notice, specialist.Country, joinList(names), nextStep)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Migration Options for %s (general guidance)\n\n", specialist.Country)
+	b.WriteString("> " + notice + "\n\n")
+
+	b.WriteString("**Main Routes:**\n")
+	for _, program := range programs {
+		fmt.Fprintf(&b, "- **%s** (%s): %s\n", program.Name, program.Category, program.Summary)
+	}
+
+	if len(specialist.Knowledge) > 0 {
+		b.WriteString("\n**Key Facts:**\n")
+		for _, fact := range specialist.Knowledge {
+			b.WriteString("- " + fact + "\n")
+		}
+	}
+
+	if pack := content().corridorPack(query, specialist); pack != nil && len(pack.Facts) > 0 {
+		b.WriteString("\n**Notes for " + pack.Name + ":**\n")
+		for _, fact := range pack.Facts {
+			b.WriteString("- " + fact + "\n")
+		}
+	}
+
+	if facts := style.Datasets.countryFacts(specialist.Country, style.Now.Year()); len(facts) > 0 {
+		b.WriteString("\n**Current Fees and Processing Times" + style.Datasets.asOf() + ":**\n")
+		for _, fact := range facts {
+			b.WriteString("- " + fact + "\n")
+		}
+	}
+
+	for _, calculate := range specialist.Calculators {
+		if section := calculate(profile, query); section != "" {
+			b.WriteString("\n" + strings.TrimRight(section, "\n") + "\n")
+		}
+	}
+
+	b.WriteString("\n" + nextStep + "\n")
+	return b.String()
+}
+
+// fallbackPrograms orders a destination's programs for the query: study
+// routes first when the query is about studying, otherwise as curated
+func fallbackPrograms(programs []VisaProgram, query string) []VisaProgram {
+	ordered := append([]VisaProgram(nil), programs...)
+	q := " " + strings.ToLower(query) + " "
+	for _, word := range studyWords {
+		if parser.IndexWord(q, word) != -1 {
+			sort.SliceStable(ordered, func(i, j int) bool {
+				return ordered[i].Category == "study" && ordered[j].Category != "study"
+			})
+			break
+		}
+	}
+	return ordered
+}
+
+// joinList joins items as "a, b and c"
+func joinList(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
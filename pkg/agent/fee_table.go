@@ -0,0 +1,254 @@
+package agent
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// feeTableData is the built-in fee table, maintained with the code and
+// replaced by the fees dataset when DATASET_FEES_URL is set
+//
+//go:embed fee_table.json
+var feeTableData []byte
+
+// FeeKindFunds marks money an applicant must show, such as settlement
+// funds, rather than pay
+const FeeKindFunds = "funds"
+
+// feeTable is a versioned list of fees keyed by country, program and year
+type feeTable struct {
+	Version string `json:"version"`
+	Updated string `json:"updated"` // YYYY-MM-DD
+	Fees    []Fee  `json:"fees"`
+}
+
+var builtinFeeTable = mustParseFeeTable(feeTableData)
+
+// mustParseFeeTable parses the embedded fee table. It ships with the
+// binary, so a broken one is a build defect.
+func mustParseFeeTable(data []byte) feeTable {
+	var table feeTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		panic(fmt.Sprintf("invalid embedded fee table: %v", err))
+	}
+	if _, err := time.Parse("2006-01-02", table.Updated); err != nil || table.Version == "" {
+		panic("embedded fee table needs a version and an updated date")
+	}
+	if err := validateFees(table.Fees); err != nil {
+		panic(fmt.Sprintf("invalid embedded fee table: %v", err))
+	}
+	return table
+}
+
+// validateFees checks that every fee is complete and its period and kind
+// are known
+func validateFees(fees []Fee) error {
+	for i, fee := range fees {
+		if fee.Country == "" || fee.Program == "" || fee.Item == "" || fee.Amount <= 0 || !currencyCode.MatchString(fee.Currency) {
+			return fmt.Errorf("fee %d needs a country, program, item, positive amount and currency code", i)
+		}
+		if fee.Per != "" && fee.Per != "month" && fee.Per != "year" {
+			return fmt.Errorf("fee %d: per must be month or year, not %q", i, fee.Per)
+		}
+		if fee.Kind != "" && fee.Kind != FeeKindFunds {
+			return fmt.Errorf("fee %d: unknown kind %q", i, fee.Kind)
+		}
+	}
+	return nil
+}
+
+// currentFees returns a country's fees in effect in year: for each
+// program, the entries of its latest schedule not after year. Entries
+// without a year apply when a program has no dated schedule.
+func (d *Datasets) currentFees(country string, year int) []Fee {
+	if d == nil {
+		return nil
+	}
+	latest := make(map[string]int)
+	for _, fee := range d.Fees {
+		key := strings.ToLower(fee.Program)
+		if strings.EqualFold(fee.Country, country) && fee.Year <= year && fee.Year > latest[key] {
+			latest[key] = fee.Year
+		}
+	}
+	var fees []Fee
+	for _, fee := range d.Fees {
+		if strings.EqualFold(fee.Country, country) && fee.Year == latest[strings.ToLower(fee.Program)] {
+			fees = append(fees, fee)
+		}
+	}
+	return fees
+}
+
+// programFees finds the fees of the program a recommendation names
+func (d *Datasets) programFees(country, pathway string, year int) []Fee {
+	current := d.currentFees(country, year)
+	names := make([]string, len(current))
+	for i, fee := range current {
+		names[i] = fee.Program
+	}
+	best := matchProgram(pathway, names)
+	if best == "" {
+		return nil
+	}
+	var fees []Fee
+	for _, fee := range current {
+		if fee.Program == best {
+			fees = append(fees, fee)
+		}
+	}
+	return fees
+}
+
+// matchProgram picks the program a pathway names: the one whose name's
+// words all appear in the pathway, the most specific one if several do, or
+// "" if none does
+func matchProgram(pathway string, programs []string) string {
+	words := programWords(pathway)
+	best, bestWords := "", 0
+	for _, program := range programs {
+		programWords := programWords(program)
+		if len(programWords) > bestWords && containsAll(words, programWords) {
+			best, bestWords = program, len(programWords)
+		}
+	}
+	return best
+}
+
+var programWordPattern = regexp.MustCompile(`[a-z0-9]+`)
+
+// programFillerWords don't tell programs apart
+var programFillerWords = map[string]bool{"the": true, "and": true, "of": true, "for": true, "visa": true, "program": true, "programme": true, "stream": true}
+
+// programWords lists the distinguishing words of a program name
+func programWords(name string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range programWordPattern.FindAllString(strings.ToLower(name), -1) {
+		if !programFillerWords[word] {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+func containsAll(words, subset map[string]bool) bool {
+	if len(subset) == 0 {
+		return false
+	}
+	for word := range subset {
+		if !words[word] {
+			return false
+		}
+	}
+	return true
+}
+
+// costLinePattern matches the Cost line of the key details
+var costLinePattern = regexp.MustCompile(`(?im)^([ \t]*[-*][ \t]+)(\*\*)?cost(?:\*\*)?:(?:\*\*)?.*$`)
+
+// FeeTableSource identifies the fee table entries a Cost line was written
+// from
+type FeeTableSource struct {
+	Program string `json:"program"`
+	Year    int    `json:"year,omitempty"`
+	Version string `json:"version"` // fees dataset version
+}
+
+// applyFeeTable replaces the Cost line of a recommendation with the
+// official fees of the program it recommends, so the figures come from the
+// fee table rather than the model. It returns the answer unchanged and a
+// nil source when the answer has no Cost line or the program isn't in the
+// table.
+func applyFeeTable(markdown, country string, year int, d *Datasets) (string, *FeeTableSource) {
+	loc := costLinePattern.FindStringSubmatchIndex(markdown)
+	if loc == nil || d == nil {
+		return markdown, nil
+	}
+	fees := d.programFees(country, pathwayName(markdown), year)
+	if len(fees) == 0 {
+		return markdown, nil
+	}
+
+	source := &FeeTableSource{Program: fees[0].Program, Year: fees[0].Year, Version: d.Versions[DatasetFees].Version}
+	label := "Cost:"
+	if loc[4] != -1 {
+		label = "**Cost:**"
+	}
+	line := markdown[loc[2]:loc[3]] + label + " " + describeCost(fees, d.rates()) + " " + source.note()
+	return markdown[:loc[0]] + line + markdown[loc[1]:], source
+}
+
+// describeCost sums a program's one-off fees, then lists recurring charges
+// and the funds to show
+func describeCost(fees []Fee, rates map[string]float64) string {
+	var once, recurring, funds []string
+	totals := make(map[string]float64)
+	for _, fee := range fees {
+		amount := formatFee(fee.Amount, fee.Currency)
+		if fee.Per != "" {
+			amount += " per " + fee.Per
+		}
+		switch {
+		case fee.Kind == FeeKindFunds:
+			funds = append(funds, fee.Item+" "+amount)
+		case fee.Per != "":
+			recurring = append(recurring, fee.Item+" "+amount)
+		default:
+			once = append(once, fee.Item+" "+amount)
+			totals[fee.Currency] += fee.Amount
+		}
+	}
+
+	var parts []string
+	if len(once) > 0 {
+		currencies := make([]string, 0, len(totals))
+		for currency := range totals {
+			currencies = append(currencies, currency)
+		}
+		sort.Strings(currencies)
+		var sums []string
+		for _, currency := range currencies {
+			sum := formatFee(totals[currency], currency)
+			if rate := rates[currency]; rate > 0 && currency != "USD" {
+				sum += " (≈ " + formatFee(math.Round(totals[currency]/rate), "USD") + ")"
+			}
+			sums = append(sums, sum)
+		}
+		parts = append(parts, strings.Join(sums, " + ")+" in government fees ("+strings.Join(once, ", ")+")")
+	}
+	if len(recurring) > 0 {
+		parts = append(parts, "plus "+joinList(recurring))
+	}
+	if len(funds) > 0 {
+		parts = append(parts, "and funds to show: "+joinList(funds))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatFee writes an amount with thousands separators, as dollars for US
+// dollar amounts so they are localized like the rest of the answer
+func formatFee(amount float64, currency string) string {
+	decimals := 0
+	if amount != float64(int64(amount)) {
+		decimals = 2
+	}
+	number := Locale{Thousands: ",", Decimal: "."}.formatNumber(amount, decimals)
+	if currency == "USD" {
+		return "$" + number
+	}
+	return number + " " + currency
+}
+
+// note cites the fee table in the answer
+func (s *FeeTableSource) note() string {
+	if s.Year > 0 {
+		return fmt.Sprintf("(official fees for %d, fee table version %s)", s.Year, s.Version)
+	}
+	return fmt.Sprintf("(official fees, fee table version %s)", s.Version)
+}
@@ -0,0 +1,220 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Feedback is a user's rating of a task's recommendation
+type Feedback struct {
+	Rating      int       `json:"rating"` // 1 (not helpful) to 5 (very helpful)
+	Comment     string    `json:"comment,omitempty"`
+	SubmittedAt time.Time `json:"submittedAt"`
+}
+
+// FeedbackParams is the params of feedback/send and the body of POST /feedback
+type FeedbackParams struct {
+	TaskID  string `json:"taskId"`
+	ID      string `json:"id,omitempty"` // older spelling of taskId
+	Rating  int    `json:"rating"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// maxFeedbackComment bounds the length of a feedback comment in characters
+const maxFeedbackComment = 2000
+
+// AuditOutcomeFeedback marks audit entries that record feedback rather
+// than a processed query
+const AuditOutcomeFeedback = "feedback"
+
+// SubmitFeedback stores a rating on a finished task, replacing any earlier
+// feedback for it, and appends it to the audit trail so it outlives the
+// in-memory task. Personal data is stripped from the comment first.
+func (a *MigrationAgent) SubmitFeedback(tenant *Tenant, params FeedbackParams) (*Feedback, error) {
+	if params.TaskID == "" {
+		params.TaskID = params.ID
+	}
+	if params.TaskID == "" {
+		return nil, fmt.Errorf("taskId is required")
+	}
+	if params.Rating < 1 || params.Rating > 5 {
+		return nil, fmt.Errorf("rating must be between 1 and 5")
+	}
+	comment := strings.TrimSpace(params.Comment)
+	if utf8.RuneCountInString(comment) > maxFeedbackComment {
+		return nil, fmt.Errorf("comment must not exceed %d characters", maxFeedbackComment)
+	}
+	comment, _ = redactPII(comment)
+
+	feedback := &Feedback{
+		Rating:      params.Rating,
+		Comment:     comment,
+		SubmittedAt: a.clock.Now().UTC(),
+	}
+
+	task, err := a.taskFor(tenant, params.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	state := task.Status.State
+	if state.Terminal() {
+		task.Feedback = feedback
+	}
+	a.mu.Unlock()
+	if !state.Terminal() {
+		return nil, fmt.Errorf("task %s is still %s; feedback can be sent once it finishes", task.ID, state)
+	}
+	a.saveTask(task)
+
+	a.audit.Append(AuditEntry{
+		Timestamp: feedback.SubmittedAt,
+		TaskID:    task.ID,
+		SessionID: task.SessionID,
+		Model:     task.Model,
+		Variant:   task.Variant,
+		Outcome:   AuditOutcomeFeedback,
+		Rating:    feedback.Rating,
+		Comment:   feedback.Comment,
+	})
+	log.Printf("Task %s rated %d/5", task.ID, feedback.Rating)
+	return feedback, nil
+}
+
+// handleFeedbackSend processes the feedback/send RPC method
+func (a *MigrationAgent) handleFeedbackSend(w http.ResponseWriter, req JSONRPCRequest) {
+	var params FeedbackParams
+	if err := remarshal(req.Params, &params); err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	feedback, err := a.SubmitFeedback(req.tenant, params)
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+	a.sendSuccess(w, feedback, req.ID)
+}
+
+// ServeFeedback handles POST /feedback, the REST form of feedback/send
+func (a *MigrationAgent) ServeFeedback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tenant, err := requestTenant(r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSONError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var params FeedbackParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "body must be a JSON object with taskId, rating and comment")
+		return
+	}
+
+	feedback, err := a.SubmitFeedback(tenant, params)
+	switch {
+	case errors.Is(err, ErrTaskNotFound):
+		writeJSONError(w, http.StatusNotFound, err.Error())
+	case err != nil:
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, feedback)
+	}
+}
+
+// ServeFeedbackReport handles GET /admin/feedback, summarizing ratings
+// overall and per rollout variant and listing the most recent feedback.
+// Only the latest rating for each task counts towards the summary.
+func (a *MigrationAgent) ServeFeedbackReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			writeJSONError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	entries, err := a.audit.Query(AuditFilter{Outcome: AuditOutcomeFeedback})
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	latest := latestFeedback(entries)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"summary":  summarizeFeedback(latest),
+		"variants": feedbackByVariant(latest),
+		"recent":   latest[:min(limit, len(latest))],
+	})
+}
+
+// latestFeedback keeps each task's most recent feedback entry. Entries are
+// newest first, as returned by AuditLog.Query.
+func latestFeedback(entries []AuditEntry) []AuditEntry {
+	latest := []AuditEntry{}
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Outcome != AuditOutcomeFeedback || seen[entry.TaskID] {
+			continue
+		}
+		seen[entry.TaskID] = true
+		latest = append(latest, entry)
+	}
+	return latest
+}
+
+// summarizeFeedback counts ratings and averages them
+func summarizeFeedback(entries []AuditEntry) map[string]interface{} {
+	distribution := map[string]int{"1": 0, "2": 0, "3": 0, "4": 0, "5": 0}
+	total := 0
+	for _, entry := range entries {
+		distribution[strconv.Itoa(entry.Rating)]++
+		total += entry.Rating
+	}
+	summary := map[string]interface{}{
+		"count":        len(entries),
+		"distribution": distribution,
+	}
+	if len(entries) > 0 {
+		summary["averageRating"] = float64(total) / float64(len(entries))
+	}
+	return summary
+}
+
+// feedbackByVariant summarizes ratings for each rollout variant
+func feedbackByVariant(entries []AuditEntry) map[string]interface{} {
+	grouped := make(map[string][]AuditEntry)
+	for _, entry := range entries {
+		if entry.Variant != "" {
+			grouped[entry.Variant] = append(grouped[entry.Variant], entry)
+		}
+	}
+	variants := make(map[string]interface{}, len(grouped))
+	for name, group := range grouped {
+		variants[name] = summarizeFeedback(group)
+	}
+	return variants
+}
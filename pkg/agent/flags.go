@@ -0,0 +1,217 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Feature flags gating experimental or optional behaviour
+const (
+	FlagGrounding   = "grounding"   // let Gemini ground answers with Google Search
+	FlagDelegation  = "delegation"  // ask peer agents sub-questions
+	FlagCoalescing  = "coalescing"  // share one Gemini call between identical in-flight queries
+	FlagJudge       = "judge"       // grade answers with a second model
+	FlagFallback    = "fallback"    // answer from the knowledge base when Gemini fails
+	FlagBudgetRetry = "budgetRetry" // regenerate answers the user's budget can't cover
+	FlagStreaming   = "streaming"   // accept tasks/sendSubscribe and message/stream
+)
+
+// FeatureFlag describes a flag and its value when nothing overrides it
+type FeatureFlag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     bool   `json:"default"`
+}
+
+// featureFlags are the flags the agent knows; others are rejected
+var featureFlags = []FeatureFlag{
+	{Name: FlagGrounding, Description: "Ground answers with Google Search results", Default: false},
+	{Name: FlagDelegation, Description: "Delegate sub-questions to peer agents configured with A2A_DELEGATES", Default: true},
+	{Name: FlagCoalescing, Description: "Answer identical in-flight queries with a single Gemini call", Default: true},
+	{Name: FlagJudge, Description: "Grade completed answers in the background with JUDGE_MODEL", Default: false},
+	{Name: FlagFallback, Description: "Answer from the curated knowledge base, labeled as not personalized, when Gemini fails", Default: true},
+	{Name: FlagBudgetRetry, Description: "Regenerate, once, answers whose pathway costs more than the user's budget", Default: true},
+	{Name: FlagStreaming, Description: "Stream task updates over server-sent events with tasks/sendSubscribe and message/stream", Default: true},
+}
+
+// Sources of a flag's value, from lowest to highest precedence
+const (
+	FlagSourceDefault  = "default"
+	FlagSourceEnv      = "env"
+	FlagSourceFile     = "file"
+	FlagSourceOverride = "override" // set through POST /admin/flags
+)
+
+// FlagSet is a snapshot of every flag's value and where it came from.
+// Snapshots are never modified; changes swap in a new one.
+type FlagSet struct {
+	values  map[string]bool
+	sources map[string]string
+}
+
+var (
+	currentFlags atomic.Pointer[FlagSet]
+	flagsMu      sync.Mutex // serializes admin switches
+)
+
+// featureEnabled reports whether a flag is on
+func featureEnabled(name string) bool {
+	flags := currentFlags.Load()
+	if flags == nil {
+		flags = defaultFlags()
+	}
+	return flags.values[name]
+}
+
+// defaultFlags is every flag at its default value
+func defaultFlags() *FlagSet {
+	flags := &FlagSet{values: make(map[string]bool), sources: make(map[string]string)}
+	for _, flag := range featureFlags {
+		flags.set(flag.Name, flag.Default, FlagSourceDefault)
+	}
+	return flags
+}
+
+func (f *FlagSet) set(name string, value bool, source string) {
+	f.values[name] = value
+	f.sources[name] = source
+}
+
+// clone copies the snapshot so it can be changed and swapped in
+func (f *FlagSet) clone() *FlagSet {
+	c := &FlagSet{values: make(map[string]bool), sources: make(map[string]string)}
+	for name, value := range f.values {
+		c.set(name, value, f.sources[name])
+	}
+	return c
+}
+
+// knownFlag reports whether name is one of featureFlags
+func knownFlag(name string) bool {
+	for _, flag := range featureFlags {
+		if flag.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// loadFeatureFlags applies FEATURE_FLAGS (comma-separated name=true|false
+// pairs) and then FEATURE_FLAGS_FILE (a JSON object of name to boolean) on
+// top of the defaults. The file can be edited and reloaded at runtime, so
+// it wins over the environment; an invalid file fails the load.
+func loadFeatureFlags() (*FlagSet, error) {
+	flags := defaultFlags()
+
+	for _, entry := range strings.Split(os.Getenv("FEATURE_FLAGS"), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil || !knownFlag(name) {
+			log.Printf("⚠️  Ignoring invalid FEATURE_FLAGS entry %q", entry)
+			continue
+		}
+		flags.set(name, enabled, FlagSourceEnv)
+	}
+
+	path := os.Getenv("FEATURE_FLAGS_FILE")
+	if path == "" {
+		return flags, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FEATURE_FLAGS_FILE: %v", err)
+	}
+	var values map[string]bool
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse FEATURE_FLAGS_FILE: %v", err)
+	}
+	for name, enabled := range values {
+		if !knownFlag(name) {
+			return nil, fmt.Errorf("FEATURE_FLAGS_FILE: unknown flag %q", name)
+		}
+		flags.set(name, enabled, FlagSourceFile)
+	}
+	return flags, nil
+}
+
+// ReloadFeatureFlags re-reads the flag configuration, dropping overrides
+// made through the admin API. On error the flags in use are kept.
+func ReloadFeatureFlags() error {
+	flags, err := loadFeatureFlags()
+	if err != nil {
+		return err
+	}
+	currentFlags.Store(flags)
+	return nil
+}
+
+// flagStatus lists every flag with its current value and source
+func flagStatus() []map[string]interface{} {
+	flags := currentFlags.Load()
+	if flags == nil {
+		flags = defaultFlags()
+	}
+	status := make([]map[string]interface{}, 0, len(featureFlags))
+	for _, flag := range featureFlags {
+		status = append(status, map[string]interface{}{
+			"name":        flag.Name,
+			"description": flag.Description,
+			"enabled":     flags.values[flag.Name],
+			"source":      flags.sources[flag.Name],
+		})
+	}
+	return status
+}
+
+// ServeFlags handles GET /admin/flags, which lists the flags, and POST
+// /admin/flags, which switches flags immediately with a JSON object of name
+// to boolean. Switches last until the next reload or restart.
+func (a *MigrationAgent) ServeFlags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		var values map[string]bool
+		if err := json.NewDecoder(r.Body).Decode(&values); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "body must be a JSON object of flag names to booleans")
+			return
+		}
+		for name := range values {
+			if !knownFlag(name) {
+				writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("unknown flag %q", name))
+				return
+			}
+		}
+
+		flagsMu.Lock()
+		flags := currentFlags.Load()
+		if flags == nil {
+			flags = defaultFlags()
+		}
+		flags = flags.clone()
+		for name, enabled := range values {
+			flags.set(name, enabled, FlagSourceOverride)
+			log.Printf("🚩 Feature flag %s switched to %v", name, enabled)
+		}
+		currentFlags.Store(flags)
+		flagsMu.Unlock()
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"flags": flagStatus()})
+}
@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kinds of health rule
+const (
+	HealthInsurance = "insurance"
+	HealthMedical   = "medical"
+)
+
+// HealthRule is one of a destination's health-insurance or medical-exam
+// requirements, part of its specialist's knowledge base
+type HealthRule struct {
+	Kind        string   `json:"kind"`        // HealthInsurance or HealthMedical
+	Requirement string   `json:"requirement"` // what applicants must do
+	Document    string   `json:"document,omitempty"`
+	Categories  []string `json:"categories,omitempty"` // program categories it applies to; none means all
+	Origins     []string `json:"origins,omitempty"`    // ISO 3166 codes of the applicants it applies to; none means all
+	Cost        *Fee     `json:"cost,omitempty"`       // typical cost, when the fee table doesn't list it
+}
+
+// healthRules returns the specialist's rules that apply to a route of the
+// given category ("" if unknown) for applicants from originCode ("" if
+// unknown). Origin-specific rules need a known origin.
+func (s *Specialist) healthRules(category, originCode string) []HealthRule {
+	var rules []HealthRule
+	for _, r := range s.Health {
+		if len(r.Categories) > 0 && !containsString(r.Categories, category) {
+			continue
+		}
+		if len(r.Origins) > 0 && !containsString(r.Origins, strings.ToUpper(originCode)) {
+			continue
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// programCategory returns the category of the specialist's program a
+// pathway names, falling back to study for study routes it doesn't know
+func (s *Specialist) programCategory(pathway string) string {
+	names := make([]string, len(s.Programs))
+	for i, p := range s.Programs {
+		names[i] = p.Name
+	}
+	if name := matchProgram(pathway, names); name != "" {
+		for _, p := range s.Programs {
+			if p.Name == name {
+				return p.Category
+			}
+		}
+	}
+	if studyPathwayPattern.MatchString(pathway) {
+		return "study"
+	}
+	return ""
+}
+
+// healthPromptContext lists the destination's health requirements for the
+// prompt, or "" when the knowledge base has none
+func (s *Specialist) healthPromptContext() string {
+	if len(s.Health) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\nHEALTH INSURANCE AND MEDICAL REQUIREMENTS (include those that apply in the document checklist and costs):\n")
+	for _, r := range s.Health {
+		line := "- " + r.Requirement
+		if len(r.Categories) > 0 {
+			line += " [" + strings.Join(r.Categories, ", ") + " routes]"
+		}
+		if len(r.Origins) > 0 {
+			line += " [applicants from " + strings.Join(r.Origins, ", ") + "]"
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// documentChecklistPattern matches the Document Checklist heading of deep
+// answers
+var documentChecklistPattern = regexp.MustCompile(`(?im)^#+[ \t]*document checklist[ \t]*$`)
+
+// applyHealthRequirements adds the destination's health-insurance and
+// medical-exam requirements for the recommended route to an answer: their
+// typical costs as a line after Cost, and the documents they need at the
+// end of the Document Checklist, or every requirement in a section after
+// the key details when there is no checklist. Answers are returned
+// unchanged when no rule applies.
+func applyHealthRequirements(markdown string, s *Specialist, originCode string) string {
+	rules := s.healthRules(s.programCategory(pathwayName(markdown)), originCode)
+	if len(rules) == 0 {
+		return markdown
+	}
+
+	var costs []string
+	for _, r := range rules {
+		if r.Cost == nil {
+			continue
+		}
+		cost := r.Cost.Item + " about " + formatFee(r.Cost.Amount, r.Cost.Currency)
+		if r.Cost.Per != "" {
+			cost += " per " + r.Cost.Per
+		}
+		costs = append(costs, cost)
+	}
+	if loc := costLinePattern.FindStringSubmatchIndex(markdown); loc != nil && len(costs) > 0 {
+		label := "Health costs:"
+		if loc[4] != -1 {
+			label = "**Health costs:**"
+		}
+		line := "\n" + markdown[loc[2]:loc[3]] + label + " " + joinList(costs) + " (typical, not in the fees above)"
+		markdown = markdown[:loc[1]] + line + markdown[loc[1]:]
+	}
+
+	var items, documents strings.Builder
+	for _, r := range rules {
+		if r.Document != "" {
+			fmt.Fprintf(&documents, "- %s: %s\n", r.Document, r.Requirement)
+			fmt.Fprintf(&items, "- %s: %s\n", r.Document, r.Requirement)
+		} else {
+			fmt.Fprintf(&items, "- %s\n", strings.ToUpper(r.Requirement[:1])+r.Requirement[1:])
+		}
+	}
+	if loc := documentChecklistPattern.FindStringIndex(markdown); loc != nil {
+		if documents.Len() == 0 {
+			return markdown
+		}
+		return appendToList(markdown, loc[1], documents.String())
+	}
+	return insertAfterKeyDetails(markdown, "**Health Insurance and Medical Requirements:**\n"+items.String())
+}
+
+// appendToList adds items to the end of the list that starts after offset
+func appendToList(markdown string, offset int, items string) string {
+	lines := strings.Split(markdown[offset:], "\n")
+	at, seen := 0, false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") {
+			at, seen = i+1, true
+		} else if seen && trimmed != "" {
+			break
+		}
+	}
+	if !seen {
+		at = 1
+	}
+	lines = append(lines[:at], append([]string{strings.TrimRight(items, "\n")}, lines[at:]...)...)
+	return markdown[:offset] + strings.Join(lines, "\n")
+}
@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+)
+
+// HookContext describes the task a hook runs for. Hooks must not modify
+// Metadata.
+type HookContext struct {
+	TaskID    string
+	SessionID string
+	Tenant    string   // "" for the default tenant
+	Query     string   // the redacted query; "" in OnTaskCreated
+	Metadata  Metadata // caller metadata of the task
+}
+
+// Hooks are the points in task processing where a plugin can step in, e.g.
+// to enrich prompts, moderate answers or attach artifacts of its own. Any
+// of them may be nil.
+type Hooks struct {
+	// OnTaskCreated runs once a task is stored, before its message is
+	// read, and may change the message. An error fails the task; wrap
+	// ErrInvalidProfile to tell the user their request can't be planned.
+	OnTaskCreated func(hc HookContext, message *Message) error
+
+	// BeforeLLMCall sees each prompt sent to the model for a task and
+	// returns the prompt to send. An error fails the call like a model
+	// error, so the answer may come from the knowledge base.
+	BeforeLLMCall func(hc HookContext, prompt string) (string, error)
+
+	// AfterLLMCall sees each response of the model and returns the text to
+	// use. Return an error wrapping ErrSafetyBlocked to refuse the answer;
+	// other errors are treated like model errors.
+	AfterLLMCall func(hc HookContext, prompt, response string, usage llm.TokenUsage) (string, error)
+
+	// OnTaskCompleted runs when an answer is ready, before the task is
+	// marked completed, and returns artifacts to attach alongside the
+	// agent's own. An error is logged and the task completes without them.
+	OnTaskCompleted func(hc HookContext, answer string) ([]Artifact, error)
+}
+
+// hookSet holds the registered hooks, run in registration order
+type hookSet struct {
+	mu    sync.RWMutex
+	hooks []Hooks
+}
+
+// RegisterHooks adds a plugin's hooks, run after those already registered
+func (a *MigrationAgent) RegisterHooks(h Hooks) {
+	a.hooks.mu.Lock()
+	defer a.hooks.mu.Unlock()
+	a.hooks.hooks = append(a.hooks.hooks, h)
+}
+
+// list returns the registered hooks
+func (s *hookSet) list() []Hooks {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hooks
+}
+
+// hookContext describes task to hooks
+func (a *MigrationAgent) hookContext(task *Task) HookContext {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return HookContext{
+		TaskID:    task.ID,
+		SessionID: task.SessionID,
+		Tenant:    task.tenant,
+		Query:     task.query,
+		Metadata:  task.Metadata,
+	}
+}
+
+// taskCreated runs the OnTaskCreated hooks, stopping at the first error
+func (a *MigrationAgent) taskCreated(task *Task, message *Message) error {
+	for _, h := range a.hooks.list() {
+		if h.OnTaskCreated == nil {
+			continue
+		}
+		if err := h.OnTaskCreated(a.hookContext(task), message); err != nil {
+			return fmt.Errorf("task hook: %w", err)
+		}
+	}
+	return nil
+}
+
+// hookedClient returns a copy of gemini that runs the BeforeLLMCall and
+// AfterLLMCall hooks for task, or gemini itself when there are none
+func (a *MigrationAgent) hookedClient(gemini *llm.GeminiClient, task *Task) *llm.GeminiClient {
+	var before, after []Hooks
+	for _, h := range a.hooks.list() {
+		if h.BeforeLLMCall != nil {
+			before = append(before, h)
+		}
+		if h.AfterLLMCall != nil {
+			after = append(after, h)
+		}
+	}
+	if len(before) == 0 && len(after) == 0 {
+		return gemini
+	}
+
+	return gemini.Intercepted(
+		func(prompt string) (string, error) {
+			hc := a.hookContext(task)
+			for _, h := range before {
+				var err error
+				if prompt, err = h.BeforeLLMCall(hc, prompt); err != nil {
+					return "", fmt.Errorf("before LLM call hook: %w", err)
+				}
+			}
+			return prompt, nil
+		},
+		func(prompt, response string, usage llm.TokenUsage) (string, error) {
+			hc := a.hookContext(task)
+			for _, h := range after {
+				var err error
+				if response, err = h.AfterLLMCall(hc, prompt, response, usage); err != nil {
+					return "", fmt.Errorf("after LLM call hook: %w", err)
+				}
+			}
+			return response, nil
+		},
+	)
+}
+
+// taskCompleted runs the OnTaskCompleted hooks and returns the artifacts
+// they add, with IDs filled in where a hook left them out
+func (a *MigrationAgent) taskCompleted(task *Task, answer string) []Artifact {
+	var artifacts []Artifact
+	for _, h := range a.hooks.list() {
+		if h.OnTaskCompleted == nil {
+			continue
+		}
+		extra, err := h.OnTaskCompleted(a.hookContext(task), answer)
+		if err != nil {
+			log.Printf("⚠️  Completion hook failed for task %s: %v", task.ID, err)
+			continue
+		}
+		for _, artifact := range extra {
+			if artifact.ArtifactID == "" {
+				artifact.ArtifactID = a.ids.NewID()
+			}
+			artifacts = append(artifacts, artifact)
+		}
+	}
+	return artifacts
+}
@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HorizonRule estimates how long a destination's routes take to lead to
+// permanent residence and citizenship, part of its specialist's knowledge
+// base
+type HorizonRule struct {
+	Program     string       `json:"program,omitempty"`    // a program it applies to, ahead of category rules
+	Categories  []string     `json:"categories,omitempty"` // program categories it applies to; none means all
+	Origins     []string     `json:"origins,omitempty"`    // ISO 3166 codes of the applicants it applies to; none means all
+	Residency   HorizonStage `json:"residency"`
+	Citizenship HorizonStage `json:"citizenship"`
+}
+
+// HorizonStage is how long reaching a status takes, in months from the
+// start of the route, and how it is reached
+type HorizonStage struct {
+	MinMonths int    `json:"minMonths"`
+	MaxMonths int    `json:"maxMonths"`
+	Via       string `json:"via"`
+}
+
+// horizonRule returns the first of the specialist's rules that applies to
+// the pathway for applicants from originCode ("" if unknown), or nil
+func (s *Specialist) horizonRule(pathway, originCode string) *HorizonRule {
+	category := s.programCategory(pathway)
+	for i, r := range s.Horizon {
+		if r.Program != "" && matchProgram(pathway, []string{r.Program}) == "" {
+			continue
+		}
+		if r.Program == "" && len(r.Categories) > 0 && !containsString(r.Categories, category) {
+			continue
+		}
+		if len(r.Origins) > 0 && !containsString(r.Origins, strings.ToUpper(originCode)) {
+			continue
+		}
+		return &s.Horizon[i]
+	}
+	return nil
+}
+
+// formatMonths describes a span of months, in years from two years up
+func formatMonths(months int) string {
+	if months < 24 {
+		return strconv.Itoa(months)
+	}
+	years := float64(months) / 12
+	if years == float64(int(years)) {
+		return strconv.Itoa(int(years))
+	}
+	return strconv.FormatFloat(float64(int(years*2+0.5))/2, 'f', -1, 64)
+}
+
+// describeStage says when a stage is reached, e.g. "about 2 to 3 years
+// (2027 to 2028)"
+func describeStage(stage HorizonStage, now time.Time) string {
+	unit := "years"
+	if stage.MaxMonths < 24 {
+		unit = "months"
+	}
+	span := formatMonths(stage.MinMonths) + " to " + formatMonths(stage.MaxMonths)
+	if stage.MinMonths < 24 && stage.MaxMonths >= 24 {
+		span = formatMonths(stage.MinMonths) + " months to " + formatMonths(stage.MaxMonths)
+	}
+	from, to := now.AddDate(0, stage.MinMonths, 0).Year(), now.AddDate(0, stage.MaxMonths, 0).Year()
+	years := strconv.Itoa(from)
+	if to != from {
+		years += " to " + strconv.Itoa(to)
+	}
+	return fmt.Sprintf("about %s %s (%s)", span, unit, years)
+}
+
+// applyHorizon adds a long-term outlook before the next step of an answer:
+// roughly when the recommended route leads to permanent residence and then
+// citizenship, from the specialist's rules. Routes without a rule are
+// returned unchanged.
+func applyHorizon(markdown string, s *Specialist, originCode string, now time.Time) string {
+	r := s.horizonRule(pathwayName(markdown), originCode)
+	if r == nil {
+		return markdown
+	}
+	var section strings.Builder
+	section.WriteString("**Long-Term Outlook:**\n")
+	fmt.Fprintf(&section, "- Permanent residence: %s, via %s\n", describeStage(r.Residency, now), r.Residency.Via)
+	fmt.Fprintf(&section, "- Citizenship: %s, after %s\n", describeStage(r.Citizenship, now), r.Citizenship.Via)
+	section.WriteString("Counted from when you start, assuming each application is approved first time; rules and processing times change.\n")
+	return insertBeforeNextStep(markdown, section.String())
+}
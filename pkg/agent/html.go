@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Output formats accepted in the outputFormat request parameter
+const (
+	OutputFormatMarkdown = "markdown"
+	OutputFormatHTML     = "html"
+	OutputFormatJSON     = "json" // the answer as a data part only
+)
+
+var (
+	boldPattern   = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	italicPattern = regexp.MustCompile(`\*([^*\s][^*]*?)\*`)
+	codePattern   = regexp.MustCompile("`([^`]+)`")
+	linkPattern   = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^\s)]+)\)`)
+	orderedItem   = regexp.MustCompile(`^\d+[.)]\s+`)
+)
+
+// renderMarkdownHTML converts the markdown produced by the LLM into HTML.
+// The input is escaped before any tags are emitted, so the only markup in
+// the result is the small set of elements generated here; raw HTML coming
+// back from the model is never passed through.
+func renderMarkdownHTML(markdown string) string {
+	var out strings.Builder
+	var paragraph []string
+	listTag := ""
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + strings.Join(paragraph, "<br>") + "</p>\n")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if listTag != "" {
+			out.WriteString("</" + listTag + ">\n")
+			listTag = ""
+		}
+	}
+	openList := func(tag string) {
+		if listTag != tag {
+			closeList()
+			out.WriteString("<" + tag + ">\n")
+			listTag = tag
+		}
+	}
+
+	for _, rawLine := range strings.Split(markdown, "\n") {
+		line := strings.TrimSpace(rawLine)
+
+		switch {
+		case line == "":
+			flushParagraph()
+			closeList()
+
+		case line == "---" || line == "***":
+			flushParagraph()
+			closeList()
+			out.WriteString("<hr>\n")
+
+		case strings.HasPrefix(line, "#"):
+			flushParagraph()
+			closeList()
+			level := 0
+			for level < len(line) && line[level] == '#' {
+				level++
+			}
+			if level > 6 {
+				level = 6
+			}
+			text := strings.TrimSpace(strings.TrimLeft(line, "#"))
+			tag := "h" + string(rune('0'+level))
+			out.WriteString("<" + tag + ">" + renderInline(text) + "</" + tag + ">\n")
+
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* "):
+			flushParagraph()
+			openList("ul")
+			out.WriteString("<li>" + renderInline(line[2:]) + "</li>\n")
+
+		case orderedItem.MatchString(line):
+			flushParagraph()
+			openList("ol")
+			out.WriteString("<li>" + renderInline(orderedItem.ReplaceAllString(line, "")) + "</li>\n")
+
+		default:
+			closeList()
+			paragraph = append(paragraph, renderInline(line))
+		}
+	}
+	flushParagraph()
+	closeList()
+
+	return strings.TrimSpace(out.String())
+}
+
+// renderInline escapes a single line and applies inline markdown styles
+func renderInline(text string) string {
+	escaped := html.EscapeString(text)
+	escaped = codePattern.ReplaceAllString(escaped, "<code>$1</code>")
+	escaped = linkPattern.ReplaceAllString(escaped, `<a href="$2" rel="noopener noreferrer">$1</a>`)
+	escaped = boldPattern.ReplaceAllString(escaped, "<strong>$1</strong>")
+	escaped = italicPattern.ReplaceAllString(escaped, "<em>$1</em>")
+	return escaped
+}
@@ -0,0 +1,156 @@
+package agent
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// jobResourceData is the curated list of job boards, recruiters and
+// professional associations, maintained with the code like the fee table
+//
+//go:embed job_resources.json
+var jobResourceData []byte
+
+// jobResourcesArtifactName names the artifact listing job-search resources
+const jobResourcesArtifactName = "Job Search Resources"
+
+// Kinds of job-search resource
+const (
+	JobBoard       = "board"
+	JobRecruiter   = "recruiter"
+	JobAssociation = "association"
+)
+
+// jobResourceKinds head each kind in the artifact, in order
+var jobResourceKinds = []struct {
+	Kind    string
+	Heading string
+}{
+	{JobBoard, "Job Boards"},
+	{JobRecruiter, "Recruiters"},
+	{JobAssociation, "Professional Associations"},
+}
+
+// JobResource is a place to look for work at one destination
+type JobResource struct {
+	Destination string   `json:"destination"`
+	Kind        string   `json:"kind"` // JobBoard, JobRecruiter or JobAssociation
+	Name        string   `json:"name"`
+	Professions []string `json:"professions,omitempty"` // lowercase words for the professions it serves; none means any
+	URL         string   `json:"url"`
+	Note        string   `json:"note,omitempty"`
+}
+
+// jobResourceTable is the versioned built-in list
+type jobResourceTable struct {
+	Version   string        `json:"version"`
+	Updated   string        `json:"updated"` // YYYY-MM-DD
+	Resources []JobResource `json:"resources"`
+}
+
+var jobResources = mustParseJobResources(jobResourceData)
+
+// mustParseJobResources parses the embedded list, which ships with the
+// binary, so a broken one is a build defect
+func mustParseJobResources(data []byte) jobResourceTable {
+	var table jobResourceTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		panic(fmt.Sprintf("invalid embedded job resources: %v", err))
+	}
+	if _, err := time.Parse("2006-01-02", table.Updated); err != nil || table.Version == "" {
+		panic("embedded job resources need a version and an updated date")
+	}
+	for i, r := range table.Resources {
+		if r.Name == "" || r.Destination == "" || (r.Kind != JobBoard && r.Kind != JobRecruiter && r.Kind != JobAssociation) {
+			panic(fmt.Sprintf("job resource %d needs a name, destination and known kind", i))
+		}
+		if u, err := url.Parse(r.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+			panic(fmt.Sprintf("job resource %q needs an https url", r.Name))
+		}
+	}
+	return table
+}
+
+// findJobResources returns the resources at destination for the
+// profession text mentions: those for the profession first, then those
+// open to any
+func findJobResources(destination, text string) []JobResource {
+	lower := " " + strings.ToLower(text) + " "
+	var specific, general []JobResource
+	for _, r := range jobResources.Resources {
+		if !strings.EqualFold(r.Destination, destination) {
+			continue
+		}
+		if len(r.Professions) == 0 {
+			general = append(general, r)
+			continue
+		}
+		for _, word := range r.Professions {
+			if parser.IndexWord(lower, word) != -1 || parser.IndexWord(lower, word+"s") != -1 {
+				specific = append(specific, r)
+				break
+			}
+		}
+	}
+	return append(specific, general...)
+}
+
+// jobResourcesArtifact lists where to look for a job offer at the
+// destination, for recommendations of routes that need one, as markdown
+// and as data. It returns nil for study routes and destinations without
+// resources.
+func jobResourcesArtifact(s *Specialist, pathway string, profile parser.UserProfile, ids IDGenerator) *Artifact {
+	if s.programCategory(pathway) == "study" {
+		return nil
+	}
+	found := findJobResources(s.Country, profile.Profession)
+	if len(found) == 0 {
+		return nil
+	}
+
+	profession := professionKeyword(profile.Profession)
+	var text strings.Builder
+	if profession != "" {
+		fmt.Fprintf(&text, "# Job Search Resources: %s in %s\n", titleWords(profession), countryName(s.Country))
+	} else {
+		fmt.Fprintf(&text, "# Job Search Resources: %s\n", s.Country)
+	}
+	for _, kind := range jobResourceKinds {
+		heading := false
+		for _, r := range found {
+			if r.Kind != kind.Kind {
+				continue
+			}
+			if !heading {
+				fmt.Fprintf(&text, "\n## %s\n", kind.Heading)
+				heading = true
+			}
+			fmt.Fprintf(&text, "- [%s](%s)", r.Name, r.URL)
+			if r.Note != "" {
+				text.WriteString(": " + r.Note)
+			}
+			text.WriteString("\n")
+		}
+	}
+	fmt.Fprintf(&text, "\nNever pay a recruiter or employer for a job offer or visa sponsorship. Resource list version %s.\n", jobResources.Version)
+
+	return &Artifact{
+		ArtifactID: ids.NewID(),
+		Name:       jobResourcesArtifactName,
+		Parts: []Part{
+			{Kind: "text", Text: text.String()},
+			{Kind: "data", Data: map[string]interface{}{
+				"destination": s.Country,
+				"profession":  profession,
+				"resources":   found,
+				"version":     jobResources.Version,
+			}},
+		},
+	}
+}
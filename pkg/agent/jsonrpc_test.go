@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNullIDIsAnsweredButMissingIDIsNot(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	a := NewMigrationAgent()
+
+	for _, tc := range []struct {
+		name     string
+		body     string
+		answered bool
+	}{
+		{"missing id", `{"jsonrpc": "2.0", "method": "tasks/get", "params": {"id": "unknown"}}`, false},
+		{"null id", `{"jsonrpc": "2.0", "id": null, "method": "tasks/get", "params": {"id": "unknown"}}`, true},
+		{"numeric id", `{"jsonrpc": "2.0", "id": 7, "method": "tasks/get", "params": {"id": "unknown"}}`, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/a2a/planner", strings.NewReader(tc.body)))
+
+			if !tc.answered {
+				if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+					t.Errorf("notification got %d %q, want 204 and no body", rec.Code, rec.Body)
+				}
+				return
+			}
+			var response map[string]json.RawMessage
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("got %d %q, want a JSON-RPC response: %v", rec.Code, rec.Body, err)
+			}
+			var request map[string]json.RawMessage
+			json.Unmarshal([]byte(tc.body), &request)
+			if id, ok := response["id"]; !ok || string(id) != string(request["id"]) {
+				t.Errorf("response id = %s, want %s", id, request["id"])
+			}
+		})
+	}
+}
+
+func TestReadRPCTellsNullIDFromMissingID(t *testing.T) {
+	for body, notification := range map[string]bool{
+		`{"jsonrpc": "2.0", "method": "tasks/send"}`:             true,
+		`{"jsonrpc": "2.0", "id": null, "method": "tasks/send"}`: false,
+		`{"jsonrpc": "2.0", "id": "a", "method": "tasks/send"}`:  false,
+	} {
+		_, read := readRPC(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/a2a/planner", strings.NewReader(body)))
+		if !read.ok {
+			t.Fatalf("readRPC(%s) not recognized as JSON-RPC", body)
+		}
+		if (read.envelope.ID == nil) != notification {
+			t.Errorf("readRPC(%s) id = %q, want notification %v", body, read.envelope.ID, notification)
+		}
+	}
+}
+
+func TestReadRPCReadsTheBodyOnce(t *testing.T) {
+	r, first := readRPC(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/a2a/planner", strings.NewReader(`{"jsonrpc": "2.0", "id": 1, "method": "tasks/get"}`)))
+	_, second := readRPC(httptest.NewRecorder(), r)
+	if first != second {
+		t.Error("readRPC read the body again instead of using the one in the context")
+	}
+	if second.envelope.Method != "tasks/get" {
+		t.Errorf("method = %q, want tasks/get", second.envelope.Method)
+	}
+}
+
+func TestOversizedRequestIsRefused(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	a := NewMigrationAgent()
+
+	body := `{"jsonrpc": "2.0", "id": 1, "method": "tasks/send", "params": {"padding": "` + strings.Repeat("x", maxRPCBody) + `"}}`
+	for _, path := range []string{"/a2a/planner", "/mcp"} {
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s answered %d, want 413", path, rec.Code)
+		}
+	}
+}
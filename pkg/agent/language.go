@@ -0,0 +1,222 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// languageNames are the response languages the agent can be asked for,
+// keyed by ISO 639-1 code
+var languageNames = map[string]string{
+	"en": "English",
+	"fr": "French",
+	"es": "Spanish",
+	"pt": "Portuguese",
+	"de": "German",
+	"it": "Italian",
+	"nl": "Dutch",
+	"tr": "Turkish",
+	"sw": "Swahili",
+	"yo": "Yoruba",
+	"ha": "Hausa",
+	"ar": "Arabic",
+	"fa": "Persian",
+	"ru": "Russian",
+	"uk": "Ukrainian",
+	"hi": "Hindi",
+	"bn": "Bengali",
+	"zh": "Chinese",
+	"ja": "Japanese",
+	"ko": "Korean",
+}
+
+// languageStopwords are common words that identify Latin-script languages;
+// words shared by several languages are left out
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "i", "want", "to", "my", "with", "from", "move", "am", "is", "have", "what", "how", "can"},
+	"fr": {"je", "le", "la", "les", "et", "suis", "veux", "pour", "avec", "mon", "une", "des", "du", "au", "vers", "est"},
+	"es": {"el", "los", "las", "y", "soy", "quiero", "para", "con", "mi", "una", "del", "desde", "emigrar", "es", "tengo"},
+	"pt": {"eu", "os", "as", "e", "sou", "quero", "para", "com", "meu", "uma", "do", "da", "em", "tenho", "não"},
+	"de": {"ich", "der", "die", "das", "und", "bin", "möchte", "nach", "mit", "mein", "ein", "eine", "aus", "habe", "ist"},
+	"it": {"il", "gli", "e", "sono", "voglio", "per", "con", "mio", "una", "della", "trasferirmi", "ho", "è"},
+	"nl": {"ik", "de", "het", "en", "ben", "wil", "naar", "met", "mijn", "een", "uit", "heb", "is"},
+	"tr": {"ben", "ve", "bir", "için", "istiyorum", "ile", "benim", "gitmek", "var", "mı"},
+	"sw": {"mimi", "na", "kwa", "ya", "nataka", "kuhamia", "ni", "wa", "katika", "kutoka"},
+	"yo": {"mo", "fẹ", "si", "ni", "ati", "lati", "jẹ", "mi", "ṣe", "lọ"},
+	"ha": {"ina", "son", "zuwa", "da", "ni", "na", "daga", "kuma", "yi", "ake"},
+}
+
+// detectLanguage guesses the ISO 639-1 code of text from its script or,
+// for Latin script, its common words. It returns "" when unsure.
+func detectLanguage(text string) string {
+	scripts := make(map[string]int)
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			scripts["ja"]++
+		case unicode.Is(unicode.Han, r):
+			scripts["zh"]++
+		case unicode.Is(unicode.Hangul, r):
+			scripts["ko"]++
+		case unicode.Is(unicode.Arabic, r):
+			scripts["ar"]++
+		case unicode.Is(unicode.Devanagari, r):
+			scripts["hi"]++
+		case unicode.Is(unicode.Bengali, r):
+			scripts["bn"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			scripts["ru"]++
+			if strings.ContainsRune("іїєґІЇЄҐ", r) {
+				scripts["uk"] += 10
+			}
+		}
+	}
+	if scripts["ja"] > 0 {
+		return "ja"
+	}
+	if scripts["uk"] > 0 {
+		return "uk"
+	}
+	if scripts["ar"] > 0 && strings.ContainsAny(text, "پچژگکی") {
+		return "fa"
+	}
+	best, bestCount := "", 0
+	for code, count := range scripts {
+		if count > bestCount && code != "uk" {
+			best, bestCount = code, count
+		}
+	}
+	if bestCount >= 2 {
+		return best
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	scores := make(map[string]int)
+	for _, word := range words {
+		for code, stopwords := range content().LanguageStopwords {
+			for _, stopword := range stopwords {
+				if word == stopword {
+					scores[code]++
+					break
+				}
+			}
+		}
+	}
+
+	best, bestScore, runnerUp := "", 0, 0
+	for code, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, runnerUp = code, score, bestScore
+		case score > runnerUp:
+			runnerUp = score
+		}
+	}
+	if bestScore < 2 || bestScore == runnerUp {
+		return ""
+	}
+	return best
+}
+
+// normalizeLanguage accepts a code ("fr"), a locale ("fr-CA") or an English
+// name ("French") and returns the ISO 639-1 code, or "" if unsupported
+func normalizeLanguage(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if i := strings.IndexAny(value, "-_"); i != -1 {
+		value = value[:i]
+	}
+	if _, ok := languageNames[value]; ok {
+		return value
+	}
+	for code, name := range languageNames {
+		if strings.ToLower(name) == value {
+			return code
+		}
+	}
+	return ""
+}
+
+// defaultLanguage reads DEFAULT_LANGUAGE (default English), used when the
+// query's language can't be detected
+func defaultLanguage() string {
+	value := os.Getenv("DEFAULT_LANGUAGE")
+	if value == "" {
+		return "en"
+	}
+	code := normalizeLanguage(value)
+	if code == "" {
+		log.Printf("⚠️  Unsupported DEFAULT_LANGUAGE %q, using English", value)
+		return "en"
+	}
+	return code
+}
+
+// responseLanguage picks the language to answer in: the language param,
+// else a "language" entry in the caller's metadata, else the query's
+// detected language, else the configured default
+func (a *MigrationAgent) responseLanguage(query string, metadata Metadata, param string) string {
+	if code := normalizeLanguage(param); code != "" {
+		return code
+	}
+	if value, ok := metadata["language"].(string); ok {
+		if code := normalizeLanguage(value); code != "" {
+			return code
+		}
+	}
+	if code := detectLanguage(query); code != "" {
+		return code
+	}
+	return a.defaultLanguage
+}
+
+// languageTagPattern matches well-formed BCP 47 tags: a language subtag,
+// then optional script, region and variant subtags
+var languageTagPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z]{4})?(-(?:[A-Za-z]{2}|[0-9]{3}))?(-(?:[A-Za-z0-9]{5,8}|[0-9][A-Za-z0-9]{3}))*$`)
+
+// validateLanguage rejects a language param that is neither a language
+// name nor a well-formed BCP 47 tag, or that the agent can't answer in
+func validateLanguage(language string) error {
+	if language == "" {
+		return nil
+	}
+	if !isLanguageName(language) && !languageTagPattern.MatchString(language) {
+		return fmt.Errorf("malformed language %q (expected a BCP 47 tag such as \"pt-BR\" or a language name)", language)
+	}
+	if normalizeLanguage(language) != "" {
+		return nil
+	}
+	codes := make([]string, 0, len(languageNames))
+	for code := range languageNames {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return fmt.Errorf("unsupported language %q (expected one of %s)", language, strings.Join(codes, ", "))
+}
+
+// isLanguageName reports whether value names a supported language, such as
+// "Spanish"
+func isLanguageName(value string) bool {
+	for _, name := range languageNames {
+		if strings.EqualFold(strings.TrimSpace(value), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// languageInstruction tells the model which language to answer in while
+// keeping the parts other code reads stable
+func languageInstruction(code string) string {
+	name, ok := languageNames[code]
+	if !ok || code == "en" {
+		return ""
+	}
+	return "\nRESPONSE LANGUAGE: Write the entire answer in " + name + ". Keep the markdown structure, the literal \"Timeline\" label and every ISO date (YYYY-MM-DD) exactly as in the format below so milestones can be added to calendars; translate everything else, including visa descriptions.\n"
+}
@@ -0,0 +1,33 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// offlineNotice labels answers written with LLM_MODE=off
+const offlineNotice = "ℹ️ This answer comes from our curated visa knowledge base and calculators. It is general guidance, not a personalized recommendation."
+
+// offlineAnswer writes the templated knowledge-base answer used when
+// LLM_MODE is off. Destinations without a specialist have no curated data,
+// so the user is asked to name one that does.
+func offlineAnswer(specialist *Specialist, profile parser.UserProfile, query string, style AnswerStyle) (string, error) {
+	if specialist == generalist || len(specialist.Programs) == 0 {
+		var countries []string
+		for _, s := range content().Specialists {
+			countries = append(countries, s.Country)
+		}
+		return "", fmt.Errorf("%w: no curated data for the destination; covered destinations are %s", ErrInvalidProfile, joinList(countries))
+	}
+	return renderKnowledgeAnswer(specialist, profile, query, style, offlineNotice), nil
+}
+
+// offlineTranscriber stands in for speech-to-text when LLM_MODE is off, as
+// every provider sends the recording to an external model
+type offlineTranscriber struct{}
+
+func (offlineTranscriber) Transcribe(mimeType string, audio []byte) (string, error) {
+	return "", llm.ErrOff
+}
@@ -0,0 +1,187 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// Locale controls how amounts, dates and numbers are written in answers
+type Locale struct {
+	Tag         string   // BCP 47 tag, e.g. en-NG
+	Country     string   // English country name
+	Demonyms    []string // adjectives that identify the country in queries; never language names
+	Currency    string   // ISO 4217 code of the local currency
+	Symbol      string   // local currency symbol
+	DateLayout  string   // Go time layout for dates
+	Thousands   string
+	Decimal     string
+	SymbolAfter bool // amounts are written "5.000 $" rather than "$5,000"
+}
+
+// locales are keyed by ISO 3166 country code
+var locales = map[string]Locale{
+	"NG": {Tag: "en-NG", Country: "nigeria", Demonyms: []string{"nigerian"}, Currency: "NGN", Symbol: "₦", DateLayout: "02/01/2006", Thousands: ",", Decimal: "."},
+	"GH": {Tag: "en-GH", Country: "ghana", Demonyms: []string{"ghanaian"}, Currency: "GHS", Symbol: "GH₵", DateLayout: "02/01/2006", Thousands: ",", Decimal: "."},
+	"KE": {Tag: "en-KE", Country: "kenya", Demonyms: []string{"kenyan"}, Currency: "KES", Symbol: "KSh", DateLayout: "02/01/2006", Thousands: ",", Decimal: "."},
+	"ZA": {Tag: "en-ZA", Country: "south africa", Demonyms: []string{"south african"}, Currency: "ZAR", Symbol: "R", DateLayout: "2006/01/02", Thousands: " ", Decimal: ","},
+	"EG": {Tag: "en-EG", Country: "egypt", Demonyms: []string{"egyptian"}, Currency: "EGP", Symbol: "E£", DateLayout: "02/01/2006", Thousands: ",", Decimal: "."},
+	"IN": {Tag: "en-IN", Country: "india", Demonyms: []string{"indian"}, Currency: "INR", Symbol: "₹", DateLayout: "02/01/2006", Thousands: ",", Decimal: "."},
+	"PK": {Tag: "en-PK", Country: "pakistan", Demonyms: []string{"pakistani"}, Currency: "PKR", Symbol: "Rs", DateLayout: "02/01/2006", Thousands: ",", Decimal: "."},
+	"BD": {Tag: "en-BD", Country: "bangladesh", Demonyms: []string{"bangladeshi"}, Currency: "BDT", Symbol: "৳", DateLayout: "02/01/2006", Thousands: ",", Decimal: "."},
+	"PH": {Tag: "en-PH", Country: "philippines", Demonyms: []string{"filipino", "filipina"}, Currency: "PHP", Symbol: "₱", DateLayout: "01/02/2006", Thousands: ",", Decimal: "."},
+	"CN": {Tag: "zh-CN", Country: "china", Currency: "CNY", Symbol: "¥", DateLayout: "2006/01/02", Thousands: ",", Decimal: "."},
+	"BR": {Tag: "pt-BR", Country: "brazil", Demonyms: []string{"brazilian"}, Currency: "BRL", Symbol: "R$", DateLayout: "02/01/2006", Thousands: ".", Decimal: ","},
+	"MX": {Tag: "es-MX", Country: "mexico", Demonyms: []string{"mexican"}, Currency: "MXN", Symbol: "MX$", DateLayout: "02/01/2006", Thousands: ",", Decimal: "."},
+	"US": {Tag: "en-US", Country: "united states", Demonyms: []string{"american"}, Currency: "USD", Symbol: "$", DateLayout: "01/02/2006", Thousands: ",", Decimal: "."},
+	"GB": {Tag: "en-GB", Country: "united kingdom", Demonyms: []string{"british"}, Currency: "GBP", Symbol: "£", DateLayout: "02/01/2006", Thousands: ",", Decimal: "."},
+	"DE": {Tag: "de-DE", Country: "germany", Currency: "EUR", Symbol: "€", DateLayout: "02.01.2006", Thousands: ".", Decimal: ",", SymbolAfter: true},
+	"FR": {Tag: "fr-FR", Country: "france", Currency: "EUR", Symbol: "€", DateLayout: "02/01/2006", Thousands: " ", Decimal: ",", SymbolAfter: true},
+	"ES": {Tag: "es-ES", Country: "spain", Currency: "EUR", Symbol: "€", DateLayout: "02/01/2006", Thousands: ".", Decimal: ",", SymbolAfter: true},
+}
+
+// languageRegions picks the country for a locale given without a region
+var languageRegions = map[string]string{
+	"en": "US", "de": "DE", "fr": "FR", "es": "ES", "pt": "BR", "zh": "CN",
+}
+
+var (
+	isoDatePattern = regexp.MustCompile(`\b(\d{4}-\d{2}-\d{2})\b`)
+	usdPattern     = regexp.MustCompile(`\$\s?(\d{1,3}(?:,\d{3})+|\d+)(\.\d{1,2})?([kK]\b)?`)
+	originPrefix   = regexp.MustCompile(`(?:from|live in|living in|based in|resident in|citizen of)\s+(?:the\s+)?$`)
+)
+
+// lookupLocale resolves a BCP 47 tag such as "en-NG" or "de", reporting
+// false if its region is unknown
+func lookupLocale(tag string) (Locale, bool) {
+	parts := strings.FieldsFunc(tag, func(r rune) bool { return r == '-' || r == '_' })
+	if len(parts) == 0 {
+		return Locale{}, false
+	}
+	region := ""
+	if len(parts) > 1 {
+		region = strings.ToUpper(parts[len(parts)-1])
+	} else {
+		region = languageRegions[strings.ToLower(parts[0])]
+	}
+	locale, ok := locales[region]
+	return locale, ok
+}
+
+// originLocale finds the locale of the country the user says they come
+// from or live in, preferring the earliest mention
+func originLocale(query string) (Locale, bool) {
+	q := " " + strings.ToLower(query) + " "
+	best, bestPos := Locale{}, -1
+	for _, locale := range locales {
+		pos := -1
+		if idx := parser.IndexWord(q, locale.Country); idx != -1 && originPrefix.MatchString(q[:idx]) {
+			pos = idx
+		}
+		for _, demonym := range locale.Demonyms {
+			if idx := parser.IndexWord(q, demonym); idx != -1 && (pos == -1 || idx < pos) {
+				pos = idx
+			}
+		}
+		if pos != -1 && (bestPos == -1 || pos < bestPos) {
+			best, bestPos = locale, pos
+		}
+	}
+	return best, bestPos != -1
+}
+
+// exchangeRates reads EXCHANGE_RATES as comma-separated CODE=rate pairs,
+// each the number of units of that currency per US dollar
+func exchangeRates() map[string]float64 {
+	rates := make(map[string]float64)
+	for _, entry := range strings.Split(os.Getenv("EXCHANGE_RATES"), ",") {
+		code, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || rate <= 0 {
+			log.Printf("⚠️  Ignoring invalid EXCHANGE_RATES entry %q", entry)
+			continue
+		}
+		rates[strings.ToUpper(strings.TrimSpace(code))] = rate
+	}
+	return rates
+}
+
+// formatNumber writes n with the locale's separators and the given number
+// of decimals
+func (l Locale) formatNumber(n float64, decimals int) string {
+	s := strconv.FormatFloat(n, 'f', decimals, 64)
+	whole, frac, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(l.Thousands)
+		}
+		b.WriteRune(c)
+	}
+	if frac != "" {
+		b.WriteString(l.Decimal + frac)
+	}
+	return b.String()
+}
+
+// formatAmount writes an amount with a currency symbol in the locale's style
+func (l Locale) formatAmount(n float64, decimals int, symbol string) string {
+	if l.SymbolAfter {
+		return l.formatNumber(n, decimals) + " " + symbol
+	}
+	return symbol + l.formatNumber(n, decimals)
+}
+
+// localizeMarkdown rewrites ISO dates and US dollar amounts in an answer
+// for the locale. Dollar amounts keep their value; when a rate for the
+// local currency is known, an approximate local amount is added.
+func localizeMarkdown(markdown string, locale Locale, rates map[string]float64) string {
+	markdown = isoDatePattern.ReplaceAllStringFunc(markdown, func(match string) string {
+		date, err := time.Parse("2006-01-02", match)
+		if err != nil {
+			return match
+		}
+		return date.Format(locale.DateLayout)
+	})
+
+	rate := rates[locale.Currency]
+	return usdPattern.ReplaceAllStringFunc(markdown, func(match string) string {
+		m := usdPattern.FindStringSubmatch(match)
+		amount, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", "")+m[2], 64)
+		if err != nil {
+			return match
+		}
+		if m[3] != "" {
+			amount *= 1000
+		}
+		decimals := 0
+		if m[2] != "" && m[3] == "" {
+			decimals = 2
+		}
+
+		formatted := locale.formatAmount(amount, decimals, "$")
+		if rate > 0 && locale.Currency != "USD" {
+			formatted += fmt.Sprintf(" (≈ %s)", locale.formatAmount(amount*rate, 0, locale.Symbol))
+		}
+		return formatted
+	})
+}
+
+// answerLocale picks the locale for an answer: the request's locale option,
+// else the user's origin country, else none (answers are left as written)
+func answerLocale(requested, query string) (Locale, bool) {
+	if requested != "" {
+		return lookupLocale(requested)
+	}
+	return originLocale(query)
+}
@@ -0,0 +1,417 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// mcpProtocolVersion is the Model Context Protocol revision implemented
+const mcpProtocolVersion = "2025-06-18"
+
+// MCPTool describes a tool in tools/list
+type MCPTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// MCPToolResult is the result of tools/call
+type MCPToolResult struct {
+	Content           []MCPContent `json:"content"`
+	StructuredContent interface{}  `json:"structuredContent,omitempty"`
+	IsError           bool         `json:"isError,omitempty"`
+}
+
+// MCPContent is a content block in a tool result
+type MCPContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// mcpTools are the capabilities exposed to MCP clients
+var mcpTools = []MCPTool{
+	{
+		Name:        "get_migration_pathway",
+		Description: "Recommend the single best migration pathway for a profile described in natural language (profession, origin, destination, budget), with costs, requirements and a dated timeline.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "The person's situation, e.g. \"Nurse from India wanting to move to UK with $3000\"",
+				},
+				"detail": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{DetailBrief, DetailStandard, DetailDeep},
+					"description": "Answer length: a short paragraph, the standard recommendation, or a multi-section guide",
+				},
+				"tone": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{ToneFormal, ToneEncouraging, TonePlainLanguage},
+					"description": "Voice of the answer; plain-language writes B1-level English",
+				},
+				"userId": map[string]interface{}{
+					"type":        "string",
+					"description": "Your ID for the end user, counted against their daily quota",
+				},
+			},
+			"required": []string{"query"},
+		},
+	},
+	{
+		Name:        "calculate_crs",
+		Description: "Estimate a Canadian Express Entry Comprehensive Ranking System (CRS) score for a single applicant.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"age": map[string]interface{}{"type": "integer", "minimum": 17},
+				"education": map[string]interface{}{
+					"type": "string",
+					"enum": []string{parser.EducationSecondary, parser.EducationOneYear, parser.EducationTwoYear, parser.EducationBachelor, parser.EducationTwoOrMore, parser.EducationMaster, parser.EducationDoctorate},
+				},
+				"clb":                     map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 12, "description": "First official language level (Canadian Language Benchmark)"},
+				"ielts":                   map[string]interface{}{"type": "number", "minimum": 0, "maximum": 9, "description": "Overall IELTS General band, used when clb is not given"},
+				"foreignExperienceYears":  map[string]interface{}{"type": "integer", "minimum": 0},
+				"canadianExperienceYears": map[string]interface{}{"type": "integer", "minimum": 0},
+				"provincialNomination":    map[string]interface{}{"type": "boolean"},
+			},
+			"required": []string{"age", "education"},
+		},
+	},
+	{
+		Name:        "simulate_crs",
+		Description: "Simulate how hypothetical changes (a better IELTS score, a master's degree, Canadian work experience, a provincial nomination) would change a Canadian Express Entry CRS score and eligibility, reporting the difference from the current profile.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query":   map[string]interface{}{"type": "string", "description": "The current profile in words, e.g. \"I'm 29 with a bachelor's degree, IELTS 6.5 and 3 years of experience\""},
+				"profile": crsChangesSchema("The current profile; overrides anything read from query"),
+				"scenarios": map[string]interface{}{
+					"type":     "array",
+					"minItems": 1,
+					"maxItems": maxScenarios,
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"label":   map[string]interface{}{"type": "string"},
+							"whatIf":  map[string]interface{}{"type": "string", "description": "The change in words, e.g. \"IELTS 8\" or \"a master's degree\""},
+							"changes": crsChangesSchema("The changed fields"),
+						},
+					},
+				},
+			},
+			"required": []string{"scenarios"},
+		},
+	},
+	{
+		Name:        "list_visa_programs",
+		Description: "List the visa and migration programs this agent specialises in, optionally for one destination country.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"country": map[string]interface{}{"type": "string", "description": "Destination country, e.g. Canada or UK"},
+			},
+		},
+	},
+}
+
+// crsChangesSchema describes CRS profile fields, all optional
+func crsChangesSchema(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"description": description,
+		"properties": map[string]interface{}{
+			"age": map[string]interface{}{"type": "integer", "minimum": 17},
+			"education": map[string]interface{}{
+				"type": "string",
+				"enum": []string{parser.EducationSecondary, parser.EducationOneYear, parser.EducationTwoYear, parser.EducationBachelor, parser.EducationTwoOrMore, parser.EducationMaster, parser.EducationDoctorate},
+			},
+			"clb":                     map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 12},
+			"ielts":                   map[string]interface{}{"type": "number", "minimum": 0, "maximum": 9, "description": "Overall IELTS General band, used when clb is not given"},
+			"foreignExperienceYears":  map[string]interface{}{"type": "integer", "minimum": 0},
+			"canadianExperienceYears": map[string]interface{}{"type": "integer", "minimum": 0},
+			"provincialNomination":    map[string]interface{}{"type": "boolean"},
+		},
+	}
+}
+
+// ServeMCP handles POST /mcp, the Streamable HTTP transport for MCP. Every
+// response is returned as a single JSON body; no server-initiated stream is
+// offered. Register mounts it behind Authenticate and RateLimit, like the
+// A2A endpoint.
+func (a *MigrationAgent) ServeMCP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r, body := readRPC(w, r)
+	if body.tooLarge() {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, body.err.Error())
+		return
+	}
+	if body.err != nil {
+		a.sendError(w, body.err, ErrCodeParse, errorMessages[ErrCodeParse], nil)
+		return
+	}
+	var req JSONRPCRequest
+	if err := json.Unmarshal(body.raw, &req); err != nil {
+		a.sendError(w, err, ErrCodeParse, errorMessages[ErrCodeParse], nil)
+		return
+	}
+
+	// With tenants configured, tool calls run as the tenant whose key the
+	// request carries
+	tenant, err := requestTenant(r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		a.sendError(w, err, ErrCodeUnauthenticated, errorMessages[ErrCodeUnauthenticated], req.ID)
+		return
+	}
+	req.tenant = tenant
+
+	resp := a.handleMCPRequest(req)
+	if resp == nil {
+		// Notifications are acknowledged without a body
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ServeMCPStdio runs the MCP stdio transport: newline-delimited JSON-RPC on
+// in and out, as launched by desktop and IDE MCP clients
+func (a *MigrationAgent) ServeMCPStdio(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	encoder := json.NewEncoder(out)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var req JSONRPCRequest
+		var resp *JSONRPCResponse
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			resp = &JSONRPCResponse{JSONRPC: "2.0", Error: &RPCError{Code: ErrCodeParse, Message: errorMessages[ErrCodeParse], Data: err.Error()}}
+		} else {
+			resp = a.handleMCPRequest(req)
+		}
+		if resp == nil {
+			continue
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return fmt.Errorf("failed to write MCP response: %v", err)
+		}
+	}
+	return scanner.Err()
+}
+
+// handleMCPRequest dispatches an MCP method, returning nil for notifications
+func (a *MigrationAgent) handleMCPRequest(req JSONRPCRequest) *JSONRPCResponse {
+	if req.notification() || strings.HasPrefix(req.Method, "notifications/") {
+		return nil
+	}
+
+	resp := &JSONRPCResponse{JSONRPC: "2.0", ID: req.ID}
+	switch req.Method {
+	case "initialize":
+		resp.Result = map[string]interface{}{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo": map[string]string{
+				"name":    "migration-pathways-agent",
+				"version": agentVersion(),
+			},
+			"instructions": "Use get_migration_pathway for personalised recommendations, calculate_crs for Canadian Express Entry scores, simulate_crs for what-if changes to them and list_visa_programs to browse supported routes.",
+		}
+	case "ping":
+		resp.Result = map[string]interface{}{}
+	case "tools/list":
+		resp.Result = map[string]interface{}{"tools": mcpTools}
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := remarshal(req.Params, &params); err != nil {
+			resp.Error = &RPCError{Code: ErrCodeInvalidParams, Message: errorMessages[ErrCodeInvalidParams], Data: err.Error()}
+			break
+		}
+		result, err := a.callMCPTool(req.tenant, params.Name, params.Arguments)
+		if err != nil {
+			resp.Error = &RPCError{Code: ErrCodeInvalidParams, Message: err.Error()}
+			break
+		}
+		resp.Result = result
+	default:
+		resp.Error = &RPCError{Code: ErrCodeMethodNotFound, Message: errorMessages[ErrCodeMethodNotFound]}
+	}
+	return resp
+}
+
+// callMCPTool runs a tool. Unknown tools and malformed arguments are
+// protocol errors; failures while running a tool are reported in the result.
+// Tasks run as tenant, nil for the default tenant and the stdio transport.
+func (a *MigrationAgent) callMCPTool(tenant *Tenant, name string, arguments json.RawMessage) (*MCPToolResult, error) {
+	if len(arguments) == 0 {
+		arguments = json.RawMessage("{}")
+	}
+
+	switch name {
+	case "get_migration_pathway":
+		var args struct {
+			Query  string `json:"query"`
+			Detail string `json:"detail"`
+			Tone   string `json:"tone"`
+			UserID string `json:"userId"`
+		}
+		if err := json.Unmarshal(arguments, &args); err != nil || strings.TrimSpace(args.Query) == "" {
+			return nil, fmt.Errorf("get_migration_pathway requires a query string")
+		}
+		if err := validateDetail(args.Detail); err != nil {
+			return nil, err
+		}
+		if err := validateTone(args.Tone); err != nil {
+			return nil, err
+		}
+
+		if err := a.userQuota.Take(tenant, args.UserID); err != nil {
+			return mcpText(err.Error(), true), nil
+		}
+
+		// Calls only share a session when they name the same user
+		opts := TaskOptions{Detail: args.Detail, Tone: args.Tone, Tenant: tenant.id()}
+		if args.UserID != "" {
+			opts.SessionID = "mcp:" + args.UserID
+		}
+		message := Message{Role: "user", Parts: []Part{{Kind: "text", Text: args.Query}}}
+		task, err := a.ProcessTask(a.ids.NewID(), message, opts)
+		if err != nil {
+			return mcpText(classifyFailure(err).Message, true), nil
+		}
+		text := ""
+		if task.Status.Message != nil && len(task.Status.Message.Parts) > 0 {
+			text = task.Status.Message.Parts[0].Text
+		}
+		return mcpText(text, task.Status.State != TaskStateCompleted), nil
+
+	case "calculate_crs":
+		var args struct {
+			parser.CRSInput
+			IELTS float64 `json:"ielts"`
+		}
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return nil, fmt.Errorf("invalid calculate_crs arguments: %v", err)
+		}
+		if args.IELTS < 0 || args.IELTS > 9 {
+			return nil, fmt.Errorf("calculate_crs: ielts must be a band between 0 and 9")
+		}
+		if args.CLB == 0 && args.IELTS > 0 {
+			args.CLB = parser.IELTSToCLB(args.IELTS)
+		}
+		if err := validateCRSInput(args.CRSInput); err != nil {
+			return nil, fmt.Errorf("calculate_crs: %v", err)
+		}
+
+		breakdown := CalculateCRS(args.CRSInput)
+		result := mcpText(fmt.Sprintf("Estimated CRS score: %d (age %d, education %d, language %d, Canadian experience %d, transferability %d, additional %d)",
+			breakdown.Total, breakdown.Age, breakdown.Education, breakdown.Language, breakdown.CanadianExperience, breakdown.Transferability, breakdown.Additional), false)
+		result.StructuredContent = breakdown
+		return result, nil
+
+	case "simulate_crs":
+		var args SimulateParams
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return nil, fmt.Errorf("invalid simulate_crs arguments: %v", err)
+		}
+		sim, err := Simulate(args)
+		if err != nil {
+			return nil, fmt.Errorf("simulate_crs: %v", err)
+		}
+		result := mcpText(sim.Summary, false)
+		result.StructuredContent = sim
+		return result, nil
+
+	case "list_visa_programs":
+		var args struct {
+			Country string `json:"country"`
+		}
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return nil, fmt.Errorf("invalid list_visa_programs arguments: %v", err)
+		}
+
+		programs := VisaPrograms(args.Country)
+		if len(programs) == 0 {
+			return mcpText(fmt.Sprintf("No specialist programs are available for %q.", args.Country), false), nil
+		}
+		var b strings.Builder
+		for _, p := range programs {
+			fmt.Fprintf(&b, "- %s (%s, %s): %s\n", p.Name, p.Country, p.Category, p.Summary)
+		}
+		result := mcpText(b.String(), false)
+		result.StructuredContent = map[string]interface{}{"programs": programs}
+		return result, nil
+
+	default:
+		return nil, fmt.Errorf("unknown tool %q", name)
+	}
+}
+
+// validateCRSInput checks calculate_crs arguments against the limits its
+// input schema declares, since clients aren't bound to honour them
+func validateCRSInput(in parser.CRSInput) error {
+	switch {
+	case in.Age < 17:
+		return fmt.Errorf("age must be at least 17")
+	case in.CLB < 0 || in.CLB > 12:
+		return fmt.Errorf("clb must be between 0 and 12")
+	case in.ForeignExperience < 0:
+		return fmt.Errorf("foreignExperienceYears can't be negative")
+	case in.CanadianExperience < 0:
+		return fmt.Errorf("canadianExperienceYears can't be negative")
+	}
+	if _, ok := crsEducationPoints[in.Education]; !ok {
+		return fmt.Errorf("unknown education %q", in.Education)
+	}
+	return nil
+}
+
+// mcpText builds a tool result with a single text block
+func mcpText(text string, isError bool) *MCPToolResult {
+	return &MCPToolResult{
+		Content: []MCPContent{{Type: "text", Text: text}},
+		IsError: isError,
+	}
+}
+
+// remarshal converts loosely typed params into a concrete struct
+func remarshal(in interface{}, out interface{}) error {
+	encoded, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, out)
+}
+
+// agentVersion returns the version advertised on the agent card
+func agentVersion() string {
+	var card struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(agentCardData, &card); err != nil {
+		log.Printf("failed to read agent card version: %v", err)
+	}
+	return card.Version
+}
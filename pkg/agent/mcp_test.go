@@ -0,0 +1,163 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// mcpResponse is a JSON-RPC response with the result left raw
+type mcpResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+	ID     interface{}     `json:"id"`
+}
+
+// postMCP sends a request to /mcp and decodes the response, if any
+func postMCP(t *testing.T, a *MigrationAgent, body string) (*httptest.ResponseRecorder, mcpResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)))
+	var resp mcpResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("got %q, want a JSON-RPC response: %v", rec.Body, err)
+		}
+	}
+	return rec, resp
+}
+
+func TestMCPInitializeAndToolsList(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	a := NewMigrationAgent()
+
+	_, resp := postMCP(t, a, `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2025-06-18", "capabilities": {}, "clientInfo": {"name": "test", "version": "1"}}}`)
+	var initialized struct {
+		ProtocolVersion string                 `json:"protocolVersion"`
+		Capabilities    map[string]interface{} `json:"capabilities"`
+		ServerInfo      struct{ Name string }  `json:"serverInfo"`
+	}
+	if resp.Error != nil || json.Unmarshal(resp.Result, &initialized) != nil {
+		t.Fatalf("initialize = %s, %+v", resp.Result, resp.Error)
+	}
+	if initialized.ProtocolVersion != mcpProtocolVersion || initialized.Capabilities["tools"] == nil || initialized.ServerInfo.Name == "" {
+		t.Errorf("initialize = %s, want protocol %s with tools", resp.Result, mcpProtocolVersion)
+	}
+
+	rec, _ := postMCP(t, a, `{"jsonrpc": "2.0", "method": "notifications/initialized"}`)
+	if rec.Code != http.StatusAccepted || rec.Body.Len() != 0 {
+		t.Errorf("notification got %d %q, want 202 and no body", rec.Code, rec.Body)
+	}
+
+	_, resp = postMCP(t, a, `{"jsonrpc": "2.0", "id": 2, "method": "tools/list"}`)
+	var listed struct{ Tools []MCPTool }
+	if resp.Error != nil || json.Unmarshal(resp.Result, &listed) != nil {
+		t.Fatalf("tools/list = %s, %+v", resp.Result, resp.Error)
+	}
+	var names []string
+	for _, tool := range listed.Tools {
+		names = append(names, tool.Name)
+		if tool.InputSchema["type"] != "object" {
+			t.Errorf("tool %s has no object input schema", tool.Name)
+		}
+	}
+	if got := strings.Join(names, ","); got != "get_migration_pathway,calculate_crs,simulate_crs,list_visa_programs" {
+		t.Errorf("tools = %s", got)
+	}
+
+	_, resp = postMCP(t, a, `{"jsonrpc": "2.0", "id": 3, "method": "resources/list"}`)
+	if resp.Error == nil || resp.Error.Code != ErrCodeMethodNotFound {
+		t.Errorf("unknown method error = %+v, want %d", resp.Error, ErrCodeMethodNotFound)
+	}
+}
+
+func TestMCPCalculateCRS(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	a := NewMigrationAgent()
+
+	for _, tc := range []struct {
+		name      string
+		arguments string
+		invalid   string // "" for a valid call, else part of the error
+	}{
+		{"valid", `{"age": 29, "education": "bachelor", "clb": 9, "foreignExperienceYears": 3}`, ""},
+		{"ielts instead of clb", `{"age": 29, "education": "bachelor", "ielts": 7}`, ""},
+		{"missing age", `{"education": "bachelor", "clb": 9}`, "age must be at least 17"},
+		{"under 17", `{"age": 16, "education": "bachelor", "clb": 9}`, "age must be at least 17"},
+		{"clb above 12", `{"age": 29, "education": "bachelor", "clb": 13}`, "clb must be between 0 and 12"},
+		{"negative clb", `{"age": 29, "education": "bachelor", "clb": -1}`, "clb must be between 0 and 12"},
+		{"ielts above 9", `{"age": 29, "education": "bachelor", "ielts": 10}`, "ielts must be a band between 0 and 9"},
+		{"negative foreign experience", `{"age": 29, "education": "bachelor", "clb": 9, "foreignExperienceYears": -2}`, "foreignExperienceYears can't be negative"},
+		{"negative Canadian experience", `{"age": 29, "education": "bachelor", "clb": 9, "canadianExperienceYears": -1}`, "canadianExperienceYears can't be negative"},
+		{"unknown education", `{"age": 29, "education": "wizardry", "clb": 9}`, "unknown education"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, resp := postMCP(t, a, `{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "calculate_crs", "arguments": `+tc.arguments+`}}`)
+			if tc.invalid != "" {
+				if resp.Error == nil || resp.Error.Code != ErrCodeInvalidParams || !strings.Contains(resp.Error.Message, tc.invalid) {
+					t.Errorf("error = %+v, want %d mentioning %q", resp.Error, ErrCodeInvalidParams, tc.invalid)
+				}
+				return
+			}
+
+			var result struct {
+				Content           []MCPContent
+				StructuredContent CRSBreakdown
+				IsError           bool
+			}
+			if resp.Error != nil || json.Unmarshal(resp.Result, &result) != nil {
+				t.Fatalf("tools/call = %s, %+v", resp.Result, resp.Error)
+			}
+			if result.IsError || result.StructuredContent.Total == 0 || !strings.Contains(result.Content[0].Text, "Estimated CRS score") {
+				t.Errorf("result = %s, want a CRS score", resp.Result)
+			}
+		})
+	}
+
+	_, resp := postMCP(t, a, `{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "book_flight", "arguments": {}}}`)
+	if resp.Error == nil || resp.Error.Code != ErrCodeInvalidParams {
+		t.Errorf("unknown tool error = %+v, want %d", resp.Error, ErrCodeInvalidParams)
+	}
+}
+
+func TestMCPStdio(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	a := NewMigrationAgent()
+
+	in := strings.Join([]string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {}}`,
+		`{"jsonrpc": "2.0", "method": "notifications/initialized"}`,
+		``,
+		`not json`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "list_visa_programs", "arguments": {"country": "Canada"}}}`,
+	}, "\n")
+	var out bytes.Buffer
+	if err := a.ServeMCPStdio(strings.NewReader(in), &out); err != nil {
+		t.Fatalf("ServeMCPStdio: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d responses, want one per request except the notification:\n%s", len(lines), out.String())
+	}
+	var responses []mcpResponse
+	for _, line := range lines {
+		var resp mcpResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("response %q is not JSON: %v", line, err)
+		}
+		responses = append(responses, resp)
+	}
+	if responses[0].Error != nil || !strings.Contains(string(responses[0].Result), mcpProtocolVersion) {
+		t.Errorf("initialize = %s, %+v", responses[0].Result, responses[0].Error)
+	}
+	if responses[1].Error == nil || responses[1].Error.Code != ErrCodeParse {
+		t.Errorf("malformed line got %+v, want a parse error", responses[1].Error)
+	}
+	if responses[2].Error != nil || responses[2].ID != float64(2) || !strings.Contains(string(responses[2].Result), "Express Entry") {
+		t.Errorf("tools/call = %v %s, %+v", responses[2].ID, responses[2].Result, responses[2].Error)
+	}
+}
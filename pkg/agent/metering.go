@@ -0,0 +1,337 @@
+package agent
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultTenantLabel names the default tenant in metering records
+const defaultTenantLabel = "default"
+
+// ModelPrice is what a model costs in US dollars per million tokens
+type ModelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// modelPrices reads MODEL_PRICES as comma-separated model=input:output
+// pairs, in US dollars per million prompt and output tokens
+func modelPrices() map[string]ModelPrice {
+	prices := make(map[string]ModelPrice)
+	for _, entry := range strings.Split(os.Getenv("MODEL_PRICES"), ",") {
+		model, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		in, out, ok := strings.Cut(value, ":")
+		input, inErr := strconv.ParseFloat(strings.TrimSpace(in), 64)
+		output, outErr := strconv.ParseFloat(strings.TrimSpace(out), 64)
+		if !ok || inErr != nil || outErr != nil || input < 0 || output < 0 {
+			log.Printf("⚠️  Ignoring invalid MODEL_PRICES entry %q", entry)
+			continue
+		}
+		prices[strings.TrimSpace(model)] = ModelPrice{Input: input, Output: output}
+	}
+	return prices
+}
+
+// cost prices token usage in US dollars
+func (p ModelPrice) cost(promptTokens, outputTokens int) float64 {
+	return (float64(promptTokens)*p.Input + float64(outputTokens)*p.Output) / 1e6
+}
+
+// MeterRecord is one tenant's usage of one model over a period, the unit
+// the billing export is made of
+type MeterRecord struct {
+	Tenant       string    `json:"tenant"`
+	PeriodStart  time.Time `json:"periodStart"`
+	PeriodEnd    time.Time `json:"periodEnd"`
+	Model        string    `json:"model"`
+	Requests     int       `json:"requests"`
+	Completed    int       `json:"completed"`
+	Failed       int       `json:"failed"`
+	PromptTokens int       `json:"promptTokens"`
+	OutputTokens int       `json:"outputTokens"`
+	CostUSD      float64   `json:"costUsd"`
+	Priced       bool      `json:"priced"` // false when MODEL_PRICES has no price for the model
+}
+
+// meterUsage totals the processed tasks in [start, end) per tenant and
+// model from the audit trail. Feedback entries and attempts held for quota
+// aren't billable; a held task is counted once its retry finishes.
+func meterUsage(entries []AuditEntry, start, end time.Time, prices map[string]ModelPrice) []MeterRecord {
+	type key struct{ tenant, model string }
+	records := make(map[key]*MeterRecord)
+	for _, entry := range entries {
+		if entry.Outcome == AuditOutcomeFeedback || entry.Outcome == string(TaskStateSubmitted) {
+			continue
+		}
+		if entry.Timestamp.Before(start) || !entry.Timestamp.Before(end) {
+			continue
+		}
+		tenant := entry.Tenant
+		if tenant == "" {
+			tenant = defaultTenantLabel
+		}
+		k := key{tenant, entry.Model}
+		r := records[k]
+		if r == nil {
+			_, priced := prices[entry.Model]
+			r = &MeterRecord{Tenant: tenant, PeriodStart: start.UTC(), PeriodEnd: end.UTC(), Model: entry.Model, Priced: priced}
+			records[k] = r
+		}
+		r.Requests++
+		switch entry.Outcome {
+		case string(TaskStateCompleted):
+			r.Completed++
+		case string(TaskStateFailed):
+			r.Failed++
+		}
+		r.PromptTokens += entry.PromptTokens
+		r.OutputTokens += entry.OutputTokens
+	}
+
+	rows := make([]MeterRecord, 0, len(records))
+	for _, r := range records {
+		if price, ok := prices[r.Model]; ok {
+			r.CostUSD = math.Round(price.cost(r.PromptTokens, r.OutputTokens)*1e6) / 1e6
+		}
+		rows = append(rows, *r)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Tenant != rows[j].Tenant {
+			return rows[i].Tenant < rows[j].Tenant
+		}
+		return rows[i].Model < rows[j].Model
+	})
+	return rows
+}
+
+// TenantMeter sums a tenant's records over all models
+type TenantMeter struct {
+	Tenant       string        `json:"tenant"`
+	Requests     int           `json:"requests"`
+	Completed    int           `json:"completed"`
+	Failed       int           `json:"failed"`
+	PromptTokens int           `json:"promptTokens"`
+	OutputTokens int           `json:"outputTokens"`
+	CostUSD      float64       `json:"costUsd"`
+	Models       []MeterRecord `json:"models"`
+}
+
+// summarizeMeters groups records by tenant
+func summarizeMeters(records []MeterRecord) []TenantMeter {
+	tenants := []TenantMeter{}
+	for _, r := range records {
+		if len(tenants) == 0 || tenants[len(tenants)-1].Tenant != r.Tenant {
+			tenants = append(tenants, TenantMeter{Tenant: r.Tenant})
+		}
+		t := &tenants[len(tenants)-1]
+		t.Requests += r.Requests
+		t.Completed += r.Completed
+		t.Failed += r.Failed
+		t.PromptTokens += r.PromptTokens
+		t.OutputTokens += r.OutputTokens
+		t.CostUSD = math.Round((t.CostUSD+r.CostUSD)*1e6) / 1e6
+		t.Models = append(t.Models, r)
+	}
+	return tenants
+}
+
+// writeMeterCSV writes records as CSV with a header line. Cost is left
+// empty for unpriced models so the billing system doesn't bill them as
+// free.
+func writeMeterCSV(w io.Writer, records []MeterRecord) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"tenant_id", "period_start", "period_end", "model", "requests", "completed", "failed", "prompt_tokens", "output_tokens", "cost_usd"})
+	for _, r := range records {
+		cost := ""
+		if r.Priced {
+			cost = strconv.FormatFloat(r.CostUSD, 'f', 6, 64)
+		}
+		out.Write([]string{
+			r.Tenant,
+			r.PeriodStart.Format(time.RFC3339),
+			r.PeriodEnd.Format(time.RFC3339),
+			r.Model,
+			strconv.Itoa(r.Requests),
+			strconv.Itoa(r.Completed),
+			strconv.Itoa(r.Failed),
+			strconv.Itoa(r.PromptTokens),
+			strconv.Itoa(r.OutputTokens),
+			cost,
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// BillingExporter periodically writes each period's metering records as a
+// CSV file for the billing system
+type BillingExporter struct {
+	audit    *AuditLog
+	dir      string
+	interval time.Duration
+	clock    Clock
+	prices   map[string]ModelPrice
+}
+
+// NewBillingExporter configures the export from BILLING_EXPORT_DIR (the
+// export is off without it) and BILLING_EXPORT_INTERVAL (default 24h)
+func NewBillingExporter(audit *AuditLog) *BillingExporter {
+	e := &BillingExporter{
+		audit:    audit,
+		dir:      os.Getenv("BILLING_EXPORT_DIR"),
+		interval: 24 * time.Hour,
+		clock:    systemClock{},
+		prices:   modelPrices(),
+	}
+	if v := os.Getenv("BILLING_EXPORT_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= time.Minute {
+			e.interval = d
+		} else {
+			log.Printf("⚠️  Invalid BILLING_EXPORT_INTERVAL %q, exporting every %s", v, e.interval)
+		}
+	}
+	return e
+}
+
+// Enabled reports whether an export directory is configured
+func (e *BillingExporter) Enabled() bool {
+	return e.dir != ""
+}
+
+// Run exports each completed period, aligned to the interval in UTC, until
+// the process exits
+func (e *BillingExporter) Run() {
+	if !e.Enabled() {
+		return
+	}
+	if err := os.MkdirAll(e.dir, 0o755); err != nil {
+		log.Printf("⚠️  Billing export disabled: %v", err)
+		return
+	}
+	log.Printf("💳 Exporting billing records to %s every %s", e.dir, e.interval)
+
+	for {
+		now := e.clock.Now().UTC()
+		end := now.Truncate(e.interval).Add(e.interval)
+		time.Sleep(end.Sub(now))
+		path, err := e.Export(end.Add(-e.interval), end)
+		if err != nil {
+			log.Printf("⚠️  Billing export failed: %v", err)
+			continue
+		}
+		if path != "" {
+			log.Printf("💳 Wrote billing records to %s", path)
+		}
+	}
+}
+
+// Export writes the records for [start, end) and returns the file path. A
+// period that was already exported, e.g. by another replica sharing the
+// directory, is skipped and "" is returned. Periods without usage still get
+// a file with just the header, so a missing file always means a gap.
+func (e *BillingExporter) Export(start, end time.Time) (string, error) {
+	path := filepath.Join(e.dir, fmt.Sprintf("billing-%s.csv", start.UTC().Format("20060102T1504Z")))
+	if _, err := os.Stat(path); err == nil {
+		return "", nil
+	}
+
+	entries, err := e.audit.Query(AuditFilter{Since: start, Until: end})
+	if err != nil {
+		return "", err
+	}
+	records := meterUsage(entries, start, end, e.prices)
+
+	// Write to a temporary file first so the billing system never picks up
+	// a partial export
+	tmp, err := os.CreateTemp(e.dir, ".billing-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create export: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	err = writeMeterCSV(tmp, records)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write export: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to move export into place: %v", err)
+	}
+	return path, nil
+}
+
+// ServeMetering handles GET /admin/metering: usage per tenant and model
+// from since to until (RFC 3339, default the current calendar month in
+// UTC), optionally for one tenant, as JSON or, with format=csv, in the
+// billing export's CSV layout
+func (a *MigrationAgent) ServeMetering(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	q := r.URL.Query()
+	until := a.clock.Now().UTC()
+	since := time.Date(until.Year(), until.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for name, dst := range map[string]*time.Time{"since": &since, "until": &until} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s: %v", name, err))
+				return
+			}
+			*dst = t
+		}
+	}
+
+	entries, err := a.audit.Query(AuditFilter{Since: since, Until: until})
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	records := meterUsage(entries, since, until, a.billing.prices)
+	if tenant := q.Get("tenant"); tenant != "" {
+		var filtered []MeterRecord
+		for _, record := range records {
+			if record.Tenant == tenant {
+				filtered = append(filtered, record)
+			}
+		}
+		records = filtered
+	}
+
+	switch q.Get("format") {
+	case "", "json":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"since":   since.UTC(),
+			"until":   until.UTC(),
+			"tenants": summarizeMeters(records),
+		})
+	case ExportFormatCSV:
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=billing-%s.csv", since.UTC().Format("20060102T1504Z")))
+		if err := writeMeterCSV(w, records); err != nil {
+			log.Printf("⚠️  Failed to write metering CSV: %v", err)
+		}
+	default:
+		writeJSONError(w, http.StatusBadRequest, "format must be json or csv")
+	}
+}
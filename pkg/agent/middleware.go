@@ -0,0 +1,293 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Middleware wraps a handler, e.g. to authenticate or log its requests
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h in middlewares, the first outermost, so it sees each
+// request first
+func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// Handler returns every endpoint of the agent as one handler, wrapped in
+// panic recovery, request logging and then middlewares, outermost first, so
+// embedders can mount the agent under their own mux
+func (a *MigrationAgent) Handler(middlewares ...Middleware) http.Handler {
+	mux := http.NewServeMux()
+	a.Register(mux)
+	return Chain(mux, append([]Middleware{Recovery(), logRequests(a.clock)}, middlewares...)...)
+}
+
+// Recovery answers 500 when a handler panics, logging the panic with its
+// stack, instead of dropping the connection
+func Recovery() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				log.Printf("⚠️  Panic serving %s %s: %v\n%s", r.Method, r.URL.Path, p, debug.Stack())
+				writeJSONError(w, http.StatusInternalServerError, "internal error")
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Logging logs the method, path, status and duration of every request
+func Logging() Middleware {
+	return logRequests(systemClock{})
+}
+
+// logRequests is Logging timed with clock
+func logRequests(clock Clock) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := clock.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			log.Printf("%s %s %d %s", r.Method, r.URL.Path, rec.status, clock.Now().Sub(start).Round(time.Millisecond))
+		})
+	}
+}
+
+// statusRecorder remembers the status a handler wrote. It passes flushes
+// on, so streamed answers still stream.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// CORS lets browser clients on origins call the endpoints it wraps and
+// answers their preflight requests. No origins allows any.
+func CORS(origins ...string) Middleware {
+	allowed := make(map[string]bool)
+	for _, origin := range origins {
+		allowed[origin] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if origin := r.Header.Get("Origin"); len(allowed) == 0 {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else if allowed[origin] {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Add("Vary", "Origin")
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
+
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// corsOrigins reads CORS_ORIGINS, a comma-separated list of the origins
+// browser clients may call from; unset allows any
+func corsOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(os.Getenv("CORS_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// tenantKey is the request context key of the tenant Authenticate found
+type tenantKey struct{}
+
+// Authenticate checks the tenant API key of every request when tenants are
+// configured and passes the tenant on to the handler. Requests without a
+// valid key are refused with 401.
+func (a *MigrationAgent) Authenticate() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant, err := authenticateTenant(r)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				a.reject(w, r, http.StatusUnauthorized, ErrCodeUnauthenticated, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantKey{}, tenant)))
+		})
+	}
+}
+
+// requestTenant returns the tenant Authenticate found for a request, or
+// authenticates the request itself when the handler is mounted without it
+func requestTenant(r *http.Request) (*Tenant, error) {
+	if tenant, ok := r.Context().Value(tenantKey{}).(*Tenant); ok {
+		return tenant, nil
+	}
+	return authenticateTenant(r)
+}
+
+// sendMethods are the JSON-RPC methods that start LLM work
+var sendMethods = map[string]bool{"tasks/send": true, "message/send": true, "tasks/sendSubscribe": true, "message/stream": true}
+
+// sendsTask reports whether a JSON-RPC call starts LLM work: an A2A send,
+// or an MCP call of the recommendation tool
+func sendsTask(method string, params json.RawMessage) bool {
+	if method != "tools/call" {
+		return sendMethods[method]
+	}
+	var call struct {
+		Name string `json:"name"`
+	}
+	json.Unmarshal(params, &call)
+	return call.Name == "get_migration_pathway"
+}
+
+// RateLimit holds each tenant to its task sends per minute and counts the
+// requests in the tenant stats. Only JSON-RPC sends count against the
+// limit, and MCP calls of the recommendation tool, since they start LLM
+// work. Put it after Authenticate.
+func (a *MigrationAgent) RateLimit() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant, err := requestTenant(r)
+			if err != nil {
+				// The handler refuses it
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			r, body := readRPC(w, r)
+			limited := false
+			if body.ok && sendsTask(body.envelope.Method, body.envelope.Params) {
+				if ok, wait := a.tenantLimiter.Allow(tenant); !ok {
+					limited = true
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				}
+			}
+			a.tenantStats.RecordRequest(tenant.id(), limited)
+			if limited {
+				err := fmt.Errorf("%w: %d task sends per minute", ErrRateLimited, tenant.RateLimit)
+				a.reject(w, r, http.StatusTooManyRequests, ErrCodeRateLimited, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rpcCall is the envelope of a JSON-RPC request, read by middlewares
+type rpcCall struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"` // nil without an id member; "null" for "id": null
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// maxRPCBody caps the body of a JSON-RPC request, leaving room for files
+// sent inline in a message
+const maxRPCBody = 20 << 20
+
+// rpcBodyKey is the request context key of the body readRPC read
+type rpcBodyKey struct{}
+
+// rpcBody is a JSON-RPC request body, read once and shared by the
+// middlewares and the handler. ok is false for other requests; err is set
+// when the body could not be read or is over maxRPCBody.
+type rpcBody struct {
+	raw      []byte
+	envelope rpcCall
+	ok       bool
+	err      error
+}
+
+// readRPC reads the body of a request up to maxRPCBody and parses its
+// JSON-RPC envelope, once per request: it returns the request with the
+// body in its context, and later calls return that body. The request body
+// is left to be read again by handlers that decode it themselves.
+func readRPC(w http.ResponseWriter, r *http.Request) (*http.Request, *rpcBody) {
+	if body, ok := r.Context().Value(rpcBodyKey{}).(*rpcBody); ok {
+		return r, body
+	}
+	body := &rpcBody{}
+	if r.Method == http.MethodPost && r.Body != nil {
+		body.raw, body.err = io.ReadAll(http.MaxBytesReader(w, r.Body, maxRPCBody))
+		r.Body = io.NopCloser(bytes.NewReader(body.raw))
+		if body.err == nil && json.Unmarshal(body.raw, &body.envelope) == nil && body.envelope.JSONRPC != "" {
+			body.ok = true
+		}
+	}
+	return r.WithContext(context.WithValue(r.Context(), rpcBodyKey{}, body)), body
+}
+
+// tooLarge reports whether a body was refused for being over maxRPCBody
+func (b *rpcBody) tooLarge() bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(b.err, &maxErr)
+}
+
+// reject refuses a request the way its endpoint answers: JSON-RPC calls
+// get a JSON-RPC error, notifications (no id member) just the status, and
+// anything else a JSON error with the status
+func (a *MigrationAgent) reject(w http.ResponseWriter, r *http.Request, status, code int, err error) {
+	_, body := readRPC(w, r)
+	switch {
+	case body.ok && body.envelope.ID == nil:
+		w.WriteHeader(status)
+	case body.ok:
+		a.sendError(w, err, code, errorMessages[code], body.envelope.ID)
+	default:
+		writeJSONError(w, status, err.Error())
+	}
+}
@@ -0,0 +1,10 @@
+CREATE TABLE tasks (
+    id          TEXT PRIMARY KEY,
+    session_id  TEXT NOT NULL DEFAULT '',
+    state       TEXT NOT NULL,
+    data        JSONB NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX tasks_session_id_idx ON tasks (session_id, created_at) WHERE session_id <> '';
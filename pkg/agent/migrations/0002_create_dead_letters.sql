@@ -0,0 +1,10 @@
+CREATE TABLE dead_letters (
+    id          TEXT PRIMARY KEY,
+    task_id     TEXT NOT NULL,
+    tenant      TEXT NOT NULL DEFAULT '',
+    data        JSONB NOT NULL,
+    failed_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX dead_letters_failed_at_idx ON dead_letters (failed_at);
+CREATE INDEX dead_letters_task_id_idx ON dead_letters (task_id);
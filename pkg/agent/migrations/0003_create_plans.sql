@@ -0,0 +1,12 @@
+CREATE TABLE plans (
+    id          TEXT PRIMARY KEY,
+    user_id     TEXT NOT NULL,
+    tenant      TEXT NOT NULL DEFAULT '',
+    task_id     TEXT NOT NULL,
+    data        JSONB NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX plans_user_id_idx ON plans (tenant, user_id, updated_at);
+CREATE INDEX plans_task_id_idx ON plans (task_id);
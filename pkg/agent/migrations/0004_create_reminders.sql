@@ -0,0 +1,12 @@
+CREATE TABLE reminders (
+    id            TEXT PRIMARY KEY,
+    task_id       TEXT NOT NULL,
+    data          JSONB NOT NULL,
+    send_at       TIMESTAMPTZ NOT NULL,
+    sent_at       TIMESTAMPTZ,
+    claimed_until TIMESTAMPTZ,
+    last_error    TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX reminders_due_idx ON reminders (send_at) WHERE sent_at IS NULL;
+CREATE INDEX reminders_task_id_idx ON reminders (task_id);
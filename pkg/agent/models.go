@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// allowedModels reads ALLOWED_MODELS, the comma-separated Gemini models
+// callers may choose with the model param besides the default
+func allowedModels() []string {
+	var models []string
+	for _, model := range strings.Split(os.Getenv("ALLOWED_MODELS"), ",") {
+		if model = strings.TrimSpace(model); model != "" {
+			models = append(models, model)
+		}
+	}
+	return models
+}
+
+// modelsFor returns the models a tenant's tasks may ask for: the default
+// model, plus the tenant's own list or else ALLOWED_MODELS
+func (a *MigrationAgent) modelsFor(tenant *Tenant) []string {
+	models := a.models
+	if tenant != nil && tenant.Models != nil {
+		models = tenant.Models
+	}
+	if containsString(models, a.gemini.Model) {
+		return models
+	}
+	return append([]string{a.gemini.Model}, models...)
+}
+
+// validateModel rejects a requested model the tenant may not use
+func (a *MigrationAgent) validateModel(model string, tenant *Tenant) error {
+	if model == "" {
+		return nil
+	}
+	models := a.modelsFor(tenant)
+	if containsString(models, model) {
+		return nil
+	}
+	return fmt.Errorf("unsupported model %q (expected one of %s)", model, strings.Join(models, ", "))
+}
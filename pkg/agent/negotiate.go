@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"fmt"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// outputModes maps the media types callers can ask for onto output formats.
+// Markdown is plain text, so text/plain gets it too.
+var outputModes = map[string]string{
+	"text/markdown":    OutputFormatMarkdown,
+	"text/plain":       OutputFormatMarkdown,
+	"text/html":        OutputFormatHTML,
+	"application/json": OutputFormatJSON,
+}
+
+// negotiateFormat picks the answer artifact's format: outputFormat if set,
+// else the first supported entry of acceptedOutputModes, else the preferred
+// text type in the Accept header. Accept can't select JSON, since JSON-RPC
+// clients send application/json for the response envelope itself.
+func negotiateFormat(params TaskSendParams, accept string) (string, error) {
+	if params.OutputFormat != "" {
+		return params.OutputFormat, nil
+	}
+
+	modes := params.AcceptedOutputModes
+	if len(modes) == 0 && params.Configuration != nil {
+		modes = params.Configuration.AcceptedOutputModes
+	}
+	if len(modes) > 0 {
+		for _, mode := range modes {
+			mediaType, _, err := mime.ParseMediaType(mode)
+			if err != nil {
+				continue
+			}
+			if mediaType == "*/*" || mediaType == "text/*" {
+				return OutputFormatMarkdown, nil
+			}
+			if format, ok := outputModes[mediaType]; ok {
+				return format, nil
+			}
+		}
+		return "", fmt.Errorf("%w: none of acceptedOutputModes %s is supported (expected text/markdown, text/html or application/json)", ErrContentTypeNotSupported, strings.Join(modes, ", "))
+	}
+
+	return acceptedTextFormat(accept), nil
+}
+
+// acceptedTextFormat reads the highest-quality text type from an Accept
+// header, or "" if it names neither markdown nor HTML
+func acceptedTextFormat(accept string) string {
+	type candidate struct {
+		format string
+		q      float64
+	}
+	var candidates []candidate
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil || (mediaType != "text/markdown" && mediaType != "text/html") {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{outputModes[mediaType], q})
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].format
+}
+
+// AnswerSection is one headed part of a recommendation
+type AnswerSection struct {
+	Title string   `json:"title"`
+	Text  string   `json:"text,omitempty"`
+	Items []string `json:"items,omitempty"`
+}
+
+// answerData turns a markdown recommendation into the structure returned
+// for application/json: the pathway, each section's text and list items,
+// the dated milestones and the next step. Milestones are passed in, parsed
+// before dates were localized.
+func answerData(markdown string, milestones []Milestone) map[string]interface{} {
+	var sections []AnswerSection
+	nextStep := ""
+	for start := 0; start < len(markdown); {
+		end := nextSection(markdown, start)
+		if end == -1 {
+			end = len(markdown)
+		}
+		section, step := parseAnswerSection(markdown[start:end])
+		if step != "" {
+			nextStep = step
+		}
+		if section.Title != "" || section.Text != "" || len(section.Items) > 0 {
+			sections = append(sections, section)
+		}
+		start = end
+	}
+
+	timeline := []map[string]string{}
+	for _, m := range milestones {
+		timeline = append(timeline, map[string]string{"date": m.Date.Format("2006-01-02"), "milestone": m.Title})
+	}
+	return map[string]interface{}{
+		"pathway":  pathwayName(markdown),
+		"sections": sections,
+		"timeline": timeline,
+		"nextStep": nextStep,
+	}
+}
+
+// parseAnswerSection splits a section into its title, text and list items,
+// without markdown emphasis. The step of a "Next step:" line is returned
+// separately; anything after it, such as the disclaimer, stays as text.
+func parseAnswerSection(text string) (AnswerSection, string) {
+	var section AnswerSection
+	var paragraph []string
+	nextStep := ""
+	for i, rawLine := range strings.Split(strings.TrimSpace(text), "\n") {
+		line := strings.TrimSpace(rawLine)
+		switch {
+		case line == "" || line == "---":
+		case i == 0 && strings.HasPrefix(line, "#"):
+			section.Title = strings.TrimSpace(strings.TrimLeft(line, "#"))
+		case i == 0 && strings.HasPrefix(line, "**"):
+			// A bold label, with or without text after it
+			label, rest, _ := strings.Cut(strings.TrimPrefix(line, "**"), "**")
+			section.Title = strings.TrimSuffix(strings.TrimSpace(label), ":")
+			if rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), ":")); rest != "" {
+				paragraph = append(paragraph, rest)
+			}
+		case i == 0 && strings.HasPrefix(strings.ToLower(line), "next step"):
+			_, step, _ := strings.Cut(line, ":")
+			nextStep = stripEmphasis(strings.TrimSpace(step))
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* "):
+			section.Items = append(section.Items, stripEmphasis(strings.TrimSpace(line[2:])))
+		default:
+			paragraph = append(paragraph, stripEmphasis(line))
+		}
+	}
+	section.Text = strings.Join(paragraph, "\n")
+	return section, nextStep
+}
+
+// stripEmphasis removes bold and italic markers
+func stripEmphasis(text string) string {
+	return italicPattern.ReplaceAllString(boldPattern.ReplaceAllString(text, "$1"), "$1")
+}
@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// notificationWriter stands in for the response to a JSON-RPC
+// notification, which the caller never gets. The body is kept so a failure
+// can still be logged.
+type notificationWriter struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (w *notificationWriter) Header() http.Header         { return w.header }
+func (w *notificationWriter) Write(b []byte) (int, error) { return w.body.Write(b) }
+func (w *notificationWriter) WriteHeader(int)             {}
+
+// handleNotification runs a request sent without an id. The caller has
+// already been answered with 204, so the task is processed in the
+// background and errors only go to the log. Streaming methods are run as
+// plain sends since there is nobody to stream to.
+func (a *MigrationAgent) handleNotification(r *http.Request, req JSONRPCRequest) {
+	w := &notificationWriter{header: make(http.Header)}
+	switch req.Method {
+	case "tasks/sendSubscribe":
+		a.handleTasksSend(w, req)
+	case "message/stream":
+		a.handleMessage(w, req)
+	default:
+		a.dispatch(w, r, req)
+	}
+
+	var response struct {
+		Error *struct {
+			Message string          `json:"message"`
+			Data    json.RawMessage `json:"data"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(w.body.Bytes(), &response) == nil && response.Error != nil {
+		log.Printf("⚠️  Notification %s failed: %s %s", req.Method, response.Error.Message, response.Error.Data)
+	}
+}
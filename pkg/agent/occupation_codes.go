@@ -0,0 +1,201 @@
+package agent
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// occupationCodeData maps job titles, including uncommon ones, to official
+// occupation codes
+//
+//go:embed occupation_codes.json
+var occupationCodeData []byte
+
+// CodedOccupation is an occupation with its codes in each classification,
+// keyed by classification name such as "NOC 2021"
+type CodedOccupation struct {
+	Title   string            `json:"title"`
+	Aliases []string          `json:"aliases"` // lowercase job titles meaning it
+	Codes   map[string]string `json:"codes"`
+}
+
+// occupationCodeTable is the versioned built-in mapping
+type occupationCodeTable struct {
+	Version     string            `json:"version"`
+	Updated     string            `json:"updated"` // YYYY-MM-DD
+	Occupations []CodedOccupation `json:"occupations"`
+}
+
+var occupationCodes = mustParseOccupationCodes(occupationCodeData)
+
+// mustParseOccupationCodes parses the embedded mapping, which ships with the
+// binary, so a broken one is a build defect
+func mustParseOccupationCodes(data []byte) occupationCodeTable {
+	var table occupationCodeTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		panic(fmt.Sprintf("invalid embedded occupation codes: %v", err))
+	}
+	if _, err := time.Parse("2006-01-02", table.Updated); err != nil || table.Version == "" {
+		panic("embedded occupation codes need a version and an updated date")
+	}
+	for _, o := range table.Occupations {
+		if o.Title == "" || len(o.Aliases) == 0 || o.Codes["ISCO-08"] == "" {
+			panic(fmt.Sprintf("occupation %q needs a title, aliases and an ISCO-08 code", o.Title))
+		}
+	}
+	return table
+}
+
+// classifications is the occupation classification each destination uses;
+// others fall back to the international ISCO-08
+var classifications = map[string]string{
+	"canada":         "NOC 2021",
+	"australia":      "ANZSCO",
+	"united kingdom": "SOC 2020",
+	"united states":  "US SOC 2018",
+}
+
+// genericTitleWords are words too common in job titles to identify an
+// occupation on their own
+var genericTitleWords = map[string]bool{
+	"engineer": true, "specialist": true, "worker": true, "manager": true, "officer": true,
+	"senior": true, "junior": true, "lead": true, "assistant": true, "head": true,
+}
+
+// matchOccupationCodes returns up to three occupations a job title most
+// likely means, best first. Occupations with an alias found whole in the
+// title win; only without one do aliases sharing a distinctive word count.
+func matchOccupationCodes(title string) []CodedOccupation {
+	text := " " + strings.ToLower(title) + " "
+	type scored struct {
+		occupation CodedOccupation
+		score      int
+		whole      bool
+	}
+	var found []scored
+	anyWhole := false
+	for _, o := range occupationCodes.Occupations {
+		best := scored{occupation: o}
+		for _, alias := range o.Aliases {
+			if parser.IndexWord(text, alias) != -1 || parser.IndexWord(text, alias+"s") != -1 {
+				if !best.whole || len(alias) > best.score {
+					best.score, best.whole = len(alias), true
+				}
+				continue
+			}
+			if best.whole {
+				continue
+			}
+			shared := 0
+			for _, word := range strings.Fields(alias) {
+				if !genericTitleWords[word] && parser.IndexWord(text, word) != -1 {
+					shared++
+				}
+			}
+			if shared > best.score {
+				best.score = shared
+			}
+		}
+		if best.score > 0 {
+			found = append(found, best)
+			anyWhole = anyWhole || best.whole
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].whole != found[j].whole {
+			return found[i].whole
+		}
+		return found[i].score > found[j].score
+	})
+	var matches []CodedOccupation
+	for _, s := range found {
+		if len(matches) == 3 || anyWhole && !s.whole {
+			break
+		}
+		matches = append(matches, s.occupation)
+	}
+	return matches
+}
+
+// occupationCodeFunction lets Gemini resolve a job title to official codes
+// while writing a recommendation, rather than quoting one from memory
+var occupationCodeFunction = llm.GeminiFunction{
+	Declaration: llm.GeminiFunctionDeclaration{
+		Name:        "lookup_occupation_code",
+		Description: "Maps a job title, including uncommon ones such as \"DevRel engineer\" or \"theatre nurse\", to official occupation codes: NOC 2021 for Canada, ANZSCO for Australia, SOC 2020 for the United Kingdom, US SOC 2018 for the United States and ISCO-08 everywhere. Also says whether the occupation is on the destination's skilled-occupation lists. Call it before quoting any occupation code.",
+		Parameters: &llm.GeminiSchema{
+			Type: "OBJECT",
+			Properties: map[string]llm.GeminiSchema{
+				"job_title": {Type: "STRING", Description: "The applicant's job title as they describe it"},
+				"country":   {Type: "STRING", Description: "The destination country, e.g. Canada"},
+			},
+			Required: []string{"job_title"},
+		},
+	},
+	Call: lookupOccupationCode,
+}
+
+// lookupOccupationCode answers lookup_occupation_code calls with the
+// likeliest occupations and their codes in the destination's
+// classification, or says no code is known so the model doesn't make one up
+func lookupOccupationCode(args map[string]interface{}) map[string]interface{} {
+	title, _ := args["job_title"].(string)
+	country, _ := args["country"].(string)
+	matches := matchOccupationCodes(title)
+	if len(matches) == 0 {
+		return map[string]interface{}{
+			"found": false,
+			"note":  "No official code is known for this job title. Describe the occupation's main duties and tell the applicant to find its code in the destination's classification; do not quote a code.",
+		}
+	}
+
+	system := classifications[strings.ToLower(country)]
+	var results []map[string]interface{}
+	for _, o := range matches {
+		codes := make(map[string]string)
+		for name, code := range o.Codes {
+			if system == "" || name == system || name == "ISCO-08" {
+				codes[name] = code
+			}
+		}
+		results = append(results, map[string]interface{}{"occupation": o.Title, "codes": codes})
+	}
+	response := map[string]interface{}{
+		"found":   true,
+		"matches": results,
+		"note":    "The first match is the likeliest. Codes are from the built-in mapping version " + occupationCodes.Version + "; the applicant should confirm theirs against the official duties.",
+	}
+	if occupation, statuses := checkOccupationLists(country, matches[0].Title+" "+title); len(statuses) > 0 {
+		response["lists"] = describeOccupationLists(occupation, statuses)
+	}
+	return response
+}
+
+// recommendationFunctions are the functions Gemini may call while writing a
+// recommendation for the tenant. Gemini can't combine them with Google
+// Search grounding, so grounded tenants get none.
+func recommendationFunctions(tenant *Tenant) []llm.GeminiFunction {
+	if tenant.enabled(FlagGrounding) {
+		return nil
+	}
+	return []llm.GeminiFunction{occupationCodeFunction}
+}
+
+// functionPromptContext tells the model to use its functions, or is ""
+// when it has none
+func functionPromptContext(fns []llm.GeminiFunction) string {
+	for _, fn := range fns {
+		if fn.Declaration.Name == occupationCodeFunction.Declaration.Name {
+			return "\nOCCUPATION CODES: Call lookup_occupation_code for the applicant's job title before quoting an occupation code (NOC, ANZSCO, SOC or ISCO), and quote only codes it returns. If it finds none, say the code must be confirmed instead of guessing one.\n"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,187 @@
+package agent
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// occupationListData holds the destinations' skilled-occupation and
+// shortage lists, maintained with the code like the fee table
+//
+//go:embed occupation_lists.json
+var occupationListData []byte
+
+// OccupationList is a destination's list of occupations that open, or
+// ease, a route, such as Australia's MLTSSL
+type OccupationList struct {
+	Country     string             `json:"country"`
+	Name        string             `json:"name"`
+	IfListed    string             `json:"ifListed"` // what being on the list means
+	IfNot       string             `json:"ifNot"`    // what not being on it means; "" when nothing
+	Occupations []ListedOccupation `json:"occupations"`
+}
+
+// ListedOccupation is one occupation on a list
+type ListedOccupation struct {
+	Title    string   `json:"title"`
+	Code     string   `json:"code,omitempty"` // classification code, e.g. NOC 31301
+	Keywords []string `json:"keywords"`       // lowercase words identifying it in a profession
+}
+
+// occupationListTable is the versioned built-in lists
+type occupationListTable struct {
+	Version string           `json:"version"`
+	Updated string           `json:"updated"` // YYYY-MM-DD
+	Lists   []OccupationList `json:"lists"`
+}
+
+var occupationLists = mustParseOccupationLists(occupationListData)
+
+// mustParseOccupationLists parses the embedded lists. They ship with the
+// binary, so broken ones are a build defect.
+func mustParseOccupationLists(data []byte) occupationListTable {
+	var table occupationListTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		panic(fmt.Sprintf("invalid embedded occupation lists: %v", err))
+	}
+	if _, err := time.Parse("2006-01-02", table.Updated); err != nil || table.Version == "" {
+		panic("embedded occupation lists need a version and an updated date")
+	}
+	for _, list := range table.Lists {
+		for _, o := range list.Occupations {
+			if o.Title == "" || len(o.Keywords) == 0 {
+				panic(fmt.Sprintf("occupation on %s needs a title and keywords", list.Name))
+			}
+		}
+	}
+	return table
+}
+
+// OccupationStatus is whether an occupation is on one of a destination's
+// lists
+type OccupationStatus struct {
+	List       string            `json:"list"`
+	Listed     bool              `json:"listed"`
+	Occupation *ListedOccupation `json:"occupation,omitempty"` // the entry it matched
+	Meaning    string            `json:"meaning,omitempty"`
+	ifNot      string            // the list's meaning when not listed
+}
+
+// checkOccupationLists looks up a profession on each of the destination's
+// lists. It returns the occupation it recognized and its status on every
+// list, or "" and nil when the destination has no lists or the
+// profession can't be recognized.
+func checkOccupationLists(country, profession string) (string, []OccupationStatus) {
+	text := " " + strings.ToLower(profession) + " "
+	var statuses []OccupationStatus
+	occupation, matched := "", 0
+	for _, list := range occupationLists.Lists {
+		if !strings.EqualFold(list.Country, country) {
+			continue
+		}
+		status := OccupationStatus{List: list.Name, Meaning: list.IfNot, ifNot: list.IfNot}
+		best := 0
+		for i, o := range list.Occupations {
+			for _, keyword := range o.Keywords {
+				if len(keyword) > best && (parser.IndexWord(text, keyword) != -1 || parser.IndexWord(text, keyword+"s") != -1) {
+					best = len(keyword)
+					status.Listed, status.Occupation, status.Meaning = true, &list.Occupations[i], list.IfListed
+					if len(keyword) > matched {
+						occupation, matched = keyword, len(keyword)
+					}
+				}
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	if occupation == "" {
+		occupation = professionKeyword(profession)
+	}
+	if occupation == "" || len(statuses) == 0 {
+		return "", nil
+	}
+	return occupation, statuses
+}
+
+// describeOccupationLists says in one sentence which lists an occupation
+// is on and what that means. Lists it isn't on are grouped by what that
+// means, and left out when it is on a sibling list with the same meaning,
+// such as another Express Entry category.
+func describeOccupationLists(occupation string, statuses []OccupationStatus) string {
+	listedSiblings := make(map[string]bool)
+	for _, s := range statuses {
+		if s.Listed {
+			listedSiblings[s.ifNot] = true
+		}
+	}
+	var parts, meanings []string
+	unlisted := make(map[string][]string)
+	for _, s := range statuses {
+		switch {
+		case s.Listed:
+			entry := s.Occupation.Title
+			if s.Occupation.Code != "" {
+				entry += ", " + s.Occupation.Code
+			}
+			parts = append(parts, fmt.Sprintf("on the %s as %s (%s)", s.List, entry, s.Meaning))
+		case !listedSiblings[s.ifNot]:
+			if _, ok := unlisted[s.Meaning]; !ok {
+				meanings = append(meanings, s.Meaning)
+			}
+			unlisted[s.Meaning] = append(unlisted[s.Meaning], s.List)
+		}
+	}
+	for _, meaning := range meanings {
+		part := "not on the " + joinList(unlisted[meaning])
+		if meaning != "" {
+			part += " (" + meaning + ")"
+		}
+		parts = append(parts, part)
+	}
+	return "Your occupation (" + occupation + ") is " + strings.Join(parts, "; ")
+}
+
+// occupationPromptContext tells the model which lists the applicant's
+// occupation is on, since that often decides the route, or "" when it
+// isn't known
+func occupationPromptContext(country, profession string) string {
+	occupation, statuses := checkOccupationLists(country, profession)
+	if len(statuses) == 0 {
+		return ""
+	}
+	return "\nOCCUPATION LISTS (as of " + occupationLists.Updated + "; prefer routes the occupation is listed for):\n" + describeOccupationLists(occupation, statuses) + ".\n"
+}
+
+// applyOccupationLists adds an Occupation lists line to the key details
+// stating whether the applicant's occupation is on the destination's
+// lists. Answers without key details, and professions that can't be
+// recognized, are returned unchanged.
+func applyOccupationLists(markdown, country, profession string) string {
+	occupation, statuses := checkOccupationLists(country, profession)
+	if len(statuses) == 0 {
+		return markdown
+	}
+	lines := strings.Split(markdown, "\n")
+	for i, line := range lines {
+		if !keyDetailsPattern.MatchString(line) {
+			continue
+		}
+		at := i + 1
+		for at < len(lines) && (strings.HasPrefix(strings.TrimSpace(lines[at]), "- ") || strings.HasPrefix(strings.TrimSpace(lines[at]), "* ")) {
+			at++
+		}
+		label := "Occupation lists:"
+		if at > i+1 && strings.Contains(lines[at-1], "**") {
+			label = "**Occupation lists:**"
+		}
+		entry := "- " + label + " " + describeOccupationLists(occupation, statuses) + " (lists version " + occupationLists.Version + ")"
+		lines = append(lines[:at], append([]string{entry}, lines[at:]...)...)
+		return strings.Join(lines, "\n")
+	}
+	return markdown
+}
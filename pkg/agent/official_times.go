@@ -0,0 +1,210 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultIRCCTimesURL is the JSON document behind IRCC's processing-times
+// tool
+const defaultIRCCTimesURL = "https://www.canada.ca/content/dam/ircc/documents/json/data-ptime-en.json"
+
+// officialTimesRetry is how long a failed download is remembered before the
+// source is tried again
+const officialTimesRetry = 5 * time.Minute
+
+// OfficialTime is a program's processing time as published by the
+// destination's immigration authority
+type OfficialTime struct {
+	Program   string    `json:"program"`
+	Time      string    `json:"time"`
+	Source    string    `json:"source"` // the authority, e.g. IRCC
+	URL       string    `json:"url"`    // the page users can check it on
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// officialTimesSource is an official processing-times endpoint. Its
+// document maps keys to a time, or to times by ISO country code of the
+// applicant for programs that vary by where the application comes from.
+type officialTimesSource struct {
+	Authority string
+	URL       string            // the JSON document
+	PageURL   string            // cited in answers
+	Programs  map[string]string // program name to its key in the document
+
+	mu        sync.Mutex
+	doc       map[string]json.RawMessage
+	fetchedAt time.Time
+	failedAt  time.Time
+}
+
+// OfficialTimes is the processing-times tool: it looks up the recommended
+// program at the destination's official source, keeping each document for
+// a TTL
+type OfficialTimes struct {
+	client  *http.Client
+	clock   Clock
+	ttl     time.Duration
+	sources map[string]*officialTimesSource // by destination country
+}
+
+// NewOfficialTimes configures the official sources: IRCC for Canada, from
+// IRCC_PROCESSING_TIMES_URL ("off" disables it). Documents are cached for
+// OFFICIAL_TIMES_CACHE_TTL, 6h by default.
+func NewOfficialTimes() *OfficialTimes {
+	t := &OfficialTimes{
+		client:  &http.Client{Timeout: 5 * time.Second},
+		clock:   systemClock{},
+		ttl:     6 * time.Hour,
+		sources: make(map[string]*officialTimesSource),
+	}
+	if v := os.Getenv("OFFICIAL_TIMES_CACHE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			t.ttl = d
+		} else {
+			log.Printf("⚠️  Invalid OFFICIAL_TIMES_CACHE_TTL %q, using %s", v, t.ttl)
+		}
+	}
+
+	irccURL := os.Getenv("IRCC_PROCESSING_TIMES_URL")
+	if irccURL == "" {
+		irccURL = defaultIRCCTimesURL
+	}
+	if irccURL != "off" {
+		t.sources["canada"] = &officialTimesSource{
+			Authority: "IRCC",
+			URL:       irccURL,
+			PageURL:   "https://www.canada.ca/en/immigration-refugees-citizenship/services/application/check-processing-times.html",
+			Programs: map[string]string{
+				"Express Entry (Federal Skilled Worker)": "fsw",
+				"Canadian Experience Class":              "cec",
+				"Federal Skilled Trades":                 "fst",
+				"Provincial Nominee Program":             "pnp",
+				"Study Permit":                           "study",
+				"Work Permit":                            "work",
+				"Visitor Visa":                           "visitor-outside-canada",
+			},
+		}
+	}
+	return t
+}
+
+// Lookup returns the official processing time of the program a
+// recommendation names for applicants from originCode (an ISO 3166 code,
+// "" if unknown), or nil when the destination has no official source or it
+// doesn't list the program. A source that can't be reached is logged and
+// served from its last copy, if any.
+func (t *OfficialTimes) Lookup(ctx context.Context, country, pathway, originCode string) *OfficialTime {
+	source := t.sources[strings.ToLower(country)]
+	if source == nil {
+		return nil
+	}
+	names := make([]string, 0, len(source.Programs))
+	for name := range source.Programs {
+		names = append(names, name)
+	}
+	program := matchProgram(pathway, names)
+	if program == "" {
+		return nil
+	}
+
+	doc, checkedAt := t.document(ctx, source)
+	raw, ok := doc[source.Programs[program]]
+	if !ok {
+		return nil
+	}
+	var published string
+	if json.Unmarshal(raw, &published) != nil {
+		var byCountry map[string]string
+		if json.Unmarshal(raw, &byCountry) != nil || originCode == "" {
+			return nil
+		}
+		published = byCountry[strings.ToUpper(originCode)]
+	}
+	if published = strings.TrimSpace(published); published == "" {
+		return nil
+	}
+	return &OfficialTime{Program: program, Time: published, Source: source.Authority, URL: source.PageURL, CheckedAt: checkedAt}
+}
+
+// document returns a source's cached document, downloading it again once
+// it is older than the TTL
+func (t *OfficialTimes) document(ctx context.Context, s *officialTimesSource) (map[string]json.RawMessage, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := t.clock.Now()
+	if s.doc != nil && now.Sub(s.fetchedAt) < t.ttl || now.Sub(s.failedAt) < officialTimesRetry {
+		return s.doc, s.fetchedAt
+	}
+
+	doc, err := t.fetch(ctx, s.URL)
+	if err != nil {
+		s.failedAt = now
+		log.Printf("⚠️  Could not fetch %s processing times, using the copy from %s: %v", s.Authority, s.fetchedAt.Format(time.RFC3339), err)
+		return s.doc, s.fetchedAt
+	}
+	s.doc, s.fetchedAt = doc, now.UTC()
+	return s.doc, s.fetchedAt
+}
+
+func (t *OfficialTimes) fetch(ctx context.Context, url string) (map[string]json.RawMessage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source: %v", err)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed: status %d", resp.StatusCode)
+	}
+	var doc map[string]json.RawMessage
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDatasetSize)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse processing times: %v", err)
+	}
+	if len(doc) == 0 {
+		return nil, fmt.Errorf("processing times are empty")
+	}
+	return doc, nil
+}
+
+// processingLinePattern matches the Processing time line of the key details
+var processingLinePattern = regexp.MustCompile(`(?im)^([ \t]*[-*][ \t]+)(\*\*)?processing time(?:\*\*)?:(?:\*\*)?.*$`)
+
+// applyOfficialTime replaces the Processing time line of a recommendation
+// with the official figure, citing where and when it was checked. Answers
+// without the line are returned unchanged.
+func applyOfficialTime(markdown string, official *OfficialTime) string {
+	loc := processingLinePattern.FindStringSubmatchIndex(markdown)
+	if loc == nil || official == nil {
+		return markdown
+	}
+	label := "Processing time:"
+	if loc[4] != -1 {
+		label = "**Processing time:**"
+	}
+	line := fmt.Sprintf("%s%s %s (official %s processing time for %s, checked %s: %s)",
+		markdown[loc[2]:loc[3]], label, official.Time, official.Source, official.Program, official.CheckedAt.Format("2006-01-02"), official.URL)
+	return markdown[:loc[0]] + line + markdown[loc[1]:]
+}
+
+// localeCode returns the ISO 3166 code of a locale's country
+func localeCode(locale Locale) string {
+	for code, l := range locales {
+		if l.Country == locale.Country {
+			return code
+		}
+	}
+	return ""
+}
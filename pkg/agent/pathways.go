@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// promptVersion identifies the prompt template in audit records; bump it
+// whenever buildPrompt changes meaningfully
+const promptVersion = "2025-11-v11"
+
+// groundingTools returns the Google Search tool when the grounding flag is
+// on for the tenant, or nil
+func groundingTools(tenant *Tenant) []llm.GeminiTool {
+	if !tenant.enabled(FlagGrounding) {
+		return nil
+	}
+	return []llm.GeminiTool{{GoogleSearch: &struct{}{}}}
+}
+
+// buildPrompt constructs the prompt for Gemini from the full user query,
+// letting Gemini extract the profile, plus any attached CV, the destination
+// specialist's context, the corridor's pack and the requested answer style
+func buildPrompt(userQuery string, profile parser.UserProfile, style AnswerStyle, specialist *Specialist) string {
+	prompt := `You are a migration planning expert. Provide personalized migration pathway recommendations in a well-structured markdown format.
+
+CRITICAL BEHAVIOR RULES:
+- Never ask the user for additional information or clarifying questions.
+- Extract profession, origin country, and destination country directly from the user's query.
+- If any information is unclear or missing, make reasonable assumptions and proceed.
+- Placeholders such as [NAME], [EMAIL], [PHONE] or [PASSPORT] mark personal data removed for privacy; ignore them.
+- Output exactly ONE best migration option. Do not include follow-up questions.
+
+TODAY'S DATE: ` + style.Now.Format("2006-01-02") + `
+
+USER QUERY:
+"` + userQuery + `"
+`
+	if profile.Budget > 0 {
+		prompt += fmt.Sprintf("\nBUDGET: $%d USD\n", profile.Budget)
+	}
+	if summary := profile.Summary(); summary != "" {
+		prompt += "\nKNOWN PROFILE (source in brackets):\n" + summary + "\n"
+	}
+	prompt += style.Context.promptContext()
+	if profile.DocumentFacts != "" {
+		prompt += "\nFACTS READ FROM UPLOADED DOCUMENT IMAGES:\n" + profile.DocumentFacts + "\n"
+	}
+	if profile.Resume != "" {
+		prompt += "\nAPPLICANT CV (extracted from an uploaded document; use it for profession, experience, education and languages):\n\"\"\"\n" + profile.Resume + "\n\"\"\"\n"
+	}
+	prompt += specialist.promptContext()
+	prompt += specialist.familyPromptContext(profile)
+	prompt += content().corridorPack(userQuery, specialist).promptContext()
+	prompt += style.Datasets.promptContext(specialist.Country, style.Now.Year())
+	prompt += occupationPromptContext(specialist.Country, profile.Profession)
+	prompt += functionPromptContext(recommendationFunctions(style.Tenant))
+	prompt += languageInstruction(style.Language)
+	prompt += style.toneInstruction()
+	prompt += style.Constraint
+	prompt += style.prompts().DetailFormats[style.detail()]
+
+	return prompt
+}
@@ -0,0 +1,384 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrPlanNotFound is returned for plan IDs that don't exist or belong to
+// another tenant
+var ErrPlanNotFound = errors.New("plan not found")
+
+// maxPlanName bounds the length of a plan's name
+const maxPlanName = 100
+
+// Plan is a recommendation the user chose to keep, saved under a name with
+// the profile it was made for. Reminders and the wizard's step progress
+// follow the task it was saved from.
+type Plan struct {
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	UserID         string            `json:"userId"`
+	Tenant         string            `json:"tenant,omitempty"`
+	TaskID         string            `json:"taskId"` // the task the plan was saved from
+	Pathway        string            `json:"pathway,omitempty"`
+	Country        string            `json:"country,omitempty"` // destination
+	Profile        map[string]string `json:"profile,omitempty"` // keyed by the wizard's field names
+	Recommendation string            `json:"recommendation,omitempty"`
+	Steps          []WizardStep      `json:"steps,omitempty"`
+	Notify         *ReminderParams   `json:"notify,omitempty"` // where policy-change alerts go; the task's push callback when unset
+	CreatedAt      time.Time         `json:"createdAt"`
+	UpdatedAt      time.Time         `json:"updatedAt"`
+}
+
+// summary leaves out the recommendation text, for listings
+func (p Plan) summary() Plan {
+	p.Recommendation = ""
+	return p
+}
+
+// PlanFilter narrows a plan query. Tenant is always matched, "" being the
+// default tenant, unless AllTenants is set.
+type PlanFilter struct {
+	UserID     string
+	TaskID     string
+	Tenant     string
+	AllTenants bool
+}
+
+func (f PlanFilter) matches(p Plan) bool {
+	if f.UserID != "" && p.UserID != f.UserID {
+		return false
+	}
+	if f.TaskID != "" && p.TaskID != f.TaskID {
+		return false
+	}
+	return f.AllTenants || p.Tenant == f.Tenant
+}
+
+// PlanStore keeps saved plans. The PostgreSQL task store keeps them in the
+// plans table; without one they are kept in memory.
+type PlanStore interface {
+	// SavePlan inserts or replaces a plan
+	SavePlan(ctx context.Context, p Plan) error
+	// LoadPlan returns a plan, or nil when it isn't stored
+	LoadPlan(ctx context.Context, id string) (*Plan, error)
+	// Plans returns matching plans, most recently updated first
+	Plans(ctx context.Context, filter PlanFilter) ([]Plan, error)
+	// DeletePlans removes a user's plans in every tenant and returns how
+	// many were removed
+	DeletePlans(ctx context.Context, userID string) (int, error)
+}
+
+// newPlanStore uses the task store for plans when it can keep them
+func newPlanStore(store TaskStore) PlanStore {
+	if ps, ok := store.(PlanStore); ok {
+		return ps
+	}
+	return &memoryPlans{plans: make(map[string]Plan)}
+}
+
+// memoryPlans keeps the plans saved in this process
+type memoryPlans struct {
+	mu    sync.Mutex
+	plans map[string]Plan
+}
+
+func (m *memoryPlans) SavePlan(_ context.Context, p Plan) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.plans[p.ID] = p
+	return nil
+}
+
+func (m *memoryPlans) LoadPlan(_ context.Context, id string) (*Plan, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.plans[id]
+	if !ok {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+func (m *memoryPlans) Plans(_ context.Context, filter PlanFilter) ([]Plan, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var results []Plan
+	for _, p := range m.plans {
+		if filter.matches(p) {
+			results = append(results, p)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].UpdatedAt.After(results[j].UpdatedAt) })
+	return results, nil
+}
+
+func (m *memoryPlans) DeletePlans(_ context.Context, userID string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	deleted := 0
+	for id, p := range m.plans {
+		if p.UserID == userID {
+			delete(m.plans, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// PlanSaveParams is the params of plans/save
+type PlanSaveParams struct {
+	ID        string            `json:"id,omitempty"` // replace this plan's recommendation instead of saving a new plan
+	TaskID    string            `json:"taskId"`
+	Name      string            `json:"name"`
+	UserID    string            `json:"userId,omitempty"`  // default the task's session
+	Profile   map[string]string `json:"profile,omitempty"` // corrections to the profile read from the task
+	Reminders *ReminderParams   `json:"reminders,omitempty"`
+	Notify    *ReminderParams   `json:"notify,omitempty"` // channel for policy-change alerts, default the reminders channel
+}
+
+// PlanIDParams is the params of plans/get
+type PlanIDParams struct {
+	ID string `json:"id"`
+}
+
+// PlanListParams is the params of plans/list
+type PlanListParams struct {
+	UserID string `json:"userId"`
+}
+
+// SavePlan saves the recommendation of a finished task, or the plan a
+// wizard generated, as a named plan. Reminders in the params are
+// scheduled for the plan's milestones on the recommendation's task.
+func (a *MigrationAgent) SavePlan(tenant *Tenant, params PlanSaveParams) (*Plan, error) {
+	params.Name = strings.TrimSpace(params.Name)
+	if params.TaskID == "" || params.Name == "" {
+		return nil, fmt.Errorf("taskId and name are required")
+	}
+	if len(params.Name) > maxPlanName {
+		return nil, fmt.Errorf("name must not exceed %d characters", maxPlanName)
+	}
+	if err := a.reminders.validateReminderParams(params.Reminders); err != nil {
+		return nil, err
+	}
+	if err := a.reminders.validateReminderParams(params.Notify); err != nil {
+		return nil, fmt.Errorf("notify: %v", err)
+	}
+	if params.Notify == nil && params.Reminders != nil {
+		params.Notify = &ReminderParams{Channel: params.Reminders.Channel, Target: params.Reminders.Target}
+	}
+
+	task, err := a.taskFor(tenant, params.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	a.mu.RLock()
+	wizard := task.Wizard.clone()
+	userID := params.UserID
+	if userID == "" {
+		userID = task.SessionID
+	}
+	a.mu.RUnlock()
+	if userID == "" {
+		return nil, fmt.Errorf("give a userId, or save a task sent with a sessionId")
+	}
+
+	// A wizard's recommendation is in the task it generated
+	source := task
+	if wizard != nil {
+		if wizard.PlanTaskID == "" {
+			return nil, fmt.Errorf("wizard %s hasn't generated its plan yet", params.TaskID)
+		}
+		if source, err = a.taskFor(tenant, wizard.PlanTaskID); err != nil {
+			return nil, err
+		}
+	}
+	a.mu.RLock()
+	state, query, milestones := source.Status.State, source.query, source.milestones
+	markdown := ""
+	if source.Status.Message != nil && len(source.Status.Message.Parts) > 0 {
+		markdown = source.Status.Message.Parts[0].Text
+	}
+	a.mu.RUnlock()
+	if state != TaskStateCompleted || markdown == "" {
+		return nil, fmt.Errorf("task %s has no recommendation to save (state %s)", source.ID, state)
+	}
+	if len(milestones) == 0 {
+		milestones = parseTimeline(markdown)
+	}
+
+	// The profile is the wizard's confirmed fields or what the query said,
+	// corrected by the caller
+	var fields []WizardField
+	if wizard != nil {
+		fields = wizard.Fields
+	} else {
+		fields = newWizardState(query, a.parseProfile(query), TaskOptions{}).Fields
+	}
+	profile := make(map[string]string)
+	for _, f := range fields {
+		if f.Value != "" {
+			profile[f.Name] = f.Value
+		}
+	}
+	for name, value := range params.Profile {
+		if !isWizardField(name) {
+			return nil, fmt.Errorf("unknown profile field %q", name)
+		}
+		parsed, ok := parseFieldValue(name, value)
+		if !ok {
+			return nil, fmt.Errorf("can't read %s from %q", name, value)
+		}
+		profile[name] = parsed
+	}
+
+	now := a.clock.Now().UTC()
+	plan := &Plan{ID: a.ids.NewID(), CreatedAt: now}
+	if params.ID != "" {
+		existing, err := a.loadPlan(tenant, params.ID)
+		if err != nil {
+			return nil, err
+		}
+		if existing.UserID != userID {
+			return nil, fmt.Errorf("%w: %s", ErrPlanNotFound, params.ID)
+		}
+		plan.ID, plan.CreatedAt, plan.Notify = existing.ID, existing.CreatedAt, existing.Notify
+	}
+	if params.Notify != nil {
+		plan.Notify = params.Notify
+	}
+	plan.Name, plan.UserID, plan.Tenant, plan.TaskID = params.Name, userID, tenant.id(), task.ID
+	plan.Pathway, plan.Country, plan.Profile = pathwayName(markdown), profile["destination"], profile
+	plan.Recommendation, plan.UpdatedAt = markdown, now
+	if wizard != nil {
+		plan.Steps = wizard.Steps
+	} else {
+		for _, m := range milestones {
+			plan.Steps = append(plan.Steps, WizardStep{Title: m.Title, Due: m.Date.Format("2006-01-02"), Status: StepPending})
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := a.plans.SavePlan(ctx, *plan); err != nil {
+		return nil, err
+	}
+
+	if params.Reminders != nil {
+		a.mu.Lock()
+		source.Reminders = scheduleReminders(milestones, *params.Reminders, now, a.ids)
+		a.mu.Unlock()
+		a.saveTask(source)
+	}
+	return plan, nil
+}
+
+// loadPlan returns a plan of the tenant
+func (a *MigrationAgent) loadPlan(tenant *Tenant, id string) (*Plan, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	plan, err := a.plans.LoadPlan(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil || plan.Tenant != tenant.id() {
+		return nil, fmt.Errorf("%w: %s", ErrPlanNotFound, id)
+	}
+	return plan, nil
+}
+
+// syncPlanSteps copies a wizard's step progress to the plans saved from it
+func (a *MigrationAgent) syncPlanSteps(task *Task) {
+	a.mu.RLock()
+	filter := PlanFilter{TaskID: task.ID, Tenant: task.tenant}
+	steps := task.Wizard.clone().Steps
+	a.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	plans, err := a.plans.Plans(ctx, filter)
+	if err != nil {
+		log.Printf("⚠️  Failed to update the plans of wizard %s: %v", task.ID, err)
+		return
+	}
+	for _, plan := range plans {
+		plan.Steps, plan.UpdatedAt = steps, a.clock.Now().UTC()
+		if err := a.plans.SavePlan(ctx, plan); err != nil {
+			log.Printf("⚠️  Failed to update plan %s: %v", plan.ID, err)
+		}
+	}
+}
+
+func isWizardField(name string) bool {
+	for _, f := range wizardFields {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// handlePlansSave processes the plans/save RPC method
+func (a *MigrationAgent) handlePlansSave(w http.ResponseWriter, req JSONRPCRequest) {
+	var params PlanSaveParams
+	if err := remarshal(req.Params, &params); err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+	plan, err := a.SavePlan(req.tenant, params)
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+	a.sendSuccess(w, plan, req.ID)
+}
+
+// handlePlansGet processes the plans/get RPC method
+func (a *MigrationAgent) handlePlansGet(w http.ResponseWriter, req JSONRPCRequest) {
+	var params PlanIDParams
+	if err := remarshal(req.Params, &params); err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+	plan, err := a.loadPlan(req.tenant, params.ID)
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+	a.sendSuccess(w, plan, req.ID)
+}
+
+// handlePlansList processes the plans/list RPC method, listing a user's
+// plans without their recommendation text
+func (a *MigrationAgent) handlePlansList(w http.ResponseWriter, req JSONRPCRequest) {
+	var params PlanListParams
+	if err := remarshal(req.Params, &params); err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+	if params.UserID == "" {
+		a.sendError(w, nil, ErrCodeInvalidParams, "userId is required", req.ID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	plans, err := a.plans.Plans(ctx, PlanFilter{UserID: params.UserID, Tenant: req.tenant.id()})
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeInternal, req.ID)
+		return
+	}
+	summaries := []Plan{}
+	for _, plan := range plans {
+		summaries = append(summaries, plan.summary())
+	}
+	a.sendSuccess(w, map[string]interface{}{"plans": summaries}, req.ID)
+}
@@ -0,0 +1,233 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// PolicyChange is how one program's fees or processing time changed in a
+// dataset refresh
+type PolicyChange struct {
+	Dataset string   `json:"dataset"` // DatasetFees or DatasetProcessingTimes
+	Country string   `json:"country"`
+	Program string   `json:"program"`
+	Changes []string `json:"changes"` // e.g. "application fee: was 1,365 CAD, now 1,525 CAD"
+	Version string   `json:"version"` // the new dataset version
+}
+
+// PolicyAlert tells the owner of a saved plan that the program it follows
+// changed
+type PolicyAlert struct {
+	PlanID   string         `json:"planId"`
+	PlanName string         `json:"planName"`
+	UserID   string         `json:"userId"`
+	Pathway  string         `json:"pathway,omitempty"`
+	Changes  []PolicyChange `json:"changes"`
+	Summary  string         `json:"summary"`
+}
+
+// AlertDelivery sends policy-change alerts over a reminder channel
+type AlertDelivery interface {
+	DeliverAlert(tenantID, target string, alert PolicyAlert) error
+}
+
+// policyChanges compares the fees or processing times in effect before and
+// after a refresh, program by program, using the fees of year. Other
+// datasets don't change policy.
+func policyChanges(name string, before, after *Datasets, year int) []PolicyChange {
+	var old, current map[string]map[string]string
+	switch name {
+	case DatasetFees:
+		old, current = feesByProgram(before, year), feesByProgram(after, year)
+	case DatasetProcessingTimes:
+		old, current = processingTimesByProgram(before), processingTimesByProgram(after)
+	default:
+		return nil
+	}
+
+	var changes []PolicyChange
+	for _, key := range programKeys(current, old) {
+		country, program, _ := strings.Cut(key, "|")
+		var lines []string
+		for _, item := range itemKeys(current[key], old[key]) {
+			was, wasOK := old[key][item]
+			now, nowOK := current[key][item]
+			switch {
+			case !wasOK:
+				lines = append(lines, fmt.Sprintf("%s: now %s", item, now))
+			case !nowOK:
+				lines = append(lines, fmt.Sprintf("%s: no longer listed (was %s)", item, was))
+			case was != now:
+				lines = append(lines, fmt.Sprintf("%s: was %s, now %s", item, was, now))
+			}
+		}
+		if len(lines) > 0 {
+			changes = append(changes, PolicyChange{Dataset: name, Country: country, Program: program, Changes: lines, Version: after.Versions[name].Version})
+		}
+	}
+	return changes
+}
+
+// feesByProgram describes each fee in effect, keyed by country and program
+// and then by item
+func feesByProgram(d *Datasets, year int) map[string]map[string]string {
+	programs := make(map[string]map[string]string)
+	seen := make(map[string]bool)
+	for _, fee := range d.Fees {
+		if seen[strings.ToLower(fee.Country)] {
+			continue
+		}
+		seen[strings.ToLower(fee.Country)] = true
+		for _, current := range d.currentFees(fee.Country, year) {
+			key := current.Country + "|" + current.Program
+			if programs[key] == nil {
+				programs[key] = make(map[string]string)
+			}
+			amount := formatFee(current.Amount, current.Currency)
+			if current.Per != "" {
+				amount += " per " + current.Per
+			}
+			programs[key][current.Item] = amount
+		}
+	}
+	return programs
+}
+
+// processingTimesByProgram keys each processing time by country and program
+func processingTimesByProgram(d *Datasets) map[string]map[string]string {
+	programs := make(map[string]map[string]string)
+	for _, pt := range d.ProcessingTimes {
+		programs[pt.Country+"|"+pt.Program] = map[string]string{"processing time": pt.Time}
+	}
+	return programs
+}
+
+// programKeys lists the programs in either snapshot once, sorted
+func programKeys(a, b map[string]map[string]string) []string {
+	seen := make(map[string]string)
+	for k := range a {
+		seen[k] = ""
+	}
+	for k := range b {
+		seen[k] = ""
+	}
+	return itemKeys(seen, nil)
+}
+
+// itemKeys lists the keys of both maps once, sorted
+func itemKeys(a, b map[string]string) []string {
+	var keys []string
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// alertPolicyChange is called when a dataset refresh swaps in a new
+// version. Owners of saved plans following a program whose fees or
+// processing time changed are told over the plan's notify channel, or the
+// push callback of the task the plan was saved from.
+func (a *MigrationAgent) alertPolicyChange(name string, before, after *Datasets) {
+	changes := policyChanges(name, before, after, a.clock.Now().Year())
+	if len(changes) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	plans, err := a.plans.Plans(ctx, PlanFilter{AllTenants: true})
+	if err != nil {
+		log.Printf("⚠️  Could not load saved plans to alert about the %s update: %v", name, err)
+		return
+	}
+	var alerted int
+	for _, plan := range plans {
+		alert := planAlert(plan, changes)
+		if alert == nil {
+			continue
+		}
+		if err := a.deliverAlert(plan, *alert); err != nil {
+			log.Printf("⚠️  Policy-change alert for plan %s failed: %v", plan.ID, err)
+			continue
+		}
+		alerted++
+	}
+	log.Printf("📣 %s update changed %d programs; alerted %d saved plans", name, len(changes), alerted)
+}
+
+// planAlert picks the changes to the program a plan follows, or returns nil
+// when there are none
+func planAlert(plan Plan, changes []PolicyChange) *PolicyAlert {
+	var relevant []PolicyChange
+	for _, change := range changes {
+		if strings.EqualFold(change.Country, plan.Country) && matchProgram(plan.Pathway, []string{change.Program}) != "" {
+			relevant = append(relevant, change)
+		}
+	}
+	if len(relevant) == 0 {
+		return nil
+	}
+
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "The official figures for the program your plan %q follows have changed:\n", plan.Name)
+	for _, change := range relevant {
+		for _, line := range change.Changes {
+			fmt.Fprintf(&summary, "- %s, %s\n", change.Program, line)
+		}
+	}
+	summary.WriteString("Your budget and timeline may need updating. Ask again about the same move to get a recommendation that shows what changed.")
+	return &PolicyAlert{PlanID: plan.ID, PlanName: plan.Name, UserID: plan.UserID, Pathway: plan.Pathway, Changes: relevant, Summary: summary.String()}
+}
+
+// deliverAlert sends an alert over the plan's notify channel, falling back
+// to the push callback of the task it was saved from
+func (a *MigrationAgent) deliverAlert(plan Plan, alert PolicyAlert) error {
+	if plan.Notify != nil {
+		delivery, ok := a.reminders.deliveries[plan.Notify.Channel].(AlertDelivery)
+		if !ok {
+			return fmt.Errorf("alert channel not configured: %s", plan.Notify.Channel)
+		}
+		return delivery.DeliverAlert(plan.Tenant, plan.Notify.Target, alert)
+	}
+
+	task, ok := a.lookupTask(plan.TaskID)
+	if !ok {
+		return fmt.Errorf("no notify channel, and task %s is gone", plan.TaskID)
+	}
+	a.mu.RLock()
+	config := task.PushNotification
+	a.mu.RUnlock()
+	if config == nil {
+		return fmt.Errorf("no notify channel or push callback")
+	}
+	payload, err := json.Marshal(map[string]interface{}{"type": "policyChange", "taskId": plan.TaskID, "alert": alert})
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %v", err)
+	}
+	go a.deliverPush(plan.TaskID, plan.Tenant, config, payload)
+	return nil
+}
+
+func (d *webhookDelivery) DeliverAlert(tenantID, target string, alert PolicyAlert) error {
+	payload, err := json.Marshal(map[string]interface{}{"type": "policyChange", "alert": alert})
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %v", err)
+	}
+	return d.post(target, tenantID, payload)
+}
+
+func (d *emailDelivery) DeliverAlert(_, target string, alert PolicyAlert) error {
+	subject := fmt.Sprintf("Policy change affecting your plan: %s", alert.PlanName)
+	body := strings.ReplaceAll(alert.Summary, "\n", "\r\n") + "\r\n\r\nPlan ID: " + alert.PlanID + "\r\n"
+	return d.send(target, subject, body)
+}
@@ -0,0 +1,243 @@
+package agent
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// DataExport is the data-subject access response for one session
+type DataExport struct {
+	SessionID  string    `json:"sessionId"`
+	ExportedAt time.Time `json:"exportedAt"`
+	Tasks      []*Task   `json:"tasks"`
+	Uploads    []*Upload `json:"uploads"`
+	Plans      []Plan    `json:"plans"` // plans saved for the session as the user
+
+	// DeadLetters are push notifications about the session's tasks that
+	// could not be delivered
+	DeadLetters []DeadLetter `json:"deadLetters"`
+
+	// Audit holds the audit log entries of the session's tasks and the
+	// feedback on them, newest first
+	Audit []AuditEntry `json:"audit"`
+}
+
+// sessionAudit matches the audit entries of a session or of its tasks
+func sessionAudit(sessionID string, taskIDs map[string]bool) func(AuditEntry) bool {
+	return func(e AuditEntry) bool {
+		return (e.SessionID != "" && e.SessionID == sessionID) || taskIDs[e.TaskID]
+	}
+}
+
+// TasksForSession returns all tasks recorded for a session, oldest first,
+// including those only in the task store
+func (a *MigrationAgent) TasksForSession(sessionID string) ([]*Task, error) {
+	var stored []*Task
+	if a.store != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		defer cancel()
+		var err error
+		if stored, err = a.store.ForSession(ctx, sessionID); err != nil {
+			return nil, err
+		}
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var tasks []*Task
+	seen := make(map[string]bool)
+	for _, task := range a.tasks.Tasks() {
+		if task.SessionID == sessionID {
+			tasks = append(tasks, task)
+			seen[task.ID] = true
+		}
+	}
+	for _, task := range stored {
+		if !seen[task.ID] {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+	})
+	return tasks, nil
+}
+
+// DeleteSessionData removes every task (including its artifacts, scheduled
+// reminders and undelivered push notifications) recorded for a session, in
+// memory and in the task store, and returns how many were removed. The
+// session's audit log entries are reduced to tombstones.
+func (a *MigrationAgent) DeleteSessionData(sessionID string) (int, error) {
+	deleted := make(map[string]bool)
+	if a.store != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		defer cancel()
+		ids, err := a.store.DeleteSession(ctx, sessionID)
+		if err != nil {
+			return 0, err
+		}
+		for _, id := range ids {
+			deleted[id] = true
+		}
+	}
+
+	a.mu.Lock()
+	for _, task := range a.tasks.Tasks() {
+		if task.SessionID == sessionID {
+			a.tasks.Delete(task.ID)
+			deleted[task.ID] = true
+		}
+	}
+	a.mu.Unlock()
+
+	// Files kept in object storage and failed deliveries, which hold the
+	// whole task, go with their tasks
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ids := make([]string, 0, len(deleted))
+	for id := range deleted {
+		if _, err := a.files.DeleteTask(ctx, id); err != nil {
+			return len(deleted), err
+		}
+		ids = append(ids, id)
+	}
+	letters, err := a.deadLetters.DeleteDeadLetters(ctx, ids)
+	if err != nil {
+		return len(deleted), err
+	}
+	if letters > 0 {
+		log.Printf("Deleted %d dead letter(s) for session %s", letters, sessionID)
+	}
+	erased, err := a.audit.Erase(sessionAudit(sessionID, deleted))
+	if err != nil {
+		return len(deleted), err
+	}
+	if erased > 0 {
+		log.Printf("Erased %d audit entry(ies) for session %s", erased, sessionID)
+	}
+	return len(deleted), nil
+}
+
+// ServePrivacyExport handles GET /privacy/export?sessionId=...
+func (a *MigrationAgent) ServePrivacyExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	sessionID := r.URL.Query().Get("sessionId")
+	if sessionID == "" {
+		writeJSONError(w, http.StatusBadRequest, "sessionId is required")
+		return
+	}
+
+	tasks, err := a.TasksForSession(sessionID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if tasks == nil {
+		tasks = []*Task{}
+	}
+	a.mu.RLock()
+	for i, task := range tasks {
+		tasks[i] = a.files.Resign(task)
+	}
+	a.mu.RUnlock()
+	uploads := a.uploads.ForSession(sessionID)
+	if uploads == nil {
+		uploads = []*Upload{}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	plans, err := a.plans.Plans(ctx, PlanFilter{UserID: sessionID, AllTenants: true})
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if plans == nil {
+		plans = []Plan{}
+	}
+	letters, err := a.deadLettersForTasks(ctx, tasks)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if letters == nil {
+		letters = []DeadLetter{}
+	}
+	taskIDs := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		taskIDs[task.ID] = true
+	}
+	entries, err := a.audit.Query(AuditFilter{})
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	audit := []AuditEntry{}
+	match := sessionAudit(sessionID, taskIDs)
+	for _, entry := range entries {
+		if match(entry) {
+			audit = append(audit, entry)
+		}
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="data-export.json"`)
+	writeJSON(w, http.StatusOK, DataExport{
+		SessionID:  sessionID,
+		ExportedAt: a.clock.Now().UTC(),
+		Tasks:      tasks,
+		Uploads:    uploads,
+		Plans:      plans,
+
+		DeadLetters: letters,
+		Audit:       audit,
+	})
+}
+
+// ServePrivacyDelete handles DELETE /privacy/data?sessionId=...
+func (a *MigrationAgent) ServePrivacyDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	sessionID := r.URL.Query().Get("sessionId")
+	if sessionID == "" {
+		writeJSONError(w, http.StatusBadRequest, "sessionId is required")
+		return
+	}
+
+	deleted, err := a.DeleteSessionData(sessionID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	deletedUploads := a.uploads.DeleteSession(sessionID)
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	deletedPlans, err := a.plans.DeletePlans(ctx, sessionID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	log.Printf("Deleted %d task(s), %d upload(s) and %d plan(s) for session %s on data-subject request", deleted, deletedUploads, deletedPlans, sessionID)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"sessionId":      sessionID,
+		"deletedTasks":   deleted,
+		"deletedUploads": deletedUploads,
+		"deletedPlans":   deletedPlans,
+	})
+}
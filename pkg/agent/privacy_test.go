@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDeleteSessionDataRemovesDeadLetters(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	a := NewMigrationAgent()
+
+	message := Message{Role: "user", Parts: []Part{{Kind: "text", Text: "Nurse from India wanting to move to UK"}}}
+	task, err := a.ProcessTask("task-1", message, TaskOptions{SessionID: "user-1"})
+	if err != nil {
+		t.Fatalf("ProcessTask: %v", err)
+	}
+	a.recordDeadLetter(DeadLetter{TaskID: task.ID, URL: "https://example.com/hook", Payload: []byte(`{"sessionId":"user-1"}`)})
+	a.recordDeadLetter(DeadLetter{TaskID: "other-task", URL: "https://example.com/hook", Payload: []byte(`{}`)})
+
+	ctx := context.Background()
+	letters, err := a.deadLettersForTasks(ctx, []*Task{task})
+	if err != nil || len(letters) != 1 {
+		t.Fatalf("deadLettersForTasks = %d letters, %v; want 1", len(letters), err)
+	}
+
+	if _, err := a.DeleteSessionData("user-1"); err != nil {
+		t.Fatalf("DeleteSessionData: %v", err)
+	}
+	if letters, _ := a.deadLetters.DeadLetters(ctx, DeadLetterFilter{TaskID: task.ID}); len(letters) != 0 {
+		t.Errorf("%d dead letter(s) of the deleted session remain", len(letters))
+	}
+	if letters, _ := a.deadLetters.DeadLetters(ctx, DeadLetterFilter{TaskID: "other-task"}); len(letters) != 1 {
+		t.Errorf("dead letters of other sessions were deleted")
+	}
+}
+
+func TestPrivacyExportAndDeleteCoverTheAuditLog(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	t.Setenv("ADMIN_API_KEY", "admin-key")
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	t.Setenv("AUDIT_LOG_PATH", path)
+	t.Setenv("LLM_MOCK_LATENCY", "0s")
+	a := NewMigrationAgent()
+
+	message := Message{Role: "user", Parts: []Part{{Kind: "text", Text: "Nurse from India wanting to move to UK"}}}
+	for _, session := range []string{"user-1", "user-2"} {
+		if _, err := a.ProcessTask("task-"+session, message, TaskOptions{SessionID: session}); err != nil {
+			t.Fatalf("ProcessTask: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/privacy/export?sessionId=user-1", nil)
+	req.Header.Set("Authorization", "Bearer admin-key")
+	a.ServePrivacyExport(rec, req)
+	var export DataExport
+	if err := json.Unmarshal(rec.Body.Bytes(), &export); err != nil {
+		t.Fatalf("decoding export: %v", err)
+	}
+	if len(export.Audit) != 1 || export.Audit[0].TaskID != "task-user-1" {
+		t.Fatalf("export audit = %+v, want the entry of task-user-1", export.Audit)
+	}
+
+	if _, err := a.DeleteSessionData("user-1"); err != nil {
+		t.Fatalf("DeleteSessionData: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading audit log: %v", err)
+	}
+	if strings.Contains(string(data), "user-1") || strings.Count(string(data), "Nurse") != 1 {
+		t.Errorf("audit log still holds the deleted session:\n%s", data)
+	}
+
+	entries, err := a.audit.Query(AuditFilter{})
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Query = %d entries, %v; want 2", len(entries), err)
+	}
+	erased := 0
+	for _, entry := range entries {
+		if entry.Erased {
+			erased++
+			if entry.Outcome == "" || entry.Model == "" {
+				t.Errorf("tombstone lost the fields metering counts: %+v", entry)
+			}
+		}
+	}
+	if erased != 1 {
+		t.Errorf("%d entries erased, want 1", erased)
+	}
+
+	// Entries appended after the rewrite still reach the file
+	a.audit.Append(AuditEntry{TaskID: "task-3", Outcome: "completed"})
+	if entries, _ := a.audit.Query(AuditFilter{TaskID: "task-3"}); len(entries) != 1 {
+		t.Error("entry appended after erasing was lost")
+	}
+}
@@ -0,0 +1,209 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PushNotificationConfig tells the agent where to send task updates
+type PushNotificationConfig struct {
+	URL            string              `json:"url"`
+	Token          string              `json:"token,omitempty"` // echoed in X-A2A-Notification-Token
+	Authentication *AuthenticationInfo `json:"authentication,omitempty"`
+	Headers        map[string]string   `json:"headers,omitempty"` // extra headers, e.g. an API key
+}
+
+// AuthenticationInfo describes how to authenticate to the callback URL
+type AuthenticationInfo struct {
+	Schemes     []string `json:"schemes"`               // Bearer or Basic
+	Credentials string   `json:"credentials,omitempty"` // token, or base64 user:password for Basic
+}
+
+// TaskPushNotificationConfig is the params and result of the
+// tasks/pushNotificationConfig methods
+type TaskPushNotificationConfig struct {
+	TaskID                 string                  `json:"taskId"`
+	ID                     string                  `json:"id,omitempty"` // older spelling of taskId
+	PushNotificationConfig *PushNotificationConfig `json:"pushNotificationConfig,omitempty"`
+}
+
+// pushClient sends push notifications
+var pushClient = &http.Client{Timeout: 10 * time.Second}
+
+// validatePushConfig checks a callback configuration before it is stored
+func validatePushConfig(config *PushNotificationConfig) error {
+	if config == nil {
+		return fmt.Errorf("pushNotificationConfig is required")
+	}
+	u, err := url.Parse(config.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("pushNotificationConfig.url must be an http(s) URL")
+	}
+	if auth := config.Authentication; auth != nil {
+		for _, scheme := range auth.Schemes {
+			if !strings.EqualFold(scheme, "bearer") && !strings.EqualFold(scheme, "basic") {
+				return fmt.Errorf("unsupported authentication scheme %q (expected Bearer or Basic)", scheme)
+			}
+		}
+		if len(auth.Schemes) > 0 && auth.Credentials == "" {
+			return fmt.Errorf("pushNotificationConfig.authentication.credentials is required")
+		}
+	}
+	for name := range config.Headers {
+		if strings.EqualFold(name, "Content-Type") || strings.EqualFold(name, "Host") || strings.EqualFold(name, "Content-Length") ||
+			strings.EqualFold(name, SignatureHeader) || strings.EqualFold(name, SignatureTimestampHeader) {
+			return fmt.Errorf("header %q cannot be overridden", name)
+		}
+	}
+	return nil
+}
+
+// handlePushConfigSet processes tasks/pushNotificationConfig/set
+func (a *MigrationAgent) handlePushConfigSet(w http.ResponseWriter, req JSONRPCRequest) {
+	var params TaskPushNotificationConfig
+	if err := remarshal(req.Params, &params); err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+	if params.TaskID == "" {
+		params.TaskID = params.ID
+	}
+	if err := validatePushConfig(params.PushNotificationConfig); err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	task, err := a.taskFor(req.tenant, params.TaskID)
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+	a.mu.Lock()
+	task.PushNotification = params.PushNotificationConfig
+	a.mu.Unlock()
+	a.saveTask(task)
+
+	// Work finished before the callback was attached is reported right away
+	if task.Status.State.Terminal() {
+		go a.notifyPush(task)
+	}
+
+	a.sendSuccess(w, TaskPushNotificationConfig{TaskID: task.ID, PushNotificationConfig: params.PushNotificationConfig}, req.ID)
+}
+
+// handlePushConfigGet processes tasks/pushNotificationConfig/get
+func (a *MigrationAgent) handlePushConfigGet(w http.ResponseWriter, req JSONRPCRequest) {
+	var params TaskIDParams
+	if err := remarshal(req.Params, &params); err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	task, err := a.taskFor(req.tenant, params.ID)
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	a.mu.RLock()
+	config := task.PushNotification
+	a.mu.RUnlock()
+	if config == nil {
+		err := fmt.Errorf("no push notification config for task %s", task.ID)
+		a.sendError(w, err, ErrCodeInvalidParams, err.Error(), req.ID)
+		return
+	}
+
+	a.sendSuccess(w, TaskPushNotificationConfig{TaskID: task.ID, PushNotificationConfig: config}, req.ID)
+}
+
+// notifyPush POSTs the task to its push notification URL, if configured,
+// retrying failed deliveries
+func (a *MigrationAgent) notifyPush(task *Task) {
+	a.mu.RLock()
+	config, tenant := task.PushNotification, task.tenant
+	payload, err := json.Marshal(task)
+	a.mu.RUnlock()
+	if config == nil {
+		return
+	}
+	if err != nil {
+		log.Printf("failed to marshal push notification for task %s: %v", task.ID, err)
+		return
+	}
+
+	a.deliverPush(task.ID, tenant, config, payload)
+}
+
+// pushStatusError is a callback that answered with an error status
+type pushStatusError struct {
+	url    string
+	status int
+}
+
+func (e *pushStatusError) Error() string {
+	return fmt.Sprintf("%s returned status %d", e.url, e.status)
+}
+
+// retryablePush reports whether a failed delivery may succeed later. A
+// callback that rejects the request outright, e.g. with 401 or 404, won't
+// accept it on a retry either.
+func retryablePush(err error) bool {
+	var statusErr *pushStatusError
+	if !errors.As(err, &statusErr) {
+		return true
+	}
+	return statusErr.status >= 500 || statusErr.status == http.StatusTooManyRequests || statusErr.status == http.StatusRequestTimeout
+}
+
+// pushStatus returns the HTTP status a failed delivery got, or 0 if the
+// callback couldn't be reached
+func pushStatus(err error) int {
+	var statusErr *pushStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status
+	}
+	return 0
+}
+
+// sendPushNotification delivers a payload with the configured credentials,
+// signed with secret when there is one at time now
+func sendPushNotification(config *PushNotificationConfig, payload []byte, secret string, now time.Time) error {
+	req, err := http.NewRequest(http.MethodPost, config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	for name, value := range config.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if config.Token != "" {
+		req.Header.Set("X-A2A-Notification-Token", config.Token)
+	}
+	if auth := config.Authentication; auth != nil && len(auth.Schemes) > 0 {
+		scheme := "Bearer"
+		if strings.EqualFold(auth.Schemes[0], "basic") {
+			scheme = "Basic"
+		}
+		req.Header.Set("Authorization", scheme+" "+auth.Credentials)
+	}
+	signWebhook(req, payload, secret, now)
+
+	resp, err := pushClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %v", config.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return &pushStatusError{url: config.URL, status: resp.StatusCode}
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// TaskJob is a tasks/send call queued for a worker
+type TaskJob struct {
+	TaskID     string      `json:"taskId"`
+	Message    Message     `json:"message"`
+	Options    TaskOptions `json:"options"`
+	EnqueuedAt time.Time   `json:"enqueuedAt"`
+
+	// Output and status history of an earlier run of the same task ID, so
+	// reprocessing keeps versions as it does without a queue
+	Artifacts []Artifact   `json:"artifacts,omitempty"`
+	History   []TaskStatus `json:"history,omitempty"`
+
+	// When the task was first held for an exhausted Gemini quota and when
+	// the quota resets; held jobs go back on the queue until then
+	HeldSince time.Time `json:"heldSince,omitempty"`
+	RetryAt   time.Time `json:"retryAt,omitempty"`
+}
+
+// TaskQueues carries jobs from the API tier to workers and finished tasks
+// back. Both are nil unless queue mode is configured.
+type TaskQueues struct {
+	Jobs    MessageQueue
+	Results MessageQueue
+}
+
+// queueVisibility is how long a worker has to finish a job before another
+// worker may pick it up
+const queueVisibility = 5 * time.Minute
+
+// NewTaskQueues configures queue mode from TASK_QUEUE_URL and
+// TASK_RESULTS_QUEUE_URL, both Amazon SQS queue URLs. Without them tasks
+// are processed in the API process. Requests are signed with clock's time.
+func NewTaskQueues(clock Clock) *TaskQueues {
+	jobsURL, resultsURL := os.Getenv("TASK_QUEUE_URL"), os.Getenv("TASK_RESULTS_QUEUE_URL")
+	if jobsURL == "" && resultsURL == "" {
+		return &TaskQueues{}
+	}
+	if jobsURL == "" || resultsURL == "" {
+		log.Printf("⚠️  Queue mode needs both TASK_QUEUE_URL and TASK_RESULTS_QUEUE_URL; processing tasks in-process")
+		return &TaskQueues{}
+	}
+
+	jobs, err := newSQSQueue(jobsURL, queueVisibility, clock)
+	if err == nil {
+		var results *sqsQueue
+		if results, err = newSQSQueue(resultsURL, time.Minute, clock); err == nil {
+			return &TaskQueues{Jobs: jobs, Results: results}
+		}
+	}
+	log.Printf("⚠️  Queue mode disabled, processing tasks in-process: %v", err)
+	return &TaskQueues{}
+}
+
+// Enabled reports whether tasks are handed to workers
+func (q *TaskQueues) Enabled() bool {
+	return q.Jobs != nil && q.Results != nil
+}
+
+// EnqueueTask stores a submitted task and queues it for a worker, returning
+// the task without waiting for it to be processed. Clients follow it with
+// tasks/get or a push notification.
+func (a *MigrationAgent) EnqueueTask(taskID string, message Message, opts TaskOptions) (*Task, error) {
+	task := &Task{
+		ID:        taskID,
+		SessionID: opts.SessionID,
+		Kind:      "task",
+		Metadata:  mergeMetadata(opts.Metadata, message.Metadata),
+
+		PushNotification: opts.Push,
+		CreatedAt:        a.clock.Now(),
+		tenant:           opts.Tenant,
+	}
+	job := TaskJob{TaskID: taskID, Message: message, Options: opts, EnqueuedAt: task.CreatedAt}
+
+	a.mu.Lock()
+	if previous, ok := a.tasks.Peek(taskID); ok {
+		task.Artifacts = previous.Artifacts
+		task.statusHistory = append([]TaskStatus(nil), previous.statusHistory...)
+		job.Artifacts = previous.Artifacts
+		job.History = previous.statusHistory
+	}
+	body, err := json.Marshal(job)
+	a.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := a.queues.Jobs.Send(ctx, body); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	task.SetStatus(TaskStateSubmitted, nil, a.clock.Now())
+	a.tasks.Put(task)
+	a.mu.Unlock()
+	a.saveTask(task)
+	return task, nil
+}
+
+// submitTask processes a task from tasks/send or message/send: in queue
+// mode it is handed to a worker, falling back to processing it here when
+// the queue can't be reached
+func (a *MigrationAgent) submitTask(taskID string, message Message, opts TaskOptions) (*Task, error) {
+	// Callers can collect the answer later, so tasks wait out an exhausted
+	// quota
+	opts.HoldOnQuota = a.quotaHold.Enabled()
+
+	if a.queues.Enabled() {
+		task, err := a.EnqueueTask(taskID, message, opts)
+		if err == nil {
+			return task, nil
+		}
+		log.Printf("⚠️  Could not queue task %s, processing it in-process: %v", taskID, err)
+	}
+
+	task, err := a.ProcessTask(taskID, message, opts)
+	if quota, held := a.heldFor(task, err); held {
+		a.quotaHold.Add(heldTask{TaskID: taskID, Message: message, Options: opts, HeldSince: a.clock.Now()}, quota.RetryAt)
+		return task, nil
+	}
+	return task, err
+}
+
+// RunResults stores finished tasks reported by workers until the process
+// exits
+func (a *MigrationAgent) RunResults() {
+	for {
+		messages, err := a.queues.Results.Receive(context.Background(), 10)
+		if err != nil {
+			log.Printf("⚠️  Failed to receive task results: %v", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		for _, msg := range messages {
+			var task Task
+			if err := json.Unmarshal(msg.Body, &task); err != nil || task.ID == "" {
+				log.Printf("⚠️  Dropping unreadable task result: %v", err)
+			} else {
+				a.storeResult(&task)
+			}
+			if err := a.queues.Results.Delete(context.Background(), msg); err != nil {
+				log.Printf("⚠️  Failed to delete task result %s: %v", task.ID, err)
+			}
+		}
+	}
+}
+
+// storeResult replaces the submitted placeholder with a worker's finished
+// task, keeping the fields that never leave this process
+func (a *MigrationAgent) storeResult(task *Task) {
+	task.statusHistory = task.History
+	task.History = nil
+
+	a.mu.Lock()
+	if existing, ok := a.tasks.Peek(task.ID); ok {
+		task.PushNotification = existing.PushNotification
+		task.Feedback = existing.Feedback
+		task.tenant = existing.tenant
+	}
+	a.tasks.Put(task)
+	a.mu.Unlock()
+	a.saveTask(task)
+	a.events.Publish(task.ID, statusEvent(task.ID, task.Status))
+}
+
+// RunWorker processes queued jobs, WORKER_CONCURRENCY (default 4) at a
+// time, until the process exits. Finished tasks are sent back on the results
+// queue; push notifications and the audit log are handled by the worker.
+// Content reloads on SIGHUP and datasets are refreshed as when serving.
+func (a *MigrationAgent) RunWorker() error {
+	if !a.queues.Enabled() {
+		return fmt.Errorf("worker mode needs TASK_QUEUE_URL and TASK_RESULTS_QUEUE_URL")
+	}
+	go a.reloadOnSignal()
+	go a.dataRefresher.Run()
+
+	concurrency := 4
+	if v := os.Getenv("WORKER_CONCURRENCY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			concurrency = n
+		} else {
+			log.Printf("⚠️  Invalid WORKER_CONCURRENCY %q, using %d", v, concurrency)
+		}
+	}
+	log.Printf("🛠️  Worker processing queued tasks, %d at a time", concurrency)
+
+	slots := make(chan struct{}, concurrency)
+	for {
+		messages, err := a.queues.Jobs.Receive(context.Background(), min(concurrency, 10))
+		if err != nil {
+			log.Printf("⚠️  Failed to receive jobs: %v", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		for _, msg := range messages {
+			slots <- struct{}{}
+			go func(msg QueueMessage) {
+				defer func() { <-slots }()
+				a.runJob(msg)
+			}(msg)
+		}
+	}
+}
+
+// runJob processes one queued job and reports the finished task. A job
+// whose result can't be sent is left on the queue to be retried.
+func (a *MigrationAgent) runJob(msg QueueMessage) {
+	var job TaskJob
+	if err := json.Unmarshal(msg.Body, &job); err != nil || job.TaskID == "" {
+		log.Printf("⚠️  Dropping unreadable job: %v", err)
+		a.queues.Jobs.Delete(context.Background(), msg)
+		return
+	}
+	log.Printf("Task %s picked up after %s in the queue", job.TaskID, a.clock.Now().Sub(job.EnqueuedAt).Round(time.Millisecond))
+
+	// A held job comes back at most every 15 minutes; wait longer for a
+	// daily quota without spending a call on it
+	if a.clock.Now().Before(job.RetryAt) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if a.requeueHeld(ctx, job, job.RetryAt) {
+			a.queues.Jobs.Delete(ctx, msg)
+		}
+		return
+	}
+
+	// Seed the earlier run so its artifacts are versioned as usual
+	a.mu.Lock()
+	if len(job.Artifacts) > 0 || len(job.History) > 0 {
+		a.tasks.Put(&Task{ID: job.TaskID, Artifacts: job.Artifacts, statusHistory: job.History})
+	}
+	a.mu.Unlock()
+
+	if !job.HeldSince.IsZero() {
+		job.Options.HoldOnQuota = a.quotaHold.holdFor(job.HeldSince)
+	}
+	task, err := a.ProcessTask(job.TaskID, job.Message, job.Options)
+	quota, held := a.heldFor(task, err)
+
+	a.mu.Lock()
+	if held {
+		job.Artifacts = task.Artifacts
+		job.History = task.statusHistory
+	}
+	body, err := json.Marshal(task.withHistory(len(task.statusHistory)))
+	// Workers keep no state; the API process holds the task from here on
+	a.tasks.Delete(job.TaskID)
+	a.mu.Unlock()
+	if err != nil {
+		log.Printf("⚠️  Failed to encode task %s: %v", job.TaskID, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := a.queues.Results.Send(ctx, body); err != nil {
+		log.Printf("⚠️  Failed to report task %s, leaving the job to be retried: %v", job.TaskID, err)
+		return
+	}
+	if held && !a.requeueHeld(ctx, job, quota.RetryAt) {
+		return
+	}
+	if err := a.queues.Jobs.Delete(ctx, msg); err != nil {
+		log.Printf("⚠️  Failed to delete job for task %s: %v", job.TaskID, err)
+	}
+}
+
+// requeueHeld puts a job held for quota back on the queue, delayed until the
+// quota resets or as long as the queue allows, and reports whether it did.
+// A job that can't be requeued is left to be retried after its visibility
+// timeout.
+func (a *MigrationAgent) requeueHeld(ctx context.Context, job TaskJob, retryAt time.Time) bool {
+	if job.HeldSince.IsZero() {
+		job.HeldSince = a.clock.Now()
+	}
+	job.RetryAt = retryAt
+	body, err := json.Marshal(job)
+	if err == nil {
+		err = a.queues.Jobs.SendAfter(ctx, body, retryAt.Sub(a.clock.Now()))
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to requeue held task %s: %v", job.TaskID, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,190 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+)
+
+// heldTask is a task waiting for the Gemini quota to come back
+type heldTask struct {
+	TaskID    string
+	Message   Message
+	Options   TaskOptions
+	HeldSince time.Time
+}
+
+// QuotaHold keeps tasks that ran out of Gemini quota in the submitted state
+// and runs them again once the quota resets, instead of failing them. Held
+// tasks are kept in memory; in queue mode they go back on the queue instead.
+type QuotaHold struct {
+	agent   *MigrationAgent
+	maxHold time.Duration // tasks held longer than this fail or fall back; 0 disables holding
+
+	mu      sync.Mutex
+	held    []heldTask // oldest first
+	retryAt time.Time  // when the quota is expected back
+	wake    chan struct{}
+}
+
+// NewQuotaHold configures holding from QUOTA_HOLD_MAX (default 24h)
+func NewQuotaHold(agent *MigrationAgent) *QuotaHold {
+	h := &QuotaHold{agent: agent, maxHold: 24 * time.Hour, wake: make(chan struct{}, 1)}
+	if v := os.Getenv("QUOTA_HOLD_MAX"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			h.maxHold = d
+		} else {
+			log.Printf("⚠️  Invalid QUOTA_HOLD_MAX %q, using %s", v, h.maxHold)
+		}
+	}
+	return h
+}
+
+// Enabled reports whether tasks are held when the quota runs out
+func (h *QuotaHold) Enabled() bool {
+	return h.maxHold > 0
+}
+
+// holdFor reports whether a task held since then may be held again
+func (h *QuotaHold) holdFor(since time.Time) bool {
+	return h.Enabled() && h.agent.clock.Now().Sub(since) < h.maxHold
+}
+
+// Add holds a task until retryAt, or later if other held tasks are already
+// waiting for a later reset
+func (h *QuotaHold) Add(t heldTask, retryAt time.Time) {
+	h.mu.Lock()
+	h.held = append(h.held, t)
+	sort.SliceStable(h.held, func(i, j int) bool { return h.held[i].HeldSince.Before(h.held[j].HeldSince) })
+	if retryAt.After(h.retryAt) {
+		h.retryAt = retryAt
+	}
+	h.mu.Unlock()
+
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run retries held tasks whenever the quota is expected back, until the
+// process exits. One task goes first to check the quota really is back;
+// the rest follow a few at a time.
+func (h *QuotaHold) Run() {
+	if !h.Enabled() {
+		return
+	}
+	for {
+		h.mu.Lock()
+		waiting, wait := len(h.held), h.retryAt.Sub(h.agent.clock.Now())
+		h.mu.Unlock()
+
+		switch {
+		case waiting == 0:
+			<-h.wake
+		case wait > 0:
+			select {
+			case <-time.After(wait):
+			case <-h.wake:
+			}
+		default:
+			h.release()
+		}
+	}
+}
+
+// release retries held tasks until none are left or one is held again
+func (h *QuotaHold) release() {
+	probe, ok := h.next()
+	if !ok || !h.retry(probe) {
+		return
+	}
+
+	slots := make(chan struct{}, 4)
+	var wg sync.WaitGroup
+	for {
+		t, ok := h.next()
+		if !ok {
+			break
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-slots; wg.Done() }()
+			h.retry(t)
+		}()
+	}
+	wg.Wait()
+}
+
+// next takes the oldest held task, unless the quota isn't expected back
+func (h *QuotaHold) next() (heldTask, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.held) == 0 || h.agent.clock.Now().Before(h.retryAt) {
+		return heldTask{}, false
+	}
+	t := h.held[0]
+	h.held = h.held[1:]
+	return t, true
+}
+
+// retry runs a held task again and reports whether it got past the quota.
+// Tasks deleted or otherwise finished while held are skipped.
+func (h *QuotaHold) retry(t heldTask) bool {
+	a := h.agent
+	// The task may have been evicted from memory while it waited
+	current, ok := a.lookupTask(t.TaskID)
+	a.mu.RLock()
+	pending := ok && current.Status.State == TaskStateSubmitted
+	a.mu.RUnlock()
+	if !pending {
+		return true
+	}
+
+	log.Printf("Retrying task %s, held for %s", t.TaskID, a.clock.Now().Sub(t.HeldSince).Round(time.Second))
+	opts := t.Options
+	opts.HoldOnQuota = h.holdFor(t.HeldSince)
+	task, err := a.ProcessTask(t.TaskID, t.Message, opts)
+	if quota, held := a.heldFor(task, err); held {
+		h.Add(t, quota.RetryAt)
+		return false
+	}
+	return true
+}
+
+// holdTask puts a task that ran out of quota back in the submitted state,
+// telling the caller when it will be retried
+func (a *MigrationAgent) holdTask(task *Task, messageID string, quota *llm.QuotaError) {
+	log.Printf("Task %s held until %s: %v", task.ID, quota.RetryAt.UTC().Format(time.RFC3339), quota)
+	a.updateStatus(task, TaskStateSubmitted, agentMessage(task.ID, messageID,
+		Part{
+			Kind: "text",
+			Text: fmt.Sprintf("We've reached our AI usage limit for now. Your request is queued and will be answered automatically after %s.", quota.RetryAt.UTC().Format("15:04 UTC on 2 January")),
+		},
+		Part{
+			Kind: "data",
+			Data: map[string]interface{}{"held": map[string]interface{}{
+				"reason":  FailureQuotaExceeded,
+				"retryAt": quota.RetryAt.UTC().Format(time.RFC3339),
+			}},
+		},
+	))
+}
+
+// heldFor reports whether ProcessTask held a task for quota, and until when
+func (a *MigrationAgent) heldFor(task *Task, err error) (*llm.QuotaError, bool) {
+	var quota *llm.QuotaError
+	if task == nil || !errors.As(err, &quota) {
+		return nil, false
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return quota, task.Status.State == TaskStateSubmitted
+}
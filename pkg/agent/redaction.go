@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// piiRule replaces one kind of personal data with a placeholder
+type piiRule struct {
+	kind        string
+	placeholder string
+	pattern     *regexp.Regexp
+	// group selects the submatch to replace; 0 replaces the whole match
+	group int
+	// accept can veto a match, e.g. to keep budget amounts that look like numbers
+	accept func(text string, start, end int) bool
+}
+
+// piiRules are applied in order; more specific patterns run first so that
+// e.g. passport numbers aren't partially consumed by the phone rule
+var piiRules = []piiRule{
+	{
+		kind:        "email",
+		placeholder: "[EMAIL]",
+		pattern:     regexp.MustCompile(`(?i)[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}`),
+	},
+	{
+		kind:        "passport",
+		placeholder: "[PASSPORT]",
+		pattern:     regexp.MustCompile(`(?i)passport\s*(?:no\.?|number|num|#)?\s*(?:is|:)?\s*([A-Z0-9]{6,9})\b`),
+		group:       1,
+		accept: func(text string, start, end int) bool {
+			// Require at least one digit so words like "renewal" aren't redacted
+			return strings.ContainsAny(text[start:end], "0123456789")
+		},
+	},
+	{
+		kind:        "passport",
+		placeholder: "[PASSPORT]",
+		pattern:     regexp.MustCompile(`\b[A-Z]{1,2}\d{7,8}\b`),
+	},
+	{
+		kind:        "phone",
+		placeholder: "[PHONE]",
+		pattern:     regexp.MustCompile(`\+?\(?\d[\d\s().-]{7,}\d`),
+		accept: func(text string, start, end int) bool {
+			// Budgets ($10,000) and years (2024-2025) are not phone numbers
+			if r, _ := utf8.DecodeLastRuneInString(text[:start]); strings.ContainsRune("$€£₦₹", r) {
+				return false
+			}
+			digits := 0
+			for _, c := range text[start:end] {
+				if c >= '0' && c <= '9' {
+					digits++
+				}
+			}
+			return digits >= 9
+		},
+	},
+	{
+		kind:        "name",
+		placeholder: "[NAME]",
+		pattern:     regexp.MustCompile(`(?i:\bmy\s+name\s+is|\bname\s*:|\bcall\s+me|\bi'?m\s+called)\s+((?:[A-Z][\p{L}'-]+)(?:\s+[A-Z][\p{L}'-]+){0,3})`),
+		group:       1,
+	},
+}
+
+// redactPII strips emails, phone numbers, passport numbers and self-introduced
+// names from user text. It returns the redacted text and the kinds of data
+// that were removed.
+func redactPII(text string) (string, []string) {
+	var kinds []string
+	seen := make(map[string]bool)
+
+	for _, rule := range piiRules {
+		matches := rule.pattern.FindAllStringSubmatchIndex(text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		var b strings.Builder
+		last := 0
+		for _, m := range matches {
+			start, end := m[2*rule.group], m[2*rule.group+1]
+			if start < 0 || start < last {
+				continue
+			}
+			if rule.accept != nil && !rule.accept(text, start, end) {
+				continue
+			}
+			b.WriteString(text[last:start])
+			b.WriteString(rule.placeholder)
+			last = end
+
+			if !seen[rule.kind] {
+				seen[rule.kind] = true
+				kinds = append(kinds, rule.kind)
+			}
+		}
+		b.WriteString(text[last:])
+		text = b.String()
+	}
+
+	return text, kinds
+}
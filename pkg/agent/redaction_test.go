@@ -0,0 +1,40 @@
+package agent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedactPII(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		text  string
+		want  string
+		kinds []string
+	}{
+		{"email", "Write to ada.obi@example.com please", "Write to [EMAIL] please", []string{"email"}},
+		{"labelled passport", "My passport number is A1234567X", "My passport number is [PASSPORT]", []string{"passport"}},
+		{"labelled word isn't a passport", "Passport renewal takes weeks", "Passport renewal takes weeks", nil},
+		{"bare passport", "Document AB1234567 expires soon", "Document [PASSPORT] expires soon", []string{"passport"}},
+		{"phone", "Call +234 801 234 5678 today", "Call [PHONE] today", []string{"phone"}},
+		{"short number isn't a phone", "I scored 7.5 in 2024-2025", "I scored 7.5 in 2024-2025", nil},
+		{"dollar budget", "Budget is $1000000000 total", "Budget is $1000000000 total", nil},
+		{"euro budget", "Budget is €1000000000 total", "Budget is €1000000000 total", nil},
+		{"pound budget", "Budget is £1000000000 total", "Budget is £1000000000 total", nil},
+		{"naira budget", "Budget is ₦1500000000 total", "Budget is ₦1500000000 total", nil},
+		{"rupee budget", "Budget is ₹2500000000 total", "Budget is ₹2500000000 total", nil},
+		{"name", "Hi, my name is Ada Obi and I am a nurse", "Hi, my name is [NAME] and I am a nurse", []string{"name"}},
+		{"called", "People call me Tunde", "People call me [NAME]", []string{"name"}},
+		{"several kinds", "I'm called Ada, email ada@example.com, phone 08012345678", "I'm called [NAME], email [EMAIL], phone [PHONE]", []string{"email", "phone", "name"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, kinds := redactPII(tc.text)
+			if got != tc.want {
+				t.Errorf("redactPII(%q) = %q, want %q", tc.text, got, tc.want)
+			}
+			if !reflect.DeepEqual(kinds, tc.kinds) {
+				t.Errorf("redactPII(%q) kinds = %v, want %v", tc.text, kinds, tc.kinds)
+			}
+		})
+	}
+}
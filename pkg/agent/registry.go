@@ -0,0 +1,251 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/a2aclient"
+)
+
+// DiscoveredAgent is a peer agent found through the registry or peer list
+type DiscoveredAgent struct {
+	URL       string               `json:"url"`
+	Name      string               `json:"name,omitempty"`
+	Tags      []string             `json:"tags,omitempty"`
+	Card      *a2aclient.AgentCard `json:"card,omitempty"`
+	FetchedAt time.Time            `json:"fetchedAt"`
+	Error     string               `json:"error,omitempty"`
+}
+
+// AgentRegistry discovers peer agents and caches their cards. Peers come
+// from A2A_PEERS (comma-separated base URLs) and from the registry at
+// A2A_REGISTRY_URL, and are refreshed every A2A_REGISTRY_REFRESH.
+type AgentRegistry struct {
+	registryURL string
+	peers       []string
+	refresh     time.Duration
+	client      *http.Client
+	clock       Clock
+
+	mu     sync.RWMutex
+	agents map[string]*DiscoveredAgent
+}
+
+// NewAgentRegistry reads the discovery configuration
+func NewAgentRegistry() *AgentRegistry {
+	r := &AgentRegistry{
+		registryURL: strings.TrimSpace(os.Getenv("A2A_REGISTRY_URL")),
+		refresh:     10 * time.Minute,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		clock:       systemClock{},
+		agents:      make(map[string]*DiscoveredAgent),
+	}
+	for _, peer := range strings.Split(os.Getenv("A2A_PEERS"), ",") {
+		if peer = strings.TrimRight(strings.TrimSpace(peer), "/"); peer != "" {
+			r.peers = append(r.peers, peer)
+		}
+	}
+	if v := os.Getenv("A2A_REGISTRY_REFRESH"); v != "" {
+		if refresh, err := time.ParseDuration(v); err == nil && refresh > 0 {
+			r.refresh = refresh
+		} else {
+			log.Printf("⚠️  Invalid A2A_REGISTRY_REFRESH %q, using %s", v, r.refresh)
+		}
+	}
+	return r
+}
+
+// Enabled reports whether any discovery source is configured
+func (r *AgentRegistry) Enabled() bool {
+	return r.registryURL != "" || len(r.peers) > 0
+}
+
+// Run discovers agents immediately and then on every refresh interval
+func (r *AgentRegistry) Run() {
+	if !r.Enabled() {
+		return
+	}
+	r.Refresh()
+
+	ticker := time.NewTicker(r.refresh)
+	defer ticker.Stop()
+	for range ticker.C {
+		r.Refresh()
+	}
+}
+
+// Refresh re-fetches the registry and every peer's agent card. Agents whose
+// card can't be fetched keep their last good card with the error recorded.
+func (r *AgentRegistry) Refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	urls := append([]string(nil), r.peers...)
+	if r.registryURL != "" {
+		listed, err := r.fetchRegistry(ctx)
+		if err != nil {
+			log.Printf("Failed to fetch agent registry %s: %v", r.registryURL, err)
+		}
+		urls = append(urls, listed...)
+	}
+
+	var unique []string
+	seen := make(map[string]bool)
+	for _, url := range urls {
+		if !seen[url] {
+			seen[url] = true
+			unique = append(unique, url)
+		}
+	}
+
+	found := make([]*DiscoveredAgent, len(unique))
+	var wg sync.WaitGroup
+	for i, url := range unique {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			agent := &DiscoveredAgent{URL: url, FetchedAt: r.clock.Now().UTC()}
+			card, err := a2aclient.New(url, a2aclient.WithHTTPClient(r.client)).GetAgentCard(ctx)
+			if err != nil {
+				agent.Error = err.Error()
+				if previous := r.Get(url); previous != nil && previous.Card != nil {
+					agent.Card, agent.Name, agent.Tags = previous.Card, previous.Name, previous.Tags
+				}
+			} else {
+				agent.Card = card
+				agent.Name = card.Name
+				agent.Tags = card.Tags()
+			}
+			found[i] = agent
+		}(i, url)
+	}
+	wg.Wait()
+
+	agents := make(map[string]*DiscoveredAgent, len(found))
+	healthy := 0
+	for _, agent := range found {
+		agents[agent.URL] = agent
+		if agent.Error == "" {
+			healthy++
+		}
+	}
+	log.Printf("🔎 Discovered %d peer agent(s), %d reachable", len(agents), healthy)
+
+	r.mu.Lock()
+	r.agents = agents
+	r.mu.Unlock()
+}
+
+// fetchRegistry lists agent URLs from the registry, which may return a JSON
+// array of URLs or of objects with a url field, optionally wrapped in
+// {"agents": [...]}
+func (r *AgentRegistry) fetchRegistry(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.registryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("registry returned status %d", resp.StatusCode)
+	}
+
+	var wrapped struct {
+		Agents []json.RawMessage `json:"agents"`
+	}
+	var entries []json.RawMessage
+	if err := json.Unmarshal(body, &entries); err != nil {
+		if err := json.Unmarshal(body, &wrapped); err != nil {
+			return nil, fmt.Errorf("unrecognized registry format: %v", err)
+		}
+		entries = wrapped.Agents
+	}
+
+	var urls []string
+	for _, entry := range entries {
+		var url string
+		if json.Unmarshal(entry, &url) != nil {
+			var object struct {
+				URL string `json:"url"`
+			}
+			json.Unmarshal(entry, &object)
+			url = object.URL
+		}
+		if url = strings.TrimRight(strings.TrimSpace(url), "/"); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls, nil
+}
+
+// Agents returns every discovered agent sorted by URL
+func (r *AgentRegistry) Agents() []DiscoveredAgent {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	agents := make([]DiscoveredAgent, 0, len(r.agents))
+	for _, agent := range r.agents {
+		agents = append(agents, *agent)
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].URL < agents[j].URL })
+	return agents
+}
+
+// Get returns the cached entry for an agent URL, or nil
+func (r *AgentRegistry) Get(url string) *DiscoveredAgent {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.agents[url]
+}
+
+// Find returns the reachable agents advertising a tag
+func (r *AgentRegistry) Find(tag string) []DiscoveredAgent {
+	tag = strings.ToLower(tag)
+	var matches []DiscoveredAgent
+	for _, agent := range r.Agents() {
+		if agent.Error != "" {
+			continue
+		}
+		for _, t := range agent.Tags {
+			if t == tag {
+				matches = append(matches, agent)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// ServeAgents handles GET /admin/agents, listing discovered peer agents
+func (a *MigrationAgent) ServeAgents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	agents := a.registry.Agents()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"agents": agents,
+		"count":  len(agents),
+	})
+}
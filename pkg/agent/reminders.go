@@ -0,0 +1,418 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Reminder delivery channels
+const (
+	ReminderChannelWebhook = "webhook"
+	ReminderChannelEmail   = "email"
+)
+
+// Reminder is a scheduled nudge for one milestone of a task's timeline
+type Reminder struct {
+	ID        string     `json:"id"`
+	Milestone string     `json:"milestone"`
+	DueDate   string     `json:"dueDate"` // milestone date, YYYY-MM-DD
+	SendAt    time.Time  `json:"sendAt"`
+	Channel   string     `json:"channel"`
+	Target    string     `json:"target"`
+	SentAt    *time.Time `json:"sentAt,omitempty"`
+	LastError string     `json:"lastError,omitempty"`
+}
+
+// ReminderDelivery sends a reminder over a specific channel. metadata is
+// the task's caller metadata, echoed so integrators can correlate reminders,
+// and tenantID the tenant the task belongs to.
+type ReminderDelivery interface {
+	Deliver(taskID, tenantID string, metadata Metadata, r Reminder) error
+}
+
+// DueReminder is a reminder to send with the task it belongs to
+type DueReminder struct {
+	TaskID   string   `json:"taskId"`
+	Tenant   string   `json:"tenant,omitempty"`
+	Metadata Metadata `json:"metadata,omitempty"`
+	Reminder Reminder `json:"reminder"`
+}
+
+// ReminderStore keeps scheduled reminders where every replica can claim
+// them, so reminders of tasks this process doesn't hold are still sent, and
+// sent once. The PostgreSQL task store keeps them in the reminders table as
+// tasks are saved; without one the scheduler reads the tasks in memory.
+type ReminderStore interface {
+	// ClaimDueReminders claims up to limit unsent reminders due by now for
+	// lease, during which no other replica is given them
+	ClaimDueReminders(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]DueReminder, error)
+	// FinishReminder records a delivery attempt and releases the claim; a
+	// nil sentAt leaves the reminder to be retried
+	FinishReminder(ctx context.Context, id string, sentAt *time.Time, lastError string) error
+}
+
+// Reminders are claimed from the store in batches of reminderBatch, each
+// for reminderLease to be delivered
+const (
+	reminderBatch = 100
+	reminderLease = 5 * time.Minute
+)
+
+// ReminderScheduler periodically delivers due reminders stored on tasks
+type ReminderScheduler struct {
+	agent      *MigrationAgent
+	interval   time.Duration
+	deliveries map[string]ReminderDelivery
+	store      ReminderStore // nil reads reminders from the tasks in memory
+}
+
+// NewReminderScheduler creates a scheduler with the webhook channel enabled
+// and the email channel enabled when SMTP settings are present
+func NewReminderScheduler(agent *MigrationAgent) *ReminderScheduler {
+	interval := time.Minute
+	if v := os.Getenv("REMINDER_POLL_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			interval = d
+		}
+	}
+
+	deliveries := map[string]ReminderDelivery{
+		ReminderChannelWebhook: &webhookDelivery{client: publicOnlyClient(10 * time.Second), clock: agent.clock},
+	}
+	if email := newEmailDeliveryFromEnv(); email != nil {
+		deliveries[ReminderChannelEmail] = email
+	}
+
+	store, _ := agent.store.(ReminderStore)
+	return &ReminderScheduler{
+		agent:      agent,
+		interval:   interval,
+		deliveries: deliveries,
+		store:      store,
+	}
+}
+
+// Supports reports whether a delivery channel is configured
+func (s *ReminderScheduler) Supports(channel string) bool {
+	_, ok := s.deliveries[channel]
+	return ok
+}
+
+// Run delivers due reminders until the process exits
+func (s *ReminderScheduler) Run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.deliverDue(s.agent.clock.Now())
+	}
+}
+
+// deliverDue sends every unsent reminder whose send time has passed. Network
+// calls happen outside the agent lock; results are written back afterwards.
+func (s *ReminderScheduler) deliverDue(now time.Time) {
+	for _, d := range s.due(now) {
+		var deliveryErr error
+		if delivery, ok := s.deliveries[d.Reminder.Channel]; ok {
+			deliveryErr = delivery.Deliver(d.TaskID, d.Tenant, d.Metadata, d.Reminder)
+		} else {
+			deliveryErr = fmt.Errorf("reminder channel not configured: %s", d.Reminder.Channel)
+		}
+
+		var sentAt *time.Time
+		lastError := ""
+		if deliveryErr != nil {
+			lastError = deliveryErr.Error()
+			log.Printf("reminder %s for task %s failed: %v", d.Reminder.ID, d.TaskID, deliveryErr)
+			// Retry on the next tick, but give up once the milestone has passed
+			if now.Format("2006-01-02") > d.Reminder.DueDate {
+				sentAt = &now
+			}
+		} else {
+			sentAt = &now
+		}
+
+		if s.store != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+			err := s.store.FinishReminder(ctx, d.Reminder.ID, sentAt, lastError)
+			cancel()
+			if err != nil {
+				log.Printf("⚠️  %v", err)
+			}
+		}
+		s.agent.recordReminder(d.TaskID, d.Reminder.ID, sentAt, lastError)
+	}
+}
+
+// due claims the reminders to send now from the store, or lists them from
+// the tasks in memory without one
+func (s *ReminderScheduler) due(now time.Time) []DueReminder {
+	if s.store != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		defer cancel()
+		due, err := s.store.ClaimDueReminders(ctx, now, reminderLease, reminderBatch)
+		if err != nil {
+			log.Printf("⚠️  %v", err)
+		}
+		return due
+	}
+
+	var due []DueReminder
+	s.agent.mu.RLock()
+	defer s.agent.mu.RUnlock()
+	for _, task := range s.agent.tasks.Tasks() {
+		for _, r := range task.Reminders {
+			if r.SentAt == nil && !r.SendAt.After(now) {
+				due = append(due, DueReminder{TaskID: task.ID, Tenant: task.tenant, Metadata: task.Metadata, Reminder: r})
+			}
+		}
+	}
+	return due
+}
+
+// recordReminder writes the outcome of a delivery attempt to the task's
+// copy of the reminder. Callers must not hold a.mu.
+func (a *MigrationAgent) recordReminder(taskID, reminderID string, sentAt *time.Time, lastError string) {
+	task, ok := a.lookupTask(taskID)
+	if !ok {
+		return
+	}
+
+	a.mu.Lock()
+	for i := range task.Reminders {
+		if task.Reminders[i].ID == reminderID {
+			task.Reminders[i].SentAt = sentAt
+			task.Reminders[i].LastError = lastError
+		}
+	}
+	a.mu.Unlock()
+	a.saveTask(task)
+}
+
+// scheduleReminders creates one reminder per upcoming milestone, sent
+// leadDays before the milestone date at 09:00 UTC
+func scheduleReminders(milestones []Milestone, params ReminderParams, now time.Time, ids IDGenerator) []Reminder {
+	leadDays := params.LeadDays
+	if leadDays <= 0 {
+		leadDays = 7
+	}
+
+	today := now.UTC().Format("2006-01-02")
+	var reminders []Reminder
+	for _, m := range milestones {
+		dueDate := m.Date.Format("2006-01-02")
+		if dueDate < today {
+			continue
+		}
+
+		sendAt := time.Date(m.Date.Year(), m.Date.Month(), m.Date.Day(), 9, 0, 0, 0, time.UTC).AddDate(0, 0, -leadDays)
+		if sendAt.Before(now) {
+			sendAt = now
+		}
+
+		reminders = append(reminders, Reminder{
+			ID:        ids.NewID(),
+			Milestone: m.Title,
+			DueDate:   dueDate,
+			SendAt:    sendAt,
+			Channel:   params.Channel,
+			Target:    params.Target,
+		})
+	}
+
+	return reminders
+}
+
+// validateReminderParams checks the opt-in settings supplied with a task
+func (s *ReminderScheduler) validateReminderParams(params *ReminderParams) error {
+	if params == nil {
+		return nil
+	}
+
+	switch params.Channel {
+	case ReminderChannelWebhook:
+		if err := validateWebhookTarget(params.Target); err != nil {
+			return err
+		}
+	case ReminderChannelEmail:
+		if _, err := mail.ParseAddress(params.Target); err != nil {
+			return fmt.Errorf("reminders.target must be a valid email address: %v", err)
+		}
+	default:
+		return fmt.Errorf("unsupported reminders.channel %q (expected %q or %q)", params.Channel, ReminderChannelWebhook, ReminderChannelEmail)
+	}
+
+	if !s.Supports(params.Channel) {
+		return fmt.Errorf("reminder channel %q is not configured on this server", params.Channel)
+	}
+	if params.LeadDays < 0 {
+		return fmt.Errorf("reminders.leadDays must not be negative")
+	}
+
+	return nil
+}
+
+// validateWebhookTarget checks that a webhook URL is http(s) and that its
+// host resolves to public addresses only, so reminders can't be aimed at the
+// server's own network or a cloud metadata endpoint
+func validateWebhookTarget(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("reminders.target must be an http(s) URL for the webhook channel")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("reminders.target host %s could not be resolved: %v", u.Hostname(), err)
+	}
+	for _, addr := range addrs {
+		if !publicIP(addr.IP) {
+			return fmt.Errorf("reminders.target must not point to a private, loopback or link-local address")
+		}
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, 100.64.0.0/10
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// publicIP reports whether ip is reachable on the internet rather than
+// loopback, private, link-local (including 169.254.169.254, the metadata
+// endpoint), multicast or unspecified
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsMulticast() && !ip.IsUnspecified() && !sharedAddressSpace.Contains(ip)
+}
+
+// publicOnlyClient makes an HTTP client that refuses to connect to
+// non-public addresses. The address is checked as it is dialled, after DNS
+// resolution, so a host can't be re-pointed after its target was accepted.
+func publicOnlyClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return fmt.Errorf("refusing to call non-public address %s", host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{Timeout: timeout, Transport: &http.Transport{DialContext: dialer.DialContext}}
+}
+
+// webhookDelivery POSTs reminders as JSON to the registered URL, signed
+// with the tenant's webhook secret
+type webhookDelivery struct {
+	client *http.Client
+	clock  Clock
+}
+
+func (d *webhookDelivery) Deliver(taskID, tenantID string, metadata Metadata, r Reminder) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"type":     "reminder",
+		"taskId":   taskID,
+		"metadata": metadata,
+		"reminder": r,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal reminder: %v", err)
+	}
+	return d.post(r.Target, tenantID, payload)
+}
+
+// post sends a signed JSON payload to a webhook
+func (d *webhookDelivery) post(target, tenantID string, payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	signWebhook(req, payload, webhookSecret(tenantID), d.clock.Now())
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// emailDelivery sends reminders through an SMTP relay
+type emailDelivery struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// newEmailDeliveryFromEnv configures SMTP delivery from SMTP_HOST, SMTP_PORT,
+// SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM. Returns nil if SMTP_HOST is unset.
+func newEmailDeliveryFromEnv() *emailDelivery {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil
+	}
+
+	port := 587
+	if v, err := strconv.Atoi(os.Getenv("SMTP_PORT")); err == nil && v > 0 {
+		port = v
+	}
+
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = os.Getenv("SMTP_USERNAME")
+	}
+
+	var auth smtp.Auth
+	if user := os.Getenv("SMTP_USERNAME"); user != "" {
+		auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+	}
+
+	return &emailDelivery{
+		addr: fmt.Sprintf("%s:%d", host, port),
+		from: from,
+		auth: auth,
+	}
+}
+
+func (d *emailDelivery) Deliver(taskID, _ string, _ Metadata, r Reminder) error {
+	subject := fmt.Sprintf("Reminder: %s (due %s)", r.Milestone, r.DueDate)
+	body := fmt.Sprintf("This is a reminder from your migration plan.\r\n\r\nMilestone: %s\r\nDue date: %s\r\nTask ID: %s\r\n",
+		r.Milestone, r.DueDate, taskID)
+	return d.send(r.Target, subject, body)
+}
+
+// send emails a plain-text message
+func (d *emailDelivery) send(to, subject, body string) error {
+	msg := "From: " + d.from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body
+
+	if err := smtp.SendMail(d.addr, d.auth, d.from, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+	return nil
+}
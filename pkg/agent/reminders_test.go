@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeReminderStore hands out each due reminder once, as claiming does
+type fakeReminderStore struct {
+	mu       sync.Mutex
+	due      []DueReminder
+	finished map[string]*time.Time
+}
+
+func (f *fakeReminderStore) ClaimDueReminders(_ context.Context, now time.Time, _ time.Duration, limit int) ([]DueReminder, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var claimed []DueReminder
+	for i := 0; i < len(f.due) && len(claimed) < limit; {
+		if f.due[i].Reminder.SendAt.After(now) {
+			i++
+			continue
+		}
+		claimed = append(claimed, f.due[i])
+		f.due = append(f.due[:i], f.due[i+1:]...)
+	}
+	return claimed, nil
+}
+
+func (f *fakeReminderStore) FinishReminder(_ context.Context, id string, sentAt *time.Time, _ string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.finished[id] = sentAt
+	return nil
+}
+
+// recordedDeliveries remembers the reminders it was asked to send
+type recordedDeliveries struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (d *recordedDeliveries) Deliver(taskID, _ string, _ Metadata, r Reminder) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sent = append(d.sent, taskID+"/"+r.ID)
+	return nil
+}
+
+func TestReminderSchedulerSendsStoredRemindersOfTasksNotInMemory(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	a := NewMigrationAgentWithDeps(nil, nil, fixedClock{now}, &sequentialIDs{})
+
+	store := &fakeReminderStore{finished: make(map[string]*time.Time)}
+	store.due = []DueReminder{
+		{TaskID: "evicted", Reminder: Reminder{ID: "r1", Channel: ReminderChannelWebhook, SendAt: now.Add(-time.Hour), DueDate: "2025-03-08"}},
+		{TaskID: "evicted", Reminder: Reminder{ID: "r2", Channel: ReminderChannelWebhook, SendAt: now.Add(time.Hour), DueDate: "2025-03-08"}},
+	}
+	deliveries := &recordedDeliveries{}
+	s := &ReminderScheduler{
+		agent:      a,
+		deliveries: map[string]ReminderDelivery{ReminderChannelWebhook: deliveries},
+		store:      store,
+	}
+
+	s.deliverDue(now)
+	s.deliverDue(now)
+
+	if len(deliveries.sent) != 1 || deliveries.sent[0] != "evicted/r1" {
+		t.Errorf("sent %v, want only evicted/r1 once", deliveries.sent)
+	}
+	if sentAt := store.finished["r1"]; sentAt == nil || !sentAt.Equal(now) {
+		t.Errorf("r1 finished with sentAt %v, want %v", sentAt, now)
+	}
+	if _, ok := store.finished["r2"]; ok {
+		t.Error("r2 was handled before it was due")
+	}
+}
+
+func TestValidateWebhookTargetRejectsNonPublicAddresses(t *testing.T) {
+	tests := []struct {
+		target string
+		ok     bool
+	}{
+		{"https://93.184.216.34/hooks", true},
+		{"http://127.0.0.1:8080/hooks", false},
+		{"http://localhost/hooks", false},
+		{"http://[::1]/hooks", false},
+		{"http://10.0.0.5/hooks", false},
+		{"http://192.168.1.10/hooks", false},
+		{"http://169.254.169.254/latest/meta-data/", false},
+		{"http://100.64.0.1/hooks", false},
+		{"http://0.0.0.0/hooks", false},
+		{"ftp://93.184.216.34/hooks", false},
+	}
+	for _, tt := range tests {
+		err := validateWebhookTarget(tt.target)
+		if (err == nil) != tt.ok {
+			t.Errorf("validateWebhookTarget(%q) = %v, want ok %v", tt.target, err, tt.ok)
+		}
+	}
+}
+
+func TestPublicOnlyClientRefusesLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached the loopback server")
+	}))
+	defer srv.Close()
+
+	if _, err := publicOnlyClient(time.Second).Post(srv.URL, "application/json", nil); err == nil {
+		t.Error("publicOnlyClient called a loopback address")
+	}
+}
@@ -0,0 +1,216 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+)
+
+// ControlVariant names tasks that use the default model and prompts
+const ControlVariant = "control"
+
+// Variant is an alternative model or prompt set given to a share of tasks
+type Variant struct {
+	Name       string `json:"name"`
+	Weight     int    `json:"weight"`               // percentage of tasks, 1-100
+	Model      string `json:"model,omitempty"`      // Gemini model; the default when empty
+	ContentDir string `json:"contentDir,omitempty"` // prompts.json overrides, as for CONTENT_DIR
+
+	prompts *Content
+}
+
+// Rollout is the weighted set of variants; tasks not assigned to one are
+// in the control group
+type Rollout struct {
+	Variants []*Variant `json:"variants"`
+}
+
+var currentRollout atomic.Pointer[Rollout]
+
+// loadRollout reads ROLLOUT_FILE, a JSON object with a "variants" list. No
+// file means every task is in the control group.
+func loadRollout() (*Rollout, error) {
+	rollout := &Rollout{}
+	path := os.Getenv("ROLLOUT_FILE")
+	if path == "" {
+		return rollout, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ROLLOUT_FILE: %v", err)
+	}
+	if err := json.Unmarshal(data, rollout); err != nil {
+		return nil, fmt.Errorf("failed to parse ROLLOUT_FILE: %v", err)
+	}
+
+	total := 0
+	names := map[string]bool{ControlVariant: true}
+	for _, v := range rollout.Variants {
+		if v.Name == "" || names[v.Name] {
+			return nil, fmt.Errorf("ROLLOUT_FILE: variant names must be unique, non-empty and not %q", ControlVariant)
+		}
+		names[v.Name] = true
+		if v.Weight < 1 || v.Weight > 100 {
+			return nil, fmt.Errorf("ROLLOUT_FILE: variant %s needs a weight between 1 and 100", v.Name)
+		}
+		total += v.Weight
+		if v.ContentDir != "" {
+			// Variants change prompts only; routing and parsing keep
+			// using the deployment's content
+			prompts, err := loadContent(v.ContentDir)
+			if err != nil {
+				return nil, fmt.Errorf("ROLLOUT_FILE: variant %s: %v", v.Name, err)
+			}
+			v.prompts = prompts
+		}
+	}
+	if total > 100 {
+		return nil, fmt.Errorf("ROLLOUT_FILE: variant weights add up to %d%%, more than 100%%", total)
+	}
+	return rollout, nil
+}
+
+// rolloutActive reports whether any variant is configured
+func rolloutActive() bool {
+	rollout := currentRollout.Load()
+	return rollout != nil && len(rollout.Variants) > 0
+}
+
+// assignVariant picks the variant for a task, or nil for control. The
+// choice is a hash of the session (or task) ID, so a user keeps seeing the
+// same variant while the weights are unchanged.
+func assignVariant(taskID, sessionID string) *Variant {
+	rollout := currentRollout.Load()
+	if rollout == nil || len(rollout.Variants) == 0 {
+		return nil
+	}
+
+	key := sessionID
+	if key == "" {
+		key = taskID
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	bucket := int(h.Sum32() % 100)
+
+	for _, v := range rollout.Variants {
+		if bucket < v.Weight {
+			return v
+		}
+		bucket -= v.Weight
+	}
+	return nil
+}
+
+// name returns the variant's name, or the control group's for nil
+func (v *Variant) name() string {
+	if v == nil {
+		return ControlVariant
+	}
+	return v.Name
+}
+
+// client returns the Gemini client for the variant's model
+func (v *Variant) client(gemini *llm.GeminiClient) *llm.GeminiClient {
+	if v == nil || v.Model == "" {
+		return gemini
+	}
+	return gemini.WithModel(v.Model)
+}
+
+// VariantStats are the outcomes of the tasks given one variant
+type VariantStats struct {
+	Tasks        int   `json:"tasks"`
+	Completed    int   `json:"completed"`
+	Failed       int   `json:"failed"`
+	AvgLatencyMs int64 `json:"avgLatencyMs"`
+
+	totalMs int64
+}
+
+// RolloutStats counts task outcomes per variant since the server started
+type RolloutStats struct {
+	mu       sync.Mutex
+	variants map[string]*VariantStats
+}
+
+// NewRolloutStats creates empty rollout statistics
+func NewRolloutStats() *RolloutStats {
+	return &RolloutStats{variants: make(map[string]*VariantStats)}
+}
+
+// Record adds a finished task to its variant's statistics
+func (s *RolloutStats) Record(variant string, state TaskState, duration time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.variants[variant]
+	if stats == nil {
+		stats = &VariantStats{}
+		s.variants[variant] = stats
+	}
+	stats.Tasks++
+	switch state {
+	case TaskStateCompleted:
+		stats.Completed++
+	case TaskStateFailed:
+		stats.Failed++
+	}
+	stats.totalMs += duration.Milliseconds()
+	stats.AvgLatencyMs = stats.totalMs / int64(stats.Tasks)
+}
+
+// Snapshot copies the statistics for reporting
+func (s *RolloutStats) Snapshot() map[string]VariantStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make(map[string]VariantStats, len(s.variants))
+	for name, stats := range s.variants {
+		snapshot[name] = *stats
+	}
+	return snapshot
+}
+
+// ServeRollout handles GET /admin/rollout, listing the configured variants
+// and the outcomes of the tasks each one handled
+func (a *MigrationAgent) ServeRollout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	control := 100
+	variants := []map[string]interface{}{}
+	if rollout := currentRollout.Load(); rollout != nil {
+		for _, v := range rollout.Variants {
+			control -= v.Weight
+			variants = append(variants, map[string]interface{}{
+				"name":       v.Name,
+				"weight":     v.Weight,
+				"model":      v.client(a.gemini).Model,
+				"contentDir": v.ContentDir,
+			})
+		}
+	}
+	variants = append(variants, map[string]interface{}{
+		"name":   ControlVariant,
+		"weight": control,
+		"model":  a.gemini.Model,
+	})
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"variants": variants,
+		"stats":    a.rolloutStats.Snapshot(),
+	})
+}
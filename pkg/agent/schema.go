@@ -0,0 +1,260 @@
+package agent
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// Embedded schemas for RPC params
+const (
+	SchemaSendParams = "send_params.json" // tasks/send, message/send and the streaming methods
+	SchemaMessage    = "message.json"     // a bare message sent to message/send
+)
+
+// jsonSchema is the subset of JSON Schema draft-07 the embedded schemas
+// use: type, enum, properties, required, additionalProperties, items,
+// minItems, minimum, definitions and $ref to a definition or another file.
+// true and false are accepted as schemas anywhere.
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 string                 `json:"type"`
+	Enum                 []interface{}          `json:"enum"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	MinItems             int                    `json:"minItems"`
+	Minimum              *float64               `json:"minimum"`
+	Definitions          map[string]*jsonSchema `json:"definitions"`
+
+	never bool // the false schema, which nothing matches
+}
+
+// UnmarshalJSON reads a schema object or a boolean schema
+func (s *jsonSchema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*s = jsonSchema{}
+		return nil
+	case "false":
+		*s = jsonSchema{never: true}
+		return nil
+	}
+	type plain jsonSchema
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// schemas holds the embedded schemas by file name
+var schemas = mustLoadSchemas()
+
+// mustLoadSchemas parses the embedded schemas. They ship with the binary,
+// so a broken one is a build defect.
+func mustLoadSchemas() map[string]*jsonSchema {
+	names, err := schemaFiles.ReadDir("schemas")
+	if err != nil {
+		panic(fmt.Sprintf("failed to list schemas: %v", err))
+	}
+	loaded := make(map[string]*jsonSchema, len(names))
+	for _, entry := range names {
+		data, err := schemaFiles.ReadFile(path.Join("schemas", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("failed to read schema %s: %v", entry.Name(), err))
+		}
+		var schema jsonSchema
+		if err := json.Unmarshal(data, &schema); err != nil {
+			panic(fmt.Sprintf("invalid schema %s: %v", entry.Name(), err))
+		}
+		loaded[entry.Name()] = &schema
+	}
+	return loaded
+}
+
+// FieldError is one params field that doesn't match its schema
+type FieldError struct {
+	Field   string `json:"field"` // path from params, e.g. message.parts[0].text
+	Message string `json:"message"`
+}
+
+// SchemaError lists every field of a request's params that doesn't match
+// the schema. It is reported as structured error data.
+type SchemaError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *SchemaError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		messages[i] = fe.Field + " " + fe.Message
+	}
+	return "invalid params: " + strings.Join(messages, "; ")
+}
+
+func (e *SchemaError) rpcData() interface{} {
+	return e
+}
+
+// validateParams checks raw params against an embedded schema, returning a
+// *SchemaError describing each mismatch
+func validateParams(name string, raw []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return &SchemaError{Errors: []FieldError{{Field: "params", Message: "must be valid JSON"}}}
+	}
+
+	v := schemaValidator{root: schemas[name]}
+	v.validate(v.root, value, "params")
+	if len(v.errors) > 0 {
+		return &SchemaError{Errors: v.errors}
+	}
+	return nil
+}
+
+// schemaValidator collects the mismatches of one value
+type schemaValidator struct {
+	root   *jsonSchema // the file $refs to definitions resolve against
+	errors []FieldError
+}
+
+func (v *schemaValidator) fail(field, format string, args ...interface{}) {
+	v.errors = append(v.errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *schemaValidator) validate(s *jsonSchema, value interface{}, field string) {
+	if s.Ref != "" {
+		v.validateRef(s.Ref, value, field)
+		return
+	}
+	if s.never {
+		v.fail(field, "is not allowed")
+		return
+	}
+	if s.Type != "" && !hasSchemaType(value, s.Type) {
+		v.fail(field, "must be %s %s", article(s.Type), s.Type)
+		return
+	}
+	if len(s.Enum) > 0 && !inEnum(value, s.Enum) {
+		allowed := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			encoded, _ := json.Marshal(e)
+			allowed[i] = string(encoded)
+		}
+		v.fail(field, "must be one of %s", strings.Join(allowed, ", "))
+		return
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := value[name]; !ok {
+				v.fail(joinField(field, name), "is required")
+			}
+		}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if prop, ok := s.Properties[key]; ok {
+				v.validate(prop, value[key], joinField(field, key))
+			} else if s.AdditionalProperties != nil {
+				v.validate(s.AdditionalProperties, value[key], joinField(field, key))
+			}
+		}
+	case []interface{}:
+		if len(value) < s.MinItems {
+			v.fail(field, "must have at least %d item(s)", s.MinItems)
+		}
+		if s.Items != nil {
+			for i, item := range value {
+				v.validate(s.Items, item, field+"["+strconv.Itoa(i)+"]")
+			}
+		}
+	case json.Number:
+		if n, err := value.Float64(); err == nil && s.Minimum != nil && n < *s.Minimum {
+			v.fail(field, "must be at least %v", *s.Minimum)
+		}
+	}
+}
+
+// validateRef follows a $ref to a definition ("#/definitions/part") or to
+// another embedded schema file ("message.json")
+func (v *schemaValidator) validateRef(ref string, value interface{}, field string) {
+	if name, ok := strings.CutPrefix(ref, "#/definitions/"); ok {
+		if target := v.root.Definitions[name]; target != nil {
+			v.validate(target, value, field)
+			return
+		}
+	} else if target := schemas[ref]; target != nil {
+		outer := v.root
+		v.root = target
+		v.validate(target, value, field)
+		v.root = outer
+		return
+	}
+	panic(fmt.Sprintf("unresolvable schema $ref %q", ref))
+}
+
+// hasSchemaType reports whether a decoded JSON value is of a schema type
+func hasSchemaType(value interface{}, schemaType string) bool {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return schemaType == "object"
+	case []interface{}:
+		return schemaType == "array"
+	case string:
+		return schemaType == "string"
+	case bool:
+		return schemaType == "boolean"
+	case nil:
+		return schemaType == "null"
+	case json.Number:
+		if schemaType == "number" {
+			return true
+		}
+		_, err := value.Int64()
+		return schemaType == "integer" && err == nil
+	}
+	return false
+}
+
+// inEnum reports whether a scalar value is one of the allowed values
+func inEnum(value interface{}, enum []interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	case json.Number:
+		value, _ = v.Float64()
+	}
+	for _, allowed := range enum {
+		if value == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func article(schemaType string) string {
+	if schemaType == "object" || schemaType == "array" || schemaType == "integer" {
+		return "an"
+	}
+	return "a"
+}
+
+func joinField(parent, name string) string {
+	if parent == "params" {
+		return name
+	}
+	return parent + "." + name
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateParams(t *testing.T) {
+	const message = `"message": {"role": "user", "parts": [{"kind": "text", "text": "Nurse moving to the UK"}]}`
+	for _, tc := range []struct {
+		name   string
+		params string
+		want   []FieldError
+	}{
+		{"valid", `{` + message + `}`, nil},
+		{"not JSON", `{"message":`, []FieldError{{"params", "must be valid JSON"}}},
+		{"type mismatch", `{` + message + `, "model": 5}`, []FieldError{{"model", "must be a string"}}},
+		{"integer", `{` + message + `, "delegationDepth": 1.5}`, []FieldError{{"delegationDepth", "must be an integer"}}},
+		{"minimum", `{` + message + `, "delegationDepth": -1}`, []FieldError{{"delegationDepth", "must be at least 0"}}},
+		{"required", `{"id": "task-1"}`, []FieldError{{"message", "is required"}}},
+		{"nested required", `{"message": {"parts": [{"kind": "text", "text": "hi"}]}}`, []FieldError{{"message.role", "is required"}}},
+		{"enum", `{"message": {"role": "system", "parts": [{"kind": "text", "text": "hi"}]}}`, []FieldError{{"message.role", `must be one of "user", "agent"`}}},
+		{"min items", `{"message": {"role": "user", "parts": []}}`, []FieldError{{"message.parts", "must have at least 1 item(s)"}}},
+		{"nested array item", `{"message": {"role": "user", "parts": [{"kind": "text", "text": "hi"}, {"kind": "text", "text": 7}]}}`, []FieldError{{"message.parts[1].text", "must be a string"}}},
+		{"additional properties", `{` + message + `, "pushNotification": {"url": "https://example.com", "headers": {"X-Team": 1}}}`, []FieldError{{"pushNotification.headers.X-Team", "must be a string"}}},
+		{"several", `{"message": {"role": 1, "parts": "hi"}, "tone": false}`, []FieldError{{"message.parts", "must be an array"}, {"message.role", "must be a string"}, {"tone", "must be a string"}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateParams(SchemaSendParams, []byte(tc.params))
+			if tc.want == nil {
+				if err != nil {
+					t.Fatalf("validateParams = %v, want nil", err)
+				}
+				return
+			}
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("validateParams = %v, want a *SchemaError", err)
+			}
+			if !reflect.DeepEqual(schemaErr.Errors, tc.want) {
+				t.Errorf("errors = %+v, want %+v", schemaErr.Errors, tc.want)
+			}
+		})
+	}
+}
+
+func TestSchemaRejectsUnknownProperties(t *testing.T) {
+	var schema jsonSchema
+	if err := json.Unmarshal([]byte(`{"type": "object", "properties": {"name": {"type": "string"}}, "additionalProperties": false}`), &schema); err != nil {
+		t.Fatal(err)
+	}
+	v := schemaValidator{root: &schema}
+	v.validate(&schema, map[string]interface{}{"name": "Ada", "extra": true}, "params")
+	want := []FieldError{{"extra", "is not allowed"}}
+	if !reflect.DeepEqual(v.errors, want) {
+		t.Errorf("errors = %+v, want %+v", v.errors, want)
+	}
+}
+
+func TestInvalidParamsCarryFieldErrors(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	a := NewMigrationAgent()
+
+	body := `{"jsonrpc": "2.0", "id": 1, "method": "tasks/send", "params": {"message": {"role": "user", "parts": [{"kind": "text", "text": 7}]}}}`
+	rec := httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/a2a/planner", strings.NewReader(body)))
+
+	var response struct {
+		Error struct {
+			Code int         `json:"code"`
+			Data SchemaError `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decoding %s: %v", rec.Body, err)
+	}
+	if response.Error.Code != ErrCodeInvalidParams {
+		t.Errorf("code = %d, want %d", response.Error.Code, ErrCodeInvalidParams)
+	}
+	want := []FieldError{{"message.parts[0].text", "must be a string"}}
+	if !reflect.DeepEqual(response.Error.Data.Errors, want) {
+		t.Errorf("data.errors = %+v, want %+v", response.Error.Data.Errors, want)
+	}
+}
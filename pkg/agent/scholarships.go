@@ -0,0 +1,189 @@
+package agent
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// scholarshipData is the curated list of scholarships and tuition waivers
+// for study routes, maintained with the code like the fee table
+//
+//go:embed scholarships.json
+var scholarshipData []byte
+
+// maxScholarships caps how many are listed in an answer
+const maxScholarships = 5
+
+// Scholarship is funding for international students at one destination
+type Scholarship struct {
+	Name         string   `json:"name"`
+	Provider     string   `json:"provider"`
+	Destination  string   `json:"destination"`
+	Levels       []string `json:"levels"`            // bachelor, master or phd
+	Origins      []string `json:"origins,omitempty"` // ISO 3166 codes of eligible countries; none means any
+	Fields       []string `json:"fields,omitempty"`  // lowercase words for the fields it funds; none means any
+	Waiver       bool     `json:"waiver,omitempty"`  // a general tuition waiver rather than an award to apply for
+	Covers       string   `json:"covers"`
+	Requirements string   `json:"requirements,omitempty"`
+	Deadline     string   `json:"deadline,omitempty"`
+	URL          string   `json:"url"`
+}
+
+// scholarshipTable is the versioned built-in list
+type scholarshipTable struct {
+	Version      string        `json:"version"`
+	Updated      string        `json:"updated"` // YYYY-MM-DD
+	Scholarships []Scholarship `json:"scholarships"`
+}
+
+var scholarships = mustParseScholarships(scholarshipData)
+
+// mustParseScholarships parses the embedded list, which ships with the
+// binary, so a broken one is a build defect
+func mustParseScholarships(data []byte) scholarshipTable {
+	var table scholarshipTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		panic(fmt.Sprintf("invalid embedded scholarships: %v", err))
+	}
+	if _, err := time.Parse("2006-01-02", table.Updated); err != nil || table.Version == "" {
+		panic("embedded scholarships need a version and an updated date")
+	}
+	for i, s := range table.Scholarships {
+		if s.Name == "" || s.Destination == "" || len(s.Levels) == 0 || s.Covers == "" {
+			panic(fmt.Sprintf("scholarship %d needs a name, destination, levels and what it covers", i))
+		}
+		if u, err := url.Parse(s.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+			panic(fmt.Sprintf("scholarship %q needs an https url", s.Name))
+		}
+	}
+	return table
+}
+
+// studyPathwayPattern matches the names of study routes
+var studyPathwayPattern = regexp.MustCompile(`(?i)\b(?:study|student|studies|master'?s|mba|ph\.?d|doctoral|university|degree)\b`)
+
+// studyLevels recognize the level of study a query or pathway is about;
+// the first that matches wins
+var studyLevels = []struct {
+	Level   string
+	Pattern *regexp.Regexp
+}{
+	{"phd", regexp.MustCompile(`(?i)\b(?:ph\.?d|doctorate|doctoral)\b`)},
+	{"master", regexp.MustCompile(`(?i)\b(?:master'?s?|msc|m\.sc|mba|postgraduate)\b`)},
+	{"bachelor", regexp.MustCompile(`(?i)\b(?:bachelor'?s?|undergraduate|b\.?sc|first degree)\b`)},
+}
+
+// findScholarships returns the scholarships for studying at destination
+// that applicants from originCode ("" if unknown) can get, for the level
+// and field text mentions. Awards for the applicant's field come first,
+// then awards open to any field, then general tuition waivers.
+func findScholarships(destination, originCode, text string) []Scholarship {
+	lower := " " + strings.ToLower(text) + " "
+	level := ""
+	for _, l := range studyLevels {
+		if l.Pattern.MatchString(text) {
+			level = l.Level
+			break
+		}
+	}
+
+	type ranked struct {
+		scholarship Scholarship
+		rank        int
+	}
+	var found []ranked
+	for _, s := range scholarships.Scholarships {
+		if !strings.EqualFold(s.Destination, destination) {
+			continue
+		}
+		if level != "" && !containsString(s.Levels, level) {
+			continue
+		}
+		if len(s.Origins) > 0 && !containsString(s.Origins, strings.ToUpper(originCode)) {
+			continue
+		}
+		rank := 1
+		if len(s.Fields) > 0 {
+			matched := false
+			for _, field := range s.Fields {
+				matched = matched || parser.IndexWord(lower, field) != -1
+			}
+			if !matched {
+				continue
+			}
+			rank = 0
+		}
+		if s.Waiver {
+			rank = 2
+		}
+		found = append(found, ranked{s, rank})
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].rank < found[j].rank })
+
+	var result []Scholarship
+	for _, r := range found {
+		if len(result) == maxScholarships {
+			break
+		}
+		result = append(result, r.scholarship)
+	}
+	return result
+}
+
+// containsString reports whether list holds s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// applyScholarships adds a funding section before the next step of
+// recommendations for a study route, listing scholarships and tuition
+// waivers the applicant can get. Other routes, and destinations without
+// any, are returned unchanged.
+func applyScholarships(markdown, destination, originCode, query string, profile parser.UserProfile) string {
+	pathway := pathwayName(markdown)
+	if !studyPathwayPattern.MatchString(pathway) {
+		return markdown
+	}
+	found := findScholarships(destination, originCode, query+" "+pathway+" "+profile.Profession)
+	if len(found) == 0 {
+		return markdown
+	}
+
+	var section strings.Builder
+	section.WriteString("**Funding Your Studies:**\n")
+	for _, s := range found {
+		fmt.Fprintf(&section, "- %s (%s): %s", s.Name, s.Provider, s.Covers)
+		if s.Requirements != "" {
+			section.WriteString(". " + strings.ToUpper(s.Requirements[:1]) + s.Requirements[1:])
+		}
+		if s.Deadline != "" {
+			section.WriteString(". Deadline: " + s.Deadline)
+		}
+		section.WriteString(". " + s.URL + "\n")
+	}
+	fmt.Fprintf(&section, "Amounts and deadlines change every year; check them with the provider (scholarship list version %s).\n", scholarships.Version)
+	return insertBeforeNextStep(markdown, section.String())
+}
+
+// insertBeforeNextStep adds a section to an answer before its Next step
+// line, or at the end when there is none
+func insertBeforeNextStep(markdown, section string) string {
+	loc := nextStepPattern.FindStringIndex(markdown)
+	if loc == nil {
+		return strings.TrimRight(markdown, "\n") + "\n\n" + strings.TrimRight(section, "\n") + "\n"
+	}
+	return markdown[:loc[0]] + strings.TrimRight(section, "\n") + "\n\n" + markdown[loc[0]:]
+}
@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+)
+
+// Start runs the background work of a serving agent: milestone reminders,
+// held tasks, peer discovery, content reloads on SIGHUP, dataset refreshes,
+// the corridor and billing exports, artifact retention and, in queue mode,
+// storing the results workers send back. It returns at once.
+func (a *MigrationAgent) Start() {
+	switch {
+	case a.gemini.Mode == llm.ModeMock:
+		log.Printf("🧪 LLM_MODE=mock: answers are canned and nothing is sent to Gemini")
+	case a.gemini.Mode == llm.ModeOff:
+		log.Printf("🔒 LLM_MODE=off: answers come from the knowledge base and nothing is sent to an LLM")
+	case a.gemini.Provider != nil:
+		log.Printf("🤖 Using the embedder's LLM provider")
+	case a.gemini.APIKey == "":
+		log.Println("⚠️  WARNING: GEMINI_API_KEY environment variable not set!")
+		log.Println("   Please set it with: export GEMINI_API_KEY=your-api-key")
+		log.Println("   Get your key at: https://aistudio.google.com/app/apikey")
+		log.Println("")
+	default:
+		log.Printf("🤖 Using Gemini LLM for real-time migration pathway generation")
+	}
+
+	// Deliver milestone reminders in the background
+	go a.reminders.Run()
+
+	// Retry tasks held for quota once it resets
+	go a.quotaHold.Run()
+
+	// Discover peer agents and keep their cards fresh
+	go a.registry.Run()
+
+	// Reload prompts and dictionaries on SIGHUP without dropping tasks
+	go a.reloadOnSignal()
+
+	// Keep fees, processing times and exchange rates current
+	go a.dataRefresher.Run()
+
+	// Write anonymized corridor counts for demand analysis
+	corridors := NewCorridorExporter(a.audit)
+	corridors.clock = a.clock
+	go corridors.Run()
+
+	// Write per-tenant usage for the billing system
+	go a.billing.Run()
+
+	// Delete stored file artifacts once they pass their retention period
+	go a.files.Run()
+
+	// In queue mode, workers send finished tasks back to be stored here
+	if a.queues.Enabled() {
+		log.Printf("📬 Queue mode: tasks are processed by workers")
+		go a.RunResults()
+	}
+}
+
+// Register mounts the agent's endpoints on mux, so the agent can share a
+// server with the embedder's own handlers. Browser-facing endpoints get
+// CORS for CORS_ORIGINS, and the A2A, MCP and feedback endpoints are
+// authenticated per tenant, with task sends rate limited.
+func (a *MigrationAgent) Register(mux *http.ServeMux) {
+	cors := CORS(corsOrigins()...)
+	mux.Handle("/.well-known/agent.json", Chain(http.HandlerFunc(a.ServeAgentCard), cors))
+	mux.Handle("/a2a/planner", Chain(http.HandlerFunc(a.HandlePlanner), cors, a.Authenticate(), a.RateLimit()))
+	mux.HandleFunc("/privacy/export", a.ServePrivacyExport)
+	mux.HandleFunc("/privacy/data", a.ServePrivacyDelete)
+	mux.HandleFunc("/admin/audit", a.ServeAuditLog)
+	mux.HandleFunc("/admin/agents", a.ServeAgents)
+	mux.HandleFunc("/admin/reload", a.ServeReload)
+	mux.HandleFunc("/admin/flags", a.ServeFlags)
+	mux.HandleFunc("/admin/rollout", a.ServeRollout)
+	mux.HandleFunc("/admin/quality", a.ServeQuality)
+	mux.HandleFunc("/admin/feedback", a.ServeFeedbackReport)
+	mux.HandleFunc("/admin/analytics", a.ServeAnalytics)
+	mux.HandleFunc("/admin/cache", a.ServeCacheStats)
+	mux.HandleFunc("/admin/datasets", a.ServeDatasets)
+	mux.HandleFunc("/admin/tenants", a.ServeTenants)
+	mux.HandleFunc("/admin/metering", a.ServeMetering)
+	mux.HandleFunc("/admin/dead-letters", a.ServeDeadLetters)
+	mux.HandleFunc("/admin/connections", a.ServeConnStats)
+	mux.Handle("/feedback", Chain(http.HandlerFunc(a.ServeFeedback), cors, a.Authenticate()))
+	mux.Handle("/mcp", Chain(http.HandlerFunc(a.ServeMCP), a.Authenticate(), a.RateLimit()))
+	mux.HandleFunc("/integrations/telex", a.ServeTelex)
+	mux.HandleFunc("/integrations/slack", a.ServeSlack)
+	mux.HandleFunc("/integrations/whatsapp", a.ServeWhatsApp)
+	mux.HandleFunc("/integrations/discord", a.ServeDiscord)
+}
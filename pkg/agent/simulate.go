@@ -0,0 +1,272 @@
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// CRSChanges are hypothetical changes to a CRS profile; only the fields
+// given are changed
+type CRSChanges struct {
+	Age                 *int     `json:"age,omitempty"`
+	Education           *string  `json:"education,omitempty"`
+	CLB                 *int     `json:"clb,omitempty"`
+	IELTS               *float64 `json:"ielts,omitempty"` // overall IELTS General band, used when clb is not given
+	ForeignExperience   *int     `json:"foreignExperienceYears,omitempty"`
+	CanadianExperience  *int     `json:"canadianExperienceYears,omitempty"`
+	ProvincialNominated *bool    `json:"provincialNomination,omitempty"`
+}
+
+// Scenario is one what-if to simulate: structured changes, a description
+// such as "if I get IELTS 8 and a master's degree", or both
+type Scenario struct {
+	Label   string     `json:"label,omitempty"`
+	WhatIf  string     `json:"whatIf,omitempty"`
+	Changes CRSChanges `json:"changes"`
+}
+
+// SimulateParams is the params of the simulate method. The current profile
+// comes from query, from profile, or from both with profile taking
+// precedence.
+type SimulateParams struct {
+	Query     string     `json:"query,omitempty"`
+	Profile   CRSChanges `json:"profile"`
+	Scenarios []Scenario `json:"scenarios"`
+}
+
+// ExpressEntryEligibility reports which Express Entry programs a profile
+// meets the minimum requirements of, and what is missing otherwise
+type ExpressEntryEligibility struct {
+	FederalSkilledWorker    bool     `json:"federalSkilledWorker"`
+	CanadianExperienceClass bool     `json:"canadianExperienceClass"`
+	Missing                 []string `json:"missing,omitempty"`
+}
+
+// SimulatedProfile is a profile with its CRS estimate and eligibility
+type SimulatedProfile struct {
+	Profile     parser.CRSInput         `json:"profile"`
+	Score       CRSBreakdown            `json:"score"`
+	Eligibility ExpressEntryEligibility `json:"eligibility"`
+}
+
+// ScenarioResult is a simulated scenario and how it differs from the
+// current profile
+type ScenarioResult struct {
+	Label string `json:"label"`
+	SimulatedProfile
+	Delta CRSBreakdown `json:"delta"` // scenario score minus current score, per component
+}
+
+// Simulation is the result of the simulate method
+type Simulation struct {
+	Current   SimulatedProfile `json:"current"`
+	Scenarios []ScenarioResult `json:"scenarios"`
+	Summary   string           `json:"summary"`
+}
+
+// maxScenarios bounds the what-ifs in one simulate call
+const maxScenarios = 10
+
+// Simulate recomputes the CRS estimate and Express Entry eligibility of a
+// profile under each scenario and reports the change from the current
+// profile
+func Simulate(params SimulateParams) (*Simulation, error) {
+	current, _ := parser.ParseCRSInput(params.Query)
+	if err := params.Profile.apply(&current); err != nil {
+		return nil, fmt.Errorf("profile: %v", err)
+	}
+	if current.Age < 17 || current.Education == "" || current.CLB == 0 {
+		return nil, fmt.Errorf("%w: the current profile needs an age of at least 17, an education level and a language level (clb or ielts)", ErrInvalidProfile)
+	}
+	if len(params.Scenarios) == 0 {
+		return nil, fmt.Errorf("%w: give at least one scenario", ErrInvalidProfile)
+	}
+	if len(params.Scenarios) > maxScenarios {
+		return nil, fmt.Errorf("%w: at most %d scenarios can be simulated at once", ErrInvalidProfile, maxScenarios)
+	}
+
+	sim := &Simulation{Current: simulateProfile(current)}
+	summary := []string{fmt.Sprintf("Current estimated CRS score: %d.", sim.Current.Score.Total)}
+	for i, scenario := range params.Scenarios {
+		changed := current
+		applied := false
+		if scenario.WhatIf != "" {
+			applied = whatIfChanges(scenario.WhatIf, &changed)
+		}
+		if scenario.Changes != (CRSChanges{}) {
+			if err := scenario.Changes.apply(&changed); err != nil {
+				return nil, fmt.Errorf("scenario %d: %v", i+1, err)
+			}
+			applied = true
+		}
+		if !applied {
+			return nil, fmt.Errorf("%w: scenario %d has no changes we recognise; give changes or a whatIf such as \"IELTS 8\" or \"a master's degree\"", ErrInvalidProfile, i+1)
+		}
+
+		label := scenario.Label
+		if label == "" {
+			label = scenario.WhatIf
+		}
+		if label == "" {
+			label = fmt.Sprintf("Scenario %d", i+1)
+		}
+		result := ScenarioResult{Label: label, SimulatedProfile: simulateProfile(changed)}
+		result.Delta = result.Score.minus(sim.Current.Score)
+		sim.Scenarios = append(sim.Scenarios, result)
+		summary = append(summary, result.describe())
+	}
+	sim.Summary = strings.Join(summary, " ")
+	return sim, nil
+}
+
+// apply sets the fields given in c on in, checking their values
+func (c CRSChanges) apply(in *parser.CRSInput) error {
+	if c.Age != nil {
+		if *c.Age < 17 {
+			return fmt.Errorf("age must be at least 17")
+		}
+		in.Age = *c.Age
+	}
+	if c.Education != nil {
+		if _, ok := crsEducationPoints[*c.Education]; !ok {
+			return fmt.Errorf("unknown education %q", *c.Education)
+		}
+		in.Education = *c.Education
+	}
+	switch {
+	case c.CLB != nil:
+		if *c.CLB < 0 || *c.CLB > 12 {
+			return fmt.Errorf("clb must be between 0 and 12")
+		}
+		in.CLB = *c.CLB
+	case c.IELTS != nil:
+		if *c.IELTS < 0 || *c.IELTS > 9 {
+			return fmt.Errorf("ielts must be a band between 0 and 9")
+		}
+		in.CLB = parser.IELTSToCLB(*c.IELTS)
+	}
+	if c.ForeignExperience != nil {
+		if *c.ForeignExperience < 0 {
+			return fmt.Errorf("foreignExperienceYears can't be negative")
+		}
+		in.ForeignExperience = *c.ForeignExperience
+	}
+	if c.CanadianExperience != nil {
+		if *c.CanadianExperience < 0 {
+			return fmt.Errorf("canadianExperienceYears can't be negative")
+		}
+		in.CanadianExperience = *c.CanadianExperience
+	}
+	if c.ProvincialNominated != nil {
+		in.ProvincialNominated = *c.ProvincialNominated
+	}
+	return nil
+}
+
+// whatIfChanges applies the changes a free-text what-if mentions, reading
+// it like a query, and reports whether it found any
+func whatIfChanges(whatIf string, in *parser.CRSInput) bool {
+	found, _ := parser.ParseCRSInput(whatIf)
+	applied := false
+	if found.Age >= 17 {
+		in.Age, applied = found.Age, true
+	}
+	if found.Education != "" {
+		in.Education, applied = found.Education, true
+	}
+	if found.CLB > 0 {
+		in.CLB, applied = found.CLB, true
+	}
+	if found.ForeignExperience > 0 {
+		in.ForeignExperience, applied = found.ForeignExperience, true
+	}
+	if found.CanadianExperience > 0 {
+		in.CanadianExperience, applied = found.CanadianExperience, true
+	}
+	if found.ProvincialNominated {
+		in.ProvincialNominated, applied = true, true
+	}
+	return applied
+}
+
+// simulateProfile scores a profile and checks its eligibility
+func simulateProfile(in parser.CRSInput) SimulatedProfile {
+	return SimulatedProfile{Profile: in, Score: CalculateCRS(in), Eligibility: expressEntryEligibility(in)}
+}
+
+// expressEntryEligibility checks the minimum language and experience
+// requirements of the Federal Skilled Worker Program and the Canadian
+// Experience Class (for TEER 0 or 1 jobs)
+func expressEntryEligibility(in parser.CRSInput) ExpressEntryEligibility {
+	var e ExpressEntryEligibility
+	languageOK := in.CLB >= 7
+	if !languageOK {
+		e.Missing = append(e.Missing, fmt.Sprintf("CLB 7 in the first official language (now CLB %d)", in.CLB))
+	}
+	e.FederalSkilledWorker = languageOK && in.ForeignExperience+in.CanadianExperience >= 1
+	e.CanadianExperienceClass = languageOK && in.CanadianExperience >= 1
+	if in.ForeignExperience+in.CanadianExperience < 1 {
+		e.Missing = append(e.Missing, "one year of skilled work experience for the Federal Skilled Worker Program")
+	}
+	if in.CanadianExperience < 1 {
+		e.Missing = append(e.Missing, "one year of skilled work experience in Canada for the Canadian Experience Class")
+	}
+	return e
+}
+
+// minus subtracts another breakdown component by component
+func (b CRSBreakdown) minus(other CRSBreakdown) CRSBreakdown {
+	return CRSBreakdown{
+		Age:                b.Age - other.Age,
+		Education:          b.Education - other.Education,
+		Language:           b.Language - other.Language,
+		CanadianExperience: b.CanadianExperience - other.CanadianExperience,
+		Transferability:    b.Transferability - other.Transferability,
+		Additional:         b.Additional - other.Additional,
+		Total:              b.Total - other.Total,
+	}
+}
+
+// describe summarizes a scenario's score and the components that changed
+func (r ScenarioResult) describe() string {
+	var changes []string
+	for _, c := range []struct {
+		name  string
+		delta int
+	}{
+		{"age", r.Delta.Age},
+		{"education", r.Delta.Education},
+		{"language", r.Delta.Language},
+		{"Canadian experience", r.Delta.CanadianExperience},
+		{"skill transferability", r.Delta.Transferability},
+		{"provincial nomination", r.Delta.Additional},
+	} {
+		if c.delta != 0 {
+			changes = append(changes, fmt.Sprintf("%s %+d", c.name, c.delta))
+		}
+	}
+	text := fmt.Sprintf("%s: %d (%+d", r.Label, r.Score.Total, r.Delta.Total)
+	if len(changes) > 0 {
+		text += "; " + strings.Join(changes, ", ")
+	}
+	return text + ")."
+}
+
+// handleSimulate processes the simulate RPC method
+func (a *MigrationAgent) handleSimulate(w http.ResponseWriter, req JSONRPCRequest) {
+	var params SimulateParams
+	if err := remarshal(req.Params, &params); err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	sim, err := Simulate(params)
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+	a.sendSuccess(w, sim, req.ID)
+}
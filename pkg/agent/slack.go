@@ -0,0 +1,309 @@
+package agent
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// slackMaxSkew is how old a signed Slack request may be before it is
+// rejected as a possible replay
+const slackMaxSkew = 5 * time.Minute
+
+// slackSectionLimit is the maximum length of a Block Kit section's text
+const slackSectionLimit = 3000
+
+// SlackEvent is the envelope of an Events API callback
+type SlackEvent struct {
+	Type      string `json:"type"` // url_verification or event_callback
+	Challenge string `json:"challenge"`
+	TeamID    string `json:"team_id"`
+	Event     struct {
+		Type    string `json:"type"` // app_mention
+		User    string `json:"user"`
+		Text    string `json:"text"`
+		Channel string `json:"channel"`
+		TS      string `json:"ts"`
+		BotID   string `json:"bot_id"`
+	} `json:"event"`
+}
+
+// slackMentionPattern matches user mentions such as <@U012AB3CD>
+var slackMentionPattern = regexp.MustCompile(`<@[A-Z0-9]+>`)
+
+// ServeSlack handles /integrations/slack for both slash commands
+// (form-encoded) and Events API callbacks (JSON)
+func (a *MigrationAgent) ServeSlack(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	secret := os.Getenv("SLACK_SIGNING_SECRET")
+	if secret == "" {
+		writeJSONError(w, http.StatusServiceUnavailable, "Slack integration disabled: SLACK_SIGNING_SECRET not configured")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	if err := verifySlackSignature(secret, r.Header, body, a.clock.Now()); err != nil {
+		log.Printf("Rejected Slack request: %v", err)
+		writeJSONError(w, http.StatusUnauthorized, "invalid Slack signature")
+		return
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		a.handleSlackEvent(w, body)
+		return
+	}
+	a.handleSlackCommand(w, body)
+}
+
+// verifySlackSignature checks the v0 HMAC-SHA256 signature Slack attaches to
+// every request
+func verifySlackSignature(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	signature := header.Get("X-Slack-Signature")
+	if timestamp == "" || signature == "" {
+		return fmt.Errorf("missing signature headers")
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > slackMaxSkew || skew < -slackMaxSkew {
+		return fmt.Errorf("timestamp outside allowed window")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// handleSlackCommand acknowledges a slash command immediately and posts the
+// recommendation to its response_url once generated
+func (a *MigrationAgent) handleSlackCommand(w http.ResponseWriter, body []byte) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid form body")
+		return
+	}
+
+	query := strings.TrimSpace(form.Get("text"))
+	if query == "" {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"response_type": "ephemeral",
+			"text":          "Tell me about your situation, e.g. `" + form.Get("command") + " Nurse from India wanting to move to UK`",
+		})
+		return
+	}
+
+	responseURL := form.Get("response_url")
+	sessionID := "slack:" + form.Get("team_id") + ":" + form.Get("user_id")
+	go func() {
+		reply := a.slackReply(query, sessionID)
+		reply["response_type"] = "in_channel"
+		reply["replace_original"] = true
+		if err := postSlackJSON(responseURL, "", reply); err != nil {
+			log.Printf("Failed to post Slack response: %v", err)
+		}
+	}()
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"response_type": "ephemeral",
+		"text":          "✈️ Researching migration pathways, this can take up to a minute...",
+	})
+}
+
+// handleSlackEvent answers URL verification and replies to app mentions in
+// their thread via chat.postMessage
+func (a *MigrationAgent) handleSlackEvent(w http.ResponseWriter, body []byte) {
+	var event SlackEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid event payload")
+		return
+	}
+
+	if event.Type == "url_verification" {
+		writeJSON(w, http.StatusOK, map[string]string{"challenge": event.Challenge})
+		return
+	}
+
+	// Slack retries unless events are acknowledged within three seconds
+	w.WriteHeader(http.StatusOK)
+
+	if event.Type != "event_callback" || event.Event.Type != "app_mention" || event.Event.BotID != "" {
+		return
+	}
+	token := os.Getenv("SLACK_BOT_TOKEN")
+	if token == "" {
+		log.Printf("Ignoring Slack app_mention: SLACK_BOT_TOKEN not configured")
+		return
+	}
+
+	query := strings.TrimSpace(slackMentionPattern.ReplaceAllString(event.Event.Text, ""))
+	if query == "" {
+		return
+	}
+	sessionID := "slack:" + event.TeamID + ":" + event.Event.User
+	go func() {
+		reply := a.slackReply(query, sessionID)
+		reply["channel"] = event.Event.Channel
+		reply["thread_ts"] = event.Event.TS
+		if err := postSlackJSON("https://slack.com/api/chat.postMessage", token, reply); err != nil {
+			log.Printf("Failed to post Slack message: %v", err)
+		}
+	}()
+}
+
+// slackReply runs the query and formats the result as a Block Kit message
+func (a *MigrationAgent) slackReply(query, sessionID string) map[string]interface{} {
+	message := Message{
+		Role:  "user",
+		Parts: []Part{{Kind: "text", Text: query}},
+	}
+	task, err := a.ProcessTask(a.ids.NewID(), message, TaskOptions{SessionID: sessionID})
+	if err != nil {
+		log.Printf("Slack query failed: %v", err)
+		return map[string]interface{}{
+			"text": "❌ Sorry, I couldn't generate migration pathways right now. Please try again later.",
+		}
+	}
+
+	text := ""
+	if task.Status.Message != nil && len(task.Status.Message.Parts) > 0 {
+		text = task.Status.Message.Parts[0].Text
+	}
+	return map[string]interface{}{
+		"text":   "Migration pathway recommendation",
+		"blocks": slackBlocks(text),
+	}
+}
+
+// slackBlocks converts a markdown answer to Block Kit: headings become header
+// blocks and everything else mrkdwn sections no longer than Slack allows
+func slackBlocks(markdown string) []map[string]interface{} {
+	var blocks []map[string]interface{}
+	var section strings.Builder
+
+	flush := func() {
+		text := strings.TrimSpace(section.String())
+		section.Reset()
+		if text == "" {
+			return
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": text},
+		})
+	}
+
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			flush()
+			heading := strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			if len(heading) > 150 {
+				heading = heading[:150]
+			}
+			blocks = append(blocks, map[string]interface{}{
+				"type": "header",
+				"text": map[string]string{"type": "plain_text", "text": heading},
+			})
+			continue
+		}
+		if trimmed == "---" {
+			flush()
+			blocks = append(blocks, map[string]interface{}{"type": "divider"})
+			continue
+		}
+
+		line = slackMrkdwn(line)
+		if section.Len()+len(line)+1 > slackSectionLimit {
+			flush()
+		}
+		section.WriteString(line)
+		section.WriteString("\n")
+	}
+	flush()
+
+	// Slack rejects messages with more than 50 blocks
+	if len(blocks) > 50 {
+		blocks = blocks[:50]
+	}
+	return blocks
+}
+
+// slackBoldPattern matches markdown **bold** spans
+var slackBoldPattern = regexp.MustCompile(`\*\*(.+?)\*\*`)
+
+// slackMrkdwn rewrites markdown emphasis and bullets into Slack's mrkdwn
+func slackMrkdwn(line string) string {
+	line = slackBoldPattern.ReplaceAllString(line, "*$1*")
+	trimmed := strings.TrimLeft(line, " ")
+	if strings.HasPrefix(trimmed, "- ") {
+		indent := line[:len(line)-len(trimmed)]
+		line = indent + "• " + strings.TrimPrefix(trimmed, "- ")
+	}
+	return line
+}
+
+// postSlackJSON posts a message to a response_url or Web API method
+func postSlackJSON(target, token string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Slack message: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Slack request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Slack message: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Slack returned status %d", resp.StatusCode)
+	}
+
+	// Web API methods report failures in the body with a 200 status
+	var result struct {
+		OK    *bool  `json:"ok"`
+		Error string `json:"error"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&result) == nil && result.OK != nil && !*result.OK {
+		return fmt.Errorf("Slack API error: %s", result.Error)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// signSlack sets the v0 signature headers Slack sends with a request
+func signSlack(header http.Header, secret string, sentAt time.Time, body []byte) {
+	timestamp := strconv.FormatInt(sentAt.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	header.Set("X-Slack-Request-Timestamp", timestamp)
+	header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestVerifySlackSignature(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte("command=%2Fmigrate&text=Nurse+from+India")
+	for _, tc := range []struct {
+		name     string
+		secret   string // signs the request; "" leaves it unsigned
+		sentAt   time.Time
+		received []byte
+		valid    bool
+	}{
+		{"valid", "s3cret", now, body, true},
+		{"tampered body", "s3cret", now, []byte("command=%2Fmigrate&text=Engineer+from+Brazil"), false},
+		{"wrong secret", "other", now, body, false},
+		{"stale timestamp", "s3cret", now.Add(-slackMaxSkew - time.Second), body, false},
+		{"timestamp from the future", "s3cret", now.Add(slackMaxSkew + time.Second), body, false},
+		{"unsigned", "", now, body, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			header := http.Header{}
+			if tc.secret != "" {
+				signSlack(header, tc.secret, tc.sentAt, body)
+			}
+
+			err := verifySlackSignature("s3cret", header, tc.received, now)
+			if (err == nil) != tc.valid {
+				t.Errorf("verifySlackSignature = %v, want valid %v", err, tc.valid)
+			}
+		})
+	}
+}
+
+func TestSlackRejectsStaleRequests(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	t.Setenv("SLACK_SIGNING_SECRET", "s3cret")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	a := NewMigrationAgentWithDeps(nil, nil, fixedClock{now}, nil)
+
+	body := []byte(`{"type": "url_verification", "challenge": "abc"}`)
+	for _, tc := range []struct {
+		name   string
+		sentAt time.Time
+		want   int
+	}{
+		{"fresh", now.Add(-time.Minute), http.StatusOK},
+		{"stale", now.Add(-10 * time.Minute), http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/integrations/slack", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			signSlack(req.Header, "s3cret", tc.sentAt, body)
+
+			rec := httptest.NewRecorder()
+			a.ServeSlack(rec, req)
+			if rec.Code != tc.want {
+				t.Errorf("got %d %q, want %d", rec.Code, rec.Body, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,445 @@
+package agent
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// Calculator derives a deterministic markdown section (e.g. a points
+// estimate) from the query, or returns "" when it doesn't apply
+type Calculator func(profile parser.UserProfile, query string) string
+
+// Specialist handles queries for one destination country with its own
+// prompt guidance, knowledge pack and calculators
+type Specialist struct {
+	Name        string   // routing key, e.g. canada
+	Country     string   // display name
+	Aliases     []string // lowercase names identifying the destination in a query
+	Guidance    string   // country-specific prompt instructions
+	Knowledge   []string // curated facts given to the model as context
+	Programs    []VisaProgram
+	Health      []HealthRule  // health-insurance and medical-exam requirements
+	Family      []FamilyRule  // rules for bringing a spouse or children
+	Horizon     []HorizonRule // how routes lead to permanent residence and citizenship; the first that applies wins
+	Calculators []Calculator  `json:"-"`
+}
+
+// VisaProgram is a migration route a specialist knows about
+type VisaProgram struct {
+	Name     string `json:"name"`
+	Country  string `json:"country"`
+	Category string `json:"category"` // work, study, permanent or talent
+	Summary  string `json:"summary"`
+}
+
+// VisaPrograms lists the programs known to the specialists, optionally for
+// one country (matched by name or alias)
+func VisaPrograms(country string) []VisaProgram {
+	country = strings.ToLower(strings.TrimSpace(country))
+	var programs []VisaProgram
+	for _, s := range content().Specialists {
+		if country != "" && !s.matches(country) {
+			continue
+		}
+		for _, program := range s.Programs {
+			program.Country = s.Country
+			programs = append(programs, program)
+		}
+	}
+	return programs
+}
+
+// matches reports whether name refers to this specialist's country
+func (s *Specialist) matches(name string) bool {
+	if name == s.Name || name == strings.ToLower(s.Country) {
+		return true
+	}
+	for _, alias := range s.Aliases {
+		if name == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// generalist handles destinations without a specialist
+var generalist = &Specialist{Name: "general"}
+
+// specialists are the built-in country specialists the orchestrator routes
+// to; CONTENT_DIR can replace them (see content.go)
+var specialists = []*Specialist{
+	{
+		Name:    "canada",
+		Country: "Canada",
+		Aliases: []string{"canada", "canadian"},
+		Guidance: "Prefer Express Entry (Federal Skilled Worker, Canadian Experience Class or Federal Skilled Trades) for skilled workers, " +
+			"Provincial Nominee Programs when the profile fits a province's in-demand list, and study permits with a post-graduation work permit for students. " +
+			"Mention the CRS score the applicant would need and how to raise it.",
+		Knowledge: []string{
+			"Express Entry candidates are ranked by the Comprehensive Ranking System (CRS, maximum 1,200 points); a provincial nomination adds 600 points.",
+			"Federal Skilled Worker applicants need at least CLB 7 in their first official language and one year of continuous skilled work experience.",
+			"Proof of settlement funds is required unless the applicant has a valid job offer or is applying under the Canadian Experience Class.",
+			"Foreign degrees need an Educational Credential Assessment (ECA) from a designated organisation such as WES.",
+		},
+		Programs: []VisaProgram{
+			{Name: "Express Entry (Federal Skilled Worker)", Category: "permanent", Summary: "Points-ranked permanent residence for skilled workers with foreign experience."},
+			{Name: "Canadian Experience Class", Category: "permanent", Summary: "Permanent residence for people with at least one year of skilled work in Canada."},
+			{Name: "Provincial Nominee Program", Category: "permanent", Summary: "Province-selected immigrants; an Express Entry-aligned nomination adds 600 CRS points."},
+			{Name: "Study Permit with Post-Graduation Work Permit", Category: "study", Summary: "Study at a designated institution, then work for up to three years."},
+		},
+		Health: []HealthRule{
+			{Kind: HealthMedical, Categories: []string{"permanent"}, Document: "Immigration medical exam (IME) results",
+				Requirement: "an immigration medical exam with an IRCC panel physician, including a chest X-ray for TB",
+				Cost:        &Fee{Item: "immigration medical exam", Amount: 250, Currency: "USD"}},
+			{Kind: HealthMedical, Categories: []string{"study", "work"}, Origins: []string{"NG", "GH", "KE", "ZA", "IN", "PK", "BD", "PH", "CN"}, Document: "Upfront medical exam confirmation (IMM 1017B)",
+				Requirement: "a medical exam with an IRCC panel physician before applying, since you live in a country designated for medical screening and will stay over 6 months",
+				Cost:        &Fee{Item: "immigration medical exam", Amount: 250, Currency: "USD"}},
+			{Kind: HealthInsurance, Categories: []string{"permanent", "work"},
+				Requirement: "provincial health insurance covers residents and most work-permit holders, but some provinces make newcomers wait up to 3 months; buy private cover for the gap"},
+			{Kind: HealthInsurance, Categories: []string{"study"}, Document: "Proof of health insurance",
+				Requirement: "international students must hold health insurance, through the provincial plan in some provinces and the university's plan in others",
+				Cost:        &Fee{Item: "student health plan", Amount: 900, Currency: "CAD", Per: "year"}},
+		},
+		Family: []FamilyRule{
+			{Topic: FamilyVisa, Categories: []string{"permanent"},
+				Detail:    "include your spouse and children under 22 in the same permanent residence application; they become permanent residents with you",
+				SpouseFee: &Fee{Item: "processing and right of permanent residence fees", Amount: 1525, Currency: "CAD"},
+				ChildFee:  &Fee{Item: "processing fee", Amount: 260, Currency: "CAD"}},
+			{Topic: FamilyVisa, Categories: []string{"work", "study"},
+				Detail:    "your spouse and children apply for their own permits or visitor visas alongside your application",
+				SpouseFee: &Fee{Item: "work permit and open work permit holder fees", Amount: 255, Currency: "CAD"},
+				ChildFee:  &Fee{Item: "visitor record", Amount: 100, Currency: "CAD"}},
+			{Topic: FamilyWork, Categories: []string{"permanent"},
+				Detail: "as a permanent resident your spouse can work for any employer"},
+			{Topic: FamilyWork, Categories: []string{"work"},
+				Detail: "your spouse can get an open work permit if your job is in TEER 0 or 1, or in selected TEER 2 and 3 occupations, and your permit has at least 16 months left"},
+			{Topic: FamilyWork, Categories: []string{"study"},
+				Detail: "spouses can get an open work permit only when you study a master's of at least 16 months, a PhD or selected professional degrees"},
+			{Topic: FamilySchooling,
+				Detail: "public primary and secondary school is free for children of permanent residents and of work or study permit holders; minor children don't need a study permit for it"},
+		},
+		Horizon: []HorizonRule{
+			{Categories: []string{"permanent"},
+				Residency:   HorizonStage{MinMonths: 6, MaxMonths: 12, Via: "approval of the application, since the route grants permanent residence"},
+				Citizenship: HorizonStage{MinMonths: 48, MaxMonths: 60, Via: "3 years (1,095 days) of physical presence in the last 5 as a permanent resident, then citizenship processing"}},
+			{Categories: []string{"work"},
+				Residency:   HorizonStage{MinMonths: 24, MaxMonths: 36, Via: "the Canadian Experience Class or a Provincial Nominee Program after at least a year of skilled work in Canada"},
+				Citizenship: HorizonStage{MinMonths: 60, MaxMonths: 84, Via: "3 years of physical presence in the last 5, where up to a year on a work or study permit counts at half"}},
+			{Categories: []string{"study"},
+				Residency:   HorizonStage{MinMonths: 36, MaxMonths: 60, Via: "a post-graduation work permit, then the Canadian Experience Class after a year of skilled work"},
+				Citizenship: HorizonStage{MinMonths: 72, MaxMonths: 96, Via: "3 years of physical presence in the last 5, where up to a year on a work or study permit counts at half"}},
+		},
+		Calculators: []Calculator{crsCalculator},
+	},
+	{
+		Name:    "usa",
+		Country: "United States",
+		Aliases: []string{"united states", "usa", "u.s.", "america"},
+		Guidance: "Distinguish employer-sponsored routes (H-1B, L-1, O-1) from self-petitioned green cards (EB-2 NIW, EB-1A) and student routes (F-1 with OPT). " +
+			"Be explicit about lottery odds, employer involvement and country-specific green card backlogs.",
+		Knowledge: []string{
+			"H-1B visas are capped and allocated by an annual lottery; registration opens in March and selected petitions can start on 1 October.",
+			"The EB-2 National Interest Waiver lets applicants self-petition without an employer or labor certification.",
+			"F-1 students may work under OPT for 12 months after graduation, extended by 24 months for eligible STEM degrees.",
+			"Green card wait times depend on the applicant's country of birth; India and China have the longest employment-based backlogs.",
+		},
+		Programs: []VisaProgram{
+			{Name: "H-1B Specialty Occupation", Category: "work", Summary: "Employer-sponsored, lottery-allocated work visa for degree-level roles."},
+			{Name: "EB-2 National Interest Waiver", Category: "permanent", Summary: "Self-petitioned green card for advanced-degree professionals whose work benefits the US."},
+			{Name: "O-1 Extraordinary Ability", Category: "talent", Summary: "Work visa for people with sustained national or international acclaim."},
+			{Name: "F-1 Student with OPT", Category: "study", Summary: "Full-time study followed by 12 months (36 for STEM) of practical training."},
+		},
+		Health: []HealthRule{
+			{Kind: HealthMedical, Categories: []string{"permanent"}, Document: "Medical exam results and vaccination record (DS-3025)",
+				Requirement: "a medical exam with a US embassy panel physician, with TB screening and the required vaccinations, before the immigrant visa interview",
+				Cost:        &Fee{Item: "panel physician exam", Amount: 300, Currency: "USD"}},
+			{Kind: HealthInsurance, Categories: []string{"study"}, Document: "Proof of enrollment in a student health plan",
+				Requirement: "most universities require F-1 students to buy their health plan or show equivalent cover",
+				Cost:        &Fee{Item: "university health plan", Amount: 2500, Currency: "USD", Per: "year"}},
+			{Kind: HealthInsurance, Categories: []string{"work", "talent"},
+				Requirement: "there is no public health cover for visa holders; confirm your job offer includes employer health insurance"},
+		},
+		Family: []FamilyRule{
+			{Topic: FamilyVisa, Categories: []string{"permanent"},
+				Detail:    "your spouse and unmarried children under 21 get green cards as derivative beneficiaries of your petition",
+				SpouseFee: &Fee{Item: "immigrant visa and USCIS immigrant fees", Amount: 560, Currency: "USD"},
+				ChildFee:  &Fee{Item: "immigrant visa and USCIS immigrant fees", Amount: 560, Currency: "USD"}},
+			{Topic: FamilyVisa, Categories: []string{"work", "talent"},
+				Detail:    "your spouse and unmarried children under 21 apply for dependant visas (H-4, L-2 or O-3) at the consulate",
+				SpouseFee: &Fee{Item: "visa application fee", Amount: 205, Currency: "USD"},
+				ChildFee:  &Fee{Item: "visa application fee", Amount: 205, Currency: "USD"}},
+			{Topic: FamilyVisa, Categories: []string{"study"},
+				Detail:    "your spouse and children apply for F-2 visas with their own I-20 from your university, which needs proof of extra funds for each",
+				SpouseFee: &Fee{Item: "visa application fee", Amount: 185, Currency: "USD"},
+				ChildFee:  &Fee{Item: "visa application fee", Amount: 185, Currency: "USD"}},
+			{Topic: FamilyWork, Categories: []string{"permanent"},
+				Detail: "with a green card your spouse can work for any employer"},
+			{Topic: FamilyWork, Categories: []string{"work", "talent"},
+				Detail: "H-4 spouses can work only once you have an approved I-140 or H-1B extension beyond six years (with an EAD); L-2 spouses can work on their status; O-3 spouses cannot work"},
+			{Topic: FamilyWork, Categories: []string{"study"},
+				Detail: "F-2 spouses cannot work and can study only part-time"},
+			{Topic: FamilySchooling,
+				Detail: "public schools from kindergarten to grade 12 are free for children living in the district, whatever their visa"},
+		},
+		Horizon: []HorizonRule{
+			{Categories: []string{"permanent"}, Origins: []string{"IN", "CN"},
+				Residency:   HorizonStage{MinMonths: 60, MaxMonths: 144, Via: "the EB-2 green card, once the priority date backlog for your country of birth clears"},
+				Citizenship: HorizonStage{MinMonths: 120, MaxMonths: 210, Via: "5 years as a permanent resident (3 if married to a US citizen), then naturalization"}},
+			{Categories: []string{"permanent"},
+				Residency:   HorizonStage{MinMonths: 18, MaxMonths: 36, Via: "the green card, after I-140 approval and adjustment of status or consular processing"},
+				Citizenship: HorizonStage{MinMonths: 78, MaxMonths: 102, Via: "5 years as a permanent resident (3 if married to a US citizen), then naturalization"}},
+			{Categories: []string{"work", "talent"}, Origins: []string{"IN", "CN"},
+				Residency:   HorizonStage{MinMonths: 96, MaxMonths: 180, Via: "an employer-sponsored green card (PERM, I-140, then adjustment of status) once the backlog for your country of birth clears"},
+				Citizenship: HorizonStage{MinMonths: 156, MaxMonths: 246, Via: "5 years as a permanent resident (3 if married to a US citizen), then naturalization"}},
+			{Categories: []string{"work", "talent"},
+				Residency:   HorizonStage{MinMonths: 36, MaxMonths: 72, Via: "an employer-sponsored green card (PERM labor certification, I-140, then adjustment of status) or a self-petition such as EB-1A"},
+				Citizenship: HorizonStage{MinMonths: 96, MaxMonths: 138, Via: "5 years as a permanent resident (3 if married to a US citizen), then naturalization"}},
+			{Categories: []string{"study"},
+				Residency:   HorizonStage{MinMonths: 60, MaxMonths: 120, Via: "OPT, then an employer-sponsored work visa and green card"},
+				Citizenship: HorizonStage{MinMonths: 120, MaxMonths: 186, Via: "5 years as a permanent resident, then naturalization"}},
+		},
+	},
+	{
+		Name:    "uk",
+		Country: "United Kingdom",
+		Aliases: []string{"united kingdom", "uk", "u.k.", "britain", "england", "scotland", "wales"},
+		Guidance: "Prefer the Skilled Worker visa when a licensed sponsor is plausible, the Health and Care Worker visa for health professionals, " +
+			"the Global Talent visa for recognised leaders in their field, and the Graduate route after UK study. Account for the Immigration Health Surcharge in costs.",
+		Knowledge: []string{
+			"Skilled Worker visas need a job offer from a Home Office licensed sponsor, a Certificate of Sponsorship and English at CEFR B1 or above.",
+			"Health and Care Worker visa holders pay reduced fees and are exempt from the Immigration Health Surcharge.",
+			"Most visa applicants pay the Immigration Health Surcharge for each year of their visa on top of the application fee.",
+			"Indefinite Leave to Remain is usually available after five years on a qualifying route.",
+		},
+		Programs: []VisaProgram{
+			{Name: "Skilled Worker visa", Category: "work", Summary: "Work for a licensed sponsor in an eligible occupation at or above the salary threshold."},
+			{Name: "Health and Care Worker visa", Category: "work", Summary: "Reduced-fee Skilled Worker route for eligible health and social care roles."},
+			{Name: "Global Talent visa", Category: "talent", Summary: "Unsponsored route for endorsed leaders or emerging leaders in academia, research, arts or digital technology."},
+			{Name: "Student visa and Graduate route", Category: "study", Summary: "Study at a licensed sponsor, then stay two years (three after a PhD) to work."},
+		},
+		Health: []HealthRule{
+			{Kind: HealthInsurance, Document: "Immigration Health Surcharge payment reference",
+				Requirement: "pay the Immigration Health Surcharge when you apply for NHS access (included in the fees above; Health and Care Worker visa holders are exempt)"},
+			{Kind: HealthMedical, Origins: []string{"NG", "GH", "KE", "ZA", "IN", "PK", "BD", "PH", "CN"}, Document: "TB test certificate",
+				Requirement: "a TB test at a Home Office approved clinic, since you live in a listed country and will stay over 6 months; the certificate is valid for 6 months",
+				Cost:        &Fee{Item: "TB test", Amount: 100, Currency: "USD"}},
+		},
+		Family: []FamilyRule{
+			{Topic: FamilyVisa, Categories: []string{"work", "talent"},
+				Detail:    "your partner and children under 18 apply as dependants on your route, paying the same application fee and Immigration Health Surcharge as you; unless your sponsor certifies maintenance, show £285 for your partner and £315 for the first child",
+				SpouseFee: &Fee{Item: "dependant application fee and a year of Immigration Health Surcharge", Amount: 1800, Currency: "GBP"},
+				ChildFee:  &Fee{Item: "dependant application fee and a year of the reduced Immigration Health Surcharge", Amount: 1350, Currency: "GBP"}},
+			{Topic: FamilyVisa, Categories: []string{"study"},
+				Detail: "most Student visa holders can no longer bring dependants; only students on postgraduate research courses or government-sponsored courses over 6 months can"},
+			{Topic: FamilyWork,
+				Detail: "a dependant partner can work for any employer, except as a professional sportsperson, on most work and talent routes"},
+			{Topic: FamilySchooling,
+				Detail: "state schools are free for children aged 5 to 16 (4 to 17 in Scotland) who are dependants on your visa"},
+		},
+		Horizon: []HorizonRule{
+			{Program: "Global Talent visa",
+				Residency:   HorizonStage{MinMonths: 36, MaxMonths: 60, Via: "Indefinite Leave to Remain after 3 years for endorsed leaders or 5 for emerging leaders"},
+				Citizenship: HorizonStage{MinMonths: 48, MaxMonths: 78, Via: "12 months with Indefinite Leave to Remain, then naturalisation"}},
+			{Categories: []string{"work", "talent"},
+				Residency:   HorizonStage{MinMonths: 60, MaxMonths: 66, Via: "Indefinite Leave to Remain after 5 years on the route"},
+				Citizenship: HorizonStage{MinMonths: 72, MaxMonths: 84, Via: "12 months with Indefinite Leave to Remain, then naturalisation"}},
+			{Categories: []string{"study"},
+				Residency:   HorizonStage{MinMonths: 84, MaxMonths: 108, Via: "the Graduate route, then Indefinite Leave to Remain after 5 years on a Skilled Worker visa (study and Graduate time don't count)"},
+				Citizenship: HorizonStage{MinMonths: 96, MaxMonths: 126, Via: "12 months with Indefinite Leave to Remain, then naturalisation"}},
+		},
+	},
+	{
+		Name:    "germany",
+		Country: "Germany",
+		Aliases: []string{"germany", "german", "deutschland"},
+		Guidance: "Prefer the EU Blue Card for graduates with a qualifying job offer, the Opportunity Card (Chancenkarte) for job seekers, " +
+			"and skilled worker residence permits for vocationally trained applicants. Mention qualification recognition (anabin / ZAB) where relevant.",
+		Knowledge: []string{
+			"The EU Blue Card requires a recognised degree and a job offer meeting a minimum salary threshold, which is lower for shortage occupations.",
+			"The Opportunity Card lets qualified non-EU workers stay up to one year to look for work, using a points system.",
+			"Foreign degrees are checked against the anabin database or recognised through a ZAB Statement of Comparability.",
+			"Blue Card holders can obtain permanent settlement after 21 to 27 months depending on German language level.",
+		},
+		Programs: []VisaProgram{
+			{Name: "EU Blue Card", Category: "work", Summary: "Residence permit for graduates with a job offer above the salary threshold."},
+			{Name: "Opportunity Card (Chancenkarte)", Category: "work", Summary: "Points-based permit to stay up to a year while looking for skilled work."},
+			{Name: "Skilled Worker residence permit", Category: "work", Summary: "Permit for holders of recognised vocational or academic qualifications with a job offer."},
+			{Name: "Student residence permit", Category: "study", Summary: "Study at a German university, with 18 months afterwards to find work."},
+		},
+		Health: []HealthRule{
+			{Kind: HealthInsurance, Document: "Travel health insurance certificate",
+				Requirement: "travel health insurance with at least €30,000 of cover for the visa application, valid until German health insurance starts"},
+			{Kind: HealthInsurance, Categories: []string{"work", "permanent", "talent"},
+				Requirement: "health insurance is compulsory; employees join statutory insurance, paid from salary and shared with the employer"},
+			{Kind: HealthInsurance, Categories: []string{"study"}, Document: "Statutory health insurance confirmation for enrolment",
+				Requirement: "students must join statutory health insurance at the student rate to enrol",
+				Cost:        &Fee{Item: "student health insurance", Amount: 140, Currency: "EUR", Per: "month"}},
+		},
+		Family: []FamilyRule{
+			{Topic: FamilyVisa, Categories: []string{"work", "talent", "permanent"},
+				Detail:    "your spouse and children under 18 get family reunification residence permits; EU Blue Card and skilled worker families don't need to prove German",
+				SpouseFee: &Fee{Item: "national visa fee", Amount: 75, Currency: "EUR"},
+				ChildFee:  &Fee{Item: "national visa fee for a minor", Amount: 37.5, Currency: "EUR"}},
+			{Topic: FamilyVisa, Categories: []string{"study"},
+				Detail:    "family reunification for students needs proof that you can support everyone and enough living space",
+				SpouseFee: &Fee{Item: "national visa fee", Amount: 75, Currency: "EUR"},
+				ChildFee:  &Fee{Item: "national visa fee for a minor", Amount: 37.5, Currency: "EUR"}},
+			{Topic: FamilyWork,
+				Detail: "a spouse who joins you on a family reunification permit can work for any employer from day one"},
+			{Topic: FamilySchooling,
+				Detail: "school is compulsory and free at public schools from age 6; Kita daycare is free or heavily subsidised in many states"},
+		},
+		Horizon: []HorizonRule{
+			{Program: "EU Blue Card",
+				Residency:   HorizonStage{MinMonths: 21, MaxMonths: 27, Via: "a settlement permit after 21 months with B1 German or 27 months with A1"},
+				Citizenship: HorizonStage{MinMonths: 60, MaxMonths: 72, Via: "5 years of residence with B1 German and no reliance on benefits, then naturalisation"}},
+			{Categories: []string{"work", "permanent", "talent"},
+				Residency:   HorizonStage{MinMonths: 36, MaxMonths: 42, Via: "a settlement permit after 3 years as a skilled worker"},
+				Citizenship: HorizonStage{MinMonths: 60, MaxMonths: 72, Via: "5 years of residence with B1 German and no reliance on benefits, then naturalisation"}},
+			{Categories: []string{"study"},
+				Residency:   HorizonStage{MinMonths: 60, MaxMonths: 84, Via: "your studies, then a settlement permit after 2 years of skilled work"},
+				Citizenship: HorizonStage{MinMonths: 84, MaxMonths: 108, Via: "5 years of residence after graduating, with B1 German, then naturalisation (half the study years can count)"}},
+		},
+	},
+	{
+		Name:    "australia",
+		Country: "Australia",
+		Aliases: []string{"australia", "australian"},
+		Guidance: "Prefer points-tested General Skilled Migration (subclasses 189, 190, 491) for occupations on the skilled lists, employer sponsorship (482/186) when an employer is likely, " +
+			"and student visas for study plans. Mention the skills assessment authority for the occupation.",
+		Knowledge: []string{
+			"Points-tested skilled visas need a positive skills assessment for an occupation on the relevant skilled occupation list and at least 65 points.",
+			"Invitations to apply are issued through SkillSelect after lodging an Expression of Interest.",
+			"State nomination (subclass 190) adds 5 points and regional nomination (subclass 491) adds 15 points.",
+		},
+		Programs: []VisaProgram{
+			{Name: "Skilled Independent visa (subclass 189)", Category: "permanent", Summary: "Points-tested permanent visa without sponsorship."},
+			{Name: "Skilled Nominated visa (subclass 190)", Category: "permanent", Summary: "Points-tested permanent visa with state or territory nomination."},
+			{Name: "Skills in Demand visa (subclass 482)", Category: "work", Summary: "Employer-sponsored temporary work visa."},
+			{Name: "Student visa (subclass 500)", Category: "study", Summary: "Full-time study at a registered institution."},
+		},
+		Health: []HealthRule{
+			{Kind: HealthMedical, Document: "eMedical health examination (HAP ID)",
+				Requirement: "health examinations with a Bupa Medical Visa Services panel physician through eMedical, including a chest X-ray for TB, when the Department of Home Affairs asks",
+				Cost:        &Fee{Item: "health examinations", Amount: 300, Currency: "USD"}},
+			{Kind: HealthInsurance, Categories: []string{"study"}, Document: "Overseas Student Health Cover (OSHC) policy",
+				Requirement: "Overseas Student Health Cover for the whole visa, bought before your Confirmation of Enrolment is issued",
+				Cost:        &Fee{Item: "Overseas Student Health Cover", Amount: 700, Currency: "AUD", Per: "year"}},
+			{Kind: HealthInsurance, Categories: []string{"work"}, Document: "Overseas Visitors Health Cover (OVHC) policy",
+				Requirement: "temporary work visa holders must keep adequate private health insurance for their whole stay; permanent residents get Medicare"},
+		},
+		Family: []FamilyRule{
+			{Topic: FamilyVisa,
+				Detail:    "add your partner and dependent children to your application as secondary applicants; each pays an additional applicant charge and does their own health check",
+				SpouseFee: &Fee{Item: "additional applicant charge", Amount: 2375, Currency: "AUD"},
+				ChildFee:  &Fee{Item: "additional applicant charge for a child under 18", Amount: 1190, Currency: "AUD"}},
+			{Topic: FamilyWork, Categories: []string{"permanent", "work"},
+				Detail: "your partner has unlimited work rights on permanent and employer-sponsored visas"},
+			{Topic: FamilyWork, Categories: []string{"study"},
+				Detail: "a student's partner can work 48 hours a fortnight, or without limit when you study a master's or doctorate"},
+			{Topic: FamilySchooling,
+				Detail: "public school is free for children of permanent residents; students' and temporary workers' children may pay fees of around AUD 5,000 to 15,000 a year depending on the state"},
+		},
+		Horizon: []HorizonRule{
+			{Categories: []string{"permanent"},
+				Residency:   HorizonStage{MinMonths: 6, MaxMonths: 18, Via: "the visa grant, since the visa is permanent"},
+				Citizenship: HorizonStage{MinMonths: 54, MaxMonths: 72, Via: "4 years of residence in Australia, including 1 as a permanent resident, then citizenship processing"}},
+			{Categories: []string{"work"},
+				Residency:   HorizonStage{MinMonths: 24, MaxMonths: 36, Via: "the Employer Nomination Scheme (subclass 186) after 2 years with your sponsor"},
+				Citizenship: HorizonStage{MinMonths: 48, MaxMonths: 66, Via: "4 years of residence in Australia, including 1 as a permanent resident, then citizenship processing"}},
+			{Categories: []string{"study"},
+				Residency:   HorizonStage{MinMonths: 48, MaxMonths: 72, Via: "the Temporary Graduate visa (subclass 485), then a skilled or employer-sponsored permanent visa"},
+				Citizenship: HorizonStage{MinMonths: 60, MaxMonths: 84, Via: "4 years of residence in Australia, including 1 as a permanent resident, then citizenship processing"}},
+		},
+	},
+}
+
+// destinationPattern captures phrases that introduce a destination
+var destinationPattern = regexp.MustCompile(`\b(?:to|into|in|for)\s+(?:the\s+)?$`)
+
+// routeSpecialist picks the specialist for a query's destination. Mentions
+// introduced by "to", "in" or similar outrank bare mentions, and mentions
+// introduced by "from" (the origin) are ignored.
+func routeSpecialist(query string) *Specialist {
+	q := " " + strings.ToLower(query) + " "
+
+	best, bestScore, bestPos := generalist, 0, len(q)
+	for _, s := range content().Specialists {
+		for _, alias := range s.Aliases {
+			for offset := 0; ; {
+				idx := parser.IndexWord(q[offset:], alias)
+				if idx == -1 {
+					break
+				}
+				pos := offset + idx
+				offset = pos + len(alias)
+
+				before := strings.TrimRight(q[:pos], " ")
+				if strings.HasSuffix(before, "from") {
+					continue
+				}
+				score := 1
+				if destinationPattern.MatchString(q[:pos]) {
+					score = 2
+				}
+				if score > bestScore || (score == bestScore && pos < bestPos) {
+					best, bestScore, bestPos = s, score, pos
+				}
+			}
+		}
+	}
+	return best
+}
+
+// Handle generates the recommendation for a query routed to this specialist
+// in the requested style and appends any calculator output, except to brief
+// answers. Gemini may look up occupation codes while generating. It also
+// reports the tokens the Gemini calls used. With LLM_MODE off, the answer
+// is templated from the knowledge base instead.
+func (s *Specialist) Handle(gemini *llm.GeminiClient, profile parser.UserProfile, query string, style AnswerStyle) (string, llm.TokenUsage, error) {
+	if gemini.Mode == llm.ModeOff {
+		answer, err := offlineAnswer(s, profile, query, style)
+		return answer, llm.TokenUsage{}, err
+	}
+
+	prompt := buildPrompt(query, profile, style, s)
+	response, usage, err := gemini.WithFunctions(recommendationFunctions(style.Tenant)...).GenerateWithUsage(prompt, nil, style.generationConfig(), groundingTools(style.Tenant))
+	if err != nil {
+		return "", usage, err
+	}
+	if style.detail() == DetailBrief {
+		return response, usage, nil
+	}
+
+	for _, calculate := range s.Calculators {
+		if section := calculate(profile, query); section != "" {
+			response = strings.TrimRight(response, "\n") + "\n\n" + section
+		}
+	}
+	return response, usage, nil
+}
+
+// promptContext renders the specialist's guidance and knowledge pack for
+// the prompt, or "" for the generalist
+func (s *Specialist) promptContext() string {
+	if s.Guidance == "" && len(s.Knowledge) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\nDESTINATION SPECIALIST: " + s.Country + "\n")
+	if s.Guidance != "" {
+		b.WriteString(s.Guidance + "\n")
+	}
+	if len(s.Knowledge) > 0 {
+		b.WriteString("\nVERIFIED BACKGROUND (prefer these facts over your own assumptions):\n")
+		for _, fact := range s.Knowledge {
+			b.WriteString("- " + fact + "\n")
+		}
+	}
+	b.WriteString(s.healthPromptContext())
+	return b.String()
+}
@@ -0,0 +1,175 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// QueueMessage is a message received from a queue
+type QueueMessage struct {
+	Body    []byte
+	receipt string // handle used to delete the message once handled
+}
+
+// MessageQueue is a durable at-least-once queue. Messages that are received
+// but not deleted are delivered again after a timeout.
+type MessageQueue interface {
+	Send(ctx context.Context, body []byte) error
+	SendAfter(ctx context.Context, body []byte, delay time.Duration) error
+	Receive(ctx context.Context, max int) ([]QueueMessage, error)
+	Delete(ctx context.Context, msg QueueMessage) error
+}
+
+// sqsQueue is an Amazon SQS queue, called through the SQS JSON API with
+// requests signed using AWS Signature Version 4
+type sqsQueue struct {
+	queueURL  string
+	endpoint  string // scheme and host the API calls are sent to
+	region    string
+	accessKey string
+	secretKey string
+	token     string        // session token for temporary credentials
+	visible   time.Duration // how long a received message stays hidden from other workers
+	client    *http.Client
+	clock     Clock
+}
+
+// newSQSQueue creates a client for queueURL using AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN. The region comes from
+// AWS_REGION, or else from the queue URL's host.
+func newSQSQueue(queueURL string, visible time.Duration, clock Clock) (*sqsQueue, error) {
+	u, err := url.Parse(queueURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid SQS queue URL %q", queueURL)
+	}
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		// sqs.<region>.amazonaws.com
+		if parts := strings.Split(u.Hostname(), "."); len(parts) >= 3 && parts[0] == "sqs" {
+			region = parts[1]
+		}
+	}
+	if region == "" {
+		return nil, fmt.Errorf("AWS_REGION is required for queue %s", queueURL)
+	}
+
+	q := &sqsQueue{
+		queueURL:  queueURL,
+		endpoint:  u.Scheme + "://" + u.Host,
+		region:    region,
+		accessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		token:     os.Getenv("AWS_SESSION_TOKEN"),
+		visible:   visible,
+		clock:     clock,
+		// Long polls wait up to 20 seconds for messages
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+	if q.accessKey == "" || q.secretKey == "" {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for SQS")
+	}
+	return q, nil
+}
+
+// Send adds a message to the queue
+func (q *sqsQueue) Send(ctx context.Context, body []byte) error {
+	return q.SendAfter(ctx, body, 0)
+}
+
+// maxQueueDelay is the longest SQS will hold back a message
+const maxQueueDelay = 15 * time.Minute
+
+// SendAfter adds a message that can't be received until delay has passed,
+// at most 15 minutes
+func (q *sqsQueue) SendAfter(ctx context.Context, body []byte, delay time.Duration) error {
+	params := map[string]interface{}{
+		"QueueUrl":    q.queueURL,
+		"MessageBody": string(body),
+	}
+	if delay > 0 {
+		params["DelaySeconds"] = int(min(delay, maxQueueDelay).Seconds())
+	}
+	return q.call(ctx, "SendMessage", params, nil)
+}
+
+// Receive long-polls for up to max messages
+func (q *sqsQueue) Receive(ctx context.Context, max int) ([]QueueMessage, error) {
+	var result struct {
+		Messages []struct {
+			ReceiptHandle string
+			Body          string
+		}
+	}
+	err := q.call(ctx, "ReceiveMessage", map[string]interface{}{
+		"QueueUrl":            q.queueURL,
+		"MaxNumberOfMessages": max,
+		"WaitTimeSeconds":     20,
+		"VisibilityTimeout":   int(q.visible.Seconds()),
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]QueueMessage, 0, len(result.Messages))
+	for _, m := range result.Messages {
+		messages = append(messages, QueueMessage{Body: []byte(m.Body), receipt: m.ReceiptHandle})
+	}
+	return messages, nil
+}
+
+// Delete removes a handled message so it isn't delivered again
+func (q *sqsQueue) Delete(ctx context.Context, msg QueueMessage) error {
+	return q.call(ctx, "DeleteMessage", map[string]interface{}{
+		"QueueUrl":      q.queueURL,
+		"ReceiptHandle": msg.receipt,
+	}, nil)
+}
+
+// call invokes an SQS action and decodes its result into result, if given
+func (q *sqsQueue) call(ctx context.Context, action string, params interface{}, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode SQS %s: %v", action, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, q.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create SQS request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", "AmazonSQS."+action)
+	if q.token != "" {
+		req.Header.Set("X-Amz-Security-Token", q.token)
+	}
+	signV4(req, body, q.accessKey, q.secretKey, q.region, "sqs", q.clock.Now())
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("SQS %s failed: %v", action, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read SQS %s response: %v", action, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("SQS %s failed (status %d): %s", action, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to parse SQS %s response: %v", action, err)
+	}
+	return nil
+}
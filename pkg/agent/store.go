@@ -0,0 +1,152 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// TaskStore persists tasks beyond process memory so they survive restarts
+// and are shared by API replicas. The agent still keeps the tasks it works
+// with in memory and writes every change through to the store.
+type TaskStore interface {
+	// Save inserts or replaces a task. The task is a snapshot the store may
+	// read without holding the agent lock.
+	Save(ctx context.Context, task *Task) error
+	// Load returns a task, or nil when it isn't stored
+	Load(ctx context.Context, taskID string) (*Task, error)
+	// ForSession returns the tasks recorded for a session, oldest first
+	ForSession(ctx context.Context, sessionID string) ([]*Task, error)
+	// DeleteSession removes a session's tasks and returns their IDs
+	DeleteSession(ctx context.Context, sessionID string) ([]string, error)
+}
+
+// storeTimeout bounds each task store call
+const storeTimeout = 5 * time.Second
+
+// NewTaskStore opens the task store configured by DATABASE_URL. It returns
+// nil, keeping tasks in memory only, when none is configured or the
+// database can't be reached.
+func NewTaskStore() TaskStore {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	store, err := NewPostgresStore(ctx, dsn)
+	if err != nil {
+		log.Printf("⚠️  Could not open the task store, keeping tasks in memory: %v", err)
+		return nil
+	}
+	log.Printf("🗄️  Storing tasks in PostgreSQL")
+	return store
+}
+
+// storedTask is a task as persisted, including the fields responses leave
+// out
+type storedTask struct {
+	*Task
+	PushNotification *PushNotificationConfig `json:"pushNotification,omitempty"`
+	Tenant           string                  `json:"tenant,omitempty"`
+	Query            string                  `json:"query,omitempty"`
+	Milestones       []Milestone             `json:"milestones,omitempty"`
+}
+
+// snapshot copies a task for saving, with its full status history. Callers
+// must hold a.mu.
+func (t *Task) snapshot() *Task {
+	copied := t.withHistory(len(t.statusHistory))
+	copied.Reminders = append([]Reminder(nil), t.Reminders...)
+	copied.Wizard = t.Wizard.clone()
+	return copied
+}
+
+// encodeTask serializes a snapshot for storage
+func encodeTask(task *Task) ([]byte, error) {
+	data, err := json.Marshal(storedTask{Task: task, PushNotification: task.PushNotification, Tenant: task.tenant, Query: task.query, Milestones: task.milestones})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode task %s: %v", task.ID, err)
+	}
+	return data, nil
+}
+
+// decodeTask restores a task written by encodeTask
+func decodeTask(data []byte) (*Task, error) {
+	stored := storedTask{Task: &Task{}}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode task: %v", err)
+	}
+	task := stored.Task
+	task.statusHistory = task.History
+	task.History = nil
+	task.PushNotification = stored.PushNotification
+	task.tenant = stored.Tenant
+	task.query = stored.Query
+	task.milestones = stored.Milestones
+	return task, nil
+}
+
+// saveTask writes a task through to the task store, if one is configured.
+// Failures are logged; the in-memory task stays authoritative for this
+// process. Callers must not hold a.mu.
+func (a *MigrationAgent) saveTask(task *Task) {
+	if a.store == nil {
+		return
+	}
+
+	a.mu.RLock()
+	snapshot := task.snapshot()
+	a.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := a.store.Save(ctx, snapshot); err != nil {
+		log.Printf("⚠️  Failed to save task %s: %v", task.ID, err)
+		return
+	}
+	a.tasks.Saved(task, snapshot.UpdatedAt)
+}
+
+// lookupTask returns a task from memory, or from the task store when this
+// process doesn't have it (e.g. after a restart or eviction, or when another
+// replica created it) or has an older copy of it. Callers must not hold
+// a.mu.
+func (a *MigrationAgent) lookupTask(taskID string) (*Task, bool) {
+	cached, ok := a.tasks.Get(taskID)
+	if a.store == nil || taskID == "" {
+		return cached, ok
+	}
+
+	a.mu.RLock()
+	done := ok && cached.Status.State.Terminal()
+	a.mu.RUnlock()
+	if done {
+		return cached, true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	stored, err := a.store.Load(ctx, taskID)
+	if err != nil {
+		log.Printf("⚠️  Failed to load task %s: %v", taskID, err)
+		return cached, ok
+	}
+	if stored == nil {
+		return cached, ok
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// A task being processed here is saved as it changes, so the stored copy
+	// is only newer when another process moved it on
+	if current, ok := a.tasks.Peek(taskID); ok && !stored.UpdatedAt.After(current.UpdatedAt) {
+		return current, true
+	}
+	a.tasks.Put(stored)
+	return stored, true
+}
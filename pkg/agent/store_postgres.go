@@ -0,0 +1,461 @@
+package agent
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the advisory lock that serializes migrations when
+// several replicas start at once
+const migrationLockID = 7265401
+
+// PostgresStore keeps tasks in PostgreSQL, one row per task with the task
+// itself as JSONB, their milestone reminders in reminders, undeliverable
+// push notifications in dead_letters and saved plans in plans
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore connects to dsn and applies any pending migrations. The
+// pool is sized by DATABASE_MAX_CONNS, DATABASE_MIN_CONNS and
+// DATABASE_MAX_CONN_LIFETIME, overriding pool_* settings in the URL.
+func NewPostgresStore(ctx context.Context, dsn string) (*PostgresStore, error) {
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DATABASE_URL: %v", err)
+	}
+	if err := configurePool(config); err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection pool: %v", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %v", err)
+	}
+	if err := migrate(ctx, pool); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return &PostgresStore{pool: pool}, nil
+}
+
+// configurePool applies the DATABASE_* pool settings
+func configurePool(config *pgxpool.Config) error {
+	for name, dst := range map[string]*int32{
+		"DATABASE_MAX_CONNS": &config.MaxConns,
+		"DATABASE_MIN_CONNS": &config.MinConns,
+	} {
+		if v := os.Getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid %s %q", name, v)
+			}
+			*dst = int32(n)
+		}
+	}
+	if config.MaxConns < 1 || config.MinConns > config.MaxConns {
+		return fmt.Errorf("DATABASE_MIN_CONNS must not exceed DATABASE_MAX_CONNS, which must be at least 1")
+	}
+	if v := os.Getenv("DATABASE_MAX_CONN_LIFETIME"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid DATABASE_MAX_CONN_LIFETIME %q", v)
+		}
+		config.MaxConnLifetime = d
+	}
+	return nil
+}
+
+// migrate applies the embedded migrations that haven't run yet, in file
+// name order, recording each in schema_migrations
+func migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %v", err)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start migrations: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("failed to lock migrations: %v", err)
+	}
+	if _, err := tx.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+
+	for _, name := range names {
+		version := strings.TrimSuffix(path.Base(name), ".sql")
+		var applied bool
+		err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)", version).Scan(&applied)
+		if err != nil {
+			return fmt.Errorf("failed to check migration %s: %v", version, err)
+		}
+		if applied {
+			continue
+		}
+
+		script, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("failed to read migration %s: %v", version, err)
+		}
+		if _, err := tx.Exec(ctx, string(script)); err != nil {
+			return fmt.Errorf("migration %s failed: %v", version, err)
+		}
+		if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", version); err != nil {
+			return fmt.Errorf("failed to record migration %s: %v", version, err)
+		}
+		log.Printf("🗄️  Applied migration %s", version)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit migrations: %v", err)
+	}
+	return nil
+}
+
+// Save upserts a task and schedules its unsent reminders, dropping those
+// the task no longer has. An older copy never replaces a newer one, so a
+// late write from a slower process can't roll a task back. Reminders
+// already stored keep their delivery state.
+func (s *PostgresStore) Save(ctx context.Context, task *Task) error {
+	data, err := encodeTask(task)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to save task %s: %v", task.ID, err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO tasks (id, session_id, state, data, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE SET
+			session_id = EXCLUDED.session_id,
+			state      = EXCLUDED.state,
+			data       = EXCLUDED.data,
+			updated_at = EXCLUDED.updated_at
+		WHERE tasks.updated_at <= EXCLUDED.updated_at`,
+		task.ID, task.SessionID, string(task.Status.State), string(data), task.CreatedAt, task.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save task %s: %v", task.ID, err)
+	}
+	if tag.RowsAffected() > 0 {
+		if err := saveReminders(ctx, tx, task); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to save task %s: %v", task.ID, err)
+	}
+	return nil
+}
+
+// saveReminders brings the reminders table in line with a task being saved
+func saveReminders(ctx context.Context, tx pgx.Tx, task *Task) error {
+	ids := make([]string, 0, len(task.Reminders))
+	for _, r := range task.Reminders {
+		ids = append(ids, r.ID)
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM reminders WHERE task_id = $1 AND sent_at IS NULL AND NOT (id = ANY($2))", task.ID, ids); err != nil {
+		return fmt.Errorf("failed to save reminders of task %s: %v", task.ID, err)
+	}
+
+	for _, r := range task.Reminders {
+		if r.SentAt != nil {
+			continue
+		}
+		data, err := json.Marshal(DueReminder{TaskID: task.ID, Tenant: task.tenant, Metadata: task.Metadata, Reminder: r})
+		if err != nil {
+			return fmt.Errorf("failed to encode reminder %s: %v", r.ID, err)
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO reminders (id, task_id, data, send_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (id) DO NOTHING`,
+			r.ID, task.ID, string(data), r.SendAt)
+		if err != nil {
+			return fmt.Errorf("failed to save reminder %s: %v", r.ID, err)
+		}
+	}
+	return nil
+}
+
+// ClaimDueReminders claims up to limit unsent reminders due by now, oldest
+// first, until now plus lease. Rows another replica is claiming are
+// skipped, so each reminder is handed to one replica at a time.
+func (s *PostgresStore) ClaimDueReminders(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]DueReminder, error) {
+	rows, err := s.pool.Query(ctx, `
+		UPDATE reminders SET claimed_until = $2
+		WHERE id IN (
+			SELECT id FROM reminders
+			WHERE sent_at IS NULL AND send_at <= $1 AND (claimed_until IS NULL OR claimed_until <= $1)
+			ORDER BY send_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED)
+		RETURNING data, last_error`,
+		now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim reminders: %v", err)
+	}
+	defer rows.Close()
+
+	var due []DueReminder
+	for rows.Next() {
+		var data []byte
+		var lastError string
+		if err := rows.Scan(&data, &lastError); err != nil {
+			return nil, fmt.Errorf("failed to read reminder: %v", err)
+		}
+		var d DueReminder
+		if err := json.Unmarshal(data, &d); err != nil {
+			return nil, fmt.Errorf("failed to decode reminder: %v", err)
+		}
+		d.Reminder.LastError = lastError
+		due = append(due, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim reminders: %v", err)
+	}
+	return due, nil
+}
+
+// FinishReminder records a delivery attempt and releases the claim
+func (s *PostgresStore) FinishReminder(ctx context.Context, id string, sentAt *time.Time, lastError string) error {
+	_, err := s.pool.Exec(ctx, "UPDATE reminders SET sent_at = $2, last_error = $3, claimed_until = NULL WHERE id = $1", id, sentAt, lastError)
+	if err != nil {
+		return fmt.Errorf("failed to update reminder %s: %v", id, err)
+	}
+	return nil
+}
+
+// Load returns a task, or nil when it isn't stored
+func (s *PostgresStore) Load(ctx context.Context, taskID string) (*Task, error) {
+	var data []byte
+	err := s.pool.QueryRow(ctx, "SELECT data FROM tasks WHERE id = $1", taskID).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task %s: %v", taskID, err)
+	}
+	return decodeTask(data)
+}
+
+// ForSession returns a session's tasks, oldest first
+func (s *PostgresStore) ForSession(ctx context.Context, sessionID string) ([]*Task, error) {
+	rows, err := s.pool.Query(ctx, "SELECT data FROM tasks WHERE session_id = $1 ORDER BY created_at", sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %v", err)
+	}
+	defer rows.Close()
+
+	var tasks []*Task
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read task: %v", err)
+		}
+		task, err := decodeTask(data)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %v", err)
+	}
+	return tasks, nil
+}
+
+// DeleteSession removes a session's tasks and their reminders and returns
+// the tasks' IDs
+func (s *PostgresStore) DeleteSession(ctx context.Context, sessionID string) ([]string, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete tasks: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, "DELETE FROM tasks WHERE session_id = $1 RETURNING id", sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete tasks: %v", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete tasks: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM reminders WHERE task_id = ANY($1)", ids); err != nil {
+		return nil, fmt.Errorf("failed to delete reminders: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to delete tasks: %v", err)
+	}
+	return ids, nil
+}
+
+// Record stores a push notification that could not be delivered
+func (s *PostgresStore) Record(ctx context.Context, d DeadLetter) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter: %v", err)
+	}
+	_, err = s.pool.Exec(ctx, `
+		INSERT INTO dead_letters (id, task_id, tenant, data, failed_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		d.ID, d.TaskID, d.Tenant, string(data), d.FailedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record dead letter: %v", err)
+	}
+	return nil
+}
+
+// DeadLetters returns matching failed deliveries, newest first
+func (s *PostgresStore) DeadLetters(ctx context.Context, filter DeadLetterFilter) ([]DeadLetter, error) {
+	query := "SELECT data FROM dead_letters WHERE ($1 = '' OR task_id = $1) AND ($2 = '' OR tenant = $2) AND failed_at >= $3 ORDER BY failed_at DESC"
+	args := []interface{}{filter.TaskID, filter.Tenant, filter.Since}
+	if filter.Limit > 0 {
+		query += " LIMIT $4"
+		args = append(args, filter.Limit)
+	}
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %v", err)
+	}
+	defer rows.Close()
+
+	var letters []DeadLetter
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read dead letter: %v", err)
+		}
+		var d DeadLetter
+		if err := json.Unmarshal(data, &d); err != nil {
+			return nil, fmt.Errorf("failed to decode dead letter: %v", err)
+		}
+		letters = append(letters, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %v", err)
+	}
+	return letters, nil
+}
+
+// DeleteDeadLetters removes the failed deliveries of the given tasks
+func (s *PostgresStore) DeleteDeadLetters(ctx context.Context, taskIDs []string) (int, error) {
+	if len(taskIDs) == 0 {
+		return 0, nil
+	}
+	tag, err := s.pool.Exec(ctx, "DELETE FROM dead_letters WHERE task_id = ANY($1)", taskIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete dead letters: %v", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// SavePlan upserts a saved plan
+func (s *PostgresStore) SavePlan(ctx context.Context, p Plan) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to encode plan %s: %v", p.ID, err)
+	}
+	_, err = s.pool.Exec(ctx, `
+		INSERT INTO plans (id, user_id, tenant, task_id, data, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO UPDATE SET
+			user_id    = EXCLUDED.user_id,
+			task_id    = EXCLUDED.task_id,
+			data       = EXCLUDED.data,
+			updated_at = EXCLUDED.updated_at`,
+		p.ID, p.UserID, p.Tenant, p.TaskID, string(data), p.CreatedAt, p.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save plan %s: %v", p.ID, err)
+	}
+	return nil
+}
+
+// LoadPlan returns a plan, or nil when it isn't stored
+func (s *PostgresStore) LoadPlan(ctx context.Context, id string) (*Plan, error) {
+	var data []byte
+	err := s.pool.QueryRow(ctx, "SELECT data FROM plans WHERE id = $1", id).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plan %s: %v", id, err)
+	}
+	var p Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to decode plan %s: %v", id, err)
+	}
+	return &p, nil
+}
+
+// Plans returns matching plans, most recently updated first
+func (s *PostgresStore) Plans(ctx context.Context, filter PlanFilter) ([]Plan, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT data FROM plans
+		WHERE ($1 = '' OR user_id = $1) AND ($2 = '' OR task_id = $2) AND ($3 OR tenant = $4)
+		ORDER BY updated_at DESC`,
+		filter.UserID, filter.TaskID, filter.AllTenants, filter.Tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plans: %v", err)
+	}
+	defer rows.Close()
+
+	var plans []Plan
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read plan: %v", err)
+		}
+		var p Plan
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("failed to decode plan: %v", err)
+		}
+		plans = append(plans, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list plans: %v", err)
+	}
+	return plans, nil
+}
+
+// DeletePlans removes a user's plans in every tenant
+func (s *PostgresStore) DeletePlans(ctx context.Context, userID string) (int, error) {
+	tag, err := s.pool.Exec(ctx, "DELETE FROM plans WHERE user_id = $1", userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete plans: %v", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
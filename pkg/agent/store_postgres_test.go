@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// openTestPostgres connects to the database in TEST_DATABASE_URL, skipping
+// the test when it isn't set. The database should be a scratch one: the
+// store applies its migrations to it.
+func openTestPostgres(t *testing.T) *PostgresStore {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	store, err := NewPostgresStore(context.Background(), dsn)
+	if err != nil {
+		t.Fatalf("NewPostgresStore: %v", err)
+	}
+	t.Cleanup(store.pool.Close)
+	return store
+}
+
+// claimedIDs claims due reminders and returns the IDs of those of taskID,
+// since the table may hold other tests' reminders
+func claimedIDs(t *testing.T, store *PostgresStore, now time.Time, taskID string) []string {
+	t.Helper()
+	due, err := store.ClaimDueReminders(context.Background(), now, reminderLease, reminderBatch)
+	if err != nil {
+		t.Fatalf("ClaimDueReminders: %v", err)
+	}
+	var ids []string
+	for _, d := range due {
+		if d.TaskID == taskID {
+			ids = append(ids, d.Reminder.ID)
+		}
+	}
+	return ids
+}
+
+func TestPostgresStore(t *testing.T) {
+	store := openTestPostgres(t)
+	ctx := context.Background()
+
+	// Unique IDs keep reruns against the same database apart
+	run := fmt.Sprintf("it-%d", time.Now().UnixNano())
+	session := run + "-session"
+	t.Cleanup(func() { store.DeleteSession(ctx, session) })
+
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	newer := &Task{ID: run + "-newer", SessionID: session, Kind: "task", CreatedAt: created, UpdatedAt: created.Add(2 * time.Minute)}
+	newer.Status.State = TaskStateCompleted
+	newer.Reminders = []Reminder{
+		{ID: run + "-due", Milestone: "Submit application", SendAt: created.Add(-time.Hour), Channel: "webhook", Target: "https://example.com/hook"},
+		{ID: run + "-later", Milestone: "Book biometrics", SendAt: created.Add(24 * time.Hour), Channel: "webhook", Target: "https://example.com/hook"},
+	}
+	older := &Task{ID: run + "-older", SessionID: session, Kind: "task", CreatedAt: created.Add(-time.Hour), UpdatedAt: created.Add(-time.Hour)}
+	older.Status.State = TaskStateCompleted
+
+	for _, task := range []*Task{newer, older} {
+		if err := store.Save(ctx, task); err != nil {
+			t.Fatalf("Save(%s): %v", task.ID, err)
+		}
+	}
+
+	t.Run("stale save is ignored", func(t *testing.T) {
+		stale := &Task{ID: newer.ID, SessionID: session, Kind: "task", CreatedAt: created, UpdatedAt: created.Add(time.Minute)}
+		stale.Status.State = TaskStateWorking
+		if err := store.Save(ctx, stale); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+
+		loaded, err := store.Load(ctx, newer.ID)
+		if err != nil || loaded == nil {
+			t.Fatalf("Load = %v, %v", loaded, err)
+		}
+		if loaded.Status.State != TaskStateCompleted || len(loaded.Reminders) != 2 {
+			t.Errorf("older copy replaced the task: state %s, %d reminders", loaded.Status.State, len(loaded.Reminders))
+		}
+	})
+
+	t.Run("ForSession is oldest first", func(t *testing.T) {
+		tasks, err := store.ForSession(ctx, session)
+		if err != nil {
+			t.Fatalf("ForSession: %v", err)
+		}
+		if len(tasks) != 2 || tasks[0].ID != older.ID || tasks[1].ID != newer.ID {
+			var ids []string
+			for _, task := range tasks {
+				ids = append(ids, task.ID)
+			}
+			t.Errorf("ForSession = %v, want [%s %s]", ids, older.ID, newer.ID)
+		}
+	})
+
+	t.Run("reminders are claimed once", func(t *testing.T) {
+		if ids := claimedIDs(t, store, created, newer.ID); len(ids) != 1 || ids[0] != run+"-due" {
+			t.Fatalf("first claim = %v, want [%s-due]", ids, run)
+		}
+		if ids := claimedIDs(t, store, created.Add(time.Minute), newer.ID); len(ids) != 0 {
+			t.Errorf("claim during the lease = %v, want none", ids)
+		}
+
+		// A replica that dies mid-delivery leaves the claim to expire
+		afterLease := created.Add(reminderLease + time.Second)
+		if ids := claimedIDs(t, store, afterLease, newer.ID); len(ids) != 1 {
+			t.Fatalf("claim after the lease = %v, want the due reminder again", ids)
+		}
+		if err := store.FinishReminder(ctx, run+"-due", &afterLease, ""); err != nil {
+			t.Fatalf("FinishReminder: %v", err)
+		}
+		if ids := claimedIDs(t, store, afterLease.Add(reminderLease), newer.ID); len(ids) != 0 {
+			t.Errorf("claim after delivery = %v, want none", ids)
+		}
+	})
+
+	t.Run("DeleteSession removes tasks and reminders", func(t *testing.T) {
+		ids, err := store.DeleteSession(ctx, session)
+		if err != nil {
+			t.Fatalf("DeleteSession: %v", err)
+		}
+		if len(ids) != 2 {
+			t.Errorf("DeleteSession = %v, want both tasks", ids)
+		}
+		if tasks, _ := store.ForSession(ctx, session); len(tasks) != 0 {
+			t.Errorf("%d tasks left after DeleteSession", len(tasks))
+		}
+		if ids := claimedIDs(t, store, created.Add(48*time.Hour), newer.ID); len(ids) != 0 {
+			t.Errorf("reminders left after DeleteSession: %v", ids)
+		}
+	})
+}
@@ -0,0 +1,370 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// streamHeartbeat is how often an idle event stream gets a comment line, so
+// proxies don't close it while Gemini is thinking
+const streamHeartbeat = 15 * time.Second
+
+// TaskEvent is a TaskStatusUpdateEvent or TaskArtifactUpdateEvent sent to
+// tasks/sendSubscribe and tasks/resubscribe callers
+type TaskEvent struct {
+	Kind     string      `json:"kind"` // status-update or artifact-update
+	ID       string      `json:"id"`
+	TaskID   string      `json:"taskId"`
+	Status   *TaskStatus `json:"status,omitempty"`
+	Artifact *Artifact   `json:"artifact,omitempty"`
+	Final    bool        `json:"final"`
+}
+
+// statusEvent reports a task's status
+func statusEvent(taskID string, status TaskStatus) TaskEvent {
+	return TaskEvent{Kind: "status-update", ID: taskID, TaskID: taskID, Status: &status, Final: status.State.Terminal()}
+}
+
+// artifactEvent reports a new artifact or a chunk of one
+func artifactEvent(taskID string, artifact Artifact) TaskEvent {
+	return TaskEvent{Kind: "artifact-update", ID: taskID, TaskID: taskID, Artifact: &artifact}
+}
+
+// TaskEvents fans task updates out to the streams following each task
+type TaskEvents struct {
+	mu   sync.Mutex
+	subs map[string][]chan TaskEvent
+}
+
+// NewTaskEvents creates an empty event hub
+func NewTaskEvents() *TaskEvents {
+	return &TaskEvents{subs: make(map[string][]chan TaskEvent)}
+}
+
+// Subscribe follows a task's events until the returned function is called
+func (e *TaskEvents) Subscribe(taskID string) (<-chan TaskEvent, func()) {
+	ch := make(chan TaskEvent, 64)
+	e.mu.Lock()
+	e.subs[taskID] = append(e.subs[taskID], ch)
+	e.mu.Unlock()
+
+	return ch, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		subs := e.subs[taskID]
+		for i, sub := range subs {
+			if sub == ch {
+				subs = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		if len(subs) == 0 {
+			delete(e.subs, taskID)
+		} else {
+			e.subs[taskID] = subs
+		}
+	}
+}
+
+// Watched reports whether anyone follows a task, so work only they would
+// see can be skipped otherwise
+func (e *TaskEvents) Watched(taskID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.subs[taskID]) > 0
+}
+
+// Publish sends an event to a task's followers. A follower too slow to keep
+// up misses the event rather than holding up the task.
+func (e *TaskEvents) Publish(taskID string, event TaskEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, ch := range e.subs[taskID] {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("⚠️  Dropped a %s event for a slow follower of task %s", event.Kind, taskID)
+		}
+	}
+}
+
+// sectionStream turns the answer text generated so far into artifact
+// chunks, one per completed section (the overview, key details, timeline,
+// next step and so on), published with append semantics so a UI can render
+// the document section by section
+type sectionStream struct {
+	agent    *MigrationAgent
+	taskID   string
+	artifact Artifact // the answer artifact the chunks belong to
+	html     bool
+
+	mu     sync.Mutex
+	text   string // the answer so far
+	sent   int    // bytes of text already published
+	chunks int
+}
+
+// streamSections starts streaming the answer artifact of a task, or returns
+// nil when nobody follows the task or it was asked for as JSON, which is
+// only sent once complete
+func (a *MigrationAgent) streamSections(taskID string, artifact Artifact, format string) *sectionStream {
+	if !a.events.Watched(taskID) || format == OutputFormatJSON {
+		return nil
+	}
+	return &sectionStream{agent: a, taskID: taskID, artifact: artifact, html: format == OutputFormatHTML}
+}
+
+// Feed takes the answer generated so far and publishes the sections it
+// completes
+func (s *sectionStream) Feed(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.text = text
+	for {
+		end := nextSection(text, s.sent)
+		if end == -1 {
+			return
+		}
+		s.publish(text[s.sent:end])
+		s.sent = end
+	}
+}
+
+// Flush publishes the last section once generation has finished
+func (s *sectionStream) Flush() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.publish(s.text[s.sent:])
+	s.sent = len(s.text)
+}
+
+// publish sends one section as the next chunk. Callers must hold s.mu.
+func (s *sectionStream) publish(section string) {
+	if strings.TrimSpace(section) == "" {
+		return
+	}
+	if s.html {
+		section = renderMarkdownHTML(section)
+	}
+	chunk := s.artifact
+	chunk.Parts = []Part{{Kind: "text", Text: section}}
+	chunk.Append = s.chunks > 0
+	s.chunks++
+	s.agent.events.Publish(s.taskID, artifactEvent(s.taskID, chunk))
+}
+
+// nextSection returns where the section running from offset from ends: the
+// start of the next line that opens a section (a heading, a bold label such
+// as **Key Details:**, or the next step), or -1 if none has arrived yet
+func nextSection(text string, from int) int {
+	for i := strings.IndexByte(text[from:], '\n'); i != -1; {
+		start := from + i + 1
+		line := strings.ToLower(strings.TrimLeft(text[start:], " "))
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "**") || strings.HasPrefix(line, "next step") {
+			return start
+		}
+		from = start
+		i = strings.IndexByte(text[from:], '\n')
+	}
+	return -1
+}
+
+// handleTasksSubscribe processes tasks/sendSubscribe and message/stream:
+// the task is submitted as with tasks/send, and its status changes and
+// artifacts are streamed back as server-sent events, the answer section by
+// section as it is generated. With the streaming flag off they are refused.
+func (a *MigrationAgent) handleTasksSubscribe(w http.ResponseWriter, r *http.Request, req JSONRPCRequest) {
+	if !req.tenant.enabled(FlagStreaming) {
+		err := fmt.Errorf("streaming is turned off; use tasks/send or message/send")
+		a.sendError(w, err, ErrCodeUnsupportedOperation, errorMessages[ErrCodeUnsupportedOperation], req.ID)
+		return
+	}
+
+	paramsJSON, err := json.Marshal(req.Params)
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	if err := validateParams(SchemaSendParams, paramsJSON); err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	var params TaskSendParams
+	if err := json.Unmarshal(paramsJSON, &params); err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	opts, err := a.taskOptions(params, req)
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+	if opts.Wizard {
+		err := fmt.Errorf("wizard mode runs over tasks/send and message/send")
+		a.sendError(w, err, ErrCodeUnsupportedOperation, errorMessages[ErrCodeUnsupportedOperation], req.ID)
+		return
+	}
+
+	taskID := params.ID
+	release, replied := func() {}, false
+	if taskID == "" {
+		taskID = a.ids.NewID()
+	} else if release, replied = a.replyToDuplicate(w, req, taskID); replied {
+		return
+	}
+	if a.replyToUserQuota(w, req, params) {
+		release()
+		return
+	}
+
+	// Only this run's artifacts are streamed
+	before := 0
+	a.mu.RLock()
+	if previous, ok := a.tasks.Peek(taskID); ok {
+		before = len(previous.Artifacts)
+	}
+	a.mu.RUnlock()
+
+	// Follow the task before it starts so no update is missed; the task
+	// carries on if the caller goes away
+	events, unsubscribe := a.events.Subscribe(taskID)
+	defer unsubscribe()
+
+	failed := make(chan error, 1)
+	go func() {
+		if task, err := a.submitTask(taskID, params.Message, opts); task == nil {
+			failed <- err
+		}
+	}()
+
+	a.streamEvents(r.Context(), w, req.ID, taskID, before, events, failed)
+}
+
+// handleTasksResubscribe processes tasks/resubscribe, streaming the updates
+// of a task that is already running, or just its result if it has finished
+func (a *MigrationAgent) handleTasksResubscribe(w http.ResponseWriter, r *http.Request, req JSONRPCRequest) {
+	paramsJSON, err := json.Marshal(req.Params)
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	var params TaskIDParams
+	if err := json.Unmarshal(paramsJSON, &params); err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	events, unsubscribe := a.events.Subscribe(params.ID)
+	defer unsubscribe()
+
+	task, err := a.taskFor(req.tenant, params.ID)
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeInvalidParams, req.ID)
+		return
+	}
+
+	a.mu.RLock()
+	status := task.Status
+	a.mu.RUnlock()
+	if status.State.Terminal() {
+		// Nothing more will happen; stream the result straight away
+		finished := make(chan TaskEvent, 1)
+		finished <- statusEvent(task.ID, status)
+		events = finished
+	}
+	a.streamEvents(r.Context(), w, req.ID, task.ID, 0, events, nil)
+}
+
+// streamEvents writes a task's events as server-sent events until it
+// finishes, then its artifacts from index before onwards and its final
+// status. A send that fails before the task exists is reported as a JSON-RPC
+// error event.
+func (a *MigrationAgent) streamEvents(ctx context.Context, w http.ResponseWriter, id interface{}, taskID string, before int, events <-chan TaskEvent, failed <-chan error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		a.sendError(w, nil, ErrCodeUnsupportedOperation, "streaming is not supported by this connection", id)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	write := func(response JSONRPCResponse) {
+		data, err := json.Marshal(response)
+		if err != nil {
+			log.Printf("⚠️  Failed to encode an event for task %s: %v", taskID, err)
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-failed:
+			rpcErr := &RPCError{Code: ErrCodeInternal, Message: errorMessages[ErrCodeInternal]}
+			if err != nil {
+				rpcErr.Data = err.Error()
+			}
+			write(JSONRPCResponse{JSONRPC: "2.0", Error: rpcErr, ID: id})
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case event := <-events:
+			if !event.Final {
+				write(JSONRPCResponse{JSONRPC: "2.0", Result: event, ID: id})
+				continue
+			}
+			for _, final := range a.finalEvents(taskID, before, event) {
+				write(JSONRPCResponse{JSONRPC: "2.0", Result: final, ID: id})
+			}
+			return
+		}
+	}
+}
+
+// finalEvents lists the complete artifacts of a finished task, each
+// replacing any chunks streamed for it, followed by its final status
+func (a *MigrationAgent) finalEvents(taskID string, before int, finished TaskEvent) []TaskEvent {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	task, ok := a.tasks.Peek(taskID)
+	if !ok {
+		return []TaskEvent{finished}
+	}
+	task = a.files.Resign(task)
+
+	var events []TaskEvent
+	if before < len(task.Artifacts) {
+		for _, artifact := range latestArtifacts(task.Artifacts[before:]) {
+			artifact.Append = false
+			artifact.LastChunk = true
+			events = append(events, artifactEvent(taskID, artifact))
+		}
+	}
+	return append(events, statusEvent(taskID, task.Status))
+}
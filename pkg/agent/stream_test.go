@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStreamingFlagGatesStreamingMethods(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	a := NewMigrationAgent()
+	withFlag(t, FlagStreaming, false)
+
+	for _, method := range []string{"tasks/sendSubscribe", "message/stream"} {
+		body := `{"jsonrpc": "2.0", "id": 1, "method": "` + method + `", "params": {"message": {"role": "user", "parts": [{"kind": "text", "text": "Nurse from India wanting to move to UK"}]}}}`
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/a2a/planner", strings.NewReader(body)))
+
+		var response JSONRPCResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: got %q, want a JSON-RPC response: %v", method, rec.Body, err)
+		}
+		if response.Error == nil || response.Error.Code != ErrCodeUnsupportedOperation {
+			t.Errorf("%s: error = %+v, want code %d", method, response.Error, ErrCodeUnsupportedOperation)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/agent.json", nil))
+	var card struct {
+		Capabilities struct {
+			Streaming bool `json:"streaming"`
+		} `json:"capabilities"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &card); err != nil {
+		t.Fatalf("decoding agent card: %v", err)
+	}
+	if card.Capabilities.Streaming {
+		t.Error("agent card still advertises streaming")
+	}
+}
@@ -0,0 +1,217 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+)
+
+// Answer detail levels, selected with the detail param
+const (
+	DetailBrief    = "brief"    // one short paragraph, for chat surfaces
+	DetailStandard = "standard" // the structured recommendation (default)
+	DetailDeep     = "deep"     // a multi-section guide
+)
+
+// Answer tones, selected with the tone param
+const (
+	ToneFormal        = "formal"
+	ToneEncouraging   = "encouraging"
+	TonePlainLanguage = "plain-language" // B1-level English for non-native readers
+)
+
+// Optional answer sections, requested with the sections param
+const (
+	SectionTax     = "tax"     // headline tax considerations of the move
+	SectionArrival = "arrival" // checklist for after landing
+)
+
+// optionalSections are the sections callers can add to an answer
+var optionalSections = []string{SectionTax, SectionArrival}
+
+// AnswerStyle holds the per-request choices about how an answer is written
+// rather than what it recommends
+type AnswerStyle struct {
+	Language string        // ISO 639-1 code of the language to answer in
+	Detail   string        // one of the Detail* levels; "" means standard
+	Tone     string        // one of the Tone* values; "" leaves the default voice
+	Variant  *Variant      // rollout variant whose prompts are used; nil for control
+	Datasets *Datasets     // fees, processing times and exchange rates the answer uses
+	Tenant   *Tenant       // tenant whose features and prompts apply; nil for the default
+	Now      time.Time     // when the request is answered, from the agent's clock
+	Context  PromptContext // allowed facts from the caller's metadata
+
+	// Constraint is an extra instruction for a regenerated answer, such
+	// as to stay within the budget
+	Constraint string
+}
+
+// prompts returns the content holding the style's prompt templates: the
+// tenant's when it has its own, else the variant's, else the deployment's
+func (s AnswerStyle) prompts() *Content {
+	if s.Tenant != nil && s.Tenant.prompts != nil {
+		return s.Tenant.prompts
+	}
+	if s.Variant != nil && s.Variant.prompts != nil {
+		return s.Variant.prompts
+	}
+	return content()
+}
+
+// detail returns the style's detail level, defaulting to standard
+func (s AnswerStyle) detail() string {
+	if s.Detail == "" {
+		return DetailStandard
+	}
+	return s.Detail
+}
+
+// generationConfig returns the Gemini settings for the style, or nil to
+// use the model defaults
+func (s AnswerStyle) generationConfig() *llm.GeminiGenerationConfig {
+	if tokens := detailMaxTokens[s.detail()]; tokens > 0 {
+		return &llm.GeminiGenerationConfig{MaxOutputTokens: tokens}
+	}
+	return nil
+}
+
+// validateDetail rejects unknown detail levels
+func validateDetail(detail string) error {
+	switch detail {
+	case "", DetailBrief, DetailStandard, DetailDeep:
+		return nil
+	}
+	return fmt.Errorf("unsupported detail %q (expected %q, %q or %q)", detail, DetailBrief, DetailStandard, DetailDeep)
+}
+
+// validateSections rejects unknown optional sections
+func validateSections(sections []string) error {
+	for _, section := range sections {
+		if !containsString(optionalSections, section) {
+			return fmt.Errorf("unsupported section %q (expected one of %s)", section, strings.Join(optionalSections, ", "))
+		}
+	}
+	return nil
+}
+
+// toneInstructions are the built-in tone texts that reach the prompt; callers
+// pick a key rather than supplying their own wording
+var toneInstructions = map[string]string{
+	ToneFormal:        "Write in a formal, professional register: no contractions, slang or exclamation marks, and address the reader as \"you\" in a courteous way.",
+	ToneEncouraging:   "Write in a warm, encouraging voice: acknowledge the user's goal, present obstacles as manageable steps and end on a motivating note, without overstating their chances.",
+	TonePlainLanguage: "Write in plain language at CEFR B1 level: short sentences, common words, and a brief explanation in brackets the first time any legal or immigration term is used.",
+}
+
+// validateTone rejects tones outside the allowlist
+func validateTone(tone string) error {
+	instructions := content().ToneInstructions
+	if _, ok := instructions[tone]; ok || tone == "" {
+		return nil
+	}
+	tones := make([]string, 0, len(instructions))
+	for name := range instructions {
+		tones = append(tones, name)
+	}
+	sort.Strings(tones)
+	return fmt.Errorf("unsupported tone %q (expected one of %s)", tone, strings.Join(tones, ", "))
+}
+
+// toneInstruction returns the prompt section for the style's tone, or ""
+// for the default voice
+func (s AnswerStyle) toneInstruction() string {
+	if text, ok := s.prompts().ToneInstructions[s.Tone]; ok {
+		return "\nTONE: " + text + "\n"
+	}
+	return ""
+}
+
+// detailMaxTokens caps the length of each detail level; 0 leaves the model
+// default in place
+var detailMaxTokens = map[string]int{
+	DetailBrief:    400,
+	DetailStandard: 0,
+	DetailDeep:     8192,
+}
+
+// detailFormats are the instructions and output format for each detail level
+var detailFormats = map[string]string{
+	DetailBrief: `
+INSTRUCTIONS:
+1. Identify from the query: profession, current country (origin), and destination country.
+2. Choose the SINGLE most suitable migration pathway for this profile, inferring anything missing.
+3. Answer in ONE short paragraph of at most 80 words, suitable for a chat message: name the visa, give the typical cost in USD and processing time, and end with the single most important next step.
+
+IMPORTANT: No headings, lists or tables. Do not ask for more details. Generate the response now:`,
+
+	DetailStandard: `
+INSTRUCTIONS:
+1. First, identify from the query: profession, current country (origin), and destination country.
+2. Research and provide the SINGLE most suitable migration pathway for this profile.
+3. If some details are missing, infer reasonable constraints for 2024–2025 and proceed.
+4. Format your response as follows:
+
+# Best Migration Option: [Visa Name]
+
+Brief overview of why this is the best option for the profile (1-2 sentences).
+
+**Key Details:**
+- Processing time: [Duration]
+- Cost: [USD range]
+- Success rate: [High/Medium/Low]
+- Main requirements: [2-3 key points]
+
+**Timeline:**
+- YYYY-MM-DD: [Milestone, e.g. Book IELTS test]
+- YYYY-MM-DD: [Milestone, e.g. Submit Express Entry profile]
+(3-5 realistic milestones in chronological order, starting from today's date, each on its own line with an ISO date)
+
+Next step: [Most important action to take]
+
+IMPORTANT: Be concise. Focus on 2024-2025 requirements. Consider budget constraints if provided. Do not ask for more details. Generate the response now:`,
+
+	DetailDeep: `
+INSTRUCTIONS:
+1. First, identify from the query: profession, current country (origin), and destination country.
+2. Research and recommend the SINGLE most suitable migration pathway for this profile, then explain it as a thorough step-by-step guide.
+3. If some details are missing, state the assumptions you make and proceed.
+4. Format your response as follows:
+
+# Best Migration Option: [Visa Name]
+
+Overview of why this is the best option for the profile (one paragraph).
+
+## Eligibility
+Detailed requirements, with how this profile meets each one and what is still needed.
+
+## Step-by-Step Process
+Numbered steps from preparation to arrival, with the authority or portal involved in each.
+
+## Document Checklist
+- [Document]: [Where to get it and typical lead time]
+
+## Costs
+A table of every fee (government fees, tests, credential assessment, medicals, proof of funds, travel) in USD, with a total.
+
+**Key Details:**
+- Processing time: [Duration]
+- Cost: [USD range]
+- Success rate: [High/Medium/Low]
+
+**Timeline:**
+- YYYY-MM-DD: [Milestone, e.g. Book IELTS test]
+- YYYY-MM-DD: [Milestone, e.g. Submit Express Entry profile]
+(6-10 realistic milestones in chronological order, starting from today's date, each on its own line with an ISO date)
+
+## Alternatives
+Two other pathways worth considering and when they would be better.
+
+## Risks and Common Mistakes
+The main reasons applications like this are refused and how to avoid them.
+
+Next step: [Most important action to take]
+
+IMPORTANT: Focus on 2024-2025 requirements. Consider budget constraints if provided. Do not ask for more details. Generate the response now:`,
+}
@@ -0,0 +1,237 @@
+package agent
+
+import (
+	"container/list"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultTaskCacheSize is how many tasks are kept in memory unless
+// TASK_CACHE_SIZE says otherwise
+const defaultTaskCacheSize = 10000
+
+// defaultTaskIdleTTL is how long an unfinished task may go unused before it
+// can be evicted, unless TASK_IDLE_TTL says otherwise
+const defaultTaskIdleTTL = time.Hour
+
+// TaskCache keeps the most recently used tasks in memory. Once it is full,
+// the least recently used finished task is evicted. Unfinished tasks, such
+// as an abandoned wizard or a task held for quota, are evicted too once
+// they have gone unused for the idle TTL and their latest state is in the
+// task store; tasks being processed or not yet saved are never evicted,
+// nor are tasks with reminders to send unless the reminders are kept in the
+// store, so the cache may briefly hold more than its capacity. The cache
+// has its own lock, but task fields are still guarded by the agent's mu.
+type TaskCache struct {
+	mu       sync.Mutex
+	capacity int
+	idleTTL  time.Duration
+	clock    Clock
+	order    *list.List               // most recently used first
+	entries  map[string]*list.Element // values are *cacheEntry
+
+	// remindersStored is set when unsent reminders are sent from the task
+	// store rather than from the tasks in memory
+	remindersStored bool
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+// CacheStats reports the size and effectiveness of the task cache
+type CacheStats struct {
+	Size      int     `json:"size"`
+	Capacity  int     `json:"capacity"`
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	Evictions uint64  `json:"evictions"`
+	HitRate   float64 `json:"hitRate"`
+}
+
+// cacheEntry is a cached task with when it was last used and the UpdatedAt
+// of its last copy saved to the task store
+type cacheEntry struct {
+	task  *Task
+	used  time.Time
+	saved time.Time
+}
+
+// NewTaskCache creates a cache holding TASK_CACHE_SIZE tasks (default
+// 10000) that may evict unfinished tasks idle for TASK_IDLE_TTL (default 1h)
+func NewTaskCache() *TaskCache {
+	capacity := defaultTaskCacheSize
+	if v := os.Getenv("TASK_CACHE_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			capacity = n
+		} else {
+			log.Printf("⚠️  Invalid TASK_CACHE_SIZE %q, caching %d tasks", v, capacity)
+		}
+	}
+	idleTTL := defaultTaskIdleTTL
+	if v := os.Getenv("TASK_IDLE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			idleTTL = d
+		} else {
+			log.Printf("⚠️  Invalid TASK_IDLE_TTL %q, using %s", v, idleTTL)
+		}
+	}
+	return &TaskCache{
+		capacity: capacity,
+		idleTTL:  idleTTL,
+		clock:    systemClock{},
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns a task and marks it recently used, counting a hit or a miss
+func (c *TaskCache) Get(taskID string) (*Task, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[taskID]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	entry := elem.Value.(*cacheEntry)
+	entry.used = c.clock.Now()
+	return entry.task, true
+}
+
+// Peek returns a task without counting the lookup or changing its recency
+func (c *TaskCache) Peek(taskID string) (*Task, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[taskID]; ok {
+		return elem.Value.(*cacheEntry).task, true
+	}
+	return nil, false
+}
+
+// Put adds or replaces a task as the most recently used, evicting old
+// tasks if the cache is over capacity. Callers must hold the agent's mu.
+func (c *TaskCache) Put(task *Task) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	newest, ok := c.entries[task.ID]
+	if ok {
+		entry := newest.Value.(*cacheEntry)
+		if entry.task != task {
+			entry.task, entry.saved = task, time.Time{}
+		}
+		entry.used = now
+		c.order.MoveToFront(newest)
+	} else {
+		newest = c.order.PushFront(&cacheEntry{task: task, used: now})
+		c.entries[task.ID] = newest
+	}
+
+	idleSince := now.Add(-c.idleTTL)
+	for elem := c.order.Back(); elem != newest && c.order.Len() > c.capacity; {
+		prev := elem.Prev()
+		if entry := elem.Value.(*cacheEntry); entry.evictable(idleSince, c.remindersStored) {
+			c.order.Remove(elem)
+			delete(c.entries, entry.task.ID)
+			c.evictions++
+		}
+		elem = prev
+	}
+}
+
+// Saved records that the task's state as of updatedAt is in the task
+// store, so it can be evicted once idle even if unfinished
+func (c *TaskCache) Saved(task *Task, updatedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[task.ID]; ok {
+		if entry := elem.Value.(*cacheEntry); entry.task == task && updatedAt.After(entry.saved) {
+			entry.saved = updatedAt
+		}
+	}
+}
+
+// evictable reports whether a task can be dropped from memory: it is
+// finished or, unless it is being processed, unused since idleSince and
+// saved since it last changed, and its unsent reminders, if any, are saved
+// to the store that sends them
+func (e *cacheEntry) evictable(idleSince time.Time, remindersStored bool) bool {
+	task := e.task
+	saved := !e.saved.IsZero() && !task.UpdatedAt.After(e.saved)
+	if !task.Status.State.Terminal() {
+		idle := task.Status.State != TaskStateWorking && e.used.Before(idleSince)
+		if !idle || !saved {
+			return false
+		}
+	}
+	for _, r := range task.Reminders {
+		if r.SentAt == nil {
+			return remindersStored && saved
+		}
+	}
+	return true
+}
+
+// Delete removes a task
+func (c *TaskCache) Delete(taskID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[taskID]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, taskID)
+	}
+}
+
+// Tasks returns every cached task, most recently used first
+func (c *TaskCache) Tasks() []*Task {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tasks := make([]*Task, 0, c.order.Len())
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		tasks = append(tasks, elem.Value.(*cacheEntry).task)
+	}
+	return tasks
+}
+
+// Stats returns the cache's current size and lookup counters
+func (c *TaskCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := CacheStats{
+		Size:      c.order.Len(),
+		Capacity:  c.capacity,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+	if lookups := c.hits + c.misses; lookups > 0 {
+		stats.HitRate = float64(c.hits) / float64(lookups)
+	}
+	return stats
+}
+
+// ServeCacheStats handles GET /admin/cache
+func (a *MigrationAgent) ServeCacheStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+	writeJSON(w, http.StatusOK, a.tasks.Stats())
+}
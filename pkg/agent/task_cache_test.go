@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"testing"
+	"time"
+)
+
+// manualClock is a clock tests move forward by hand
+type manualClock struct{ now time.Time }
+
+func (c *manualClock) Now() time.Time { return c.now }
+
+func TestTaskCacheEvictsIdleUnfinishedTasksOnceSaved(t *testing.T) {
+	t.Setenv("TASK_CACHE_SIZE", "1")
+	t.Setenv("TASK_IDLE_TTL", "1h")
+	clock := &manualClock{now: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)}
+	cache := NewTaskCache()
+	cache.clock = clock
+
+	wizard := &Task{ID: "wizard"}
+	wizard.SetStatus(TaskStateInputRequired, nil, clock.now)
+	cache.Put(wizard)
+
+	clock.now = clock.now.Add(2 * time.Hour)
+	cache.Put(&Task{ID: "other-1"})
+	if _, ok := cache.Peek("wizard"); !ok {
+		t.Fatal("unsaved task was evicted")
+	}
+
+	cache.Saved(wizard, wizard.UpdatedAt)
+	cache.Put(&Task{ID: "other-2"})
+	if _, ok := cache.Peek("wizard"); ok {
+		t.Error("idle saved task was kept")
+	}
+}
+
+func TestTaskCacheKeepsRecentlyUsedUnfinishedTasks(t *testing.T) {
+	t.Setenv("TASK_CACHE_SIZE", "1")
+	t.Setenv("TASK_IDLE_TTL", "1h")
+	clock := &manualClock{now: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)}
+	cache := NewTaskCache()
+	cache.clock = clock
+
+	held := &Task{ID: "held"}
+	held.SetStatus(TaskStateSubmitted, nil, clock.now)
+	cache.Put(held)
+	cache.Saved(held, held.UpdatedAt)
+
+	clock.now = clock.now.Add(2 * time.Hour)
+	cache.Get("held")
+	cache.Put(&Task{ID: "other"})
+	if _, ok := cache.Peek("held"); !ok {
+		t.Error("task used within the idle TTL was evicted")
+	}
+
+	working := &Task{ID: "working"}
+	working.SetStatus(TaskStateWorking, nil, clock.now)
+	cache.Put(working)
+	cache.Saved(working, working.UpdatedAt)
+	clock.now = clock.now.Add(2 * time.Hour)
+	cache.Put(&Task{ID: "other-2"})
+	if _, ok := cache.Peek("working"); !ok {
+		t.Error("task being processed was evicted")
+	}
+}
@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// TaskState is the lifecycle state of a task
+type TaskState string
+
+// Task states defined by the A2A protocol, plus rejected for compliance refusals
+const (
+	TaskStateSubmitted     TaskState = "submitted"
+	TaskStateWorking       TaskState = "working"
+	TaskStateInputRequired TaskState = "input-required"
+	TaskStateCompleted     TaskState = "completed"
+	TaskStateFailed        TaskState = "failed"
+	TaskStateCanceled      TaskState = "canceled"
+	TaskStateRejected      TaskState = "rejected"
+)
+
+// taskTransitions lists the states each state may move to. Terminal states
+// have no outgoing transitions.
+var taskTransitions = map[TaskState][]TaskState{
+	TaskStateSubmitted:     {TaskStateWorking, TaskStateCanceled, TaskStateRejected},
+	TaskStateWorking:       {TaskStateCompleted, TaskStateFailed, TaskStateCanceled, TaskStateInputRequired, TaskStateRejected, TaskStateSubmitted},
+	TaskStateInputRequired: {TaskStateWorking, TaskStateCanceled},
+	TaskStateCompleted:     nil,
+	TaskStateFailed:        nil,
+	TaskStateCanceled:      nil,
+	TaskStateRejected:      nil,
+}
+
+// Valid reports whether s is a known state
+func (s TaskState) Valid() bool {
+	_, ok := taskTransitions[s]
+	return ok
+}
+
+// Terminal reports whether a task in this state will never change again
+func (s TaskState) Terminal() bool {
+	return s.Valid() && len(taskTransitions[s]) == 0
+}
+
+// CanTransitionTo reports whether moving from s to next is allowed
+func (s TaskState) CanTransitionTo(next TaskState) bool {
+	for _, allowed := range taskTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// SetStatus moves the task to a new state and records it in the task's
+// history, refusing transitions the state machine doesn't allow so invalid
+// states are never stored or returned. now stamps the transition.
+func (t *Task) SetStatus(state TaskState, message *StatusMessage, now time.Time) error {
+	if !state.Valid() {
+		return fmt.Errorf("unknown task state %q", state)
+	}
+	if t.Status.State != "" && !t.Status.State.CanTransitionTo(state) {
+		return fmt.Errorf("invalid task transition %s -> %s", t.Status.State, state)
+	}
+
+	t.Status = TaskStatus{
+		State:     state,
+		Timestamp: now.UTC().Format(time.RFC3339),
+		Message:   message,
+	}
+	t.UpdatedAt = now
+	t.statusHistory = append(t.statusHistory, t.Status)
+	return nil
+}
+
+// withHistory returns a copy of the task carrying its most recent
+// historyLength status transitions
+func (t *Task) withHistory(historyLength int) *Task {
+	copied := *t
+	history := t.statusHistory
+	if historyLength < len(history) {
+		history = history[len(history)-historyLength:]
+	}
+	copied.History = append([]TaskStatus(nil), history...)
+	return &copied
+}
+
+// updateStatus transitions a stored task under the agent lock, saves it and
+// tells anyone streaming the task. Invalid transitions are programming
+// errors; they are logged and the task keeps its current state.
+func (a *MigrationAgent) updateStatus(task *Task, state TaskState, message *StatusMessage) {
+	a.mu.Lock()
+	err := task.SetStatus(state, message, a.clock.Now())
+	status := task.Status
+	a.mu.Unlock()
+	if err != nil {
+		log.Printf("task %s: %v", task.ID, err)
+		return
+	}
+	a.saveTask(task)
+	a.events.Publish(task.ID, statusEvent(task.ID, status))
+}
+
+// agentMessage builds a status message from the agent
+func agentMessage(taskID, messageID string, parts ...Part) *StatusMessage {
+	return &StatusMessage{
+		Kind:      "message",
+		Role:      "agent",
+		Parts:     parts,
+		MessageID: messageID,
+		TaskID:    taskID,
+	}
+}
@@ -0,0 +1,123 @@
+package agent
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// taxData is the curated overview of tax residency, exit rules and tax
+// treaties, maintained with the code like the fee table
+//
+//go:embed tax.json
+var taxData []byte
+
+// TaxDestination is how a destination taxes newcomers
+type TaxDestination struct {
+	Country   string   `json:"country"`
+	Residency string   `json:"residency"` // when newcomers become resident for tax
+	Notes     []string `json:"notes,omitempty"`
+	Treaties  []string `json:"treaties"` // ISO 3166 codes of countries with a double-taxation treaty in force
+}
+
+// TaxOrigin is what leaving a country means for tax
+type TaxOrigin struct {
+	Country string `json:"country"` // ISO 3166 code
+	Exit    string `json:"exit"`
+}
+
+// taxTable is the versioned built-in overview
+type taxTable struct {
+	Version      string           `json:"version"`
+	Updated      string           `json:"updated"` // YYYY-MM-DD
+	Destinations []TaxDestination `json:"destinations"`
+	Origins      []TaxOrigin      `json:"origins"`
+}
+
+var taxes = mustParseTaxData(taxData)
+
+// mustParseTaxData parses the embedded overview, which ships with the
+// binary, so a broken one is a build defect
+func mustParseTaxData(data []byte) taxTable {
+	var table taxTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		panic(fmt.Sprintf("invalid embedded tax data: %v", err))
+	}
+	if _, err := time.Parse("2006-01-02", table.Updated); err != nil || table.Version == "" {
+		panic("embedded tax data needs a version and an updated date")
+	}
+	for _, d := range table.Destinations {
+		if d.Country == "" || d.Residency == "" {
+			panic(fmt.Sprintf("tax destination %q needs a country and residency rules", d.Country))
+		}
+	}
+	for _, o := range table.Origins {
+		if _, ok := locales[o.Country]; !ok || o.Exit == "" {
+			panic(fmt.Sprintf("tax origin %q needs a known country code and exit rules", o.Country))
+		}
+	}
+	return table
+}
+
+// taxDestination returns the overview for a destination, or nil
+func taxDestination(country string) *TaxDestination {
+	for i, d := range taxes.Destinations {
+		if strings.EqualFold(d.Country, country) {
+			return &taxes.Destinations[i]
+		}
+	}
+	return nil
+}
+
+// taxExit returns what leaving the origin means for tax, or ""
+func taxExit(originCode string) string {
+	for _, o := range taxes.Origins {
+		if o.Country == strings.ToUpper(originCode) {
+			return o.Exit
+		}
+	}
+	return ""
+}
+
+// applyTaxOverview adds a section on the headline tax considerations of
+// the move before the next step: tax residency at the destination, leaving
+// the origin and whether a double-taxation treaty links the two. It is
+// framed as general information, not tax advice. Destinations without an
+// overview are returned unchanged.
+func applyTaxOverview(markdown, destination, originCode string) string {
+	d := taxDestination(destination)
+	if d == nil {
+		return markdown
+	}
+
+	var section strings.Builder
+	section.WriteString("**Tax Considerations (general information, not tax advice):**\n")
+	destination = countryName(d.Country)
+	fmt.Fprintf(&section, "- Tax residency in %s: %s\n", destination, d.Residency)
+	if locale, ok := locales[strings.ToUpper(originCode)]; ok {
+		origin := countryName(titleWords(locale.Country))
+		if exit := taxExit(originCode); exit != "" {
+			fmt.Fprintf(&section, "- Leaving %s: %s\n", origin, exit)
+		}
+		if containsString(d.Treaties, strings.ToUpper(originCode)) {
+			fmt.Fprintf(&section, "- Double taxation: %s and %s have a tax treaty, so income taxed in one usually earns a credit or exemption in the other; it also decides where you are resident if both countries claim you\n", origin, destination)
+		} else {
+			fmt.Fprintf(&section, "- Double taxation: %s and %s have no comprehensive income tax treaty, so income taxed in both may only get the relief each country gives on its own; plan when you sell assets or stop income at home\n", origin, destination)
+		}
+	}
+	for _, note := range d.Notes {
+		section.WriteString("- " + note + "\n")
+	}
+	fmt.Fprintf(&section, "This is a general overview, not tax advice; your position depends on your circumstances, so check with a qualified tax adviser in both countries before you move (tax overview version %s).\n", taxes.Version)
+	return insertBeforeNextStep(markdown, section.String())
+}
+
+// countryName adds "the" to country names that take it in a sentence
+func countryName(country string) string {
+	if strings.HasPrefix(country, "United ") || country == "Philippines" {
+		return "the " + country
+	}
+	return country
+}
@@ -0,0 +1,220 @@
+package agent
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"html"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// TelexSetting is one entry of the settings array Telex sends with every
+// message and expects in the integration spec
+type TelexSetting struct {
+	Label    string      `json:"label"`
+	Type     string      `json:"type"`
+	Required bool        `json:"required"`
+	Default  interface{} `json:"default"`
+	Options  []string    `json:"options,omitempty"`
+}
+
+// TelexMessage is the payload Telex posts to an integration's target URL
+type TelexMessage struct {
+	ChannelID string         `json:"channel_id"`
+	Message   string         `json:"message"`
+	Settings  []TelexSetting `json:"settings"`
+}
+
+// TelexResponse is the reply Telex expects from a modifier integration
+type TelexResponse struct {
+	EventName string `json:"event_name"`
+	Message   string `json:"message"`
+	Status    string `json:"status"` // success or error
+	Username  string `json:"username"`
+}
+
+// telexUsername is the sender name Telex shows on the agent's replies
+const telexUsername = "Migration Pathways"
+
+// telexSettings are the per-channel options Telex lets admins configure
+var telexSettings = []TelexSetting{
+	{Label: "Output Format", Type: "dropdown", Required: true, Default: OutputFormatMarkdown, Options: []string{OutputFormatMarkdown, OutputFormatHTML}},
+}
+
+// htmlTagPattern matches the markup Telex wraps around channel messages
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// ServeTelex handles /integrations/telex: GET returns the integration spec
+// and POST answers a channel message from a caller telexCaller accepts
+func (a *MigrationAgent) ServeTelex(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, telexIntegrationSpec(r))
+	case http.MethodPost:
+		tenant, err := telexCaller(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(w, http.StatusUnauthorized, telexReply("error", err.Error()))
+			return
+		}
+		a.handleTelexMessage(w, r, tenant)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// telexCaller authenticates a Telex message by a tenant's API key or by
+// TELEX_SECRET, sent in X-Telex-Secret or as the secret query parameter of
+// the target URL. Messages with neither are only accepted while neither
+// TELEX_SECRET nor tenants are configured.
+func telexCaller(r *http.Request) (*Tenant, error) {
+	if tenant, err := authenticateTenant(r); err == nil && tenant != nil {
+		return tenant, nil
+	}
+	secret := os.Getenv("TELEX_SECRET")
+	if secret == "" {
+		if tenantsEnabled() {
+			return nil, ErrUnauthenticated
+		}
+		return nil, nil
+	}
+	given := r.Header.Get("X-Telex-Secret")
+	if given == "" {
+		given = r.URL.Query().Get("secret")
+	}
+	if subtle.ConstantTimeCompare([]byte(given), []byte(secret)) != 1 {
+		return nil, ErrUnauthenticated
+	}
+	return nil, nil
+}
+
+// handleTelexMessage maps a Telex message to ProcessTask as tenant and
+// replies in the shape Telex posts back to the channel
+func (a *MigrationAgent) handleTelexMessage(w http.ResponseWriter, r *http.Request, tenant *Tenant) {
+	var msg TelexMessage
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		writeJSON(w, http.StatusBadRequest, telexReply("error", "Invalid Telex payload: "+err.Error()))
+		return
+	}
+
+	query := telexPlainText(msg.Message)
+	if query == "" {
+		writeJSON(w, http.StatusBadRequest, telexReply("error", "Message is empty"))
+		return
+	}
+
+	if ok, _ := a.tenantLimiter.Allow(tenant); !ok {
+		a.tenantStats.RecordRequest(tenant.id(), true)
+		writeJSON(w, http.StatusTooManyRequests, telexReply("error", "Too many messages, please try again in a minute."))
+		return
+	}
+	a.tenantStats.RecordRequest(tenant.id(), false)
+
+	opts := TaskOptions{OutputFormat: OutputFormatMarkdown, Tenant: tenant.id()}
+	if msg.ChannelID != "" {
+		opts.SessionID = "telex:" + msg.ChannelID
+	}
+	if format := telexSetting(msg.Settings, "Output Format"); format == OutputFormatHTML {
+		opts.OutputFormat = OutputFormatHTML
+	}
+
+	message := Message{
+		Role:  "user",
+		Parts: []Part{{Kind: "text", Text: query}},
+	}
+	task, err := a.ProcessTask(a.ids.NewID(), message, opts)
+	if err != nil {
+		log.Printf("Telex message from channel %s failed: %v", msg.ChannelID, err)
+		writeJSON(w, http.StatusOK, telexReply("error", "Sorry, I couldn't generate migration pathways right now. Please try again later."))
+		return
+	}
+
+	reply := ""
+	if latest := latestArtifacts(task.Artifacts); len(latest) > 0 {
+		reply = latest[0].Parts[0].Text
+	} else if task.Status.Message != nil && len(task.Status.Message.Parts) > 0 {
+		reply = task.Status.Message.Parts[0].Text
+	}
+	writeJSON(w, http.StatusOK, telexReply("success", reply))
+}
+
+// telexReply builds a response for Telex
+func telexReply(status, message string) TelexResponse {
+	return TelexResponse{
+		EventName: "message_formatted",
+		Message:   message,
+		Status:    status,
+		Username:  telexUsername,
+	}
+}
+
+// telexPlainText strips the HTML Telex wraps around messages
+func telexPlainText(message string) string {
+	text := strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n", "</p>", "\n").Replace(message)
+	text = htmlTagPattern.ReplaceAllString(text, "")
+	return strings.TrimSpace(html.UnescapeString(text))
+}
+
+// telexSetting returns the configured (or default) value of a setting
+func telexSetting(settings []TelexSetting, label string) string {
+	for _, s := range settings {
+		if strings.EqualFold(s.Label, label) {
+			if value, ok := s.Default.(string); ok {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// telexIntegrationSpec describes the agent in the integration.json format
+// Telex reads when the integration is added to an organisation
+func telexIntegrationSpec(r *http.Request) map[string]interface{} {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	baseURL := scheme + "://" + r.Host
+
+	var card struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Metadata    struct {
+			Author string `json:"author"`
+		} `json:"metadata"`
+	}
+	json.Unmarshal(agentCardData, &card)
+
+	return map[string]interface{}{
+		"data": map[string]interface{}{
+			"date": map[string]string{
+				"created_at": "2025-11-03",
+				"updated_at": "2025-11-03",
+			},
+			"descriptions": map[string]string{
+				"app_name":         card.Name,
+				"app_description":  card.Description,
+				"app_url":          baseURL,
+				"app_logo":         "",
+				"background_color": "#0B5FFF",
+			},
+			"is_active":            true,
+			"integration_type":     "modifier",
+			"integration_category": "AI & Machine Learning",
+			"key_features": []string{
+				"Personalized visa and migration pathway recommendations",
+				"Costs, timelines and requirements for each pathway",
+				"Success probability based on profession, origin and budget",
+			},
+			"author":     card.Metadata.Author,
+			"settings":   telexSettings,
+			"target_url": baseURL + "/integrations/telex",
+		},
+	}
+}
@@ -0,0 +1,358 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+)
+
+// Tenant is a partner served by the deployment under its own API keys,
+// with its own limits, features and prompts
+type Tenant struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	APIKeys    []string        `json:"apiKeys"`              // plain keys or "sha256:<hex>" digests of them
+	RateLimit  int             `json:"rateLimit,omitempty"`  // task sends per minute; 0 means unlimited
+	Features   map[string]bool `json:"features,omitempty"`   // flag values for this tenant's tasks, overriding the deployment's
+	ContentDir string          `json:"contentDir,omitempty"` // prompts.json overrides, as for CONTENT_DIR
+
+	// UserDailyQuota overrides USER_DAILY_QUOTA for the tenant's users
+	UserDailyQuota *int `json:"userDailyQuota,omitempty"`
+	// WebhookSecret signs push notifications and reminder webhooks for the
+	// tenant's tasks instead of WEBHOOK_SIGNING_SECRET
+	WebhookSecret string `json:"webhookSecret,omitempty"`
+	// Models are the Gemini models the tenant's tasks may choose with the
+	// model param, instead of ALLOWED_MODELS
+	Models []string `json:"models,omitempty"`
+
+	prompts *Content
+}
+
+// TenantSet is the loaded TENANTS_FILE. Sets are never modified; reloads
+// swap in a new one.
+type TenantSet struct {
+	tenants []*Tenant
+	byID    map[string]*Tenant
+	byKey   map[[sha256.Size]byte]*Tenant
+}
+
+var currentTenants atomic.Pointer[TenantSet]
+
+// Errors authenticating a tenant
+var (
+	ErrUnauthenticated = errors.New("a valid API key is required")
+	ErrRateLimited     = errors.New("rate limit exceeded")
+)
+
+// loadTenants reads TENANTS_FILE, a JSON array of tenants. No file means a
+// single-tenant deployment with no API keys.
+func loadTenants() (*TenantSet, error) {
+	set := &TenantSet{byID: make(map[string]*Tenant), byKey: make(map[[sha256.Size]byte]*Tenant)}
+	path := os.Getenv("TENANTS_FILE")
+	if path == "" {
+		return set, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TENANTS_FILE: %v", err)
+	}
+	if err := json.Unmarshal(data, &set.tenants); err != nil {
+		return nil, fmt.Errorf("failed to parse TENANTS_FILE: %v", err)
+	}
+
+	for _, t := range set.tenants {
+		if t.ID == "" || set.byID[t.ID] != nil {
+			return nil, fmt.Errorf("TENANTS_FILE: tenant IDs must be unique and non-empty")
+		}
+		set.byID[t.ID] = t
+		if len(t.APIKeys) == 0 {
+			return nil, fmt.Errorf("TENANTS_FILE: tenant %s needs at least one API key", t.ID)
+		}
+		for _, key := range t.APIKeys {
+			digest, err := apiKeyDigest(key)
+			if err != nil {
+				return nil, fmt.Errorf("TENANTS_FILE: tenant %s: %v", t.ID, err)
+			}
+			if set.byKey[digest] != nil {
+				return nil, fmt.Errorf("TENANTS_FILE: tenant %s reuses an API key", t.ID)
+			}
+			set.byKey[digest] = t
+		}
+		if t.RateLimit < 0 {
+			return nil, fmt.Errorf("TENANTS_FILE: tenant %s has a negative rateLimit", t.ID)
+		}
+		if t.UserDailyQuota != nil && *t.UserDailyQuota < 0 {
+			return nil, fmt.Errorf("TENANTS_FILE: tenant %s has a negative userDailyQuota", t.ID)
+		}
+		for name := range t.Features {
+			if !knownFlag(name) {
+				return nil, fmt.Errorf("TENANTS_FILE: tenant %s: unknown feature %q", t.ID, name)
+			}
+		}
+		if t.ContentDir != "" {
+			// Tenants change prompts only, as rollout variants do
+			prompts, err := loadContent(t.ContentDir)
+			if err != nil {
+				return nil, fmt.Errorf("TENANTS_FILE: tenant %s: %v", t.ID, err)
+			}
+			t.prompts = prompts
+		}
+	}
+	return set, nil
+}
+
+// apiKeyDigest hashes a configured key, or decodes one given as a digest
+func apiKeyDigest(key string) ([sha256.Size]byte, error) {
+	var digest [sha256.Size]byte
+	if hexDigest, ok := strings.CutPrefix(key, "sha256:"); ok {
+		decoded, err := hex.DecodeString(hexDigest)
+		if err != nil || len(decoded) != sha256.Size {
+			return digest, fmt.Errorf("invalid sha256 API key digest")
+		}
+		copy(digest[:], decoded)
+		return digest, nil
+	}
+	if key == "" {
+		return digest, fmt.Errorf("empty API key")
+	}
+	return sha256.Sum256([]byte(key)), nil
+}
+
+// tenantsEnabled reports whether callers must present a tenant's API key
+func tenantsEnabled() bool {
+	set := currentTenants.Load()
+	return set != nil && len(set.tenants) > 0
+}
+
+// lookupTenant returns a tenant by ID, or nil for the default tenant or
+// one removed from TENANTS_FILE
+func lookupTenant(id string) *Tenant {
+	set := currentTenants.Load()
+	if id == "" || set == nil {
+		return nil
+	}
+	return set.byID[id]
+}
+
+// authenticateTenant finds the tenant whose API key the request carries,
+// as a bearer token or in X-API-Key. Without tenants every request is the
+// default tenant's, reported as nil.
+func authenticateTenant(r *http.Request) (*Tenant, error) {
+	if !tenantsEnabled() {
+		return nil, nil
+	}
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		key = strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	}
+	if key == "" {
+		return nil, ErrUnauthenticated
+	}
+	// Keys are compared by digest, so the lookup doesn't leak key prefixes
+	// through timing
+	if t := currentTenants.Load().byKey[sha256.Sum256([]byte(key))]; t != nil {
+		return t, nil
+	}
+	return nil, ErrUnauthenticated
+}
+
+// id returns the tenant's ID, "" for the default tenant
+func (t *Tenant) id() string {
+	if t == nil {
+		return ""
+	}
+	return t.ID
+}
+
+// enabled reports whether a feature flag is on for the tenant's tasks
+func (t *Tenant) enabled(flag string) bool {
+	if t != nil {
+		if value, ok := t.Features[flag]; ok {
+			return value
+		}
+	}
+	return featureEnabled(flag)
+}
+
+// TenantLimiter applies each tenant's rate limit to task sends with a token
+// bucket refilled continuously at the tenant's rate per minute
+type TenantLimiter struct {
+	clock Clock
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewTenantLimiter creates a limiter with every bucket full
+func NewTenantLimiter() *TenantLimiter {
+	return &TenantLimiter{clock: systemClock{}, buckets: make(map[string]*tokenBucket)}
+}
+
+// Allow takes a token for the tenant, or reports how long until one is
+// available. Tenants without a limit are always allowed.
+func (l *TenantLimiter) Allow(t *Tenant) (bool, time.Duration) {
+	if t == nil || t.RateLimit == 0 {
+		return true, 0
+	}
+	perSecond := float64(t.RateLimit) / 60
+	now := l.clock.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.buckets[t.ID]
+	if b == nil {
+		b = &tokenBucket{tokens: float64(t.RateLimit), updated: now}
+		l.buckets[t.ID] = b
+	}
+	b.tokens = math.Min(float64(t.RateLimit), b.tokens+now.Sub(b.updated).Seconds()*perSecond)
+	b.updated = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / perSecond * float64(time.Second))
+}
+
+// TenantUsage counts one tenant's requests and tasks since the server
+// started
+type TenantUsage struct {
+	Requests     int `json:"requests"`
+	RateLimited  int `json:"rateLimited"`
+	Tasks        int `json:"tasks"`
+	Completed    int `json:"completed"`
+	Failed       int `json:"failed"`
+	PromptTokens int `json:"promptTokens"`
+	OutputTokens int `json:"outputTokens"`
+}
+
+// TenantStats accumulates usage per tenant
+type TenantStats struct {
+	mu      sync.Mutex
+	tenants map[string]*TenantUsage
+}
+
+// NewTenantStats creates empty tenant statistics
+func NewTenantStats() *TenantStats {
+	return &TenantStats{tenants: make(map[string]*TenantUsage)}
+}
+
+// usage returns a tenant's counters. Callers must hold s.mu.
+func (s *TenantStats) usage(tenantID string) *TenantUsage {
+	u := s.tenants[tenantID]
+	if u == nil {
+		u = &TenantUsage{}
+		s.tenants[tenantID] = u
+	}
+	return u
+}
+
+// RecordRequest counts an A2A call, and whether it was refused for the
+// tenant's rate limit
+func (s *TenantStats) RecordRequest(tenantID string, limited bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := s.usage(tenantID)
+	u.Requests++
+	if limited {
+		u.RateLimited++
+	}
+}
+
+// RecordTask adds a processed task's outcome and token usage
+func (s *TenantStats) RecordTask(tenantID string, state TaskState, usage llm.TokenUsage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := s.usage(tenantID)
+	u.Tasks++
+	switch state {
+	case TaskStateCompleted:
+		u.Completed++
+	case TaskStateFailed:
+		u.Failed++
+	}
+	u.PromptTokens += usage.PromptTokens
+	u.OutputTokens += usage.OutputTokens
+}
+
+// Snapshot copies the statistics for reporting
+func (s *TenantStats) Snapshot() map[string]TenantUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make(map[string]TenantUsage, len(s.tenants))
+	for id, u := range s.tenants {
+		snapshot[id] = *u
+	}
+	return snapshot
+}
+
+// taskFor returns a task if it belongs to the tenant. Other tenants' tasks
+// are reported as not found, so task IDs can't be probed across tenants.
+func (a *MigrationAgent) taskFor(t *Tenant, taskID string) (*Task, error) {
+	task, exists := a.lookupTask(taskID)
+	if exists {
+		a.mu.RLock()
+		owned := task.tenant == t.id()
+		a.mu.RUnlock()
+		if owned {
+			return task, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+}
+
+// ServeTenants handles GET /admin/tenants, listing the tenants with their
+// settings and usage since the server started. API keys are never shown.
+func (a *MigrationAgent) ServeTenants(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	usage := a.tenantStats.Snapshot()
+	tenants := []map[string]interface{}{}
+	if set := currentTenants.Load(); set != nil {
+		for _, t := range set.tenants {
+			tenants = append(tenants, map[string]interface{}{
+				"id":             t.ID,
+				"name":           t.Name,
+				"apiKeys":        len(t.APIKeys),
+				"rateLimit":      t.RateLimit,
+				"userDailyQuota": a.userQuota.limitFor(t),
+				"signedWebhooks": webhookSecret(t.ID) != "",
+				"features":       t.Features,
+				"contentDir":     t.ContentDir,
+				"usage":          usage[t.ID],
+			})
+		}
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i]["id"].(string) < tenants[j]["id"].(string) })
+
+	response := map[string]interface{}{"tenants": tenants}
+	if u, ok := usage[""]; ok {
+		// Chat integrations and, without TENANTS_FILE, every caller
+		response["default"] = u
+	}
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+)
+
+// Speech-to-text providers, selected with STT_PROVIDER
+const (
+	STTProviderGemini = "gemini"
+	STTProviderOpenAI = "openai" // any OpenAI-compatible /audio/transcriptions endpoint
+)
+
+// transcribePrompt asks Gemini for a plain transcript of a voice note
+const transcribePrompt = `Transcribe this voice message exactly as spoken, in the language it was spoken in. Reply with the transcript only, without timestamps, speaker labels or commentary. If there is no speech, reply with NONE.`
+
+// Transcriber turns recorded speech into text
+type Transcriber interface {
+	Transcribe(mimeType string, audio []byte) (string, error)
+}
+
+// NewTranscriber creates the speech-to-text provider configured by
+// STT_PROVIDER (default gemini), or none when LLM_MODE is off
+func NewTranscriber(gemini *llm.GeminiClient) Transcriber {
+	if gemini.Mode == llm.ModeOff {
+		return offlineTranscriber{}
+	}
+	switch provider := os.Getenv("STT_PROVIDER"); provider {
+	case "", STTProviderGemini:
+		return &geminiTranscriber{gemini: gemini}
+	case STTProviderOpenAI:
+		url := os.Getenv("STT_API_URL")
+		if url == "" {
+			url = "https://api.openai.com/v1/audio/transcriptions"
+		}
+		model := os.Getenv("STT_MODEL")
+		if model == "" {
+			model = "whisper-1"
+		}
+		return &openAITranscriber{
+			url:    url,
+			apiKey: os.Getenv("STT_API_KEY"),
+			model:  model,
+			client: &http.Client{Timeout: 60 * time.Second},
+		}
+	default:
+		log.Printf("⚠️  Unknown STT_PROVIDER %q, using %q", provider, STTProviderGemini)
+		return &geminiTranscriber{gemini: gemini}
+	}
+}
+
+// geminiTranscriber transcribes with Gemini's audio input
+type geminiTranscriber struct {
+	gemini *llm.GeminiClient
+}
+
+func (t *geminiTranscriber) Transcribe(mimeType string, audio []byte) (string, error) {
+	text, err := t.gemini.GenerateWithMedia(transcribePrompt, []llm.GeminiInlineData{{
+		MimeType: mimeType,
+		Data:     base64.StdEncoding.EncodeToString(audio),
+	}})
+	if err != nil {
+		return "", err
+	}
+	text = strings.TrimSpace(text)
+	if strings.EqualFold(text, "NONE") {
+		return "", nil
+	}
+	return text, nil
+}
+
+// openAITranscriber posts audio to an OpenAI-compatible transcription API
+type openAITranscriber struct {
+	url    string
+	apiKey string
+	model  string
+	client *http.Client
+}
+
+func (t *openAITranscriber) Transcribe(mimeType string, audio []byte) (string, error) {
+	if t.apiKey == "" {
+		return "", fmt.Errorf("STT_API_KEY environment variable not set")
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("model", t.model)
+	file, err := form.CreateFormFile("file", "voice"+audioExtension(mimeType))
+	if err != nil {
+		return "", fmt.Errorf("failed to build transcription request: %v", err)
+	}
+	file.Write(audio)
+	form.Close()
+
+	req, err := http.NewRequest(http.MethodPost, t.url, &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create transcription request: %v", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+t.apiKey)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call transcription API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read transcription response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("transcription API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("failed to parse transcription response: %v", err)
+	}
+	return strings.TrimSpace(result.Text), nil
+}
+
+// audioExtension returns the file extension transcription APIs expect for
+// an audio type
+func audioExtension(mimeType string) string {
+	switch mimeType {
+	case "audio/mpeg":
+		return ".mp3"
+	case "audio/wav":
+		return ".wav"
+	case "audio/webm":
+		return ".webm"
+	case "audio/mp4":
+		return ".m4a"
+	case "audio/aac":
+		return ".aac"
+	case "audio/flac":
+		return ".flac"
+	default:
+		return ".ogg"
+	}
+}
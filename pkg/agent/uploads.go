@@ -0,0 +1,265 @@
+package agent
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/llm"
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// maxResumeChars bounds how much extracted CV text is added to the prompt
+const maxResumeChars = 8000
+
+// Upload records a file received as a message part, such as a CV
+type Upload struct {
+	ID         string    `json:"id"`
+	TaskID     string    `json:"taskId"`
+	SessionID  string    `json:"sessionId,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	MimeType   string    `json:"mimeType"`
+	Size       int       `json:"size"`
+	Characters int       `json:"characters"` // length of the extracted text
+	StoredAt   time.Time `json:"storedAt"`
+	path       string
+}
+
+// UploadStore keeps uploaded files, on disk under UPLOAD_DIR when set
+type UploadStore struct {
+	dir     string
+	maxSize int
+	clock   Clock
+	ids     IDGenerator
+
+	mu      sync.Mutex
+	uploads map[string]*Upload
+}
+
+// NewUploadStore creates an upload store configured from UPLOAD_DIR and
+// MAX_UPLOAD_BYTES (default 5 MB)
+func NewUploadStore() *UploadStore {
+	maxSize := 5 << 20
+	if v := os.Getenv("MAX_UPLOAD_BYTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			maxSize = n
+		}
+	}
+
+	dir := os.Getenv("UPLOAD_DIR")
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			log.Printf("⚠️  Cannot create UPLOAD_DIR %s, keeping uploads in memory only: %v", dir, err)
+			dir = ""
+		}
+	}
+
+	return &UploadStore{
+		dir:     dir,
+		maxSize: maxSize,
+		clock:   systemClock{},
+		ids:     uuidGenerator{},
+		uploads: make(map[string]*Upload),
+	}
+}
+
+// decodeFilePart returns the decoded bytes and document type of a file part
+func (s *UploadStore) decodeFilePart(file *FileContent) ([]byte, string, error) {
+	if file == nil || file.Bytes == "" {
+		return nil, "", fmt.Errorf("file parts must carry base64 bytes; uri references are not fetched")
+	}
+	if base64.StdEncoding.DecodedLen(len(file.Bytes)) > s.maxSize+3 {
+		return nil, "", fmt.Errorf("file %q exceeds the %d byte upload limit", file.Name, s.maxSize)
+	}
+	data, err := base64.StdEncoding.DecodeString(file.Bytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("file %q is not valid base64: %v", file.Name, err)
+	}
+	if len(data) > s.maxSize {
+		return nil, "", fmt.Errorf("file %q exceeds the %d byte upload limit", file.Name, s.maxSize)
+	}
+
+	mimeType := documentType(file.Name, file.MimeType, data)
+	if mimeType == "" {
+		return nil, "", fmt.Errorf("%w: file %q must be a PDF, DOCX or plain text document, a JPEG, PNG, WebP or HEIC image, or an audio recording", ErrContentTypeNotSupported, file.Name)
+	}
+	return data, mimeType, nil
+}
+
+// validateFileParts rejects file parts that can't be stored or read
+func (s *UploadStore) validateFileParts(parts []Part) error {
+	for _, part := range parts {
+		if part.Kind != "file" {
+			continue
+		}
+		if _, _, err := s.decodeFilePart(part.File); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Save stores a file part for a task and returns the text extracted from
+// it; images and audio are stored as-is with no text
+func (s *UploadStore) Save(taskID, sessionID string, file *FileContent) (*Upload, string, error) {
+	data, mimeType, err := s.decodeFilePart(file)
+	if err != nil {
+		return nil, "", err
+	}
+	var text string
+	if !isImage(mimeType) && !isAudio(mimeType) {
+		text, err = extractDocumentText(mimeType, data)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read %q: %v", file.Name, err)
+		}
+	}
+
+	upload := &Upload{
+		ID:         s.ids.NewID(),
+		TaskID:     taskID,
+		SessionID:  sessionID,
+		Name:       file.Name,
+		MimeType:   mimeType,
+		Size:       len(data),
+		Characters: len(text),
+		StoredAt:   s.clock.Now().UTC(),
+	}
+	if s.dir != "" {
+		upload.path = filepath.Join(s.dir, upload.ID+filepath.Ext(file.Name))
+		if err := os.WriteFile(upload.path, data, 0o600); err != nil {
+			log.Printf("⚠️  Failed to write upload %s to disk: %v", upload.ID, err)
+			upload.path = ""
+		}
+	}
+
+	s.mu.Lock()
+	s.uploads[upload.ID] = upload
+	s.mu.Unlock()
+	return upload, text, nil
+}
+
+// ForSession returns the uploads recorded for a session, oldest first
+func (s *UploadStore) ForSession(sessionID string) []*Upload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var uploads []*Upload
+	for _, upload := range s.uploads {
+		if upload.SessionID == sessionID {
+			uploads = append(uploads, upload)
+		}
+	}
+	sort.Slice(uploads, func(i, j int) bool {
+		return uploads[i].StoredAt.Before(uploads[j].StoredAt)
+	})
+	return uploads
+}
+
+// DeleteSession removes a session's uploads, including files on disk, and
+// returns how many were removed
+func (s *UploadStore) DeleteSession(sessionID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for id, upload := range s.uploads {
+		if upload.SessionID != sessionID {
+			continue
+		}
+		if upload.path != "" {
+			if err := os.Remove(upload.path); err != nil && !os.IsNotExist(err) {
+				log.Printf("⚠️  Failed to remove upload file %s: %v", upload.path, err)
+			}
+		}
+		delete(s.uploads, id)
+		deleted++
+	}
+	return deleted
+}
+
+// Attachments is what was read from the file parts of a message
+type Attachments struct {
+	Resume       string           // CV text, redacted and truncated for the prompt
+	CV           parser.CVProfile // facts parsed from the CV
+	ImageFacts   string           // facts Gemini read from document images, redacted
+	ImageProfile parser.CVProfile // facts parsed from ImageFacts
+	Transcript   string           // what was said in voice recordings
+}
+
+// imageFactsPrompt asks Gemini vision for the immigration-relevant facts on
+// a photographed document
+const imageFactsPrompt = `The attached image was uploaded by someone planning to migrate. If it shows a document such as a degree certificate, transcript, language test result (IELTS, TOEFL, PTE, CELPIP), professional licence or employment letter, list the facts relevant to immigration as short "Label: value" lines, for example:
+Document: IELTS Test Report Form
+IELTS overall band: 7.5
+Qualification: Bachelor of Science in Nursing
+Issued: 2019-06-01
+Do not include names, dates of birth, addresses or ID numbers. If the image is not such a document, reply with NONE.`
+
+// readAttachments extracts the text of every file part in a message,
+// redacted and truncated for the prompt, and parses it as a CV. Parsing
+// runs before redaction so date ranges aren't mistaken for phone numbers.
+// Images are read with Gemini vision and voice recordings transcribed.
+func (a *MigrationAgent) readAttachments(task *Task, message Message) Attachments {
+	var text, facts, transcript string
+	for _, part := range message.Parts {
+		if part.Kind != "file" {
+			continue
+		}
+		upload, extracted, err := a.uploads.Save(task.ID, task.SessionID, part.File)
+		if err != nil {
+			log.Printf("⚠️  Ignoring file part on task %s: %v", task.ID, err)
+			continue
+		}
+		log.Printf("📎 Stored upload %s (%s, %d bytes) for task %s", upload.ID, upload.MimeType, upload.Size, task.ID)
+
+		if isAudio(upload.MimeType) {
+			audio, _ := base64.StdEncoding.DecodeString(part.File.Bytes)
+			said, err := a.transcriber.Transcribe(upload.MimeType, audio)
+			if err != nil {
+				log.Printf("⚠️  Failed to transcribe audio %s on task %s: %v", upload.ID, task.ID, err)
+				continue
+			}
+			log.Printf("🎙️ Transcribed %d characters from audio %s", len(said), upload.ID)
+			transcript += said + " "
+			continue
+		}
+		if isImage(upload.MimeType) {
+			read, err := a.gemini.GenerateWithMedia(imageFactsPrompt, []llm.GeminiInlineData{{MimeType: upload.MimeType, Data: part.File.Bytes}})
+			if err != nil {
+				log.Printf("⚠️  Failed to read image %s on task %s: %v", upload.ID, task.ID, err)
+				continue
+			}
+			if read = strings.TrimSpace(read); read != "" && !strings.EqualFold(read, "NONE") {
+				facts += read + "\n"
+			}
+			continue
+		}
+		text += extracted + "\n"
+	}
+
+	var att Attachments
+	att.CV = parser.ParseCV(text, content().cvDictionary(), a.clock.Now())
+	att.ImageProfile = parser.ParseCV(facts, content().cvDictionary(), a.clock.Now())
+	att.ImageProfile.Profession = "" // qualifications on certificates aren't job titles
+
+	text, redacted := redactPII(text)
+	if len(redacted) > 0 {
+		log.Printf("Redacted %s from uploads on task %s", strings.Join(redacted, ", "), task.ID)
+	}
+	if runes := []rune(text); len(runes) > maxResumeChars {
+		text = string(runes[:maxResumeChars])
+	}
+	att.Resume = strings.TrimSpace(text)
+
+	facts, _ = redactPII(facts)
+	att.ImageFacts = strings.TrimSpace(facts)
+	att.Transcript = strings.TrimSpace(transcript)
+	return att
+}
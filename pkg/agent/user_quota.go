@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// UserQuotaError is a send refused because the end user has used up their
+// daily queries. It is reported to the caller as structured error data.
+type UserQuotaError struct {
+	UserID  string    `json:"userId"`
+	Limit   int       `json:"limit"`
+	Used    int       `json:"used"`
+	ResetAt time.Time `json:"resetAt"`
+}
+
+func (e *UserQuotaError) Error() string {
+	return fmt.Sprintf("user %s has used %d of %d daily queries", e.UserID, e.Used, e.Limit)
+}
+
+func (e *UserQuotaError) rpcData() interface{} {
+	return e
+}
+
+// UserQuota limits how many tasks each end user, identified by the
+// integrator's userId or sessionId, may send per UTC day. Counts are kept in
+// memory per replica and start over at midnight UTC.
+type UserQuota struct {
+	limit int // default daily queries per user; 0 means unlimited
+	clock Clock
+
+	mu     sync.Mutex
+	day    string
+	counts map[string]int // tenant ID and user ID to queries today
+}
+
+// NewUserQuota configures the default limit from USER_DAILY_QUOTA
+func NewUserQuota() *UserQuota {
+	q := &UserQuota{clock: systemClock{}, counts: make(map[string]int)}
+	if v := os.Getenv("USER_DAILY_QUOTA"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			q.limit = n
+		} else {
+			log.Printf("⚠️  Invalid USER_DAILY_QUOTA %q, not limiting users", v)
+		}
+	}
+	return q
+}
+
+// limitFor returns the daily limit for the tenant's users
+func (q *UserQuota) limitFor(t *Tenant) int {
+	if t != nil && t.UserDailyQuota != nil {
+		return *t.UserDailyQuota
+	}
+	return q.limit
+}
+
+// Take counts a query for the user, or returns a *UserQuotaError if their
+// quota for today is used up. Sends without a user ID aren't limited.
+func (q *UserQuota) Take(t *Tenant, userID string) error {
+	limit := q.limitFor(t)
+	if limit == 0 || userID == "" {
+		return nil
+	}
+	now := q.clock.Now().UTC()
+	day := now.Format("2006-01-02")
+	key := t.id() + "\x00" + userID
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if day != q.day {
+		q.day = day
+		q.counts = make(map[string]int)
+	}
+	if q.counts[key] >= limit {
+		return &UserQuotaError{
+			UserID:  userID,
+			Limit:   limit,
+			Used:    q.counts[key],
+			ResetAt: time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC),
+		}
+	}
+	q.counts[key]++
+	return nil
+}
+
+// endUserID identifies who a send is for: the userId param, or the session
+// when the integrator only supplies that
+func endUserID(params TaskSendParams) string {
+	if params.UserID != "" {
+		return params.UserID
+	}
+	return params.SessionID
+}
+
+// replyToUserQuota counts a send against its end user's daily quota and
+// reports whether it was refused, writing the error response if so
+func (a *MigrationAgent) replyToUserQuota(w http.ResponseWriter, req JSONRPCRequest, params TaskSendParams) bool {
+	err := a.userQuota.Take(req.tenant, endUserID(params))
+	if err == nil {
+		return false
+	}
+	if quotaErr, ok := err.(*UserQuotaError); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(quotaErr.ResetAt.Sub(a.clock.Now()).Seconds()))))
+	}
+	a.sendError(w, err, ErrCodeUserQuotaExceeded, errorMessages[ErrCodeUserQuotaExceeded], req.ID)
+	return true
+}
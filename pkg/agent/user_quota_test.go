@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUserQuotaLimitsEachUserPerDay(t *testing.T) {
+	t.Setenv("USER_DAILY_QUOTA", "2")
+	clock := &manualClock{now: time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)}
+	q := NewUserQuota()
+	q.clock = clock
+
+	for i := 0; i < 2; i++ {
+		if err := q.Take(nil, "alice"); err != nil {
+			t.Fatalf("query %d: %v", i+1, err)
+		}
+	}
+	err := q.Take(nil, "alice")
+	var quotaErr *UserQuotaError
+	if !errors.As(err, &quotaErr) {
+		t.Fatalf("third query = %v, want a *UserQuotaError", err)
+	}
+	want := UserQuotaError{UserID: "alice", Limit: 2, Used: 2, ResetAt: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)}
+	if *quotaErr != want {
+		t.Errorf("error = %+v, want %+v", *quotaErr, want)
+	}
+
+	if err := q.Take(nil, "bob"); err != nil {
+		t.Errorf("another user was refused: %v", err)
+	}
+	if err := q.Take(&Tenant{ID: "acme"}, "alice"); err != nil {
+		t.Errorf("the same user ID in another tenant was refused: %v", err)
+	}
+	if err := q.Take(nil, ""); err != nil {
+		t.Errorf("a send without a user ID was refused: %v", err)
+	}
+
+	clock.now = time.Date(2026, 3, 2, 0, 0, 1, 0, time.UTC)
+	if err := q.Take(nil, "alice"); err != nil {
+		t.Errorf("quota not reset at midnight UTC: %v", err)
+	}
+}
+
+func TestUserQuotaTenantOverride(t *testing.T) {
+	t.Setenv("USER_DAILY_QUOTA", "1")
+	q := NewUserQuota()
+	unlimited := 0
+	tenant := &Tenant{ID: "acme", UserDailyQuota: &unlimited}
+
+	for i := 0; i < 3; i++ {
+		if err := q.Take(tenant, "alice"); err != nil {
+			t.Fatalf("query %d for a tenant without a user quota: %v", i+1, err)
+		}
+	}
+}
+
+func TestUserQuotaRetryAfter(t *testing.T) {
+	t.Setenv("LLM_MODE", "mock")
+	t.Setenv("LLM_MOCK_LATENCY", "0s")
+	t.Setenv("USER_DAILY_QUOTA", "1")
+	clock := &manualClock{now: time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)}
+	a := NewMigrationAgentWithDeps(nil, nil, clock, nil)
+
+	send := func(id int) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"jsonrpc": "2.0", "id": %d, "method": "tasks/send", "params": {"userId": "alice", "message": {"role": "user", "parts": [{"kind": "text", "text": "Nurse from India wanting to move to UK"}]}}}`, id)
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/a2a/planner", strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := send(1); strings.Contains(rec.Body.String(), `"error"`) {
+		t.Fatalf("first send refused: %s", rec.Body)
+	}
+	rec := send(2)
+	if !strings.Contains(rec.Body.String(), fmt.Sprintf(`"code":%d`, ErrCodeUserQuotaExceeded)) {
+		t.Fatalf("second send = %s, want error %d", rec.Body, ErrCodeUserQuotaExceeded)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "3600" {
+		t.Errorf("Retry-After = %q, want 3600 seconds to midnight UTC", got)
+	}
+
+	clock.now = time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	if rec := send(3); strings.Contains(rec.Body.String(), `"error"`) {
+		t.Errorf("send after the reset refused: %s", rec.Body)
+	}
+}
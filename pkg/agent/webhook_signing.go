@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Headers carrying the signature of outgoing webhook bodies
+const (
+	SignatureHeader          = "X-Signature"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+)
+
+// webhookSecret returns the secret that signs webhooks for a tenant's
+// tasks: the tenant's own webhookSecret, else WEBHOOK_SIGNING_SECRET.
+// Without either, webhooks go out unsigned.
+func webhookSecret(tenantID string) string {
+	if t := lookupTenant(tenantID); t != nil && t.WebhookSecret != "" {
+		return t.WebhookSecret
+	}
+	return os.Getenv("WEBHOOK_SIGNING_SECRET")
+}
+
+// webhookSignature is the hex HMAC-SHA256 of "<timestamp>.<body>"
+func webhookSignature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signWebhook adds the signature headers to a webhook request. The
+// timestamp is signed with the body, so receivers can reject replays of an
+// old delivery.
+func signWebhook(req *http.Request, body []byte, secret string, now time.Time) {
+	if secret == "" {
+		return
+	}
+	timestamp := now.Unix()
+	req.Header.Set(SignatureTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, "v1="+webhookSignature(secret, timestamp, body))
+}
@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/a2aclient"
+)
+
+func TestSignWebhookRoundTrip(t *testing.T) {
+	body := []byte(`{"taskId":"task-1","status":{"state":"completed"}}`)
+	for _, tc := range []struct {
+		name     string
+		secret   string // signs the request; "" leaves it unsigned
+		verifier string // secret the receiver checks with
+		sentAt   time.Time
+		received []byte
+		want     error
+	}{
+		{"valid", "s3cret", "s3cret", time.Now(), body, nil},
+		{"tampered body", "s3cret", "s3cret", time.Now(), []byte(`{"taskId":"task-2","status":{"state":"completed"}}`), a2aclient.ErrSignatureMismatch},
+		{"wrong secret", "s3cret", "other", time.Now(), body, a2aclient.ErrSignatureMismatch},
+		{"expired timestamp", "s3cret", "s3cret", time.Now().Add(-10 * time.Minute), body, a2aclient.ErrSignatureExpired},
+		{"timestamp from the future", "s3cret", "s3cret", time.Now().Add(10 * time.Minute), body, a2aclient.ErrSignatureExpired},
+		{"unsigned", "", "s3cret", time.Now(), body, a2aclient.ErrSignatureMissing},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "https://example.com/hook", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signWebhook(req, body, tc.secret, tc.sentAt)
+
+			err = a2aclient.VerifyWebhook(tc.verifier, req.Header, tc.received, 0)
+			if !errors.Is(err, tc.want) {
+				t.Errorf("VerifyWebhook = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestWebhookSignatureCoversTimestamp(t *testing.T) {
+	body := []byte(`{}`)
+	if webhookSignature("s3cret", 1700000000, body) == webhookSignature("s3cret", 1700000001, body) {
+		t.Error("signatures of different timestamps match, so old deliveries could be replayed with a new timestamp")
+	}
+}
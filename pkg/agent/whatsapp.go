@@ -0,0 +1,388 @@
+package agent
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// whatsappAPIBase is the Graph API version used to send messages
+const whatsappAPIBase = "https://graph.facebook.com/v20.0"
+
+// whatsappTextLimit is the maximum length of a WhatsApp text message body
+const whatsappTextLimit = 4096
+
+// whatsappDedupeWindow is how long message IDs are remembered, so Meta's
+// redeliveries of a message are answered once
+const whatsappDedupeWindow = 24 * time.Hour
+
+// WhatsAppWebhook is the payload the Cloud API posts for incoming messages
+type WhatsAppWebhook struct {
+	Object string `json:"object"`
+	Entry  []struct {
+		Changes []struct {
+			Field string `json:"field"`
+			Value struct {
+				Metadata struct {
+					PhoneNumberID string `json:"phone_number_id"`
+				} `json:"metadata"`
+				Messages []WhatsAppMessage `json:"messages"`
+			} `json:"value"`
+		} `json:"changes"`
+	} `json:"entry"`
+}
+
+// WhatsAppMessage is one incoming user message
+type WhatsAppMessage struct {
+	ID   string `json:"id"`
+	From string `json:"from"` // sender's phone number (wa_id)
+	Type string `json:"type"`
+	Text struct {
+		Body string `json:"body"`
+	} `json:"text"`
+	Audio struct {
+		ID       string `json:"id"`
+		MimeType string `json:"mime_type"`
+	} `json:"audio"`
+}
+
+// ServeWhatsApp handles /integrations/whatsapp: GET completes Meta's webhook
+// verification and POST receives messages
+func (a *MigrationAgent) ServeWhatsApp(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		verifyWhatsAppWebhook(w, r)
+	case http.MethodPost:
+		a.handleWhatsAppWebhook(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// verifyWhatsAppWebhook echoes hub.challenge when the verify token matches
+func verifyWhatsAppWebhook(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	token := os.Getenv("WHATSAPP_VERIFY_TOKEN")
+	if token == "" || q.Get("hub.mode") != "subscribe" || !hmac.Equal([]byte(q.Get("hub.verify_token")), []byte(token)) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	w.Write([]byte(q.Get("hub.challenge")))
+}
+
+// handleWhatsAppWebhook verifies the payload signature, acknowledges it and
+// answers each text message in the background
+func (a *MigrationAgent) handleWhatsAppWebhook(w http.ResponseWriter, r *http.Request) {
+	secret := os.Getenv("WHATSAPP_APP_SECRET")
+	accessToken := os.Getenv("WHATSAPP_ACCESS_TOKEN")
+	if secret == "" || accessToken == "" {
+		writeJSONError(w, http.StatusServiceUnavailable, "WhatsApp integration disabled: WHATSAPP_APP_SECRET and WHATSAPP_ACCESS_TOKEN must be configured")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	if !validWhatsAppSignature(secret, r.Header.Get("X-Hub-Signature-256"), body) {
+		log.Printf("Rejected WhatsApp webhook: invalid signature")
+		writeJSONError(w, http.StatusUnauthorized, "invalid signature")
+		return
+	}
+
+	var hook WhatsAppWebhook
+	if err := json.Unmarshal(body, &hook); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid webhook payload")
+		return
+	}
+
+	// Meta retries webhooks that aren't acknowledged quickly
+	w.WriteHeader(http.StatusOK)
+
+	for _, entry := range hook.Entry {
+		for _, change := range entry.Changes {
+			phoneNumberID := change.Value.Metadata.PhoneNumberID
+			for _, msg := range change.Value.Messages {
+				isText := msg.Type == "text" && strings.TrimSpace(msg.Text.Body) != ""
+				isVoice := msg.Type == "audio" && msg.Audio.ID != ""
+				if !isText && !isVoice {
+					continue
+				}
+				if !a.whatsappSeen.first(msg.ID, a.clock.Now()) {
+					log.Printf("Skipping redelivered WhatsApp message %s", msg.ID)
+					continue
+				}
+				go a.answerWhatsApp(secret, accessToken, phoneNumberID, msg)
+			}
+		}
+	}
+}
+
+// validWhatsAppSignature checks the sha256 HMAC Meta computes over the body
+// with the app secret
+func validWhatsAppSignature(secret, header string, body []byte) bool {
+	signature := strings.TrimPrefix(header, "sha256=")
+	if signature == "" || signature == header {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// answerWhatsApp runs a user's query in the session for their phone number
+// and sends a brief recommendation back. Voice notes are downloaded and sent as
+// audio parts to be transcribed. The first contact from a number is greeted
+// with the configured template message.
+func (a *MigrationAgent) answerWhatsApp(secret, accessToken, phoneNumberID string, msg WhatsAppMessage) {
+	sessionID := whatsappSession(secret, msg.From)
+	sender := whatsappSender{accessToken: accessToken, phoneNumberID: phoneNumberID, to: msg.From}
+
+	// Greet first-time users; if their history can't be read, skip the greeting
+	if template := os.Getenv("WHATSAPP_WELCOME_TEMPLATE"); template != "" {
+		if previous, err := a.TasksForSession(sessionID); err == nil && len(previous) == 0 {
+			if err := sender.sendTemplate(template); err != nil {
+				log.Printf("Failed to send WhatsApp welcome template: %v", err)
+			}
+		}
+	}
+
+	message := Message{
+		Role:  "user",
+		Parts: []Part{{Kind: "text", Text: msg.Text.Body}},
+	}
+	if msg.Type == "audio" {
+		audio, err := sender.downloadMedia(msg.Audio.ID, a.uploads.maxSize)
+		if err != nil {
+			log.Printf("Failed to download WhatsApp voice note: %v", err)
+			if err := sender.sendText("Sorry, I couldn't receive your voice note. Please try again or type your question."); err != nil {
+				log.Printf("Failed to send WhatsApp reply: %v", err)
+			}
+			return
+		}
+		message.Parts = []Part{{Kind: "file", File: &FileContent{
+			Name:     "voice-note" + audioExtension(documentType("", msg.Audio.MimeType, audio)),
+			MimeType: msg.Audio.MimeType,
+			Bytes:    base64.StdEncoding.EncodeToString(audio),
+		}}}
+	}
+	task, err := a.ProcessTask(a.ids.NewID(), message, TaskOptions{SessionID: sessionID, Detail: DetailBrief})
+
+	reply := "Sorry, I couldn't generate migration pathways right now. Please try again later."
+	if err != nil {
+		log.Printf("WhatsApp query failed: %v", err)
+	} else if task.Status.Message != nil && len(task.Status.Message.Parts) > 0 {
+		reply = task.Status.Message.Parts[0].Text
+	}
+
+	for _, chunk := range splitMessage(whatsappFormat(reply), whatsappTextLimit) {
+		if err := sender.sendText(chunk); err != nil {
+			log.Printf("Failed to send WhatsApp reply: %v", err)
+			return
+		}
+	}
+}
+
+// whatsappSession names the session of a phone number after its HMAC with
+// the app secret, so the number itself never reaches task records, logs or
+// the audit log
+func whatsappSession(secret, waID string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(waID))
+	return "whatsapp:" + hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// seenMessages remembers the IDs of recent webhook messages for a window
+type seenMessages struct {
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[string]time.Time
+}
+
+func newSeenMessages(window time.Duration) *seenMessages {
+	return &seenMessages{window: window, seen: make(map[string]time.Time)}
+}
+
+// first records id as seen at now and reports whether it wasn't seen
+// within the window before. Messages without an ID are always new.
+func (s *seenMessages) first(id string, now time.Time) bool {
+	if id == "" {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for seenID, at := range s.seen {
+		if now.Sub(at) >= s.window {
+			delete(s.seen, seenID)
+		}
+	}
+	if _, ok := s.seen[id]; ok {
+		return false
+	}
+	s.seen[id] = now
+	return true
+}
+
+// whatsappFormat rewrites markdown into WhatsApp's formatting syntax
+func whatsappFormat(markdown string) string {
+	var lines []string
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			line = "*" + strings.TrimSpace(strings.TrimLeft(trimmed, "#")) + "*"
+		} else {
+			line = slackMrkdwn(line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitMessage breaks text into chunks of at most limit bytes, preferring to
+// split between lines and never splitting a character
+func splitMessage(text string, limit int) []string {
+	var chunks []string
+	for len(text) > limit {
+		cut := strings.LastIndex(text[:limit], "\n")
+		if cut <= 0 {
+			cut = limit
+			for cut > 0 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+			if cut == 0 {
+				_, cut = utf8.DecodeRuneInString(text)
+			}
+		}
+		chunks = append(chunks, strings.TrimSpace(text[:cut]))
+		text = text[cut:]
+	}
+	if text = strings.TrimSpace(text); text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
+
+// whatsappSender sends messages to one recipient through the Cloud API
+type whatsappSender struct {
+	accessToken   string
+	phoneNumberID string
+	to            string
+}
+
+func (s whatsappSender) sendText(body string) error {
+	return s.send(map[string]interface{}{
+		"type": "text",
+		"text": map[string]interface{}{"body": body, "preview_url": false},
+	})
+}
+
+// sendTemplate sends a pre-approved template, which WhatsApp requires for
+// messages outside a customer service window
+func (s whatsappSender) sendTemplate(name string) error {
+	language := os.Getenv("WHATSAPP_TEMPLATE_LANGUAGE")
+	if language == "" {
+		language = "en_US"
+	}
+	return s.send(map[string]interface{}{
+		"type": "template",
+		"template": map[string]interface{}{
+			"name":     name,
+			"language": map[string]string{"code": language},
+		},
+	})
+}
+
+// downloadMedia fetches an incoming media object, such as a voice note,
+// refusing files larger than limit bytes
+func (s whatsappSender) downloadMedia(mediaID string, limit int) ([]byte, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	get := func(url string) (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+s.accessToken)
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("WhatsApp API returned status %d", resp.StatusCode)
+		}
+		return resp, nil
+	}
+
+	resp, err := get(fmt.Sprintf("%s/%s", whatsappAPIBase, mediaID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up media %s: %v", mediaID, err)
+	}
+	var media struct {
+		URL string `json:"url"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&media)
+	resp.Body.Close()
+	if err != nil || media.URL == "" {
+		return nil, fmt.Errorf("no download URL for media %s", mediaID)
+	}
+
+	resp, err = get(media.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download media %s: %v", mediaID, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download media %s: %v", mediaID, err)
+	}
+	if len(data) > limit {
+		return nil, fmt.Errorf("media %s exceeds the %d byte upload limit", mediaID, limit)
+	}
+	return data, nil
+}
+
+func (s whatsappSender) send(message map[string]interface{}) error {
+	message["messaging_product"] = "whatsapp"
+	message["recipient_type"] = "individual"
+	message["to"] = s.to
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal WhatsApp message: %v", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/messages", whatsappAPIBase, s.phoneNumberID)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create WhatsApp request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.accessToken)
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send WhatsApp message: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("WhatsApp API returned status %d: %s", resp.StatusCode, string(detail))
+	}
+	return nil
+}
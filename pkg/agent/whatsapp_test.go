@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestSplitMessageKeepsCharactersWhole(t *testing.T) {
+	text := strings.Repeat("₦", 10) // 3 bytes each
+	chunks := splitMessage(text, 8)
+	if got := strings.Join(chunks, ""); got != text {
+		t.Fatalf("chunks rejoin to %q, want %q", got, text)
+	}
+	for _, chunk := range chunks {
+		if !utf8.ValidString(chunk) || len(chunk) > 8 {
+			t.Errorf("chunk %q is split mid-character or over the limit", chunk)
+		}
+	}
+}
+
+func TestWhatsAppSessionHidesNumber(t *testing.T) {
+	session := whatsappSession("secret", "2348012345678")
+	if strings.Contains(session, "2348012345678") {
+		t.Errorf("session %q contains the phone number", session)
+	}
+	if session != whatsappSession("secret", "2348012345678") {
+		t.Error("the same number got different sessions")
+	}
+	if session == whatsappSession("secret", "2348012345679") || session == whatsappSession("other", "2348012345678") {
+		t.Error("different numbers or keys share a session")
+	}
+}
+
+func TestSeenMessagesSkipsRedeliveries(t *testing.T) {
+	seen := newSeenMessages(time.Hour)
+	now := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if !seen.first("wamid.1", now) {
+		t.Fatal("first delivery reported as seen")
+	}
+	if seen.first("wamid.1", now.Add(time.Minute)) {
+		t.Error("redelivery within the window reported as new")
+	}
+	if !seen.first("wamid.1", now.Add(2*time.Hour)) {
+		t.Error("message still remembered after the window")
+	}
+}
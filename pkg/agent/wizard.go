@@ -0,0 +1,536 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/migration-pathways-agent/pkg/parser"
+)
+
+// ModeWizard is the send params mode that starts a guided wizard
+const ModeWizard = "wizard"
+
+// Wizard phases
+const (
+	WizardPhaseProfile = "profile" // confirming the profile one field at a time
+	WizardPhaseSteps   = "steps"   // walking through the application steps
+	WizardPhaseDone    = "done"
+)
+
+// Application step statuses
+const (
+	StepPending = "pending"
+	StepDone    = "done"
+	StepSkipped = "skipped"
+)
+
+// WizardField is a profile field the wizard confirms with the user
+type WizardField struct {
+	Name      string `json:"name"`
+	Value     string `json:"value,omitempty"`
+	Confirmed bool   `json:"confirmed"`
+}
+
+// WizardStep is one application step of the recommended plan
+type WizardStep struct {
+	Title  string `json:"title"`
+	Due    string `json:"due,omitempty"` // YYYY-MM-DD
+	Status string `json:"status"`        // one of the Step* statuses
+}
+
+// WizardState is a wizard's progress. It is saved with the task, so the
+// user can answer the next question whenever they come back.
+type WizardState struct {
+	Phase      string        `json:"phase"`
+	Current    int           `json:"current"` // index of the field or step being asked about
+	Fields     []WizardField `json:"fields"`
+	Pathway    string        `json:"pathway,omitempty"`
+	PlanTaskID string        `json:"planTaskId,omitempty"` // the task holding the recommendation
+	Steps      []WizardStep  `json:"steps,omitempty"`
+
+	// How the plan is written, from the params that started the wizard
+	OutputFormat string          `json:"outputFormat,omitempty"`
+	Locale       string          `json:"locale,omitempty"`
+	Detail       string          `json:"detail,omitempty"`
+	Tone         string          `json:"tone,omitempty"`
+	Sections     []string        `json:"sections,omitempty"`
+	Reminders    *ReminderParams `json:"reminders,omitempty"`
+	Model        string          `json:"model,omitempty"`
+	Language     string          `json:"language,omitempty"`
+}
+
+// wizardFields are the profile fields the wizard confirms, in order, with
+// the question asked when a field is still empty
+var wizardFields = []struct {
+	name     string
+	label    string
+	question string
+}{
+	{"destination", "destination", "Which country do you want to move to?"},
+	{"origin", "home country", "Which country are you applying from?"},
+	{"profession", "profession", "What is your profession?"},
+	{"education", "highest education", "What is your highest level of education (e.g. a bachelor's or master's degree)?"},
+	{"experienceYears", "years of work experience", "How many years of skilled work experience do you have?"},
+	{"english", "English level", "What is your English level? Give an IELTS band (e.g. IELTS 6.5) or a CLB level."},
+	{"budget", "budget", "What budget do you have for the move, in US dollars?"},
+}
+
+// Replies the wizard understands besides a field's value
+var (
+	wizardYes    = regexp.MustCompile(`(?i)^\s*(?:y|yes|yep|ok|okay|correct|confirm(?:ed)?|right|that's right)\s*[.!]*\s*$`)
+	wizardNo     = regexp.MustCompile(`(?i)^\s*(?:n|no|nope|wrong|incorrect)\s*[.!]*\s*$`)
+	wizardSkip   = regexp.MustCompile(`(?i)^\s*(?:skip|pass|not sure|don'?t know|n/a)\s*[.!]*\s*$`)
+	wizardBack   = regexp.MustCompile(`(?i)^\s*(?:back|go back|previous)\s*[.!]*\s*$`)
+	wizardDone   = regexp.MustCompile(`(?i)^\s*(?:done|finished|completed?|yes)\s*[.!]*\s*$`)
+	wizardLater  = regexp.MustCompile(`(?i)^\s*(?:later|pause|stop|not yet)\s*[.!]*\s*$`)
+	wizardYears  = regexp.MustCompile(`\d+`)
+	wizardBudget = regexp.MustCompile(`(?i)(\d[\d,]*)(?:\.\d+)?\s*(k)?`)
+)
+
+// newWizardState prefills the profile fields from the opening message
+func newWizardState(query string, profile parser.UserProfile, opts TaskOptions) *WizardState {
+	state := &WizardState{
+		Phase:        WizardPhaseProfile,
+		OutputFormat: opts.OutputFormat,
+		Locale:       opts.Locale,
+		Detail:       opts.Detail,
+		Tone:         opts.Tone,
+		Sections:     opts.Sections,
+		Reminders:    opts.Reminders,
+		Model:        opts.Model,
+		Language:     opts.Language,
+	}
+	for _, f := range wizardFields {
+		state.Fields = append(state.Fields, WizardField{Name: f.name})
+	}
+	specialist := routeSpecialist(query)
+	if specialist != generalist {
+		state.Fields[0].Value = specialist.Country
+	}
+	if locale, ok := originLocale(query); ok {
+		state.Fields[1].Value = titleWords(locale.Country)
+	}
+	state.Fields[2].Value = professionKeyword(query)
+	state.Fields[3].Value = profile.Education
+	if profile.Sources["experienceYears"] != "" {
+		state.Fields[4].Value = strconv.Itoa(profile.ExperienceYears)
+	}
+	if profile.CLB > 0 {
+		state.Fields[5].Value = fmt.Sprintf("CLB %d", profile.CLB)
+	}
+	if profile.Budget > 0 {
+		state.Fields[6].Value = strconv.Itoa(profile.Budget)
+	}
+	return state
+}
+
+// clone copies the state so a snapshot can be saved without the agent lock
+func (s *WizardState) clone() *WizardState {
+	if s == nil {
+		return nil
+	}
+	copied := *s
+	copied.Fields = append([]WizardField(nil), s.Fields...)
+	copied.Steps = append([]WizardStep(nil), s.Steps...)
+	return &copied
+}
+
+// parseFieldValue reads a field's value from a reply, reporting false when
+// the reply doesn't contain one
+func parseFieldValue(field, reply string) (string, bool) {
+	reply = strings.TrimSpace(reply)
+	switch field {
+	case "destination":
+		if specialist := routeSpecialist(reply); specialist != generalist {
+			return specialist.Country, true
+		}
+	case "origin":
+		if locale, ok := originLocale(reply); ok {
+			return titleWords(locale.Country), true
+		}
+		return reply, reply != ""
+	case "profession":
+		return reply, reply != ""
+	case "education":
+		if level := parser.EducationLevel(strings.ToLower(reply)); level != "" {
+			return level, true
+		}
+	case "experienceYears":
+		if m := wizardYears.FindString(reply); m != "" {
+			return m, true
+		}
+		if strings.Contains(strings.ToLower(reply), "none") {
+			return "0", true
+		}
+	case "english":
+		if in, _ := parser.ParseCRSInput(reply); in.CLB > 0 {
+			return fmt.Sprintf("CLB %d", in.CLB), true
+		}
+		if band, err := strconv.ParseFloat(reply, 64); err == nil && band > 0 && band <= 9 {
+			return fmt.Sprintf("CLB %d", parser.IELTSToCLB(band)), true
+		}
+	case "budget":
+		if m := wizardBudget.FindStringSubmatch(reply); m != nil {
+			budget, _ := strconv.Atoi(strings.ReplaceAll(m[1], ",", ""))
+			if m[2] != "" {
+				budget *= 1000
+			}
+			if budget > 0 {
+				return strconv.Itoa(budget), true
+			}
+		}
+	}
+	return "", false
+}
+
+// displayValue shows a field's value to the user
+func displayValue(field, value string) string {
+	switch field {
+	case "education":
+		if name, ok := educationNames[value]; ok {
+			return name
+		}
+	case "experienceYears":
+		if years, err := strconv.Atoi(value); err == nil {
+			return plural(years, "year")
+		}
+	case "budget":
+		if budget, err := strconv.Atoi(value); err == nil {
+			return formatFee(float64(budget), "USD")
+		}
+	}
+	return value
+}
+
+// question asks about the current field or step
+func (s *WizardState) question() string {
+	switch s.Phase {
+	case WizardPhaseProfile:
+		f := wizardFields[s.Current]
+		prefix := fmt.Sprintf("Profile %d of %d. ", s.Current+1, len(wizardFields))
+		if value := s.Fields[s.Current].Value; value != "" {
+			return prefix + fmt.Sprintf("I have your %s as **%s**. Reply \"yes\" to confirm, or tell me the correct value (\"skip\" to leave it out, \"back\" to change the previous answer).", f.label, displayValue(f.name, value))
+		}
+		return prefix + f.question + " (\"skip\" to leave it out, \"back\" to change the previous answer)"
+	case WizardPhaseSteps:
+		step := s.Steps[s.Current]
+		text := fmt.Sprintf("Step %d of %d: **%s**", s.Current+1, len(s.Steps), step.Title)
+		if step.Due != "" {
+			text += " (by " + step.Due + ")"
+		}
+		return text + ". Reply \"done\" when you have finished it, \"skip\" to move on, or \"later\" to pause; your progress is saved."
+	}
+	return ""
+}
+
+// planQuery describes the confirmed profile as a query for the
+// recommendation
+func (s *WizardState) planQuery() string {
+	values := make(map[string]string)
+	for _, f := range s.Fields {
+		values[f.Name] = displayValue(f.Name, f.Value)
+	}
+	var about []string
+	if values["profession"] != "" {
+		about = append(about, "I am a "+values["profession"])
+	} else {
+		about = append(about, "I")
+	}
+	if values["origin"] != "" {
+		about[0] += " from " + values["origin"]
+	}
+	var details []string
+	if values["education"] != "" {
+		details = append(details, values["education"])
+	}
+	if values["experienceYears"] != "" {
+		details = append(details, values["experienceYears"]+" of work experience")
+	}
+	if values["english"] != "" {
+		details = append(details, "English at "+values["english"])
+	}
+	if values["budget"] != "" {
+		details = append(details, "a budget of "+values["budget"])
+	}
+	query := about[0]
+	if len(details) > 0 {
+		query += " with " + joinList(details)
+	}
+	if values["destination"] != "" {
+		return query + ". I want to move to " + values["destination"] + "."
+	}
+	return query + ". Which country and visa suit me best?"
+}
+
+// startWizard creates a wizard task from the opening message and asks the
+// first profile question
+func (a *MigrationAgent) startWizard(taskID string, message Message, opts TaskOptions) (*Task, error) {
+	task := &Task{
+		ID:        taskID,
+		SessionID: opts.SessionID,
+		Kind:      "task",
+		Metadata:  mergeMetadata(opts.Metadata, message.Metadata),
+
+		PushNotification: opts.Push,
+		CreatedAt:        a.clock.Now(),
+		tenant:           opts.Tenant,
+	}
+	a.mu.Lock()
+	task.SetStatus(TaskStateSubmitted, nil, a.clock.Now())
+	a.tasks.Put(task)
+	a.mu.Unlock()
+	a.updateStatus(task, TaskStateWorking, nil)
+
+	query, _ := redactPII(strings.TrimSpace(messageText(message)))
+	messageID := a.ids.NewID()
+	if violation := a.compliance.Check(query); violation != nil {
+		a.compliance.LogViolation(taskID, violation, query)
+		a.rejectTask(task, messageID, violation)
+		go a.notifyPush(task)
+		return task, nil
+	}
+
+	state := newWizardState(query, a.parseProfile(query), opts)
+	a.mu.Lock()
+	task.Wizard = state
+	text := "Let's build your migration plan step by step. First I'll confirm your profile.\n\n" + state.question()
+	a.mu.Unlock()
+	a.updateStatus(task, TaskStateInputRequired, agentMessage(taskID, messageID, Part{Kind: "text", Text: text}))
+	go a.notifyPush(task)
+	return task, nil
+}
+
+// sendTask starts a wizard or submits a task, as the send asks
+func (a *MigrationAgent) sendTask(taskID string, message Message, opts TaskOptions) (*Task, error) {
+	if opts.Wizard {
+		return a.startWizard(taskID, message, opts)
+	}
+	return a.submitTask(taskID, message, opts)
+}
+
+// replyToWizard continues a wizard when a send targets a wizard task and
+// reports whether a response was written. A wizard that is busy or
+// finished is returned as it is.
+func (a *MigrationAgent) replyToWizard(w http.ResponseWriter, req JSONRPCRequest, taskID string, message Message) bool {
+	existing, ok := a.lookupTask(taskID)
+	if !ok {
+		return false
+	}
+	a.mu.Lock()
+	if existing.Wizard == nil || existing.tenant != req.tenant.id() {
+		a.mu.Unlock()
+		return false
+	}
+	if existing.Status.State != TaskStateInputRequired {
+		a.sendSuccess(w, a.files.Resign(existing), req.ID)
+		a.mu.Unlock()
+		return true
+	}
+	// Claim the wizard under the lock so two replies can't both answer
+	// the same question
+	err := existing.SetStatus(TaskStateWorking, nil, a.clock.Now())
+	status := existing.Status
+	a.mu.Unlock()
+	if err != nil {
+		a.sendRPCError(w, err, ErrCodeInternal, req.ID)
+		return true
+	}
+	a.saveTask(existing)
+	a.events.Publish(taskID, statusEvent(taskID, status))
+
+	a.continueWizard(existing, messageText(message))
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	a.sendSuccess(w, a.files.Resign(existing), req.ID)
+	return true
+}
+
+// continueWizard applies the user's reply to a claimed wizard task and
+// asks the next question, generating the plan once the profile is
+// confirmed
+func (a *MigrationAgent) continueWizard(task *Task, reply string) {
+	reply, _ = redactPII(strings.TrimSpace(reply))
+	messageID := a.ids.NewID()
+
+	a.mu.Lock()
+	state := task.Wizard
+	var text string
+	switch state.Phase {
+	case WizardPhaseProfile:
+		text = state.answerField(reply)
+	case WizardPhaseSteps:
+		text = state.answerStep(reply)
+	}
+	next := TaskStateInputRequired
+	if state.Phase == WizardPhaseDone {
+		next = TaskStateCompleted
+	}
+	generate := state.Phase == WizardPhaseProfile && state.Current == len(state.Fields)
+	a.mu.Unlock()
+
+	if generate {
+		text, next = a.wizardPlan(task)
+	}
+	a.updateStatus(task, next, agentMessage(task.ID, messageID, Part{Kind: "text", Text: text}))
+	a.syncPlanSteps(task)
+	go a.notifyPush(task)
+}
+
+// answerField applies a reply to the current profile field and returns the
+// next question. Once every field is confirmed, Current is past the last
+// field and the plan can be generated.
+func (s *WizardState) answerField(reply string) string {
+	if s.Current == len(s.Fields) {
+		// A retry after the plan couldn't be generated
+		return ""
+	}
+	field := &s.Fields[s.Current]
+	switch {
+	case wizardBack.MatchString(reply):
+		if s.Current > 0 {
+			s.Current--
+			s.Fields[s.Current].Confirmed = false
+		}
+		return s.question()
+	case wizardSkip.MatchString(reply):
+		field.Value, field.Confirmed = "", true
+	case wizardYes.MatchString(reply) && field.Value != "":
+		field.Confirmed = true
+	case wizardNo.MatchString(reply):
+		field.Value = ""
+		return s.question()
+	default:
+		value, ok := parseFieldValue(field.Name, reply)
+		if !ok {
+			return "Sorry, I didn't catch that. " + s.question()
+		}
+		field.Value, field.Confirmed = value, true
+	}
+	s.Current++
+	if s.Current < len(s.Fields) {
+		return s.question()
+	}
+	return ""
+}
+
+// answerStep records the user's progress on the current application step
+// and returns the next question, or the closing message once every step
+// is done or skipped
+func (s *WizardState) answerStep(reply string) string {
+	switch {
+	case wizardBack.MatchString(reply):
+		if s.Current > 0 {
+			s.Current--
+			s.Steps[s.Current].Status = StepPending
+		}
+		return s.question()
+	case wizardLater.MatchString(reply):
+		return "Your progress is saved. Send any message to this task when you're ready to continue.\n\n" + s.question()
+	case wizardSkip.MatchString(reply):
+		s.Steps[s.Current].Status = StepSkipped
+	case wizardDone.MatchString(reply):
+		s.Steps[s.Current].Status = StepDone
+	default:
+		return "Reply \"done\", \"skip\" or \"later\". " + s.question()
+	}
+	s.Current++
+	if s.Current < len(s.Steps) {
+		return s.question()
+	}
+
+	s.Phase = WizardPhaseDone
+	done := 0
+	for _, step := range s.Steps {
+		if step.Status == StepDone {
+			done++
+		}
+	}
+	return fmt.Sprintf("🎉 You have worked through every step of your %s plan (%d of %d done). Good luck with your application!", s.Pathway, done, len(s.Steps))
+}
+
+// wizardPlan generates the recommendation for the confirmed profile as
+// its own task, copies its artifacts onto the wizard task and lists its
+// milestones as the application steps. It returns the message to send and
+// the wizard task's next state. When the plan couldn't be generated the
+// wizard stays on the profile so the user can retry.
+func (a *MigrationAgent) wizardPlan(task *Task) (string, TaskState) {
+	a.mu.RLock()
+	state := task.Wizard
+	query := state.planQuery()
+	opts := TaskOptions{
+		SessionID:    task.SessionID,
+		OutputFormat: state.OutputFormat,
+		Reminders:    state.Reminders,
+		Metadata:     task.Metadata,
+		Locale:       state.Locale,
+		Detail:       state.Detail,
+		Tone:         state.Tone,
+		Sections:     state.Sections,
+		Tenant:       task.tenant,
+		Model:        state.Model,
+		Language:     state.Language,
+	}
+	a.mu.RUnlock()
+
+	planID := a.ids.NewID()
+	log.Printf("Wizard %s is generating its plan as task %s", task.ID, planID)
+	plan, err := a.ProcessTask(planID, Message{Role: "user", Parts: []Part{{Kind: "text", Text: query}}}, opts)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	markdown := ""
+	if plan != nil && plan.Status.Message != nil && len(plan.Status.Message.Parts) > 0 {
+		markdown = plan.Status.Message.Parts[0].Text
+	}
+	switch {
+	case plan != nil && plan.Status.State == TaskStateRejected:
+		return markdown, TaskStateRejected
+	case plan == nil || plan.Status.State != TaskStateCompleted:
+		log.Printf("Wizard %s could not generate its plan: %v", task.ID, err)
+		return fmt.Sprintf("I couldn't prepare your plan just now (%s). Reply \"yes\" to try again.", classifyFailure(err).Message), TaskStateInputRequired
+	}
+
+	state.PlanTaskID = planID
+	state.Pathway = pathwayName(markdown)
+	state.Steps = nil
+	for _, m := range plan.milestones {
+		state.Steps = append(state.Steps, WizardStep{Title: m.Title, Due: m.Date.Format("2006-01-02"), Status: StepPending})
+	}
+	if len(state.Steps) == 0 {
+		if next, _ := answerData(markdown, nil)["nextStep"].(string); next != "" {
+			state.Steps = []WizardStep{{Title: next, Status: StepPending}}
+		}
+	}
+	task.Artifacts = versionArtifacts(task.Artifacts, plan.Artifacts)
+
+	if len(state.Steps) == 0 {
+		state.Phase = WizardPhaseDone
+		return markdown, TaskStateCompleted
+	}
+	state.Phase, state.Current = WizardPhaseSteps, 0
+	return markdown + "\n\n---\n\nNow let's work through your application.\n\n" + state.question(), TaskStateInputRequired
+}
+
+// messageText joins the text parts of a message
+func messageText(message Message) string {
+	var text []string
+	for _, part := range message.Parts {
+		if part.Kind == "text" {
+			text = append(text, part.Text)
+		}
+	}
+	return strings.Join(text, " ")
+}
+
+// titleWords capitalizes each word, e.g. "south africa" as "South Africa"
+func titleWords(s string) string {
+	words := strings.Fields(s)
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,88 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors a model call fails with; wrap them with %w to add detail
+var (
+	ErrResponse      = errors.New("unreadable LLM response")
+	ErrUnavailable   = errors.New("LLM unavailable")
+	ErrQuotaExceeded = errors.New("LLM quota exceeded")
+	ErrSafetyBlocked = errors.New("blocked by LLM safety filters")
+)
+
+// defaultQuotaRetry is how long to wait for a quota that didn't say when it
+// resets, e.g. a plain rate limit
+const defaultQuotaRetry = time.Minute
+
+// QuotaError is a Gemini quota or rate-limit error, with the time the quota
+// is expected back
+type QuotaError struct {
+	RetryAt time.Time
+	detail  string
+}
+
+func (e *QuotaError) Error() string {
+	return ErrQuotaExceeded.Error() + ": " + e.detail
+}
+
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// newQuotaError reads when a 429 response's quota resets. A daily quota
+// resets at midnight Pacific time; otherwise Gemini's RetryInfo or the
+// Retry-After header says how long to wait.
+func newQuotaError(resp *http.Response, body []byte, now time.Time) *QuotaError {
+	qe := &QuotaError{
+		RetryAt: now.Add(defaultQuotaRetry),
+		detail:  fmt.Sprintf("API error (status %d): %s", resp.StatusCode, string(body)),
+	}
+
+	var parsed struct {
+		Error struct {
+			Details []struct {
+				RetryDelay string `json:"retryDelay"`
+				Violations []struct {
+					QuotaID string `json:"quotaId"`
+				} `json:"violations"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	json.Unmarshal(body, &parsed)
+	for _, detail := range parsed.Error.Details {
+		for _, violation := range detail.Violations {
+			if strings.Contains(violation.QuotaID, "PerDay") {
+				qe.RetryAt = nextPacificMidnight(now)
+				return qe
+			}
+		}
+	}
+	for _, detail := range parsed.Error.Details {
+		if d, err := time.ParseDuration(detail.RetryDelay); err == nil && d > 0 {
+			qe.RetryAt = now.Add(d)
+			return qe
+		}
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		qe.RetryAt = now.Add(time.Duration(seconds) * time.Second)
+	}
+	return qe
+}
+
+// nextPacificMidnight is when Gemini's daily quotas next reset
+func nextPacificMidnight(now time.Time) time.Time {
+	pacific, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		pacific = time.FixedZone("PST", -8*60*60)
+	}
+	local := now.In(pacific)
+	return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, pacific)
+}
@@ -0,0 +1,578 @@
+// Package llm is the client for the model that writes recommendations:
+// the Gemini API, canned answers for load tests (LLM_MODE=mock), or a
+// Provider supplied by an embedder.
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GeminiClient handles communication with Gemini API
+type GeminiClient struct {
+	APIKey  string
+	BaseURL string
+	Model   string
+
+	Mode        string        // LLM_MODE: ModeGemini, ModeMock or ModeOff
+	MockLatency time.Duration // average latency of mock answers
+	MaxRetries  int           // extra attempts when Gemini can't be reached
+
+	// HTTPClient is shared by every copy of the client, so they all reuse
+	// the same kept-alive connections
+	HTTPClient *http.Client
+	Conns      *ConnStats
+
+	// onText, when set, receives the answer generated so far as Gemini
+	// streams it
+	onText func(text string)
+
+	// functions are the Go functions Gemini may call while generating
+	functions []GeminiFunction
+
+	// before and after, when set, see every prompt and every response
+	before func(prompt string) (string, error)
+	after  func(prompt, response string, usage TokenUsage) (string, error)
+
+	// Provider, when set, answers in place of the Gemini API
+	Provider Provider
+
+	// Now tells the time, e.g. when a quota resets; it defaults to the wall
+	// clock
+	Now func() time.Time
+}
+
+// Streaming returns a copy of the client that streams text answers,
+// passing the text generated so far to onText as it grows
+func (gc *GeminiClient) Streaming(onText func(text string)) *GeminiClient {
+	c := *gc
+	c.onText = onText
+	return &c
+}
+
+// WithFunctions returns a copy of the client that lets Gemini call fns
+// while generating
+func (gc *GeminiClient) WithFunctions(fns ...GeminiFunction) *GeminiClient {
+	c := *gc
+	c.functions = fns
+	return &c
+}
+
+// WithModel returns a copy of the client that generates with model
+func (gc *GeminiClient) WithModel(model string) *GeminiClient {
+	c := *gc
+	c.Model = model
+	return &c
+}
+
+// Intercepted returns a copy of the client that passes every prompt through
+// before and every response through after, e.g. to enrich or moderate them.
+// Either may be nil. An error from either fails the call without a retry.
+// Streamed text reaches onText before after sees the whole response.
+func (gc *GeminiClient) Intercepted(before func(prompt string) (string, error), after func(prompt, response string, usage TokenUsage) (string, error)) *GeminiClient {
+	c := *gc
+	c.before, c.after = before, after
+	return &c
+}
+
+// streams reports whether a call with config should be streamed. JSON
+// answers are only useful once complete, so they never are.
+func (gc *GeminiClient) streams(config *GeminiGenerationConfig) bool {
+	return gc.onText != nil && (config == nil || config.ResponseMimeType != "application/json")
+}
+
+// NewGeminiClient creates a new Gemini API client
+func NewGeminiClient() *GeminiClient {
+	// Attempt to load values from a local .env file (if present). Values
+	// already set in the environment are not overwritten.
+	loadDotEnv()
+
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		// Try common alternative env var names
+		apiKey = os.Getenv("GOOGLE_API_KEY")
+	}
+
+	model := os.Getenv("GEMINI_MODEL")
+	if model == "" {
+		model = "gemini-2.0-flash-exp" // Latest Gemini model
+	}
+
+	return &GeminiClient{
+		APIKey:      apiKey,
+		BaseURL:     "https://generativelanguage.googleapis.com/v1beta",
+		Model:       model,
+		Mode:        mode(),
+		MockLatency: mockLatency(),
+		MaxRetries:  maxRetries(),
+		HTTPClient:  newGeminiHTTPClient(),
+		Conns:       &ConnStats{},
+		Now:         time.Now,
+	}
+}
+
+// maxRetries reads GEMINI_MAX_RETRIES (default 2)
+func maxRetries() int {
+	retries := 2
+	if v := os.Getenv("GEMINI_MAX_RETRIES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			retries = n
+		} else {
+			log.Printf("⚠️  Invalid GEMINI_MAX_RETRIES %q, using %d", v, retries)
+		}
+	}
+	return retries
+}
+
+// loadDotEnv reads a .env file from the current working directory and sets
+// any variables that are not already present in the environment. The parser
+// is minimal: lines starting with # are ignored; blank lines are skipped;
+// KEY=VALUE pairs are supported (values may be quoted).
+func loadDotEnv() {
+	// If env already contains GEMINI_API_KEY we can skip entirely.
+	if os.Getenv("GEMINI_API_KEY") != "" || os.Getenv("GOOGLE_API_KEY") != "" {
+		return
+	}
+
+	f, err := os.Open(".env")
+	if err != nil {
+		// no .env present — nothing to do
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Split on first '='
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		val := strings.TrimSpace(parts[1])
+
+		// Remove surrounding quotes if present
+		if len(val) >= 2 && ((val[0] == '"' && val[len(val)-1] == '"') || (val[0] == '\'' && val[len(val)-1] == '\'')) {
+			val = val[1 : len(val)-1]
+		}
+
+		if key == "" || val == "" {
+			continue
+		}
+
+		// Only set if not already present
+		if os.Getenv(key) == "" {
+			os.Setenv(key, val)
+		}
+	}
+	// ignore scanner.Err() errors silently — .env is optional
+}
+
+// GeminiRequest represents a request to Gemini API
+type GeminiRequest struct {
+	Contents         []GeminiContent         `json:"contents"`
+	GenerationConfig *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+	Tools            []GeminiTool            `json:"tools,omitempty"`
+	ToolConfig       *GeminiToolConfig       `json:"toolConfig,omitempty"`
+}
+
+// GeminiTool is a tool Gemini may use while generating
+type GeminiTool struct {
+	GoogleSearch         *struct{}                   `json:"google_search,omitempty"`
+	FunctionDeclarations []GeminiFunctionDeclaration `json:"functionDeclarations,omitempty"`
+}
+
+// GeminiToolConfig restricts how Gemini uses the tools; mode NONE stops it
+// calling functions
+type GeminiToolConfig struct {
+	FunctionCallingConfig struct {
+		Mode string `json:"mode"`
+	} `json:"functionCallingConfig"`
+}
+
+// GeminiFunctionDeclaration describes a function Gemini may call
+type GeminiFunctionDeclaration struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Parameters  *GeminiSchema `json:"parameters,omitempty"`
+}
+
+// GeminiSchema is the OpenAPI subset Gemini uses to describe function
+// parameters
+type GeminiSchema struct {
+	Type        string                  `json:"type"` // OBJECT, STRING, ...
+	Description string                  `json:"description,omitempty"`
+	Properties  map[string]GeminiSchema `json:"properties,omitempty"`
+	Required    []string                `json:"required,omitempty"`
+}
+
+// GeminiFunction is a function Gemini may call: its declaration, sent with
+// the request, and the Go code that answers the call. Call returns the
+// JSON object given back to Gemini.
+type GeminiFunction struct {
+	Declaration GeminiFunctionDeclaration
+	Call        func(args map[string]interface{}) map[string]interface{}
+}
+
+// maxFunctionRounds caps how many rounds of function calls a generation
+// may make before Gemini has to answer with what it has
+const maxFunctionRounds = 3
+
+// GeminiGenerationConfig tunes how Gemini generates a response
+type GeminiGenerationConfig struct {
+	MaxOutputTokens  int    `json:"maxOutputTokens,omitempty"`
+	ResponseMimeType string `json:"responseMimeType,omitempty"` // e.g. application/json
+}
+
+// GeminiContent represents content in a Gemini request
+type GeminiContent struct {
+	Role  string       `json:"role,omitempty"` // user or model
+	Parts []GeminiPart `json:"parts"`
+}
+
+// GeminiPart represents a part of content: text, inline media, or a
+// function call and its result
+type GeminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	InlineData       *GeminiInlineData       `json:"inlineData,omitempty"`
+	FunctionCall     *GeminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *GeminiFunctionResponse `json:"functionResponse,omitempty"`
+	ThoughtSignature string                  `json:"thoughtSignature,omitempty"` // sent back with the call it came with
+}
+
+// GeminiFunctionCall is Gemini asking for a function to be called
+type GeminiFunctionCall struct {
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// GeminiFunctionResponse is a function's result, returned to Gemini
+type GeminiFunctionResponse struct {
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+// GeminiInlineData is base64-encoded media sent alongside a prompt
+type GeminiInlineData struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+// GeminiResponse represents a response from Gemini API
+type GeminiResponse struct {
+	Candidates []struct {
+		Content      GeminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// safetyFinishReasons are the finish reasons Gemini gives when its safety
+// or policy filters cut an answer off
+var safetyFinishReasons = map[string]bool{
+	"SAFETY":             true,
+	"RECITATION":         true,
+	"BLOCKLIST":          true,
+	"PROHIBITED_CONTENT": true,
+	"SPII":               true,
+}
+
+// TokenUsage counts the tokens a Gemini call consumed
+type TokenUsage struct {
+	PromptTokens int `json:"promptTokens"`
+	OutputTokens int `json:"outputTokens"`
+}
+
+// Generate sends a prompt to Gemini and returns the generated text
+func (gc *GeminiClient) Generate(prompt string) (string, error) {
+	return gc.GenerateWithMedia(prompt, nil)
+}
+
+// GenerateWithMedia sends a prompt together with images or other media for
+// Gemini's multimodal input and returns the generated text
+func (gc *GeminiClient) GenerateWithMedia(prompt string, media []GeminiInlineData) (string, error) {
+	return gc.GenerateWithConfig(prompt, media, nil)
+}
+
+// GenerateWithConfig sends a prompt and optional media with generation
+// settings such as an output token limit
+func (gc *GeminiClient) GenerateWithConfig(prompt string, media []GeminiInlineData, config *GeminiGenerationConfig) (string, error) {
+	return gc.GenerateWithTools(prompt, media, config, nil)
+}
+
+// GenerateWithTools is GenerateWithConfig with tools, such as Google Search
+// grounding, that Gemini may use
+func (gc *GeminiClient) GenerateWithTools(prompt string, media []GeminiInlineData, config *GeminiGenerationConfig, tools []GeminiTool) (string, error) {
+	text, _, err := gc.GenerateWithUsage(prompt, media, config, tools)
+	return text, err
+}
+
+// GenerateWithUsage is GenerateWithTools that also reports the tokens used.
+// When Gemini can't be reached or returns an error, the call is retried up
+// to MaxRetries times with exponential backoff, unless part of a streamed
+// answer was already passed on.
+func (gc *GeminiClient) GenerateWithUsage(prompt string, media []GeminiInlineData, config *GeminiGenerationConfig, tools []GeminiTool) (string, TokenUsage, error) {
+	if gc.Mode == ModeOff {
+		return "", TokenUsage{}, ErrOff
+	}
+
+	if gc.before != nil {
+		var err error
+		if prompt, err = gc.before(prompt); err != nil {
+			return "", TokenUsage{}, err
+		}
+	}
+	text, usage, err := gc.generateWithRetries(prompt, media, config, tools)
+	if err == nil && gc.after != nil {
+		text, err = gc.after(prompt, text, usage)
+	}
+	return text, usage, err
+}
+
+// generateWithRetries makes a call, retrying it as GenerateWithUsage
+// describes
+func (gc *GeminiClient) generateWithRetries(prompt string, media []GeminiInlineData, config *GeminiGenerationConfig, tools []GeminiTool) (string, TokenUsage, error) {
+	client, delivered := gc, false
+	if gc.onText != nil {
+		client = gc.Streaming(func(text string) {
+			delivered = true
+			gc.onText(text)
+		})
+	}
+
+	for attempt := 0; ; attempt++ {
+		text, usage, err := client.generate(prompt, media, config, tools)
+		if err == nil || !errors.Is(err, ErrUnavailable) || attempt >= gc.MaxRetries || delivered || gc.APIKey == "" {
+			return text, usage, err
+		}
+		backoff := time.Second << attempt
+		log.Printf("⚠️  Gemini call failed, retrying in %s: %v", backoff, err)
+		time.Sleep(backoff)
+	}
+}
+
+// generate makes one Gemini call, plus one more for each round of
+// function calls Gemini makes, up to maxFunctionRounds
+func (gc *GeminiClient) generate(prompt string, media []GeminiInlineData, config *GeminiGenerationConfig, tools []GeminiTool) (string, TokenUsage, error) {
+	var usage TokenUsage
+	if gc.Provider != nil {
+		text, usage, err := gc.Provider.GenerateWithUsage(prompt, media, config, tools)
+		if err == nil && gc.streams(config) {
+			gc.onText(text)
+		}
+		return text, usage, err
+	}
+	if gc.Mode == ModeMock {
+		text, usage := gc.generateMock(prompt, config)
+		return text, usage, nil
+	}
+	if gc.APIKey == "" {
+		return "", usage, fmt.Errorf("%w: GEMINI_API_KEY environment variable not set", ErrUnavailable)
+	}
+
+	// Create request
+	parts := []GeminiPart{{Text: prompt}}
+	for i := range media {
+		parts = append(parts, GeminiPart{InlineData: &media[i]})
+	}
+	reqBody := GeminiRequest{
+		Contents: []GeminiContent{
+			{
+				Role:  "user",
+				Parts: parts,
+			},
+		},
+		GenerationConfig: config,
+		Tools:            tools,
+	}
+	if len(gc.functions) > 0 {
+		declarations := make([]GeminiFunctionDeclaration, len(gc.functions))
+		for i, fn := range gc.functions {
+			declarations[i] = fn.Declaration
+		}
+		reqBody.Tools = append(append([]GeminiTool{}, tools...), GeminiTool{FunctionDeclarations: declarations})
+	}
+
+	for round := 0; ; round++ {
+		if round == maxFunctionRounds {
+			reqBody.ToolConfig = &GeminiToolConfig{}
+			reqBody.ToolConfig.FunctionCallingConfig.Mode = "NONE"
+		}
+		geminiResp, err := gc.send(reqBody, config)
+		if err != nil {
+			return "", usage, err
+		}
+		usage.PromptTokens += geminiResp.UsageMetadata.PromptTokenCount
+		usage.OutputTokens += geminiResp.UsageMetadata.CandidatesTokenCount
+
+		// A blocked prompt has no candidates; a blocked answer stops early
+		if reason := geminiResp.PromptFeedback.BlockReason; reason != "" {
+			return "", usage, fmt.Errorf("%w: prompt blocked (%s)", ErrSafetyBlocked, reason)
+		}
+		if len(geminiResp.Candidates) > 0 && safetyFinishReasons[geminiResp.Candidates[0].FinishReason] {
+			return "", usage, fmt.Errorf("%w: answer stopped (%s)", ErrSafetyBlocked, geminiResp.Candidates[0].FinishReason)
+		}
+
+		// Extract text from response
+		if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
+			return "", usage, fmt.Errorf("%w: no response generated from API", ErrResponse)
+		}
+		answer := geminiResp.Candidates[0].Content.Parts
+		if results := gc.callFunctions(answer); len(results) > 0 {
+			reqBody.Contents = append(reqBody.Contents,
+				GeminiContent{Role: "model", Parts: answer},
+				GeminiContent{Role: "user", Parts: results})
+			continue
+		}
+
+		// Grounded answers can arrive split over several parts
+		var text strings.Builder
+		for _, part := range answer {
+			text.WriteString(part.Text)
+		}
+		return text.String(), usage, nil
+	}
+}
+
+// send posts one request to Gemini and reads its response, streamed when
+// someone is following the answer
+func (gc *GeminiClient) send(reqBody GeminiRequest, config *GeminiGenerationConfig) (GeminiResponse, error) {
+	var geminiResp GeminiResponse
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return geminiResp, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	// Make API request, streaming the answer when someone is following it.
+	// The key goes in a header, so errors quoting the URL can't leak it.
+	stream := gc.streams(config)
+	url := fmt.Sprintf("%s/models/%s:generateContent", gc.BaseURL, gc.Model)
+	if stream {
+		url = fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse", gc.BaseURL, gc.Model)
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(jsonData))
+	if err != nil {
+		return geminiResp, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", gc.APIKey)
+	resp, err := gc.HTTPClient.Do(gc.Conns.trace(req))
+	if err != nil {
+		return geminiResp, fmt.Errorf("%w: failed to make API request: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	// Check for HTTP errors
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return geminiResp, newQuotaError(resp, body, gc.Now())
+		}
+		return geminiResp, fmt.Errorf("%w: API error (status %d): %s", ErrUnavailable, resp.StatusCode, string(body))
+	}
+
+	// Read and parse response
+	if stream {
+		return gc.readStream(resp.Body)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return geminiResp, fmt.Errorf("%w: failed to read response: %v", ErrUnavailable, err)
+	}
+	if err := json.Unmarshal(body, &geminiResp); err != nil {
+		return geminiResp, fmt.Errorf("%w: failed to parse response: %v", ErrResponse, err)
+	}
+	return geminiResp, nil
+}
+
+// callFunctions runs the function calls among an answer's parts and
+// returns their results, or nil when Gemini called none. A call to an
+// unknown function is answered with an error so Gemini can carry on
+// without it.
+func (gc *GeminiClient) callFunctions(parts []GeminiPart) []GeminiPart {
+	var results []GeminiPart
+	for _, part := range parts {
+		if part.FunctionCall == nil {
+			continue
+		}
+		call := part.FunctionCall
+		response := map[string]interface{}{"error": "unknown function " + call.Name}
+		for _, fn := range gc.functions {
+			if fn.Declaration.Name == call.Name {
+				response = fn.Call(call.Args)
+				break
+			}
+		}
+		log.Printf("🔧 Gemini called %s", call.Name)
+		results = append(results, GeminiPart{FunctionResponse: &GeminiFunctionResponse{Name: call.Name, Response: response}})
+	}
+	return results
+}
+
+// readStream reads a streamed answer, a server-sent event per chunk, passing
+// the text received so far to onText after each. The chunks are merged into
+// one response; usage and finish reason come from the last chunk that has
+// them.
+func (gc *GeminiClient) readStream(body io.Reader) (GeminiResponse, error) {
+	var merged GeminiResponse
+	var text strings.Builder
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		var chunk GeminiResponse
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &chunk); err != nil {
+			return merged, fmt.Errorf("%w: failed to parse response chunk: %v", ErrResponse, err)
+		}
+
+		if chunk.PromptFeedback.BlockReason != "" {
+			merged.PromptFeedback = chunk.PromptFeedback
+		}
+		if chunk.UsageMetadata.CandidatesTokenCount > 0 || chunk.UsageMetadata.PromptTokenCount > 0 {
+			merged.UsageMetadata = chunk.UsageMetadata
+		}
+		if len(chunk.Candidates) == 0 {
+			continue
+		}
+		if len(merged.Candidates) == 0 {
+			merged.Candidates = chunk.Candidates[:1]
+		} else {
+			merged.Candidates[0].Content.Parts = append(merged.Candidates[0].Content.Parts, chunk.Candidates[0].Content.Parts...)
+			if reason := chunk.Candidates[0].FinishReason; reason != "" {
+				merged.Candidates[0].FinishReason = reason
+			}
+		}
+		for _, part := range chunk.Candidates[0].Content.Parts {
+			text.WriteString(part.Text)
+		}
+		gc.onText(text.String())
+	}
+	if err := scanner.Err(); err != nil {
+		return merged, fmt.Errorf("%w: failed to read response: %v", ErrUnavailable, err)
+	}
+	return merged, nil
+}
@@ -0,0 +1,39 @@
+package llm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGeminiSendsAPIKeyInHeader(t *testing.T) {
+	var gotKey, gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("x-goog-api-key")
+		gotQuery = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"ok"}]}}]}`))
+	}))
+	defer srv.Close()
+
+	gc := &GeminiClient{
+		APIKey:     "SECRET-KEY",
+		BaseURL:    srv.URL,
+		Model:      "test-model",
+		Mode:       ModeGemini,
+		HTTPClient: srv.Client(),
+		Conns:      &ConnStats{},
+		Now:        time.Now,
+	}
+	if _, err := gc.Generate("hello"); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if gotKey != "SECRET-KEY" {
+		t.Errorf("x-goog-api-key = %q, want SECRET-KEY", gotKey)
+	}
+	if strings.Contains(gotQuery, "SECRET-KEY") {
+		t.Errorf("API key leaked into the query string: %q", gotQuery)
+	}
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"crypto/tls"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// ConnStats counts how Gemini calls got their connections, to show whether
+// keep-alive is saving TLS handshakes
+type ConnStats struct {
+	requests   atomic.Int64
+	reused     atomic.Int64
+	handshakes atomic.Int64
+}
+
+// newGeminiHTTPClient builds the client every Gemini call shares, so
+// connections are kept alive and reused across tasks instead of each call
+// paying for a new TCP and TLS handshake. HTTP/2 is negotiated when Gemini
+// offers it. There is no overall timeout, since streamed answers stay open
+// for as long as the model writes.
+func newGeminiHTTPClient() *http.Client {
+	dialer := &net.Dialer{Timeout: envDuration("GEMINI_DIAL_TIMEOUT", 10*time.Second), KeepAlive: 30 * time.Second}
+	idlePerHost := 32
+	if v := os.Getenv("GEMINI_MAX_IDLE_CONNS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			idlePerHost = n
+		} else {
+			log.Printf("⚠️  Invalid GEMINI_MAX_IDLE_CONNS %q, using %d", v, idlePerHost)
+		}
+	}
+
+	return &http.Client{Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   envDuration("GEMINI_TLS_TIMEOUT", 10*time.Second),
+		MaxIdleConns:          idlePerHost * 2,
+		MaxIdleConnsPerHost:   idlePerHost,
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}}
+}
+
+// envDuration reads a positive duration from the environment
+func envDuration(name string, fallback time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("⚠️  Invalid %s %q, using %s", name, v, fallback)
+		return fallback
+	}
+	return d
+}
+
+// trace counts a request's connection in the stats
+func (s *ConnStats) trace(req *http.Request) *http.Request {
+	s.requests.Add(1)
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				s.reused.Add(1)
+			}
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				s.handshakes.Add(1)
+			}
+		},
+	}))
+}
+
+// Snapshot reports the counts and the share of calls that reused a
+// connection
+func (s *ConnStats) Snapshot() map[string]interface{} {
+	requests, reused := s.requests.Load(), s.reused.Load()
+	reuseRate := 0.0
+	if requests > 0 {
+		reuseRate = float64(reused) / float64(requests)
+	}
+	return map[string]interface{}{
+		"requests":          requests,
+		"reusedConnections": reused,
+		"tlsHandshakes":     s.handshakes.Load(),
+		"reuseRate":         reuseRate,
+	}
+}
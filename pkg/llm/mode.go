@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+)
+
+// LLM modes, set with LLM_MODE
+const (
+	ModeGemini = "gemini" // call the Gemini API (default)
+	ModeMock   = "mock"   // canned answers for load tests and local development
+	ModeOff    = "off"    // answer from the knowledge base only; nothing is sent to an LLM
+)
+
+// mode reads LLM_MODE, falling back to Gemini for unknown values
+func mode() string {
+	switch mode := os.Getenv("LLM_MODE"); mode {
+	case "", ModeGemini:
+		return ModeGemini
+	case ModeMock, ModeOff:
+		return mode
+	default:
+		log.Printf("⚠️  Unknown LLM_MODE %q, using %q", mode, ModeGemini)
+		return ModeGemini
+	}
+}
+
+// ErrOff is returned by every LLM call when LLM_MODE is off
+var ErrOff = fmt.Errorf("%w: LLM_MODE is off", ErrUnavailable)
+
+// mockLatency reads LLM_MOCK_LATENCY, the average time a mock answer takes
+func mockLatency() time.Duration {
+	latency := time.Second
+	if v := os.Getenv("LLM_MOCK_LATENCY"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			latency = d
+		} else {
+			log.Printf("⚠️  Invalid LLM_MOCK_LATENCY %q, using %s", v, latency)
+		}
+	}
+	return latency
+}
+
+// generateMock stands in for a Gemini call: after the configured latency,
+// give or take a quarter, it returns an answer in the standard format (or an
+// empty JSON object when JSON was asked for) and token counts estimated from
+// the text lengths. A streamed answer arrives a line at a time over the same
+// latency. No data leaves the process.
+func (gc *GeminiClient) generateMock(prompt string, config *GeminiGenerationConfig) (string, TokenUsage) {
+	var latency time.Duration
+	if gc.MockLatency > 0 {
+		jitter := time.Duration(rand.Int63n(int64(gc.MockLatency)/2+1)) - gc.MockLatency/4
+		latency = gc.MockLatency + jitter
+	}
+
+	text := "{}"
+	if config == nil || config.ResponseMimeType != "application/json" {
+		day := func(offset int) string { return gc.Now().AddDate(0, 0, offset).Format("2006-01-02") }
+		text = fmt.Sprintf(`# Best Migration Option: Skilled Worker Visa (mock)
+
+This is a canned answer from the mock LLM mode, used for load testing. It is not a real recommendation.
+
+**Key Details:**
+- Processing time: 6-8 months
+- Cost: $2,000-$3,500
+- Success rate: Medium
+- Main requirements: job offer, recognized qualification, language test
+
+**Timeline:**
+- %s: Book a language test
+- %s: Apply for a credential assessment
+- %s: Submit the visa application
+
+Next step: Book a language test.
+`, day(14), day(60), day(120))
+	}
+
+	if gc.streams(config) {
+		lines := strings.SplitAfter(text, "\n")
+		for i := range lines {
+			time.Sleep(latency / time.Duration(len(lines)))
+			gc.onText(strings.Join(lines[:i+1], ""))
+		}
+	} else {
+		time.Sleep(latency)
+	}
+	return text, TokenUsage{PromptTokens: len(prompt) / 4, OutputTokens: len(text) / 4}
+}
@@ -0,0 +1,8 @@
+package llm
+
+// Provider generates text for a prompt. The Gemini client is one; an
+// embedder can supply its own, such as a canned model for tests, and it is
+// called in place of the Gemini API.
+type Provider interface {
+	GenerateWithUsage(prompt string, media []GeminiInlineData, config *GeminiGenerationConfig, tools []GeminiTool) (string, TokenUsage, error)
+}
@@ -0,0 +1,211 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CVProfile holds the facts the CV parser found in a resume
+type CVProfile struct {
+	Profession      string
+	ExperienceYears int
+	Education       string // one of the Education* levels
+	Languages       []string
+	CLB             int
+}
+
+// cvSections are the headings that start each part of a CV
+var cvSections = map[string][]string{
+	"experience": {"experience", "work history", "employment", "career history", "professional background"},
+	"education":  {"education", "qualifications", "academic"},
+	"languages":  {"languages", "language skills"},
+	"other":      {"skills", "certifications", "certificates", "projects", "references", "interests", "summary", "profile", "awards", "publications"},
+}
+
+// defaultProfessionWords are job keywords used to spot the applicant's
+// title when the CV doesn't label it
+var defaultProfessionWords = []string{
+	"engineer", "developer", "programmer", "scientist", "analyst", "nurse", "doctor",
+	"physician", "pharmacist", "accountant", "teacher", "lecturer", "designer",
+	"architect", "manager", "consultant", "technician", "electrician", "welder",
+	"plumber", "chef", "caregiver", "midwife", "dentist", "lawyer", "researcher",
+}
+
+// defaultLanguages are the language names recognised in a CV
+var defaultLanguages = []string{
+	"English", "French", "German", "Spanish", "Portuguese", "Italian", "Dutch",
+	"Arabic", "Mandarin", "Chinese", "Hindi", "Urdu", "Bengali", "Tagalog",
+	"Yoruba", "Igbo", "Hausa", "Swahili", "Amharic", "Russian", "Turkish",
+}
+
+// Dictionary holds the words the CV parser recognises. Deployments can
+// replace the defaults, e.g. from their content directory.
+type Dictionary struct {
+	ProfessionWords []string
+	Languages       []string
+}
+
+// DefaultDictionary is the built-in dictionary
+var DefaultDictionary = Dictionary{ProfessionWords: defaultProfessionWords, Languages: defaultLanguages}
+
+var (
+	cvTitlePattern = regexp.MustCompile(`(?im)^\s*(?:job title|title|position|profession|current role|occupation)\s*[:\-]\s*(.{3,60})$`)
+	cvRangePattern = regexp.MustCompile(`(?i)\b((?:19|20)\d{2})\s*(?:-|–|—|to)\s*((?:19|20)\d{2}|present|current|now|date)\b`)
+)
+
+// ParseCV extracts profession, experience, education and languages from
+// the text of a resume, spotting titles and languages with dict. Anything
+// it can't find is left empty. Ranges running to the present end in now.
+func ParseCV(text string, dict Dictionary, now time.Time) CVProfile {
+	var cv CVProfile
+	sections := splitCVSections(text)
+	lower := strings.ToLower(text)
+
+	if m := cvTitlePattern.FindStringSubmatch(text); m != nil {
+		cv.Profession = strings.TrimSpace(m[1])
+	} else {
+		cv.Profession = findProfessionLine(sections["header"]+"\n"+sections["experience"], dict.ProfessionWords)
+	}
+
+	if m := crsExperiencePattern.FindStringSubmatch(lower); m != nil {
+		cv.ExperienceYears, _ = strconv.Atoi(m[1])
+	} else {
+		cv.ExperienceYears = yearsSpanned(sections["experience"], now.Year())
+	}
+
+	education := sections["education"]
+	if education == "" {
+		education = text
+	}
+	cv.Education = EducationLevel(strings.ToLower(education))
+
+	if m := crsIELTSPattern.FindStringSubmatch(lower); m != nil {
+		if band, err := strconv.ParseFloat(m[1], 64); err == nil {
+			cv.CLB = IELTSToCLB(band)
+		}
+	} else if m := crsCLBPattern.FindStringSubmatch(lower); m != nil {
+		cv.CLB, _ = strconv.Atoi(m[1])
+	}
+
+	languages := sections["languages"]
+	if languages == "" {
+		for _, line := range strings.Split(text, "\n") {
+			if strings.HasPrefix(strings.ToLower(strings.TrimSpace(line)), "languages") {
+				languages += line + "\n"
+			}
+		}
+	}
+	for _, name := range dict.Languages {
+		if IndexWord(strings.ToLower(languages), strings.ToLower(name)) != -1 {
+			cv.Languages = append(cv.Languages, name)
+		}
+	}
+	if cv.CLB > 0 && len(cv.Languages) == 0 {
+		cv.Languages = []string{"English"}
+	}
+	return cv
+}
+
+// splitCVSections groups the lines of a CV under the heading they follow;
+// lines before the first heading are filed under "header"
+func splitCVSections(text string) map[string]string {
+	sections := make(map[string]string)
+	current := "header"
+	for _, line := range strings.Split(text, "\n") {
+		if section := cvHeading(line); section != "" {
+			current = section
+			continue
+		}
+		sections[current] += line + "\n"
+	}
+	return sections
+}
+
+// cvHeading returns the section a heading line starts, or "" if the line
+// isn't a heading
+func cvHeading(line string) string {
+	heading := strings.ToLower(strings.Trim(strings.TrimSpace(line), ":#*-_ "))
+	if heading == "" || len(heading) > 40 {
+		return ""
+	}
+	for _, section := range []string{"experience", "education", "languages", "other"} {
+		for _, keyword := range cvSections[section] {
+			if heading == keyword || strings.HasPrefix(heading, keyword+" ") ||
+				strings.HasSuffix(heading, " "+keyword) {
+				return section
+			}
+		}
+	}
+	return ""
+}
+
+// findProfessionLine returns the first short line naming one of the
+// profession words
+func findProfessionLine(text string, words []string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || len(line) > 60 {
+			continue
+		}
+		lower := strings.ToLower(line)
+		for _, word := range words {
+			if strings.Contains(lower, word) {
+				return strings.Trim(line, " -|•")
+			}
+		}
+	}
+	return ""
+}
+
+// yearsSpanned estimates total experience from date ranges such as
+// "2016 - 2020" or "2021 – present", counting overlapping years once.
+// Ranges ending after the year now are skipped.
+func yearsSpanned(text string, now int) int {
+	years := make(map[int]bool)
+	for _, m := range cvRangePattern.FindAllStringSubmatch(text, -1) {
+		start, _ := strconv.Atoi(m[1])
+		end, err := strconv.Atoi(m[2])
+		if err != nil {
+			end = now
+		}
+		if end > now || start > end {
+			continue
+		}
+		for y := start; y < end; y++ {
+			years[y] = true
+		}
+	}
+	return len(years)
+}
+
+// MergeCV fills profile fields still empty from a parsed CV or document
+// image and records where each field came from
+func MergeCV(profile *UserProfile, cv CVProfile, source string) {
+	set := func(field string, empty bool, apply func()) {
+		if empty {
+			apply()
+			profile.Sources[field] = source
+		}
+	}
+	if profile.Sources == nil {
+		profile.Sources = make(map[string]string)
+	}
+
+	if cv.Profession != "" {
+		set("profession", profile.Sources["profession"] == "", func() { profile.Profession = cv.Profession })
+	}
+	if cv.ExperienceYears > 0 {
+		set("experienceYears", profile.ExperienceYears == 0, func() { profile.ExperienceYears = cv.ExperienceYears })
+	}
+	if cv.Education != "" {
+		set("education", profile.Education == "", func() { profile.Education = cv.Education })
+	}
+	if cv.CLB > 0 {
+		set("clb", profile.CLB == 0, func() { profile.CLB = cv.CLB })
+	}
+	if len(cv.Languages) > 0 {
+		set("languages", len(profile.Languages) == 0, func() { profile.Languages = cv.Languages })
+	}
+}
@@ -0,0 +1,22 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCVCountsYearsToNow(t *testing.T) {
+	text := "Jane Doe\nRegistered Nurse\n\nExperience\nStaff Nurse, Lagos General Hospital, 2018 - present\n"
+	for _, tc := range []struct {
+		year int
+		want int
+	}{
+		{2024, 6},
+		{2030, 12},
+	} {
+		now := time.Date(tc.year, time.June, 1, 0, 0, 0, 0, time.UTC)
+		if got := ParseCV(text, Dictionary{}, now).ExperienceYears; got != tc.want {
+			t.Errorf("in %d: ExperienceYears = %d, want %d", tc.year, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Education levels, from lowest to highest
+const (
+	EducationSecondary = "secondary"
+	EducationOneYear   = "one-year"
+	EducationTwoYear   = "two-year"
+	EducationBachelor  = "bachelor"
+	EducationTwoOrMore = "two-or-more"
+	EducationMaster    = "master"
+	EducationDoctorate = "doctorate"
+)
+
+// CRSInput holds the factors of Canada's Comprehensive Ranking System used
+// by the estimate. Only single applicants without a spouse are modelled.
+type CRSInput struct {
+	Age                 int    `json:"age"`
+	Education           string `json:"education"` // one of the Education* levels
+	CLB                 int    `json:"clb"`       // first official language, Canadian Language Benchmark
+	ForeignExperience   int    `json:"foreignExperienceYears"`
+	CanadianExperience  int    `json:"canadianExperienceYears"`
+	ProvincialNominated bool   `json:"provincialNomination"`
+}
+
+// IELTSToCLB approximates a CLB level from an overall IELTS General band
+func IELTSToCLB(band float64) int {
+	switch {
+	case band >= 7.5:
+		return 10
+	case band >= 7.0:
+		return 9
+	case band >= 6.5:
+		return 8
+	case band >= 6.0:
+		return 7
+	case band >= 5.5:
+		return 6
+	case band >= 5.0:
+		return 5
+	case band >= 4.0:
+		return 4
+	default:
+		return 0
+	}
+}
+
+var (
+	crsAgePattern        = regexp.MustCompile(`\b(\d{2})\s*(?:years? old|yrs? old|y/?o)\b|\b(?:i'?m|i am|aged?:?)\s+(\d{2})\b`)
+	crsIELTSPattern      = regexp.MustCompile(`ielts[^0-9]{0,20}(\d(?:\.\d)?)`)
+	crsCLBPattern        = regexp.MustCompile(`\bclb\s*(\d{1,2})\b`)
+	crsExperiencePattern = regexp.MustCompile(`(\d{1,2})\+?\s*(?:years?|yrs?)(?:\s+of)?(?:\s+(?:work|professional|relevant))?\s+(?:experience|exp)\b`)
+	crsCanadianPattern   = regexp.MustCompile(`(\d{1,2})\+?\s*(?:years?|yrs?)(?:\s+of)?\s+canadian\s+(?:work\s+)?experience`)
+)
+
+// ParseCRSInput extracts CRS factors from a free-text query. It reports
+// false unless age, education and language ability are all present, since
+// an estimate without them would be misleading.
+func ParseCRSInput(query string) (CRSInput, bool) {
+	q := strings.ToLower(query)
+	var in CRSInput
+
+	if m := crsAgePattern.FindStringSubmatch(q); m != nil {
+		age := m[1]
+		if age == "" {
+			age = m[2]
+		}
+		in.Age, _ = strconv.Atoi(age)
+	}
+
+	in.Education = EducationLevel(q)
+
+	if m := crsCLBPattern.FindStringSubmatch(q); m != nil {
+		in.CLB, _ = strconv.Atoi(m[1])
+	} else if m := crsIELTSPattern.FindStringSubmatch(q); m != nil {
+		if band, err := strconv.ParseFloat(m[1], 64); err == nil {
+			in.CLB = IELTSToCLB(band)
+		}
+	}
+
+	if m := crsCanadianPattern.FindStringSubmatch(q); m != nil {
+		in.CanadianExperience, _ = strconv.Atoi(m[1])
+	}
+	if m := crsExperiencePattern.FindStringSubmatch(crsCanadianPattern.ReplaceAllString(q, "")); m != nil {
+		in.ForeignExperience, _ = strconv.Atoi(m[1])
+	}
+	in.ProvincialNominated = strings.Contains(q, "provincial nomination") || strings.Contains(q, "pnp nomination")
+
+	ok := in.Age >= 17 && in.Education != "" && in.CLB > 0
+	return in, ok
+}
+
+// EducationLevel returns the highest Education* level mentioned in
+// lowercase text, or "" if none is
+func EducationLevel(q string) string {
+	switch {
+	case strings.Contains(q, "phd") || strings.Contains(q, "doctorate"):
+		return EducationDoctorate
+	case strings.Contains(q, "master") || strings.Contains(q, "msc") || strings.Contains(q, "mba"):
+		return EducationMaster
+	case strings.Contains(q, "bachelor") || strings.Contains(q, "bsc") || strings.Contains(q, "degree"):
+		return EducationBachelor
+	case strings.Contains(q, "diploma"):
+		return EducationTwoYear
+	case strings.Contains(q, "high school") || strings.Contains(q, "secondary school"):
+		return EducationSecondary
+	}
+	return ""
+}
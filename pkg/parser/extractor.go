@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ProfileExtractor reads profile fields from a query, e.g. with a
+// region-specific NER model. It fills in profile, which already holds what
+// earlier extractors found, and records the source of every field it sets
+// in profile.Sources, so later extractors and the built-in parser leave the
+// field alone.
+type ProfileExtractor interface {
+	Extract(query string, profile *UserProfile) error
+}
+
+// ExtractorFunc adapts a function to a ProfileExtractor
+type ExtractorFunc func(query string, profile *UserProfile) error
+
+// Extract calls f
+func (f ExtractorFunc) Extract(query string, profile *UserProfile) error {
+	return f(query, profile)
+}
+
+// Extractors runs registered extractors in registration order, ahead of
+// the built-in parser or instead of it. The zero value is the built-in
+// parser alone, and is safe to use from several goroutines.
+type Extractors struct {
+	mu             sync.RWMutex
+	extractors     []ProfileExtractor
+	withoutBuiltIn bool
+}
+
+// Register adds e after the extractors already registered, ahead of the
+// built-in parser
+func (x *Extractors) Register(e ProfileExtractor) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.extractors = append(x.extractors, e)
+}
+
+// DisableBuiltIn makes the registered extractors run instead of the
+// built-in parser
+func (x *Extractors) DisableBuiltIn() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.withoutBuiltIn = true
+}
+
+// Extract reads the profile from query. The built-in parser fills in the
+// fields no registered extractor set. A failed extractor doesn't stop the
+// others; its error is returned along with the profile the rest found.
+func (x *Extractors) Extract(query string) (UserProfile, error) {
+	x.mu.RLock()
+	extractors := x.extractors
+	builtIn := !x.withoutBuiltIn
+	x.mu.RUnlock()
+
+	// As with ParseQuery, the model reads profession, destination and
+	// origin from the whole query unless an extractor found them
+	profile := UserProfile{Profession: query, Destination: query, Origin: query, Sources: make(map[string]string)}
+	var errs []error
+	for i, e := range extractors {
+		if err := e.Extract(query, &profile); err != nil {
+			errs = append(errs, fmt.Errorf("extractor %d (%T): %v", i+1, e, err))
+		}
+	}
+	if profile.Sources == nil {
+		profile.Sources = make(map[string]string)
+	}
+	if builtIn {
+		fillProfile(&profile, ParseQuery(query))
+	}
+	return profile, errors.Join(errs...)
+}
+
+// fillProfile copies the fields found in from into the fields of p that
+// have no source yet
+func fillProfile(p *UserProfile, from UserProfile) {
+	for field, source := range from.Sources {
+		if p.Sources[field] != "" {
+			continue
+		}
+		switch field {
+		case "budget":
+			p.Budget = from.Budget
+		case "education":
+			p.Education = from.Education
+		case "experienceYears":
+			p.ExperienceYears = from.ExperienceYears
+		case "clb":
+			p.CLB = from.CLB
+		case "family":
+			p.Spouse, p.Children = from.Spouse, from.Children
+		default:
+			continue
+		}
+		p.Sources[field] = source
+	}
+}
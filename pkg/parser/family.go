@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	spousePattern = regexp.MustCompile(`(?i)\b(?:wife|husband|spouse|married)\b|\bmy (?:partner|fianc[ée]e?)\b`)
+	// childCountPattern captures how many children a query mentions
+	childCountPattern = regexp.MustCompile(`(?i)\b(\d{1,2}|a|an|one|two|three|four|five|six)\s+(?:young\s+|little\s+|small\s+)?(?:kids?|children|child|sons?|daughters?|toddlers?)\b`)
+	childPattern      = regexp.MustCompile(`(?i)\b(?:kids?|children|child|sons?|daughters?|toddlers?|baby)\b`)
+)
+
+// countWords are the spelled-out numbers childCountPattern knows
+var countWords = map[string]int{"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6}
+
+// ParseFamily reads whether a query mentions a spouse and how many
+// children. Children mentioned without a number count as one.
+func ParseFamily(query string) (spouse bool, children int) {
+	spouse = spousePattern.MatchString(query)
+	for _, m := range childCountPattern.FindAllStringSubmatch(query, -1) {
+		n, ok := countWords[strings.ToLower(m[1])]
+		if !ok {
+			n, _ = strconv.Atoi(m[1])
+		}
+		children += n
+	}
+	if children == 0 && childPattern.MatchString(query) {
+		children = 1
+	}
+	return spouse, children
+}
+
+// HasFamily reports whether the applicant brings a spouse or children
+func (p UserProfile) HasFamily() bool {
+	return p.Spouse || p.Children > 0
+}
+
+// Family describes who comes with the applicant, e.g. "spouse and 2
+// children", or "" for none
+func (p UserProfile) Family() string {
+	var members []string
+	if p.Spouse {
+		members = append(members, "spouse")
+	}
+	if p.Children == 1 {
+		members = append(members, "child")
+	} else if p.Children > 1 {
+		members = append(members, strconv.Itoa(p.Children)+" children")
+	}
+	return strings.Join(members, " and ")
+}
@@ -0,0 +1,144 @@
+// Package parser reads an applicant's profile from a free-text query and
+// from attached CVs: budget, education, language ability, experience and
+// family. The query itself is kept whole for the model to read the rest.
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Sources of profile fields, recorded in UserProfile.Sources
+const (
+	SourceQuery = "query"
+	SourceCV    = "cv"
+	SourceImage = "image"
+)
+
+// UserProfile represents parsed user information
+type UserProfile struct {
+	Profession  string
+	Destination string
+	Budget      int
+	Origin      string
+
+	Resume        string // text extracted from an attached CV, if any
+	DocumentFacts string // facts read from attached document images
+
+	ExperienceYears int
+	Education       string // one of the Education* levels
+	CLB             int    // English ability as a Canadian Language Benchmark
+	Languages       []string
+	Spouse          bool              // a spouse or partner comes along
+	Children        int               // children who come along
+	Sources         map[string]string // where each field came from: SourceQuery, SourceCV or SourceImage
+}
+
+// ParseQuery extracts information from user's natural language query
+// This is now simplified - we just extract the budget and pass the full query to Gemini
+// Gemini will extract profession, destination, and origin from the natural language
+func ParseQuery(query string) UserProfile {
+	queryLower := strings.ToLower(query)
+	profile := UserProfile{
+		Budget: 0, // 0 means no budget specified
+	}
+
+	// Extract budget - look for dollar amounts
+	// Patterns: $5000, $5,000, 5000 dollars, 5k
+	if idx := strings.Index(queryLower, "$"); idx != -1 {
+		// Extract number after $
+		numStr := ""
+		for i := idx + 1; i < len(queryLower); i++ {
+			c := queryLower[i]
+			if (c >= '0' && c <= '9') || c == ',' || c == '.' {
+				if c != ',' { // ignore commas
+					numStr += string(c)
+				}
+			} else {
+				break
+			}
+		}
+
+		// Handle 'k' suffix (e.g., $5k = $5000)
+		if idx+len(numStr)+1 < len(queryLower) && queryLower[idx+len(numStr)+1] == 'k' {
+			if budget, err := fmt.Sscanf(numStr, "%d", &profile.Budget); err == nil && budget == 1 {
+				profile.Budget *= 1000
+			}
+		} else {
+			fmt.Sscanf(numStr, "%d", &profile.Budget)
+		}
+	}
+
+	// Pass the original query - Gemini will extract profession, destination, and origin
+	profile.Profession = query
+	profile.Destination = query
+	profile.Origin = query
+
+	// Keep the background details the query states outright
+	profile.Sources = make(map[string]string)
+	if profile.Budget > 0 {
+		profile.Sources["budget"] = SourceQuery
+	}
+	details, _ := ParseCRSInput(query)
+	if details.Education != "" {
+		profile.Education = details.Education
+		profile.Sources["education"] = SourceQuery
+	}
+	if details.ForeignExperience > 0 {
+		profile.ExperienceYears = details.ForeignExperience
+		profile.Sources["experienceYears"] = SourceQuery
+	}
+	if details.CLB > 0 {
+		profile.CLB = details.CLB
+		profile.Sources["clb"] = SourceQuery
+	}
+	if profile.Spouse, profile.Children = ParseFamily(query); profile.HasFamily() {
+		profile.Sources["family"] = SourceQuery
+	}
+
+	return profile
+}
+
+// Summary lists the known profile fields with their sources for the
+// prompt, or "" when nothing is known
+func (p UserProfile) Summary() string {
+	var lines []string
+	add := func(field, label, value string) {
+		if value != "" && p.Sources[field] != "" {
+			lines = append(lines, "- "+label+": "+value+" ["+p.Sources[field]+"]")
+		}
+	}
+	add("profession", "Profession", p.Profession)
+	if p.ExperienceYears > 0 {
+		add("experienceYears", "Work experience", strconv.Itoa(p.ExperienceYears)+" years")
+	}
+	add("education", "Highest education", p.Education)
+	if p.CLB > 0 {
+		add("clb", "English level", "CLB "+strconv.Itoa(p.CLB))
+	}
+	add("languages", "Languages", strings.Join(p.Languages, ", "))
+	add("family", "Family coming along", p.Family())
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Data is the profile as a data part, with the source of every field
+func (p UserProfile) Data() map[string]interface{} {
+	fields := make(map[string]interface{})
+	add := func(field string, value interface{}) {
+		if source := p.Sources[field]; source != "" {
+			fields[field] = map[string]interface{}{"value": value, "source": source}
+		}
+	}
+	add("profession", p.Profession)
+	add("experienceYears", p.ExperienceYears)
+	add("education", p.Education)
+	add("clb", p.CLB)
+	add("languages", p.Languages)
+	add("family", map[string]interface{}{"spouse": p.Spouse, "children": p.Children})
+	add("budget", p.Budget)
+	return fields
+}
@@ -0,0 +1,24 @@
+package parser
+
+import "strings"
+
+// IndexWord finds word in lowercase text s at word boundaries, or returns
+// -1
+func IndexWord(s, word string) int {
+	for offset := 0; ; {
+		idx := strings.Index(s[offset:], word)
+		if idx == -1 {
+			return -1
+		}
+		pos := offset + idx
+		end := pos + len(word)
+		if (pos == 0 || !isWordChar(s[pos-1])) && (end == len(s) || !isWordChar(s[end])) {
+			return pos
+		}
+		offset = pos + 1
+	}
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9'
+}